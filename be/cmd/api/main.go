@@ -43,32 +43,65 @@ func main() {
 	penyewaRepo := repository.NewPenyewaRepository(db)
 	bookingRepo := repository.NewBookingRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	pricingPolicyRepo := repository.NewPricingPolicyRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	messageTemplateRepo := repository.NewMessageTemplateRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	loginRepo := repository.NewLoginRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	depositRepo := repository.NewDepositRepository(db)
+	maintenanceRepo := repository.NewMaintenanceRepository(db)
+	lateFeeRepo := repository.NewLateFeeRepository(db)
 
 	// 4. Initialize Services
 	emailSender := utils.NewEmailSender(cfg)
 	waSender := utils.NewWhatsAppSender(cfg)
 
+	// Notifikasi WA/email dari service bisnis ditulis ke outbox lalu dikirim worker (dengan retry)
+	outboxService := service.NewOutboxService(outboxRepo, waSender, emailSender)
+	outboxWASender := service.NewOutboxWhatsAppSender(outboxRepo)
+	outboxEmailSender := service.NewOutboxEmailSender(outboxRepo, emailSender)
+
 	// Removed Cloudinary Initialization
 
-	authService := service.NewAuthService(userRepo, penyewaRepo, cfg, emailSender, &utils.RealIDTokenVerifier{})
-	kamarService := service.NewKamarService(kamarRepo, bookingRepo, paymentRepo, penyewaRepo, waSender, cfg.AdminPhoneNumber)
-	galleryService := service.NewGalleryService(galleryRepo)
-	dashboardService := service.NewDashboardService(db)
+	messageTemplateService := service.NewMessageTemplateService(messageTemplateRepo)
+	notificationService := service.NewNotificationService(notificationRepo, cfg.NotificationRealtime)
+	auditService := service.NewAuditService(auditRepo)
+	twoFactorService := service.NewTwoFactorService(userRepo, twoFactorRepo, cfg.TOTPIssuer)
+	authService := service.NewAuthService(userRepo, penyewaRepo, cfg, outboxEmailSender, &utils.RealIDTokenVerifier{}, sessionRepo, loginRepo, outboxWASender, messageTemplateService, twoFactorService)
+	kamarService := service.NewKamarService(kamarRepo, bookingRepo, paymentRepo, refundRepo, penyewaRepo, outboxWASender, cfg.AdminPhoneNumber, notificationService, messageTemplateService, auditService, db)
+	galleryService := service.NewGalleryService(galleryRepo, auditService)
+	dashboardService := service.NewDashboardService(db, ledgerRepo)
 	reviewService := service.NewReviewService(reviewRepo, bookingRepo, penyewaRepo)
-	profileService := service.NewProfileService(userRepo, penyewaRepo)
-	bookingService := service.NewBookingService(bookingRepo, userRepo, penyewaRepo, kamarRepo, paymentRepo, db, waSender)
-	paymentService := service.NewPaymentService(paymentRepo, bookingRepo, kamarRepo, penyewaRepo, db, emailSender, waSender)
-	tenantService := service.NewTenantService(penyewaRepo)
-	contactService := service.NewContactService()
+	profileService := service.NewProfileService(userRepo, penyewaRepo, sessionRepo)
+	bookingService := service.NewBookingService(bookingRepo, userRepo, penyewaRepo, kamarRepo, paymentRepo, refundRepo, pricingPolicyRepo, ledgerRepo, db, outboxWASender, notificationService, messageTemplateService, auditService, depositRepo, cfg.ReminderCadence)
+	paymentService := service.NewPaymentService(paymentRepo, bookingRepo, kamarRepo, penyewaRepo, pricingPolicyRepo, db, outboxEmailSender, outboxWASender, notificationService, messageTemplateService, auditService, cfg.ReminderCadence)
+	tenantService := service.NewTenantService(penyewaRepo, sessionRepo, loginRepo, auditService, userRepo)
+	contactService := service.NewContactService(messageTemplateService)
+	availabilityService := service.NewAvailabilityService(kamarRepo, bookingRepo)
+	refundService := service.NewRefundService(refundRepo, outboxWASender, messageTemplateService, auditService)
+	pricingPolicyService := service.NewPricingPolicyService(pricingPolicyRepo, auditService)
+	ledgerService := service.NewLedgerService(ledgerRepo, penyewaRepo, auditService)
+	exportService := service.NewExportService(exportRepo)
+	sessionService := service.NewSessionService(sessionRepo, userRepo, cfg)
+	staffService := service.NewStaffService(userRepo, sessionRepo, auditService)
+	leaseService := service.NewLeaseService(bookingRepo, kamarRepo, penyewaRepo, auditService, db)
+	depositService := service.NewDepositService(depositRepo, bookingRepo, refundRepo, penyewaRepo, db, auditService)
+	maintenanceService := service.NewMaintenanceService(maintenanceRepo, bookingRepo, kamarRepo, penyewaRepo, userRepo, outboxWASender, notificationService, messageTemplateService, auditService)
 
 	// 4.1 Initialize Socket.io
-	socketServer, err := utils.InitSocketServer()
+	socketServer, err := utils.InitSocketServer(cfg.JWTSecret)
 	if err != nil {
 		log.Fatalf("Failed to initialize Socket.io: %v", err)
 	}
 
 	// 5. Initialize Handlers
-	authHandler := handlers.NewAuthHandler(authService, cfg)
+	authHandler := handlers.NewAuthHandler(authService, cfg, sessionService)
 	kamarHandler := handlers.NewKamarHandler(kamarService)
 	galleryHandler := handlers.NewGalleryHandler(galleryService)
 	dashboardHandler := handlers.NewDashboardHandler(dashboardService)
@@ -78,6 +111,22 @@ func main() {
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
 	tenantHandler := handlers.NewTenantHandler(tenantService)
 	contactHandler := handlers.NewContactHandler(contactService)
+	availabilityHandler := handlers.NewAvailabilityHandler(availabilityService)
+	refundHandler := handlers.NewRefundHandler(refundService)
+	pricingPolicyHandler := handlers.NewPricingPolicyHandler(pricingPolicyService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	outboxHandler := handlers.NewOutboxHandler(outboxService)
+	messageTemplateHandler := handlers.NewMessageTemplateHandler(messageTemplateService)
+	whatsAppWebhookHandler := handlers.NewWhatsAppWebhookHandler(outboxService, waSender, cfg.WhatsAppCallbackToken)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	staffHandler := handlers.NewStaffHandler(staffService)
+	auditLogHandler := handlers.NewAuditLogHandler(auditService)
+	leaseHandler := handlers.NewLeaseHandler(leaseService)
+	depositHandler := handlers.NewDepositHandler(depositService)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService)
 
 	// Initialize Routes
 	appRoutes := routes.NewRoutes(
@@ -91,6 +140,22 @@ func main() {
 		paymentHandler,
 		tenantHandler,
 		contactHandler,
+		availabilityHandler,
+		refundHandler,
+		pricingPolicyHandler,
+		ledgerHandler,
+		exportHandler,
+		notificationHandler,
+		outboxHandler,
+		messageTemplateHandler,
+		whatsAppWebhookHandler,
+		sessionHandler,
+		twoFactorHandler,
+		staffHandler,
+		auditLogHandler,
+		leaseHandler,
+		depositHandler,
+		maintenanceHandler,
 	)
 
 	// Log startup
@@ -137,8 +202,9 @@ func main() {
 		utils.GlobalLogger.Info("Starting background workers...")
 
 		// Reminder Service & Scheduler
-		reminderService := service.NewReminderService(paymentRepo, db, emailSender, waSender)
-		schedulerService := scheduler.NewScheduler(reminderService)
+		reminderService := service.NewReminderService(paymentRepo, pricingPolicyRepo, ledgerRepo, db, outboxEmailSender, outboxWASender, notificationService, messageTemplateService, cfg.ReminderCadence, cfg.FrontendURL)
+		lateFeeService := service.NewLateFeeService(lateFeeRepo, pricingPolicyRepo, outboxWASender, messageTemplateService)
+		schedulerService := scheduler.NewScheduler(reminderService, availabilityService, lateFeeService, outboxService, leaseService)
 		schedulerService.Start()
 
 		// Run initial checks
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -40,6 +43,27 @@ type Config struct {
 	// WhatsApp Config
 	FonnteToken      string
 	AdminPhoneNumber string // Nomor HP admin untuk notifikasi WA (format: 628xxx)
+
+	// WhatsApp Provider: fonnte, webhook, cloudapi, fake, log (kosong: fonnte jika FONNTE_TOKEN diisi, selain itu log)
+	WhatsAppProvider           string
+	WhatsAppWebhookURL         string
+	WhatsAppWebhookToken       string
+	WhatsAppCloudBaseURL       string
+	WhatsAppCloudPhoneNumberID string
+	WhatsAppCloudToken         string
+	WhatsAppCallbackToken      string // Token untuk memverifikasi callback status dari provider
+
+	// Notification Config
+	NotificationRealtime bool // Teruskan notifikasi in-app ke Socket.io (default: aktif)
+
+	// Reminder Config
+	ReminderCadence []int // Tahap reminder tagihan, hari relatif terhadap jatuh tempo (H-7, H-3, H-0, H+1)
+
+	// 2FA Config
+	TOTPIssuer string // Nama yang tampil di aplikasi authenticator
+
+	// Seeder Config
+	AdminInitialPassword string // Password awal akun "admin" dari seeder; kosong = akun admin tidak dibuat
 }
 
 func LoadConfig() *Config {
@@ -79,6 +103,27 @@ func LoadConfig() *Config {
 		// WhatsApp Config
 		FonnteToken:      getEnv("FONNTE_TOKEN", ""),
 		AdminPhoneNumber: getEnv("ADMIN_PHONE_NUMBER", ""),
+
+		// WhatsApp Provider
+		WhatsAppProvider:           getEnv("WHATSAPP_PROVIDER", ""),
+		WhatsAppWebhookURL:         getEnv("WHATSAPP_WEBHOOK_URL", ""),
+		WhatsAppWebhookToken:       getEnv("WHATSAPP_WEBHOOK_TOKEN", ""),
+		WhatsAppCloudBaseURL:       getEnv("WHATSAPP_CLOUD_BASE_URL", "https://graph.facebook.com/v19.0"),
+		WhatsAppCloudPhoneNumberID: getEnv("WHATSAPP_CLOUD_PHONE_NUMBER_ID", ""),
+		WhatsAppCloudToken:         getEnv("WHATSAPP_CLOUD_TOKEN", ""),
+		WhatsAppCallbackToken:      getEnv("WHATSAPP_CALLBACK_TOKEN", ""),
+
+		// Notification Config
+		NotificationRealtime: getEnv("NOTIFICATION_REALTIME", "true") != "false",
+
+		// Reminder Config
+		ReminderCadence: parseDayOffsets(getEnv("REMINDER_CADENCE", "-7,-3,0,1")),
+
+		// 2FA Config
+		TOTPIssuer: getEnv("TOTP_ISSUER", "Koskosan"),
+
+		// Seeder Config
+		AdminInitialPassword: getEnv("ADMIN_INITIAL_PASSWORD", ""),
 	}
 
 	// Validate required environment variables
@@ -103,6 +148,23 @@ func (c *Config) ValidateRequired() error {
 	return nil
 }
 
+// parseDayOffsets membaca daftar offset hari dipisah koma ("-7,-3,0,1"); nilai tidak valid dilewati
+func parseDayOffsets(value string) []int {
+	var offsets []int
+	for _, part := range strings.Split(value, ",") {
+		offset, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			if strings.TrimSpace(part) != "" {
+				log.Printf("Warning: invalid day offset %q ignored", part)
+			}
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	return offsets
+}
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
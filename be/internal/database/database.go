@@ -54,6 +54,26 @@ func InitDB(cfg *config.Config) {
 		&models.KamarImage{},
 		&models.Review{},
 		&models.PaymentReminder{},
+		&models.Refund{},
+		&models.SecurityDeposit{},
+		&models.DepositDeduction{},
+		&models.MaintenanceTicket{},
+		&models.MaintenancePhoto{},
+		&models.MaintenanceComment{},
+		&models.PricingPolicy{},
+		&models.PaymentLineItem{},
+		&models.LedgerAdjustment{},
+		&models.InvoiceSequence{},
+		&models.Notification{},
+		&models.OutboxMessage{},
+		&models.MessageTemplate{},
+		&models.UserSession{},
+		&models.LoginThrottle{},
+		&models.LoginAttempt{},
+		&models.RecoveryCode{},
+		&models.AuditLog{},
+		&models.BookingStatusHistory{},
+		&models.PaymentStatusHistory{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
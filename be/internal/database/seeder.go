@@ -1,25 +1,36 @@
 package database
 
 import (
+	"koskosan-be/internal/config"
 	"koskosan-be/internal/models"
 	"log"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-func SeedData() {
-	// Seed Admin User
+// minAdminInitialPasswordLength menolak password awal admin yang terlalu pendek
+const minAdminInitialPasswordLength = 12
+
+func SeedData(cfg *config.Config) {
+	// Seed Admin User: password awal dari ADMIN_INITIAL_PASSWORD, tidak ada password bawaan
 	var adminUser models.User
 	err := DB.Where("username = ?", "admin").First(&adminUser).Error
 	if err != nil { // Not found or error
-		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
-		admin := models.User{
-			Username: "admin",
-			Password: string(hashedPassword),
-			Role:     "admin",
+		switch {
+		case cfg.AdminInitialPassword == "":
+			log.Println("Warning: ADMIN_INITIAL_PASSWORD is not set, skipping admin user seed")
+		case len(cfg.AdminInitialPassword) < minAdminInitialPasswordLength:
+			log.Printf("Warning: ADMIN_INITIAL_PASSWORD must be at least %d characters, skipping admin user seed", minAdminInitialPasswordLength)
+		default:
+			hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(cfg.AdminInitialPassword), bcrypt.DefaultCost)
+			admin := models.User{
+				Username: "admin",
+				Password: string(hashedPassword),
+				Role:     "admin",
+			}
+			DB.Create(&admin)
+			log.Println("Admin user 'admin' ensured with ADMIN_INITIAL_PASSWORD (2FA enrolment required on first login)")
 		}
-		DB.Create(&admin)
-		log.Println("Admin user 'admin' with password 'admin123' ensured")
 	}
 
 	// Seed Kamar
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditLogHandler struct {
+	service service.AuditService
+}
+
+func NewAuditLogHandler(service service.AuditService) *AuditLogHandler {
+	return &AuditLogHandler{service}
+}
+
+// auditActor mengambil pelaku mutasi dari JWT (user_id, role) dan IP request untuk audit log
+func auditActor(c *gin.Context) service.AuditActor {
+	actor := service.AuditActor{Role: c.GetString("role"), IPAddress: c.ClientIP()}
+	if userIDRaw, exists := c.Get("user_id"); exists {
+		switch v := userIDRaw.(type) {
+		case float64:
+			actor.UserID = uint(v)
+		case int:
+			actor.UserID = uint(v)
+		case uint:
+			actor.UserID = v
+		}
+	}
+	return actor
+}
+
+// GetLogs mengembalikan audit log terbaru dulu dengan filter:
+// actor_id, action, entity_type, entity_id, date_from, date_to (YYYY-MM-DD, inklusif)
+func (h *AuditLogHandler) GetLogs(c *gin.Context) {
+	filter, err := parseAuditLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	logs, totalRows, err := h.service.GetLogs(&pagination, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if logs == nil {
+		logs = []models.AuditLog{}
+	}
+
+	pagination.SetTotal(totalRows)
+	c.JSON(http.StatusOK, utils.PaginatedResponse{Data: logs, Meta: pagination})
+}
+
+func parseAuditLogFilter(c *gin.Context) (repository.AuditLogFilter, error) {
+	filter := repository.AuditLogFilter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+	}
+
+	var err error
+	if filter.DateFrom, filter.DateTo, err = parseDateRange(c); err != nil {
+		return filter, err
+	}
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid actor_id")
+		}
+		filter.ActorUserID = uint(id)
+	}
+	if v := c.Query("entity_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid entity_id")
+		}
+		filter.EntityID = uint(id)
+	}
+	return filter, nil
+}
@@ -1,24 +1,28 @@
 package handlers
 
 import (
+	"errors"
 	"koskosan-be/internal/config"
 	"koskosan-be/internal/service"
 	"koskosan-be/internal/utils"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-	service service.AuthService
-	cfg     *config.Config
+	service  service.AuthService
+	cfg      *config.Config
+	sessions service.SessionService
 }
 
-func NewAuthHandler(s service.AuthService, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(s service.AuthService, cfg *config.Config, sessions service.SessionService) *AuthHandler {
 	return &AuthHandler{
-		service: s,
-		cfg:     cfg,
+		service:  s,
+		cfg:      cfg,
+		sessions: sessions,
 	}
 }
 
@@ -37,26 +41,36 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	input.Username = utils.SanitizeString(input.Username)
 	input.Password = utils.SanitizeString(input.Password)
 
-	_, user, err := h.service.Login(input.Username, input.Password)
+	client := service.LoginClient{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	user, err := h.service.Login(input.Username, input.Password, client)
 	if err != nil {
+		var locked *service.AccountLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(locked.Until).Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "locked_until": locked.Until})
+			return
+		}
+		if respondTwoFactorRequired(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Generate token pair for secure authentication
-	accessToken, refreshToken, err := utils.GenerateTokenPair(int(user.ID), user.Username, user.Role, h.cfg.JWTSecret)
+	// Generate token pair for secure authentication (refresh token disimpan sebagai sesi)
+	tokens, err := h.sessions.CreateSession(user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
 		return
 	}
 
 	// Set secure HttpOnly cookies (XSS protection)
-	utils.SetAuthCookies(c, accessToken, refreshToken, h.cfg.IsProduction)
+	utils.SetAuthCookies(c, tokens.AccessToken, tokens.RefreshToken, h.cfg.IsProduction)
 
 	c.JSON(http.StatusOK, gin.H{
 		"user":         user,
-		"accessToken":  accessToken,
-		"refreshToken": refreshToken,
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
 		"expiresIn":    int(utils.AccessTokenExpiry.Seconds()),
 		"message":      "Login successful",
 	})
@@ -139,31 +153,129 @@ func (h *AuthHandler) GoogleLogin(c *gin.Context) {
 	input.Picture = utils.SanitizeString(input.Picture)
 
 	// Pass ID token to service for verification
-	_, user, err := h.service.GoogleLogin(input.IDToken, input.Username, input.Picture)
+	client := service.LoginClient{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	user, err := h.service.GoogleLogin(input.IDToken, input.Username, input.Picture, client)
 	if err != nil {
+		if respondTwoFactorRequired(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Generate token pair
-	accessToken, refreshToken, err := utils.GenerateTokenPair(int(user.ID), user.Username, user.Role, h.cfg.JWTSecret)
+	tokens, err := h.sessions.CreateSession(user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
 		return
 	}
 
 	// Set secure HttpOnly cookies
-	utils.SetAuthCookies(c, accessToken, refreshToken, h.cfg.IsProduction)
+	utils.SetAuthCookies(c, tokens.AccessToken, tokens.RefreshToken, h.cfg.IsProduction)
 
 	c.JSON(http.StatusOK, gin.H{
 		"user":         user,
-		"accessToken":  accessToken,
-		"refreshToken": refreshToken,
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
 		"expiresIn":    int(utils.AccessTokenExpiry.Seconds()),
 		"message":      "Login successful",
 	})
 }
 
+// respondTwoFactorRequired menjawab langkah pertama login yang masih membutuhkan OTP
+func respondTwoFactorRequired(c *gin.Context, err error) bool {
+	var required *service.TwoFactorRequiredError
+	if !errors.As(err, &required) {
+		return false
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"two_factor_required": true,
+		"setup_required":      required.SetupRequired,
+		"challenge_token":     required.ChallengeToken,
+		"expiresIn":           int(utils.TwoFactorChallengeExpiry.Seconds()),
+		"message":             err.Error(),
+	})
+	return true
+}
+
+// TwoFactorSetup memberi secret & URI QR untuk enrol 2FA saat login (admin yang belum enrol)
+// POST /api/auth/2fa/setup
+func (h *AuthHandler) TwoFactorSetup(c *gin.Context) {
+	var input struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	setup, err := h.service.BeginTwoFactorSetup(input.ChallengeToken)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidTwoFactorChallenge) {
+			status = http.StatusUnauthorized
+		} else if errors.Is(err, service.ErrTwoFactorAlreadyEnabled) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// TwoFactorVerify menyelesaikan login dengan kode OTP / recovery code lalu menerbitkan token pair
+// POST /api/auth/2fa/verify
+func (h *AuthHandler) TwoFactorVerify(c *gin.Context) {
+	var input struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	client := service.LoginClient{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	user, recoveryCodes, err := h.service.VerifyTwoFactor(input.ChallengeToken, utils.SanitizeString(input.Code), client)
+	if err != nil {
+		var locked *service.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(locked.Until).Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "locked_until": locked.Until})
+		case errors.Is(err, service.ErrInvalidOTP), errors.Is(err, service.ErrInvalidTwoFactorChallenge):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrTwoFactorNotSetup):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	tokens, err := h.sessions.CreateSession(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
+
+	utils.SetAuthCookies(c, tokens.AccessToken, tokens.RefreshToken, h.cfg.IsProduction)
+
+	response := gin.H{
+		"user":         user,
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    int(utils.AccessTokenExpiry.Seconds()),
+		"message":      "Login successful",
+	}
+	// Recovery code hanya ditampilkan sekali, saat 2FA baru diaktifkan
+	if len(recoveryCodes) > 0 {
+		response["recovery_codes"] = recoveryCodes
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var input struct {
 		Email string `json:"email" binding:"required"`
@@ -206,61 +318,44 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully"})
 }
 
-// RefreshToken refreshes the access token using refresh token (from Cookie or Header)
+// RefreshToken merotasi refresh token (from Cookie or Header): token lama dicabut dan pasangan
+// token baru diterbitkan. Token lama yang dipakai lagi mencabut seluruh sesi perangkat tersebut.
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	// Try to get refresh token from Cookie first, then fallback to Authorization header
-	refreshToken, err := c.Cookie("refresh_token")
-	if err != nil || refreshToken == "" {
-		// Fallback for Desktop clients: Check Authorization Header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" {
-			parts := strings.Split(authHeader, " ")
-			if len(parts) == 2 && parts[0] == "Bearer" {
-				refreshToken = parts[1]
-			}
-		}
-	}
-
+	// Cookie first, fallback to Authorization header for Desktop clients
+	refreshToken := utils.GetRefreshToken(c)
 	if refreshToken == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "No refresh token found"})
 		return
 	}
 
-	// Validate refresh token
-	claims, err := utils.ValidateRefreshToken(refreshToken, h.cfg.JWTSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
-		return
-	}
-
-	// Generate new access token
-	newAccessToken, err := utils.GenerateToken(claims.UserID, claims.Username, claims.Role, "access", h.cfg.JWTSecret, utils.AccessTokenExpiry)
+	tokens, err := h.sessions.Refresh(refreshToken, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate new access token"})
+		if err == service.ErrInvalidRefreshToken {
+			utils.ClearAuthCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
 		return
 	}
 
-	// Set new access token cookie (for web browsers)
-	c.SetSameSite(http.SameSiteStrictMode)
-	c.SetCookie(
-		"access_token",
-		newAccessToken,
-		int(utils.AccessTokenExpiry.Seconds()),
-		"/",
-		"",
-		h.cfg.IsProduction,
-		true,
-	)
+	// Set new cookies (for web browsers)
+	utils.SetAuthCookies(c, tokens.AccessToken, tokens.RefreshToken, h.cfg.IsProduction)
 
 	c.JSON(http.StatusOK, gin.H{
-		"accessToken": newAccessToken,
-		"expiresIn":   int(utils.AccessTokenExpiry.Seconds()),
-		"message":     "Token refreshed successfully",
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    int(utils.AccessTokenExpiry.Seconds()),
+		"message":      "Token refreshed successfully",
 	})
 }
 
-// Logout clears authentication cookies
+// Logout mencabut sesi refresh token dan menghapus auth cookies
 func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.sessions.Logout(utils.GetRefreshToken(c)); err != nil {
+		utils.GlobalLogger.Error("Logout: failed to revoke session: %v", err)
+	}
+
 	// Clear all auth cookies
 	utils.ClearAuthCookies(c)
 
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"koskosan-be/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AvailabilityHandler struct {
+	service service.AvailabilityService
+}
+
+func NewAvailabilityHandler(s service.AvailabilityService) *AvailabilityHandler {
+	return &AvailabilityHandler{service: s}
+}
+
+// GetAvailability mengembalikan rentang tanggal kosong/terisi sebuah kamar
+// GET /api/kamar/:id/availability?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *AvailabilityHandler) GetAvailability(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	availability, err := h.service.GetAvailability(uint(id), c.Query("from"), c.Query("to"))
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Kamar not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
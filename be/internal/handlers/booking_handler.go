@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"koskosan-be/internal/models"
 	"koskosan-be/internal/service"
 	"koskosan-be/internal/utils"
 	"net/http"
@@ -73,7 +75,7 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		return
 	}
 
-	booking, err := h.service.CreateBooking(userID, req.KamarID, req.TanggalMulai, req.DurasiSewa)
+	booking, err := h.service.CreateBooking(userID, req.KamarID, req.TanggalMulai, req.DurasiSewa, auditActor(c))
 	if err != nil {
 		if err.Error() == "record not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Profile or Room not found. Please complete your profile first."})
@@ -145,7 +147,7 @@ func (h *BookingHandler) CreateBookingWithProof(c *gin.Context) {
 		return
 	}
 
-	booking, err := h.service.CreateBookingWithProof(userID, uint(kamarID), tanggalMulai, durasiSewa, proofURL, paymentType, paymentMethod)
+	booking, err := h.service.CreateBookingWithProof(userID, uint(kamarID), tanggalMulai, durasiSewa, proofURL, paymentType, paymentMethod, auditActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -181,12 +183,12 @@ func (h *BookingHandler) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.CancelBooking(uint(id), userID); err != nil {
+	if err := h.service.CancelBooking(uint(id), userID, auditActor(c)); err != nil {
 		if err.Error() == "unauthorized: you can only cancel your own bookings" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(transitionErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -230,7 +232,7 @@ func (h *BookingHandler) ExtendBooking(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.service.ExtendBooking(uint(id), req.Months, userID, req.PaymentMethod)
+	payment, err := h.service.ExtendBooking(uint(id), req.Months, userID, req.PaymentMethod, auditActor(c))
 	if err != nil {
 		if err.Error() == "unauthorized: you can only extend your own bookings" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
@@ -242,3 +244,44 @@ func (h *BookingHandler) ExtendBooking(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, payment)
 }
+
+// GetBookingHistory mengembalikan riwayat perpindahan status booking dan pembayarannya
+// GET /api/bookings/:id/history
+func (h *BookingHandler) GetBookingHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.service.GetBookingHistory(uint(id), userID, c.GetString("role"))
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			return
+		}
+		if err.Error() == "unauthorized: you can only view your own bookings" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// transitionErrorStatus mengembalikan 409 jika perpindahan status ditolak state machine
+// (lihat models/status.go), selain itu fallback
+func transitionErrorStatus(err error, fallback int) int {
+	var transitionErr *models.StatusTransitionError
+	if errors.As(err, &transitionErr) {
+		return http.StatusConflict
+	}
+	return fallback
+}
@@ -16,8 +16,17 @@ func NewDashboardHandler(s service.DashboardService) *DashboardHandler {
 	return &DashboardHandler{s}
 }
 
+// GetStats mengembalikan statistik dashboard. date_from (YYYY-MM-DD, opsional) membatasi
+// agregat pembayaran ke periode sejak tanggal tersebut.
+// GET /api/dashboard?date_from=
 func (h *DashboardHandler) GetStats(c *gin.Context) {
-	stats, err := h.service.GetStats()
+	dateFrom, _, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.service.GetStats(dateFrom)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -43,6 +52,7 @@ func (h *DashboardHandler) GetRoomOccupancy(c *gin.Context) {
 	if data == nil {
 		data = []service.RoomOccupancyInfo{}
 	}
+	maskTenantPII(c, data)
 	c.JSON(http.StatusOK, data)
 }
 
@@ -55,6 +65,7 @@ func (h *DashboardHandler) GetTenantRooms(c *gin.Context) {
 	if data == nil {
 		data = []service.TenantRoomInfo{}
 	}
+	maskTenantPII(c, data)
 	c.JSON(http.StatusOK, data)
 }
 
@@ -70,6 +81,7 @@ func (h *DashboardHandler) GetPaymentsByRoom(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	maskTenantPII(c, data)
 	c.JSON(http.StatusOK, data)
 }
 
@@ -85,5 +97,6 @@ func (h *DashboardHandler) GetPaymentsByTenant(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	maskTenantPII(c, data)
 	c.JSON(http.StatusOK, data)
 }
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DepositHandler struct {
+	service service.DepositService
+}
+
+func NewDepositHandler(s service.DepositService) *DepositHandler {
+	return &DepositHandler{service: s}
+}
+
+type depositDeductionRequest struct {
+	Kategori   string  `json:"kategori"` // damage, utilities, cleaning, other
+	Keterangan string  `json:"keterangan"`
+	Jumlah     float64 `json:"jumlah"`
+}
+
+type settleDepositRequest struct {
+	Deductions []depositDeductionRequest `json:"deductions"`
+	Catatan    string                    `json:"catatan"`
+}
+
+// GetDeposit mengembalikan uang jaminan booking beserta potongannya
+// GET /api/bookings/:id/deposit
+func (h *DepositHandler) GetDeposit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	deposit, err := h.service.GetDeposit(uint(id), userID, c.GetString("role"))
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Security deposit not found"})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "unauthorized") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deposit)
+}
+
+// SettleDeposit menyelesaikan uang jaminan setelah check-out dengan potongan per item
+// POST /api/bookings/:id/deposit/settle
+func (h *DepositHandler) SettleDeposit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req settleDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deductions := make([]models.DepositDeduction, 0, len(req.Deductions))
+	for _, d := range req.Deductions {
+		deductions = append(deductions, models.DepositDeduction{
+			Kategori:   strings.TrimSpace(d.Kategori),
+			Keterangan: strings.TrimSpace(d.Keterangan),
+			Jumlah:     d.Jumlah,
+		})
+	}
+
+	deposit, err := h.service.SettleDeposit(uint(id), deductions, req.Catatan, auditActor(c))
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deposit)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	service service.ExportService
+}
+
+func NewExportHandler(s service.ExportService) *ExportHandler {
+	return &ExportHandler{service: s}
+}
+
+// ExportPayments mengunduh daftar pembayaran (filter sama dengan GET /api/payments)
+// GET /api/exports/payments?format=csv|xlsx
+func (h *ExportHandler) ExportPayments(c *gin.Context) {
+	filter, err := parsePaymentFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.stream(c, "pembayaran", func(w io.Writer, format string) error {
+		return h.service.ExportPayments(w, format, filter)
+	})
+}
+
+// ExportBookings mengunduh daftar booking
+// GET /api/exports/bookings?format=csv|xlsx&status=&kamar_id=&tenant=&date_from=&date_to=
+func (h *ExportHandler) ExportBookings(c *gin.Context) {
+	filter := repository.BookingFilter{
+		Status:     c.Query("status"),
+		TenantName: strings.TrimSpace(c.Query("tenant")),
+		SortBy:     c.Query("sort_by"),
+		SortOrder:  c.Query("order"),
+	}
+	var err error
+	if filter.DateFrom, filter.DateTo, err = parseDateRange(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.KamarID, err = parseKamarIDQuery(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.stream(c, "booking", func(w io.Writer, format string) error {
+		return h.service.ExportBookings(w, format, filter)
+	})
+}
+
+// ExportTenants mengunduh daftar penyewa (filter sama dengan GET /api/tenants)
+// GET /api/exports/tenants?format=csv|xlsx
+func (h *ExportHandler) ExportTenants(c *gin.Context) {
+	filter, err := parseTenantFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.stream(c, "penyewa", func(w io.Writer, format string) error {
+		return h.service.ExportTenants(w, format, filter)
+	})
+}
+
+// stream menulis export langsung ke response. Setelah baris pertama terkirim status tidak
+// bisa diubah lagi, jadi error di tengah jalan hanya dicatat ke log.
+func (h *ExportHandler) stream(c *gin.Context, name string, write func(w io.Writer, format string) error) {
+	format := strings.ToLower(c.DefaultQuery("format", utils.ExportFormatCSV))
+	contentType, ok := utils.ExportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	if err := write(c.Writer, format); err != nil {
+		utils.GlobalLogger.Error("Export %s gagal: %v", name, err)
+	}
+}
@@ -64,7 +64,7 @@ func (h *GalleryHandler) CreateGallery(c *gin.Context) {
 		ImageURL: imageURL,
 	}
 
-	if err := h.service.CreateGallery(&gallery); err != nil {
+	if err := h.service.CreateGallery(&gallery, auditActor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -80,7 +80,7 @@ func (h *GalleryHandler) DeleteGallery(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteGallery(uint(id)); err != nil {
+	if err := h.service.DeleteGallery(uint(id), auditActor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"koskosan-be/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaseHandler struct {
+	service service.LeaseService
+}
+
+func NewLeaseHandler(s service.LeaseService) *LeaseHandler {
+	return &LeaseHandler{service: s}
+}
+
+type checkInRequest struct {
+	Tanggal         string `json:"tanggal"` // YYYY-MM-DD, kosong: hari ini
+	KunciDiserahkan bool   `json:"kunci_diserahkan"`
+	Catatan         string `json:"catatan"`
+}
+
+type checkOutRequest struct {
+	Tanggal      string `json:"tanggal"` // YYYY-MM-DD, kosong: hari ini
+	KondisiKamar string `json:"kondisi_kamar" binding:"required"`
+	Catatan      string `json:"catatan"`
+}
+
+// CheckIn mencatat tanggal masuk dan serah terima kunci
+// POST /api/bookings/:id/check-in
+func (h *LeaseHandler) CheckIn(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req checkInRequest
+	_ = c.ShouldBindJSON(&req) // semua field opsional
+
+	tanggal, err := parseLeaseDate(req.Tanggal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tanggal format, use YYYY-MM-DD"})
+		return
+	}
+
+	booking, err := h.service.CheckIn(uint(id), service.CheckInInput{
+		Tanggal:         tanggal,
+		KunciDiserahkan: req.KunciDiserahkan,
+		Catatan:         req.Catatan,
+	}, auditActor(c))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, booking)
+	c.JSON(http.StatusOK, booking)
+}
+
+// CheckOut mencatat tanggal keluar dan kondisi kamar (baik, perlu_perbaikan, rusak)
+// POST /api/bookings/:id/check-out
+func (h *LeaseHandler) CheckOut(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req checkOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kondisi_kamar wajib diisi"})
+		return
+	}
+
+	tanggal, err := parseLeaseDate(req.Tanggal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tanggal format, use YYYY-MM-DD"})
+		return
+	}
+
+	booking, err := h.service.CheckOut(uint(id), service.CheckOutInput{
+		Tanggal:      tanggal,
+		KondisiKamar: req.KondisiKamar,
+		Catatan:      req.Catatan,
+	}, auditActor(c))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, booking)
+	c.JSON(http.StatusOK, booking)
+}
+
+func (h *LeaseHandler) respondError(c *gin.Context, err error) {
+	if err.Error() == "record not found" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return
+	}
+	c.JSON(transitionErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+}
+
+func parseLeaseDate(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, time.Local)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"koskosan-be/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	service service.LedgerService
+}
+
+func NewLedgerHandler(s service.LedgerService) *LedgerHandler {
+	return &LedgerHandler{service: s}
+}
+
+// GetBookingLedger mengembalikan buku besar booking beserta saldo berjalan
+// GET /api/bookings/:id/ledger
+func (h *LedgerHandler) GetBookingLedger(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	role, _ := c.Get("role")
+	roleStr, _ := role.(string)
+
+	ledger, err := h.service.GetBookingLedger(uint(id), userID, roleStr)
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "unauthorized") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ledger)
+}
+
+// AddAdjustment menambahkan koreksi manual ke buku besar (admin)
+// POST /api/bookings/:id/ledger/adjustments {"jumlah": -50000, "keterangan": "Diskon"}
+func (h *LedgerHandler) AddAdjustment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	if _, ok := currentUserID(c); !ok {
+		return
+	}
+
+	var req struct {
+		Jumlah     float64 `json:"jumlah" binding:"required"`
+		Keterangan string  `json:"keterangan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adjustment, err := h.service.AddAdjustment(uint(id), req.Jumlah, req.Keterangan, auditActor(c))
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, adjustment)
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MaintenanceHandler struct {
+	service service.MaintenanceService
+}
+
+func NewMaintenanceHandler(s service.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{service: s}
+}
+
+type ticketCommentRequest struct {
+	Pesan string `json:"pesan" binding:"required"`
+}
+
+type assignTicketRequest struct {
+	AssignedTo uint `json:"assigned_to" binding:"required"`
+}
+
+type ticketStatusRequest struct {
+	Status            string `json:"status"` // Open, In Progress, Resolved, Closed
+	Catatan           string `json:"catatan"`
+	KamarOutOfService *bool  `json:"kamar_out_of_service"`
+}
+
+type rateTicketRequest struct {
+	Rating   int    `json:"rating" binding:"required"`
+	Komentar string `json:"komentar"`
+}
+
+// CreateTicket membuat laporan perbaikan (multipart: judul, deskripsi, kategori, prioritas, kamar_id, photos)
+// POST /api/maintenance
+func (h *MaintenanceHandler) CreateTicket(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	input := service.CreateTicketInput{
+		Judul:     c.PostForm("judul"),
+		Deskripsi: c.PostForm("deskripsi"),
+		Kategori:  strings.ToLower(strings.TrimSpace(c.PostForm("kategori"))),
+		Prioritas: strings.ToLower(strings.TrimSpace(c.PostForm("prioritas"))),
+	}
+	if v := c.PostForm("kamar_id"); v != "" {
+		kamarID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kamar_id"})
+			return
+		}
+		input.KamarID = uint(kamarID)
+	}
+
+	// Tolak laporan yang tidak valid sebelum foto diunggah
+	if err := h.service.CanCreateTicket(userID, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if form, err := c.MultipartForm(); err == nil {
+		photos := form.File["photos"]
+		if len(photos) > 5 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Maksimal 5 foto per laporan"})
+			return
+		}
+		for _, fileHeader := range photos {
+			if !utils.IsImageFile(fileHeader) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Semua file harus berupa gambar"})
+				return
+			}
+			url, err := utils.UploadToCloudinary(fileHeader, "maintenance")
+			if err != nil {
+				utils.GlobalLogger.Error("Failed to upload maintenance photo: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upload photo: %v", err)})
+				return
+			}
+			input.PhotoURLs = append(input.PhotoURLs, url)
+		}
+	}
+
+	ticket, err := h.service.CreateTicket(userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// GetMyTickets mengembalikan laporan perbaikan milik penyewa yang login
+// GET /api/maintenance/mine
+func (h *MaintenanceHandler) GetMyTickets(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tickets, err := h.service.GetMyTickets(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if tickets == nil {
+		tickets = []models.MaintenanceTicket{}
+	}
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetTickets mengembalikan semua tiket untuk staff dengan filter:
+// status, prioritas, kategori, kamar_id, assigned_to
+// GET /api/maintenance
+func (h *MaintenanceHandler) GetTickets(c *gin.Context) {
+	filter, err := parseMaintenanceTicketFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	tickets, totalRows, err := h.service.GetTickets(&pagination, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if tickets == nil {
+		tickets = []models.MaintenanceTicket{}
+	}
+
+	pagination.SetTotal(totalRows)
+	maskTenantPII(c, tickets)
+	c.JSON(http.StatusOK, utils.PaginatedResponse{Data: tickets, Meta: pagination})
+}
+
+func parseMaintenanceTicketFilter(c *gin.Context) (repository.MaintenanceTicketFilter, error) {
+	filter := repository.MaintenanceTicketFilter{
+		Status:    c.Query("status"),
+		Prioritas: c.Query("prioritas"),
+		Kategori:  c.Query("kategori"),
+	}
+	if v := c.Query("kamar_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid kamar_id")
+		}
+		filter.KamarID = uint(id)
+	}
+	if v := c.Query("assigned_to"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid assigned_to")
+		}
+		filter.AssignedTo = uint(id)
+	}
+	return filter, nil
+}
+
+// GetTicket mengembalikan detail tiket beserta foto dan komentar
+// GET /api/maintenance/:id
+func (h *MaintenanceHandler) GetTicket(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.service.GetTicket(uint(id), userID, c.GetString("role"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, ticket)
+	c.JSON(http.StatusOK, ticket)
+}
+
+// AddComment menambah balasan penyewa atau staff
+// POST /api/maintenance/:id/comments
+func (h *MaintenanceHandler) AddComment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ticketCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pesan wajib diisi"})
+		return
+	}
+
+	comment, err := h.service.AddComment(uint(id), userID, c.GetString("role"), req.Pesan)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// RateTicket: penyewa menilai perbaikan dan menutup tiket
+// POST /api/maintenance/:id/rating
+func (h *MaintenanceHandler) RateTicket(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req rateTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating wajib diisi"})
+		return
+	}
+
+	ticket, err := h.service.RateTicket(uint(id), userID, req.Rating, req.Komentar)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, ticket)
+	c.JSON(http.StatusOK, ticket)
+}
+
+// AssignTicket menunjuk staff penanggung jawab tiket
+// PUT /api/maintenance/:id/assign
+func (h *MaintenanceHandler) AssignTicket(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+
+	var req assignTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "assigned_to wajib diisi"})
+		return
+	}
+
+	ticket, err := h.service.AssignTicket(uint(id), req.AssignedTo, auditActor(c))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, ticket)
+	c.JSON(http.StatusOK, ticket)
+}
+
+// UpdateStatus mengubah status tiket dan/atau menandai kamar out-of-service
+// PUT /api/maintenance/:id/status
+func (h *MaintenanceHandler) UpdateStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+
+	var req ticketStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status == "" && req.KamarOutOfService == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status atau kamar_out_of_service wajib diisi"})
+		return
+	}
+
+	ticket, err := h.service.UpdateStatus(uint(id), service.UpdateTicketStatusInput{
+		Status:            req.Status,
+		Catatan:           req.Catatan,
+		KamarOutOfService: req.KamarOutOfService,
+	}, auditActor(c))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, ticket)
+	c.JSON(http.StatusOK, ticket)
+}
+
+func (h *MaintenanceHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "record not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Maintenance ticket not found"})
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
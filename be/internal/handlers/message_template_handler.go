@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageTemplateHandler struct {
+	service service.MessageTemplateService
+}
+
+func NewMessageTemplateHandler(s service.MessageTemplateService) *MessageTemplateHandler {
+	return &MessageTemplateHandler{service: s}
+}
+
+// GetTemplates menampilkan semua template pesan yang berlaku (bawaan atau hasil edit admin)
+// GET /api/message-templates
+func (h *MessageTemplateHandler) GetTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// SaveTemplate menyimpan override template untuk satu event, channel dan bahasa
+// PUT /api/message-templates {"event": "payment.confirmed", "channel": "whatsapp", "language": "en", "body": "..."}
+func (h *MessageTemplateHandler) SaveTemplate(c *gin.Context) {
+	var input models.MessageTemplate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.service.SaveTemplate(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// ResetTemplate menghapus override sehingga template bawaan dipakai lagi
+// DELETE /api/message-templates?event=payment.confirmed&channel=whatsapp&language=en
+func (h *MessageTemplateHandler) ResetTemplate(c *gin.Context) {
+	event, channel, language := c.Query("event"), c.Query("channel"), c.Query("language")
+	if event == "" || channel == "" || language == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "event, channel and language are required"})
+		return
+	}
+
+	if err := h.service.ResetTemplate(event, channel, language); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template reset to default"})
+}
+
+// PreviewTemplate merender draft template dengan data contoh (tanpa menyimpan)
+// POST /api/message-templates/preview {"event": "...", "channel": "...", "language": "...", "subject": "...", "body": "...", "data": {...}}
+func (h *MessageTemplateHandler) PreviewTemplate(c *gin.Context) {
+	var input struct {
+		models.MessageTemplate
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rendered, err := h.service.Preview(input.MessageTemplate, input.Data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
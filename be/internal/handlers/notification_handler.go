@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	service service.NotificationService
+}
+
+func NewNotificationHandler(s service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: s}
+}
+
+// currentUserID mengambil user_id dari context JWT
+func currentUserID(c *gin.Context) (uint, bool) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return 0, false
+	}
+
+	switch v := userIDRaw.(type) {
+	case float64:
+		return uint(v), true
+	case int:
+		return uint(v), true
+	case uint:
+		return v, true
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id type"})
+		return 0, false
+	}
+}
+
+// GetNotifications mengembalikan riwayat notifikasi user (terbaru dulu) beserta jumlah belum dibaca
+// GET /api/notifications?unread=true&page=1&limit=10
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, err := h.service.GetNotifications(userID, &pagination, unreadOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	unread, err := h.service.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":         notifications,
+		"meta":         pagination,
+		"unread_count": unread,
+	})
+}
+
+// GetUnreadCount untuk badge lonceng notifikasi
+// GET /api/notifications/unread-count
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	unread, err := h.service.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// MarkRead menandai satu notifikasi sebagai dibaca
+// PUT /api/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.MarkRead(uint(id), userID); err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllRead menandai semua notifikasi user sebagai dibaca
+// PUT /api/notifications/read-all
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.MarkAllRead(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read", "updated": updated})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxHandler struct {
+	service service.OutboxService
+}
+
+func NewOutboxHandler(s service.OutboxService) *OutboxHandler {
+	return &OutboxHandler{service: s}
+}
+
+// GetMessages menampilkan antrean pesan WA/email (admin)
+// GET /api/outbox?status=Dead&channel=whatsapp&page=1&limit=10
+func (h *OutboxHandler) GetMessages(c *gin.Context) {
+	pagination := utils.GeneratePaginationFromRequest(c)
+	filter := repository.OutboxFilter{
+		Status:  c.Query("status"),
+		Channel: c.Query("channel"),
+	}
+
+	messages, err := h.service.GetMessages(&pagination, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse{Data: messages, Meta: pagination})
+}
+
+// Resend mengirim ulang pesan yang gagal / dead-letter (admin)
+// POST /api/outbox/:id/resend
+func (h *OutboxHandler) Resend(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	message, err := h.service.Resend(uint(id))
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if message.Status != "Sent" {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Resend failed: " + message.LastError, "data": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message sent", "data": message})
+}
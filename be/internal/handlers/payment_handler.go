@@ -3,10 +3,12 @@ package handlers
 import (
 	"fmt"
 	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
 	"koskosan-be/internal/service"
 	"koskosan-be/internal/utils"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,7 +23,36 @@ func NewPaymentHandler(s service.PaymentService) *PaymentHandler {
 }
 
 func (h *PaymentHandler) GetAllPayments(c *gin.Context) {
-	payments, err := h.service.GetAllPayments()
+	// Pencarian berdasarkan nomor invoice, mis. ?order_id=INV/2026/10/0042
+	if orderID := c.Query("order_id"); orderID != "" {
+		payments := []models.Pembayaran{}
+		payment, err := h.service.GetPaymentByOrderID(orderID)
+		if err != nil && err.Error() != "record not found" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err == nil {
+			payments = append(payments, *payment)
+		}
+
+		pagination := utils.Pagination{Page: 1, Limit: 1}
+		pagination.SetTotal(int64(len(payments)))
+		maskTenantPII(c, payments)
+		c.JSON(http.StatusOK, utils.PaginatedResponse{
+			Data: payments,
+			Meta: pagination,
+		})
+		return
+	}
+
+	filter, err := parsePaymentFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	payments, totalRows, err := h.service.GetPaymentsPaginated(&pagination, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -29,7 +60,69 @@ func (h *PaymentHandler) GetAllPayments(c *gin.Context) {
 	if payments == nil {
 		payments = []models.Pembayaran{}
 	}
-	c.JSON(http.StatusOK, payments)
+
+	pagination.SetTotal(totalRows)
+	maskTenantPII(c, payments)
+	c.JSON(http.StatusOK, utils.PaginatedResponse{
+		Data: payments,
+		Meta: pagination,
+	})
+}
+
+// parsePaymentFilter membaca filter daftar pembayaran dari query string:
+// status, method, type, date_from, date_to (YYYY-MM-DD, inklusif), kamar_id, tenant, sort_by, order
+func parsePaymentFilter(c *gin.Context) (repository.PaymentFilter, error) {
+	filter := repository.PaymentFilter{
+		Status:     c.Query("status"),
+		Method:     c.Query("method"),
+		Type:       c.Query("type"),
+		TenantName: strings.TrimSpace(c.Query("tenant")),
+		SortBy:     c.Query("sort_by"),
+		SortOrder:  c.Query("order"),
+	}
+
+	var err error
+	if filter.DateFrom, filter.DateTo, err = parseDateRange(c); err != nil {
+		return filter, err
+	}
+	if filter.KamarID, err = parseKamarIDQuery(c); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseDateRange membaca date_from dan date_to (YYYY-MM-DD). date_to inklusif, sehingga
+// dikembalikan sebagai awal hari berikutnya untuk dipakai dengan kondisi "<".
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	var from, to time.Time
+	if v := c.Query("date_from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid date_from format, use YYYY-MM-DD")
+		}
+		from = t
+	}
+	if v := c.Query("date_to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid date_to format, use YYYY-MM-DD")
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func parseKamarIDQuery(c *gin.Context) (uint, error) {
+	v := c.Query("kamar_id")
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid kamar_id")
+	}
+	return uint(id), nil
 }
 
 func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
@@ -40,8 +133,8 @@ func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ConfirmPayment(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.ConfirmPayment(uint(id), auditActor(c)); err != nil {
+		c.JSON(transitionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -56,8 +149,8 @@ func (h *PaymentHandler) RejectPayment(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RejectPayment(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.RejectPayment(uint(id), auditActor(c)); err != nil {
+		c.JSON(transitionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -92,7 +185,7 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 		userID = v
 	}
 
-	payment, err := h.service.CreatePaymentSession(req.PemesananID, req.PaymentType, userID)
+	payment, err := h.service.CreatePaymentSession(req.PemesananID, req.PaymentType, userID, auditActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -157,12 +250,12 @@ func (h *PaymentHandler) UploadPaymentProof(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UploadPaymentProof(uint(id), proofURL, userID); err != nil {
+	if err := h.service.UploadPaymentProof(uint(id), proofURL, userID, auditActor(c)); err != nil {
 		if err.Error() == "unauthorized: you can only upload proof for your own payments" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(transitionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -187,8 +280,8 @@ func (h *PaymentHandler) ConfirmCashPayment(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ConfirmCashPayment(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.ConfirmCashPayment(uint(id), auditActor(c)); err != nil {
+		c.JSON(transitionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -211,3 +304,88 @@ func (h *PaymentHandler) GetReminders(c *gin.Context) {
 
 	c.JSON(http.StatusOK, reminders)
 }
+
+// DownloadReceipt mengunduh kwitansi PDF pembayaran (invoice jika belum lunas atau ?type=invoice)
+// GET /api/payments/:id/receipt
+func (h *PaymentHandler) DownloadReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
+	var userID uint
+	switch v := userIDRaw.(type) {
+	case float64:
+		userID = uint(v)
+	case int:
+		userID = uint(v)
+	case uint:
+		userID = v
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id type"})
+		return
+	}
+
+	role, _ := c.Get("role")
+	roleStr, _ := role.(string)
+
+	pdf, filename, err := h.service.GetPaymentDocument(uint(id), userID, roleStr, c.Query("type"))
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "unauthorized") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// RescheduleInstallments mengatur ulang cicilan yang belum dibayar dengan pembagian kustom (admin)
+// PUT /api/bookings/:id/installments {"amounts": [1000000, 500000], "first_due": "2026-11-01"}
+func (h *PaymentHandler) RescheduleInstallments(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req struct {
+		Amounts  []float64 `json:"amounts" binding:"required"`
+		FirstDue string    `json:"first_due"` // opsional, format YYYY-MM-DD
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var firstDue time.Time
+	if req.FirstDue != "" {
+		firstDue, err = time.ParseInLocation("2006-01-02", req.FirstDue, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid first_due format, use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	plan, err := h.service.RescheduleInstallments(uint(id), req.Amounts, firstDue, auditActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
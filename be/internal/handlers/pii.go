@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"koskosan-be/internal/utils"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maskTenantPII menyamarkan setiap field NIK di dalam v (Penyewa yang ter-preload di pembayaran,
+// refund, booking, tiket, maupun DTO dashboard) untuk staff tanpa tenants:read_pii.
+// Penyewa yang melihat datanya sendiri tidak disamarkan. v harus pointer atau slice agar bisa diubah.
+func maskTenantPII(c *gin.Context, v interface{}) {
+	role := c.GetString("role")
+	if !utils.IsStaffRole(role) || utils.RoleHasPermission(role, utils.PermTenantsReadPII) {
+		return
+	}
+	maskNIKFields(reflect.ValueOf(v))
+}
+
+func maskNIKFields(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			maskNIKFields(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			maskNIKFields(v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			field := v.Field(i)
+			if t.Field(i).Name == "NIK" && field.Kind() == reflect.String {
+				if field.CanSet() {
+					field.SetString(utils.MaskNIK(field.String()))
+				}
+				continue
+			}
+			maskNIKFields(field)
+		}
+	}
+}
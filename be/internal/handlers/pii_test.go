@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubPaymentService hanya mengimplementasikan daftar pembayaran paginated
+type stubPaymentService struct {
+	service.PaymentService
+	payments []models.Pembayaran
+}
+
+func (s *stubPaymentService) GetPaymentsPaginated(pagination *utils.Pagination, filter repository.PaymentFilter) ([]models.Pembayaran, int64, error) {
+	return s.payments, int64(len(s.payments)), nil
+}
+
+func getPaymentsAs(t *testing.T, role string) []models.Pembayaran {
+	gin.SetMode(gin.TestMode)
+	handler := NewPaymentHandler(&stubPaymentService{payments: []models.Pembayaran{
+		{ID: 1, Pemesanan: models.Pemesanan{Penyewa: models.Penyewa{NamaLengkap: "Budi", NIK: "3201234567890001"}}},
+	}})
+
+	router := gin.New()
+	router.GET("/payments", func(c *gin.Context) { c.Set("role", role) }, handler.GetAllPayments)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payments", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Data []models.Pembayaran `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Data, 1)
+	return body.Data
+}
+
+// Caretaker punya payments:read tetapi tidak tenants:read_pii: NIK penyewa disamarkan
+func TestGetAllPayments_CaretakerGetsMaskedNIK(t *testing.T) {
+	payments := getPaymentsAs(t, utils.RoleCaretaker)
+	assert.Equal(t, "************0001", payments[0].Pemesanan.Penyewa.NIK)
+}
+
+func TestGetAllPayments_AdminGetsFullNIK(t *testing.T) {
+	payments := getPaymentsAs(t, utils.RoleAdmin)
+	assert.Equal(t, "3201234567890001", payments[0].Pemesanan.Penyewa.NIK)
+}
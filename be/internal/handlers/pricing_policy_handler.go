@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingPolicyHandler struct {
+	service service.PricingPolicyService
+}
+
+func NewPricingPolicyHandler(s service.PricingPolicyService) *PricingPolicyHandler {
+	return &PricingPolicyHandler{service: s}
+}
+
+// GetPolicies mengembalikan semua kebijakan yang tersimpan (admin)
+func (h *PricingPolicyHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.service.GetAllPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+// GetEffectivePolicy mengembalikan kebijakan yang berlaku untuk ?tipe_kamar=, dipakai frontend
+// untuk menampilkan nominal DP sebelum booking.
+func (h *PricingPolicyHandler) GetEffectivePolicy(c *gin.Context) {
+	policy, err := h.service.GetEffectivePolicy(c.Query("tipe_kamar"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// SavePolicy membuat/memperbarui kebijakan berdasarkan tipe_kamar ("" = default)
+func (h *PricingPolicyHandler) SavePolicy(c *gin.Context) {
+	var input models.PricingPolicy
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.service.SavePolicy(input, auditActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *PricingPolicyHandler) DeletePolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.service.DeletePolicy(uint(id), auditActor(c)); err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pricing policy not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pricing policy deleted"})
+}
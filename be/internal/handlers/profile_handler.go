@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"koskosan-be/internal/models"
 	"koskosan-be/internal/service"
@@ -51,6 +52,7 @@ func (h *ProfileHandler) GetProfile(c *gin.Context) {
 		"user":           user,
 		"penyewa":        penyewa,
 		"is_google_user": isGoogleUser,
+		"permissions":    utils.PermissionsForRole(user.Role),
 	})
 }
 
@@ -89,6 +91,8 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 		input.NomorHP = c.PostForm("nomor_hp")
 		input.AlamatAsal = c.PostForm("alamat_asal")
 		input.JenisKelamin = c.PostForm("jenis_kelamin")
+		input.Bahasa = c.PostForm("bahasa")
+		input.PreferensiReminder = c.PostForm("preferensi_reminder")
 
 		// Handle file upload
 		file, err := c.FormFile("foto_profil")
@@ -113,6 +117,10 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 
 	penyewa, err := h.service.UpdateProfile(userID, input)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidReminderPreference) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -153,7 +161,9 @@ func (h *ProfileHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ChangePassword(userID, input.OldPassword, input.NewPassword); err != nil {
+	// Sesi saat ini tetap aktif, sesi di perangkat lain dicabut
+	sessionID := c.GetString("session_id")
+	if err := h.service.ChangePassword(userID, input.OldPassword, input.NewPassword, sessionID); err != nil {
 		status := http.StatusInternalServerError
 		if err.Error() == "current password is incorrect" {
 			status = http.StatusBadRequest
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	service service.RefundService
+}
+
+func NewRefundHandler(s service.RefundService) *RefundHandler {
+	return &RefundHandler{service: s}
+}
+
+type refundNoteRequest struct {
+	Catatan string `json:"catatan"`
+}
+
+// GetAllRefunds mengembalikan daftar refund, bisa difilter ?status=Requested|Approved|Paid|Rejected
+func (h *RefundHandler) GetAllRefunds(c *gin.Context) {
+	refunds, err := h.service.GetAllRefunds(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	maskTenantPII(c, refunds)
+	c.JSON(http.StatusOK, refunds)
+}
+
+func (h *RefundHandler) ApproveRefund(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req refundNoteRequest
+	_ = c.ShouldBindJSON(&req) // catatan opsional
+
+	refund, err := h.service.ApproveRefund(uint(id), req.Catatan, auditActor(c))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, refund)
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *RefundHandler) RejectRefund(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req refundNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Catatan == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alasan penolakan (catatan) wajib diisi"})
+		return
+	}
+
+	refund, err := h.service.RejectRefund(uint(id), req.Catatan, auditActor(c))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, refund)
+	c.JSON(http.StatusOK, refund)
+}
+
+// MarkRefundPaid menandai refund sudah ditransfer, dengan upload bukti transfer (form field "proof")
+func (h *RefundHandler) MarkRefundPaid(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	file, err := c.FormFile("proof")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refund transfer proof file is required"})
+		return
+	}
+
+	if !utils.IsImageFile(file) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type. Only images are allowed."})
+		return
+	}
+
+	proofURL, err := utils.UploadToCloudinary(file, "refunds")
+	if err != nil {
+		utils.GlobalLogger.Error("Upload refund proof failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upload proof: %v", err)})
+		return
+	}
+
+	refund, err := h.service.MarkRefundPaid(uint(id), proofURL, auditActor(c))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	maskTenantPII(c, refund)
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *RefundHandler) respondError(c *gin.Context, err error) {
+	if err.Error() == "record not found" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refund not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrRefundStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Refund sudah diproses admin lain, muat ulang data"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
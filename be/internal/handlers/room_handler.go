@@ -112,7 +112,7 @@ func (h *KamarHandler) CreateKamar(c *gin.Context) {
 		ImageURL:      mainImageURL,
 	}
 
-	if err := h.service.Create(&kamar); err != nil {
+	if err := h.service.Create(&kamar, auditActor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -224,7 +224,7 @@ func (h *KamarHandler) UpdateKamar(c *gin.Context) {
 		}
 	}
 
-	if err := h.service.Update(kamar); err != nil {
+	if err := h.service.Update(kamar, auditActor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -247,7 +247,7 @@ func (h *KamarHandler) DeleteKamar(c *gin.Context) {
 	}
 
 	// Jalankan service Delete (sudah berisi validasi + auto-cancel + notifikasi)
-	if err := h.service.Delete(uint(id)); err != nil {
+	if err := h.service.Delete(uint(id), auditActor(c)); err != nil {
 		// Deteksi error "kamar tidak dapat dihapus" (booking aktif/confirmed) → 409 Conflict
 		errMsg := err.Error()
 		if contains(errMsg, "tidak dapat dihapus") || contains(errMsg, "pemesanan aktif") {
@@ -315,7 +315,7 @@ func (h *KamarHandler) UpdateKamarStatus(c *gin.Context) {
 
 	// Update only the status
 	kamar.Status = input.Status
-	if err := h.service.Update(kamar); err != nil {
+	if err := h.service.Update(kamar, auditActor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
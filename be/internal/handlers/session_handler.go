@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"koskosan-be/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	service service.SessionService
+}
+
+func NewSessionHandler(s service.SessionService) *SessionHandler {
+	return &SessionHandler{service: s}
+}
+
+// GetSessions menampilkan perangkat yang sedang login (sesi saat ini ditandai current)
+// GET /api/profile/sessions
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.service.ListSessions(userID, c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeSession mengeluarkan satu perangkat
+// DELETE /api/profile/sessions/:id
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeSession(userID, c.Param("id")); err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeOtherSessions mengeluarkan semua perangkat lain selain sesi saat ini
+// DELETE /api/profile/sessions
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.service.RevokeOtherSessions(userID, c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked", "revoked": revoked})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StaffHandler struct {
+	service service.StaffService
+}
+
+func NewStaffHandler(s service.StaffService) *StaffHandler {
+	return &StaffHandler{service: s}
+}
+
+// staffErrorStatus memetakan error manajemen staff ke status HTTP
+func staffErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidStaffRole), errors.Is(err, service.ErrStaffSelfModify), errors.Is(err, service.ErrNotStaff):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrStaffOwnerOnly):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrStaffUsernameTaken):
+		return http.StatusConflict
+	case err.Error() == "record not found":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetStaff menampilkan semua akun staff
+// GET /api/staff
+func (h *StaffHandler) GetStaff(c *gin.Context) {
+	staff, err := h.service.GetStaff()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if staff == nil {
+		staff = []models.User{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": staff})
+}
+
+// GetRoles menampilkan role staff beserta permission-nya
+// GET /api/staff/roles
+func (h *StaffHandler) GetRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.service.GetRoles()})
+}
+
+// CreateStaff membuat akun staff baru
+// POST /api/staff
+func (h *StaffHandler) CreateStaff(c *gin.Context) {
+	var input struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+		Role     string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input.Username = utils.SanitizeString(input.Username)
+	if validationErr := utils.ValidateUsername(input.Username); validationErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message})
+		return
+	}
+	if validationErr := utils.ValidatePassword(input.Password); validationErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message})
+		return
+	}
+
+	user, err := h.service.CreateStaff(auditActor(c), input.Username, input.Password, input.Role)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Staff berhasil dibuat", "user": user})
+}
+
+// UpdateRole mengganti role staff
+// PUT /api/staff/:id/role
+func (h *StaffHandler) UpdateRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid staff ID"})
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := currentUserID(c); !ok {
+		return
+	}
+
+	user, err := h.service.UpdateRole(auditActor(c), uint(id), input.Role)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role staff berhasil diubah", "user": user})
+}
+
+// DeleteStaff menghapus akun staff
+// DELETE /api/staff/:id
+func (h *StaffHandler) DeleteStaff(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid staff ID"})
+		return
+	}
+
+	if _, ok := currentUserID(c); !ok {
+		return
+	}
+
+	if err := h.service.DeleteStaff(auditActor(c), uint(id)); err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Staff berhasil dihapus"})
+}
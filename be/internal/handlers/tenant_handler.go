@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
 	"koskosan-be/internal/service"
 	"koskosan-be/internal/utils"
 	"net/http"
@@ -20,10 +21,13 @@ func NewTenantHandler(s service.TenantService) *TenantHandler {
 
 func (h *TenantHandler) GetAllTenants(c *gin.Context) {
 	pagination := utils.GeneratePaginationFromRequest(c)
-	search := c.Query("search")
-	role := c.Query("role")
+	filter, err := parseTenantFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	tenants, totalRows, err := h.service.GetTenantsPaginated(&pagination, search, role)
+	tenants, totalRows, err := h.service.GetTenantsPaginated(&pagination, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -32,9 +36,9 @@ func (h *TenantHandler) GetAllTenants(c *gin.Context) {
 	if tenants == nil {
 		tenants = []models.Penyewa{}
 	}
+	maskTenantPII(c, tenants)
 
-	pagination.TotalRows = totalRows
-	pagination.TotalPages = int((totalRows + int64(pagination.Limit) - 1) / int64(pagination.Limit))
+	pagination.SetTotal(totalRows)
 
 	response := utils.PaginatedResponse{
 		Data: tenants,
@@ -44,6 +48,25 @@ func (h *TenantHandler) GetAllTenants(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// hasPermission memeriksa permission role user yang sedang login (lihat utils/permissions.go)
+func hasPermission(c *gin.Context, permission string) bool {
+	return utils.RoleHasPermission(c.GetString("role"), permission)
+}
+
+// parseTenantFilter membaca filter daftar penyewa: search, role, kamar_id, sort_by, order
+func parseTenantFilter(c *gin.Context) (repository.TenantFilter, error) {
+	filter := repository.TenantFilter{
+		Search:     c.Query("search"),
+		Role:       c.Query("role"),
+		SortBy:     c.Query("sort_by"),
+		SortOrder:  c.Query("order"),
+		ExcludePII: !hasPermission(c, utils.PermTenantsReadPII),
+	}
+	var err error
+	filter.KamarID, err = parseKamarIDQuery(c)
+	return filter, err
+}
+
 func (h *TenantHandler) DeactivateTenant(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -52,10 +75,38 @@ func (h *TenantHandler) DeactivateTenant(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeactivateTenant(uint(id)); err != nil {
+	if err := h.service.DeactivateTenant(uint(id), auditActor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Status pengguna berhasil diubah menjadi Non Active"})
 }
+
+// GetLoginHistory menampilkan riwayat login penyewa (IP, perangkat, berhasil/gagal)
+// GET /api/admin/tenants/:id/login-history?page=1&limit=10
+func (h *TenantHandler) GetLoginHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	attempts, totalRows, err := h.service.GetLoginHistory(uint(id), &pagination)
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if attempts == nil {
+		attempts = []models.LoginAttempt{}
+	}
+
+	pagination.SetTotal(totalRows)
+	c.JSON(http.StatusOK, utils.PaginatedResponse{Data: attempts, Meta: pagination})
+}
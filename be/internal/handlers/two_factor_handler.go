@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"koskosan-be/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	service service.TwoFactorService
+}
+
+func NewTwoFactorHandler(s service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{service: s}
+}
+
+type twoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// twoFactorErrorStatus memetakan error 2FA ke status HTTP
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidOTP):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrTwoFactorNotSetup), errors.Is(err, service.ErrTwoFactorNotEnabled):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTwoFactorMandatory):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetStatus menampilkan status 2FA user
+// GET /api/profile/2fa
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetStatus(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// Setup membuat secret TOTP baru; 2FA belum aktif sampai Enable
+// POST /api/profile/2fa/setup
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.service.Setup(userID)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// Enable mengaktifkan 2FA dengan kode pertama dan mengembalikan recovery code (sekali tampil)
+// POST /api/profile/2fa/enable
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input twoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.Enable(userID, input.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "2FA berhasil diaktifkan", "recovery_codes": codes})
+}
+
+// Disable menonaktifkan 2FA (tidak berlaku untuk admin)
+// POST /api/profile/2fa/disable
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input twoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Disable(userID, input.Code); err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "2FA berhasil dinonaktifkan"})
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code
+// POST /api/profile/2fa/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input twoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(userID, input.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
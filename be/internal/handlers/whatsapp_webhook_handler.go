@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"io"
+	"koskosan-be/internal/service"
+	"koskosan-be/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WhatsAppWebhookHandler menerima callback status pengiriman dari provider WA yang aktif
+type WhatsAppWebhookHandler struct {
+	outboxService service.OutboxService
+	provider      utils.WhatsAppProvider
+	callbackToken string
+}
+
+func NewWhatsAppWebhookHandler(outboxService service.OutboxService, provider utils.WhatsAppProvider, callbackToken string) *WhatsAppWebhookHandler {
+	return &WhatsAppWebhookHandler{outboxService: outboxService, provider: provider, callbackToken: callbackToken}
+}
+
+// validToken mencocokkan token dari query (?token=) atau header X-Callback-Token
+func (h *WhatsAppWebhookHandler) validToken(token string) bool {
+	if h.callbackToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.callbackToken)) == 1
+}
+
+// Verify menjawab verifikasi webhook WhatsApp Cloud API
+// GET /api/webhooks/whatsapp?hub.mode=subscribe&hub.verify_token=...&hub.challenge=...
+func (h *WhatsAppWebhookHandler) Verify(c *gin.Context) {
+	if c.Query("hub.mode") != "subscribe" || !h.validToken(c.Query("hub.verify_token")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid verify token"})
+		return
+	}
+	c.String(http.StatusOK, c.Query("hub.challenge"))
+}
+
+// StatusCallback menyimpan status pengiriman (sent/delivered/read/failed) ke pesan outbox
+// POST /api/webhooks/whatsapp?token=...
+func (h *WhatsAppWebhookHandler) StatusCallback(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		token = c.GetHeader("X-Callback-Token")
+	}
+	if !h.validToken(token) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid callback token"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+
+	statuses, err := h.provider.ParseStatusCallback(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.outboxService.RecordDeliveryStatuses(h.provider.Name(), statuses)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": len(statuses), "updated": updated})
+}
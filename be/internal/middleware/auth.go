@@ -31,6 +31,7 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 
 		c.Next()
 	}
@@ -67,6 +68,28 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
+// RequirePermission memastikan role user memiliki semua permission yang diminta (lihat utils/permissions.go)
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role == "" {
+			utils.ForbiddenError(c, "User role not found")
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !utils.RoleHasPermission(role, permission) {
+				utils.ForbiddenError(c, "You don't have permission to access this resource")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
 // OptionalAuthMiddleware verify token tapi tidak mandatory
 func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
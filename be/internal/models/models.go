@@ -16,6 +16,79 @@ type User struct {
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 	ResetToken       string         `json:"-"`
 	ResetTokenExpiry time.Time      `json:"-"`
+	// TOTP 2FA: secret diisi saat setup, aktif setelah kode pertama diverifikasi
+	TwoFactorSecret   string `gorm:"size:64" json:"-"`
+	TwoFactorEnabled  bool   `gorm:"default:false" json:"two_factor_enabled"`
+	TwoFactorLastStep int64  `json:"-"` // langkah TOTP terakhir yang dipakai (cegah replay)
+}
+
+// RecoveryCode adalah kode cadangan sekali pakai untuk login 2FA saat authenticator tidak tersedia
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;index" json:"-"` // SHA-256 kode (ternormalisasi)
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// UserSession adalah satu refresh token yang pernah diterbitkan. Semua token hasil rotasi dari
+// satu login berbagi FamilyID (= satu perangkat); token lama yang dipakai ulang mencabut seluruh family.
+type UserSession struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"index" json:"user_id"`
+	FamilyID      string    `gorm:"size:36;index" json:"family_id"`
+	TokenHash     string    `gorm:"size:64;uniqueIndex" json:"-"` // SHA-256 refresh token
+	UserAgent     string    `json:"user_agent"`
+	IPAddress     string    `json:"ip_address"`
+	SignedInAt    time.Time `json:"signed_in_at"` // waktu login awal family
+	ExpiresAt     time.Time `json:"expires_at"`
+	Revoked       bool      `gorm:"index" json:"revoked"`
+	RevokedAt     time.Time `json:"revoked_at"`
+	RevokedReason string    `json:"revoked_reason"` // rotated, logout, password_changed, password_reset, deactivated, reuse_detected, revoked
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// LoginThrottle menghitung percobaan login gagal berturut-turut per username untuk penguncian
+// sementara dengan backoff eksponensial. Kegagalan dengan username yang tidak terdaftar dihitung
+// per IP dengan key "ip:<alamat>" agar tabel tidak terisi username acak.
+type LoginThrottle struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Username       string    `gorm:"uniqueIndex" json:"username"`
+	FailedAttempts int       `json:"failed_attempts"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+	LockedUntil    time.Time `json:"locked_until"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// LoginAttempt adalah riwayat login (berhasil maupun gagal) yang ditampilkan di detail penyewa
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index" json:"user_id"` // 0 jika username tidak terdaftar
+	Username  string    `gorm:"index" json:"username"`
+	Method    string    `json:"method"` // password, google
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"` // unknown_user, wrong_password, wrong_otp, locked, deactivated
+	NewDevice bool      `json:"new_device"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// AuditLog adalah jejak append-only mutasi admin & keuangan (siapa, dari mana, apa yang berubah).
+// Before/After berisi JSON field yang berubah saja; create hanya mengisi After, delete hanya Before.
+type AuditLog struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ActorUserID uint      `gorm:"index" json:"actor_user_id"` // 0 untuk aksi sistem (scheduler)
+	ActorRole   string    `json:"actor_role"`
+	IPAddress   string    `json:"ip_address"`
+	Action      string    `gorm:"index" json:"action"` // contoh: payment.confirm, kamar.update
+	EntityType  string    `gorm:"index:idx_audit_entity" json:"entity_type"`
+	EntityID    uint      `gorm:"index:idx_audit_entity" json:"entity_id"`
+	Before      string    `gorm:"type:text" json:"before"`
+	After       string    `gorm:"type:text" json:"after"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
 }
 
 type Kamar struct {
@@ -34,6 +107,7 @@ type Kamar struct {
 	ImageURL      string         `json:"image_url"`
 	IsLocked      bool           `gorm:"default:false" json:"is_locked"` // Flag to prevent deletion if room is booked/has active tenant
 	LockedReason  string         `json:"locked_reason"`                   // Reason for lock (e.g., "tenant_non_payment", "active_booking")
+	AlasanMaintenance string     `json:"alasan_maintenance"`              // Penyebab status Maintenance: check_out (kondisi kamar saat check-out), maintenance_ticket; kosong jika diatur admin
 	Images        []KamarImage   `gorm:"foreignKey:KamarID" json:"Images,omitempty"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
@@ -71,21 +145,23 @@ type Review struct {
 }
 
 type Penyewa struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	UserID       uint           `gorm:"index" json:"user_id"`
-	User         User           `gorm:"foreignKey:UserID" json:"user"`
-	NamaLengkap  string         `json:"nama_lengkap"`
-	Email        string         `json:"email"`
-	NIK          string         `json:"nik"`
-	NomorHP      string         `json:"nomor_hp"`
-	TanggalLahir time.Time      `json:"tanggal_lahir"`
-	AlamatAsal   string         `json:"alamat_asal"`
-	JenisKelamin string         `json:"jenis_kelamin"` // enum
-	FotoProfil   string         `json:"foto_profil"`
-	Role         string         `gorm:"index;default:guest" json:"role"` // guest, tenant, former_tenant
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 uint           `gorm:"primaryKey" json:"id"`
+	UserID             uint           `gorm:"index" json:"user_id"`
+	User               User           `gorm:"foreignKey:UserID" json:"user"`
+	NamaLengkap        string         `json:"nama_lengkap"`
+	Email              string         `json:"email"`
+	NIK                string         `json:"nik"`
+	NomorHP            string         `json:"nomor_hp"`
+	TanggalLahir       time.Time      `json:"tanggal_lahir"`
+	AlamatAsal         string         `json:"alamat_asal"`
+	JenisKelamin       string         `json:"jenis_kelamin"` // enum
+	FotoProfil         string         `json:"foto_profil"`
+	Role               string         `gorm:"index;default:guest" json:"role"`                     // guest, tenant, former_tenant
+	Bahasa             string         `gorm:"size:5;default:id" json:"bahasa"`                     // Bahasa notifikasi WA/email: id, en
+	PreferensiReminder string         `gorm:"size:10;default:whatsapp" json:"preferensi_reminder"` // Channel reminder tagihan: whatsapp, email, both, none
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type Pemesanan struct {
@@ -97,7 +173,14 @@ type Pemesanan struct {
 	TanggalMulai    time.Time      `json:"tanggal_mulai"`
 	TanggalKeluar   time.Time      `json:"tanggal_keluar"`
 	DurasiSewa      int            `json:"durasi_sewa"`
-	StatusPemesanan string         `gorm:"index" json:"status_pemesanan"`   // enum
+	StatusPemesanan BookingStatus  `gorm:"index" json:"status_pemesanan"`   // lihat status.go
+	TanggalCheckIn  time.Time      `json:"tanggal_check_in"`                // Tanggal penyewa benar-benar masuk (zero: belum check-in)
+	KunciDiserahkan bool           `json:"kunci_diserahkan"`                // Kunci kamar sudah diserahkan saat check-in
+	CatatanCheckIn  string         `json:"catatan_check_in"`
+	TanggalCheckOut time.Time      `gorm:"index" json:"tanggal_check_out"`  // Tanggal penyewa benar-benar keluar
+	KondisiKamar    string         `json:"kondisi_kamar"`                   // enum: baik, perlu_perbaikan, rusak (kosong: belum diperiksa)
+	CatatanCheckOut string         `json:"catatan_check_out"`
+	AlasanCheckOut  string         `json:"alasan_check_out"`                // enum: check_out (oleh admin), lease_expired (job harian)
 	Pembayaran      []Pembayaran   `gorm:"foreignKey:PemesananID" json:"-"` // Relation for eager loading
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
@@ -111,12 +194,17 @@ type Pembayaran struct {
 	JumlahBayar       float64        `json:"jumlah_bayar"`
 	TanggalBayar      time.Time      `json:"tanggal_bayar"`
 	BuktiTransfer     string         `json:"bukti_transfer"`
-	StatusPembayaran  string         `gorm:"index" json:"status_pembayaran"` // enum: Pending, Confirmed, Failed, Settled, Cancelled
-	OrderID           string         `json:"order_id"`
+	StatusPembayaran  PaymentStatus  `gorm:"index" json:"status_pembayaran"` // lihat status.go
+	OrderID           string         `gorm:"index" json:"order_id"` // Nomor invoice berurutan, mis. INV/2026/10/0042
 	MetodePembayaran  string         `json:"metode_pembayaran"`   // enum: transfer, cash
-	TipePembayaran    string         `json:"tipe_pembayaran"`     // enum: full, dp (down payment), extend
+	TipePembayaran    string         `json:"tipe_pembayaran"`     // enum: full, dp (down payment), installment (cicilan sisa DP), extend, deposit (uang jaminan), deposit_charge (kekurangan jaminan saat check-out)
 	JumlahDP          float64        `json:"jumlah_dp"`           // Jumlah DP jika tipe_pembayaran = dp
+	CicilanKe         int            `json:"cicilan_ke"`          // Urutan cicilan jika tipe_pembayaran = installment
+	TotalDenda        float64        `json:"total_denda"`         // Akumulasi denda keterlambatan (lihat LineItems)
+	EscalationLevel   int            `json:"escalation_level"`    // 0: normal, 1: notifikasi telat terkirim, 2: kamar dikunci
+	LineItems         []PaymentLineItem `gorm:"foreignKey:PembayaranID" json:"line_items,omitempty"`
 	TanggalJatuhTempo time.Time      `json:"tanggal_jatuh_tempo"` // Tanggal pembayaran cicilan berikutnya
+	NomorKwitansi     string         `gorm:"index" json:"nomor_kwitansi"` // Diisi saat pembayaran dikonfirmasi
 	IdempotencyKey    string         `gorm:"uniqueIndex:idx_payment_idempotency;index" json:"idempotency_key"` // FIX #19: Prevent duplicate confirmations
 	ConfirmedAt       time.Time      `json:"confirmed_at"`        // FIX #1, #3: Track exact confirmation time for audit
 	CreatedAt         time.Time      `json:"created_at"`
@@ -124,6 +212,65 @@ type Pembayaran struct {
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`      // FIX #2, #9: Soft delete instead of hard delete
 }
 
+// InvoiceSequence menyimpan nomor invoice terakhir per tahun. Baris dikunci saat alokasi
+// sehingga penomoran berurutan tanpa celah.
+type InvoiceSequence struct {
+	Tahun      int       `gorm:"primaryKey;autoIncrement:false" json:"tahun"`
+	LastNumber int       `json:"last_number"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Notification adalah notifikasi in-app milik satu user (riwayat pusat notifikasi)
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index:idx_notification_user_read" json:"user_id"`
+	Event     string     `gorm:"index" json:"event"` // mis. payment.confirmed, booking.auto_cancelled
+	Title     string     `json:"title"`
+	Message   string     `gorm:"type:text" json:"message"`
+	Type      string     `json:"type"`                 // info, success, error
+	Data      string     `gorm:"type:text" json:"data"` // JSON referensi (payment_id, booking_id, ...)
+	IsRead    bool       `gorm:"index:idx_notification_user_read" json:"is_read"`
+	ReadAt    *time.Time `json:"read_at"` // nil = belum dibaca
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// OutboxMessage adalah pesan WhatsApp/email yang menunggu dikirim worker outbox.
+// Gagal kirim dicoba ulang dengan backoff eksponensial; setelah MaxAttempts menjadi "Dead".
+type OutboxMessage struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Channel       string    `gorm:"index" json:"channel"` // whatsapp, email
+	Kind          string    `json:"kind"`                 // whatsapp, email
+	Recipient     string    `json:"recipient"`
+	Payload       string    `gorm:"type:text" json:"payload"`                             // JSON isi pesan sesuai Kind
+	Status        string    `gorm:"index:idx_outbox_due;default:'Pending'" json:"status"` // enum: Pending, Sending, Sent, Dead
+	Attempts      int       `json:"attempts"`
+	MaxAttempts   int       `json:"max_attempts"`
+	NextAttemptAt time.Time `gorm:"index:idx_outbox_due" json:"next_attempt_at"`
+	LastError     string    `gorm:"type:text" json:"last_error"`
+	SentAt        time.Time `json:"sent_at"`
+	// Status pengiriman dari callback provider WA (sent, delivered, read, failed)
+	Provider          string    `json:"provider"`
+	ProviderMessageID string    `gorm:"index" json:"provider_message_id"`
+	DeliveryStatus    string    `json:"delivery_status"`
+	DeliveryUpdatedAt time.Time `json:"delivery_updated_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// MessageTemplate menyimpan template pesan WA/email yang diubah admin. Template bawaan ada di
+// kode (service/message_templates.go); baris ini hanya berisi override per event, channel dan bahasa.
+type MessageTemplate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Event     string    `gorm:"uniqueIndex:idx_message_template_key" json:"event"`    // mis. payment.confirmed
+	Channel   string    `gorm:"uniqueIndex:idx_message_template_key" json:"channel"`  // whatsapp, email
+	Language  string    `gorm:"uniqueIndex:idx_message_template_key" json:"language"` // id, en
+	Subject   string    `json:"subject"`                                              // Hanya untuk email
+	Body      string    `gorm:"type:text" json:"body"`                                // text/template (WA) atau html/template (email)
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // PaymentReminder untuk tracking pembayaran bulanan
 type PaymentReminder struct {
 	ID              uint           `gorm:"primaryKey" json:"id"`
@@ -132,8 +279,148 @@ type PaymentReminder struct {
 	JumlahBayar     float64        `json:"jumlah_bayar"`
 	TanggalReminder time.Time      `json:"tanggal_reminder"`
 	StatusReminder  string         `gorm:"index" json:"status_reminder"` // enum: Pending, Paid, Expired
-	IsSent          bool           `json:"is_sent"`                      // Apakah reminder sudah dikirim
+	IsSent          bool           `json:"is_sent"`                      // Semua tahap reminder (REMINDER_CADENCE) sudah dikirim
+	SentCount       int            `json:"sent_count"`                   // Jumlah tahap yang sudah dikirim
+	LastSentAt      time.Time      `json:"last_sent_at"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 }
+
+// Refund mencatat pengembalian dana atas Pembayaran yang bookingnya dibatalkan
+// (oleh penyewa atau karena kamar dihapus admin).
+type Refund struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	PembayaranID  uint           `gorm:"index" json:"pembayaran_id"`
+	Pembayaran    Pembayaran     `gorm:"foreignKey:PembayaranID" json:"pembayaran,omitempty"`
+	PemesananID   uint           `gorm:"index" json:"pemesanan_id"`
+	JumlahRefund  float64        `json:"jumlah_refund"`
+	Alasan        string         `json:"alasan"`                    // enum: booking_cancelled, room_deleted, deposit_settlement
+	StatusRefund  string         `gorm:"index" json:"status_refund"` // enum: Requested, Approved, Paid, Rejected
+	BuktiTransfer string         `json:"bukti_transfer"`            // Bukti transfer pengembalian dana dari admin
+	CatatanAdmin  string         `json:"catatan_admin"`
+	ApprovedAt    time.Time      `json:"approved_at"`
+	PaidAt        time.Time      `json:"paid_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// SecurityDeposit adalah uang jaminan booking, terpisah dari sewa. Ditagih lewat Pembayaran
+// bertipe deposit saat booking dikonfirmasi dan diselesaikan setelah check-out.
+type SecurityDeposit struct {
+	ID                 uint               `gorm:"primaryKey" json:"id"`
+	PemesananID        uint               `gorm:"uniqueIndex" json:"pemesanan_id"`
+	PembayaranID       uint               `gorm:"index" json:"pembayaran_id"` // Tagihan jaminan (tipe_pembayaran = deposit)
+	Jumlah             float64            `json:"jumlah"`
+	Status             string             `gorm:"index" json:"status"` // enum: Pending, Held, Settled, Refunded (booking batal), Cancelled (batal sebelum dibayar)
+	HeldAt             time.Time          `json:"held_at"`
+	Deductions         []DepositDeduction `gorm:"foreignKey:DepositID" json:"deductions"`
+	TotalPotongan      float64            `json:"total_potongan"`
+	Selisih            float64            `json:"selisih"`                        // Jumlah - TotalPotongan; positif dikembalikan, negatif ditagih
+	RefundID           uint               `json:"refund_id,omitempty"`            // Refund sisa jaminan (Selisih > 0)
+	ChargePembayaranID uint               `json:"charge_pembayaran_id,omitempty"` // Tagihan kekurangan (Selisih < 0)
+	CatatanSettlement  string             `json:"catatan_settlement"`
+	SettledBy          uint               `json:"settled_by"`
+	SettledAt          time.Time          `json:"settled_at"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+}
+
+// DepositDeduction adalah satu potongan uang jaminan saat settlement
+type DepositDeduction struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DepositID  uint      `gorm:"index" json:"deposit_id"`
+	Kategori   string    `json:"kategori"` // enum: damage, utilities, cleaning, other
+	Keterangan string    `json:"keterangan"`
+	Jumlah     float64   `json:"jumlah"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MaintenanceTicket adalah laporan kerusakan dari penyewa untuk kamar yang sedang disewanya
+type MaintenanceTicket struct {
+	ID                uint                 `gorm:"primaryKey" json:"id"`
+	PenyewaID         uint                 `gorm:"index" json:"penyewa_id"`
+	Penyewa           Penyewa              `gorm:"foreignKey:PenyewaID" json:"penyewa,omitempty"`
+	KamarID           uint                 `gorm:"index" json:"kamar_id"`
+	Kamar             Kamar                `gorm:"foreignKey:KamarID" json:"kamar,omitempty"`
+	PemesananID       uint                 `gorm:"index" json:"pemesanan_id"` // sewa aktif saat tiket dibuat
+	Judul             string               `json:"judul"`
+	Deskripsi         string               `gorm:"type:text" json:"deskripsi"`
+	Kategori          string               `gorm:"index" json:"kategori"`              // enum: ac, plumbing, electrical, furniture, internet, other
+	Prioritas         string               `gorm:"index" json:"prioritas"`             // enum: low, medium, high, urgent
+	Status            string               `gorm:"index" json:"status"`                // enum: Open, In Progress, Resolved, Closed
+	AssignedTo        uint                 `gorm:"index" json:"assigned_to,omitempty"` // user staff yang menangani
+	Assignee          *User                `gorm:"foreignKey:AssignedTo" json:"assignee,omitempty"`
+	KamarOutOfService bool                 `json:"kamar_out_of_service"` // kamar di-set Maintenance karena tiket ini
+	Photos            []MaintenancePhoto   `gorm:"foreignKey:TicketID" json:"photos"`
+	Comments          []MaintenanceComment `gorm:"foreignKey:TicketID" json:"comments,omitempty"`
+	Rating            int                  `json:"rating,omitempty"` // 1 - 5, diisi penyewa setelah Resolved
+	RatingKomentar    string               `json:"rating_komentar,omitempty"`
+	ResolvedAt        time.Time            `json:"resolved_at"`
+	ClosedAt          time.Time            `json:"closed_at"`
+	CreatedAt         time.Time            `json:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+}
+
+type MaintenancePhoto struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TicketID  uint      `gorm:"index" json:"ticket_id"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MaintenanceComment adalah percakapan tiket antara penyewa dan staff, termasuk catatan perubahan status
+type MaintenanceComment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TicketID  uint      `gorm:"index" json:"ticket_id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Role      string    `json:"role"` // role penulis saat komentar dibuat
+	Pesan     string    `gorm:"type:text" json:"pesan"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PaymentLineItem adalah komponen tambahan di luar JumlahBayar pada sebuah Pembayaran,
+// saat ini dipakai untuk denda keterlambatan.
+type PaymentLineItem struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	PembayaranID uint      `gorm:"uniqueIndex:idx_line_item_type" json:"pembayaran_id"`
+	Tipe         string    `gorm:"uniqueIndex:idx_line_item_type" json:"tipe"` // enum: late_fee
+	Deskripsi    string    `json:"deskripsi"`
+	Jumlah       float64   `json:"jumlah"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// LedgerAdjustment adalah koreksi manual admin pada buku besar booking.
+// Jumlah positif menambah tagihan (debit), negatif mengurangi tagihan (kredit).
+type LedgerAdjustment struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	PemesananID uint           `gorm:"index" json:"pemesanan_id"`
+	Jumlah      float64        `json:"jumlah"`
+	Keterangan  string         `json:"keterangan"`
+	CreatedBy   uint           `json:"created_by"` // user_id admin
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// PricingPolicy mengatur kebijakan DP dan pelunasan. TipeKamar kosong = kebijakan default,
+// selain itu berlaku sebagai override untuk tipe kamar tersebut.
+type PricingPolicy struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	TipeKamar        string    `gorm:"uniqueIndex" json:"tipe_kamar"`
+	DPPercentage     float64   `json:"dp_percentage"`      // Persentase DP dari total sewa, mis. 30
+	MinDPAmount      float64   `json:"min_dp_amount"`      // DP minimal dalam rupiah
+	SettlementDays   int       `json:"settlement_days"`    // Batas pelunasan sisa DP (hari setelah tanggal mulai)
+	BillingLeadDays  int       `json:"billing_lead_days"`  // Tagihan bulanan dibuat H-N sebelum masa sewa habis
+	ReminderLeadDays int       `json:"reminder_lead_days"` // Tidak dipakai lagi: reminder pertama mengikuti tahap terawal REMINDER_CADENCE
+	InstallmentCount int       `json:"installment_count"`  // Jumlah cicilan bulanan untuk sisa DP
+	LateFeeType      string    `json:"late_fee_type"`      // enum: daily, flat
+	LateFeeAmount    float64   `json:"late_fee_amount"`    // Nominal denda per hari (daily) atau sekali (flat); 0 = tanpa denda
+	LockGraceDays    int       `json:"lock_grace_days"`    // Kamar dikunci jika tagihan telat lebih dari N hari; 0 = tidak dikunci
+	DepositAmount    float64   `json:"deposit_amount"`     // Uang jaminan yang ditagih saat booking dikonfirmasi; 0 = tanpa jaminan
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// BookingStatus adalah status Pemesanan. Perpindahan status hanya boleh mengikuti
+// bookingTransitions dan dilakukan lewat BookingRepository.Transition.
+type BookingStatus string
+
+const (
+	BookingPending       BookingStatus = "Pending"
+	BookingPartiallyPaid BookingStatus = "Partially Paid" // DP dikonfirmasi, cicilan belum lunas
+	BookingConfirmed     BookingStatus = "Confirmed"
+	BookingActive        BookingStatus = "Aktif" // status lama, diperlakukan seperti Confirmed
+	BookingCancelled     BookingStatus = "Cancelled"
+	BookingCheckedOut    BookingStatus = "Checked Out" // penyewa sudah keluar atau masa sewa berakhir
+)
+
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	BookingPending:       {BookingPartiallyPaid, BookingConfirmed, BookingCancelled},
+	BookingPartiallyPaid: {BookingConfirmed, BookingCancelled, BookingCheckedOut},
+	BookingConfirmed:     {BookingCancelled, BookingCheckedOut},
+	BookingActive:        {BookingConfirmed, BookingCancelled, BookingCheckedOut},
+}
+
+// IsLeaseActive true untuk booking yang sudah dibayar (DP/lunas) dan belum check-out/batal
+func (s BookingStatus) IsLeaseActive() bool {
+	return s == BookingConfirmed || s == BookingPartiallyPaid || s == BookingActive
+}
+
+// CanTransitionTo true jika perpindahan status diizinkan. Status yang sama selalu diizinkan (no-op).
+func (s BookingStatus) CanTransitionTo(to BookingStatus) bool {
+	if s == to {
+		return true
+	}
+	for _, next := range bookingTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentStatus adalah status Pembayaran. Perpindahan status hanya boleh mengikuti
+// paymentTransitions dan dilakukan lewat PaymentRepository.Transition.
+type PaymentStatus string
+
+const (
+	PaymentPending              PaymentStatus = "Pending"
+	PaymentAwaitingConfirmation PaymentStatus = "Menunggu Konfirmasi Admin" // pembayaran tunai menunggu admin
+	PaymentConfirmed            PaymentStatus = "Confirmed"
+	PaymentRejected             PaymentStatus = "Rejected"
+	PaymentCancelled            PaymentStatus = "Cancelled"
+)
+
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:              {PaymentAwaitingConfirmation, PaymentConfirmed, PaymentRejected, PaymentCancelled},
+	PaymentAwaitingConfirmation: {PaymentPending, PaymentConfirmed, PaymentRejected, PaymentCancelled},
+	PaymentRejected:             {PaymentPending, PaymentCancelled}, // penyewa mengunggah ulang bukti
+}
+
+// CanTransitionTo true jika perpindahan status diizinkan. Status yang sama selalu diizinkan (no-op).
+// Confirmed dan Cancelled adalah status akhir; pengembalian dana dicatat lewat Refund.
+func (s PaymentStatus) CanTransitionTo(to PaymentStatus) bool {
+	if s == to {
+		return true
+	}
+	for _, next := range paymentTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusTransitionError dikembalikan saat perpindahan status tidak diizinkan
+// atau status sudah diubah proses lain sejak data dibaca.
+type StatusTransitionError struct {
+	Entity string // booking, payment
+	ID     uint
+	From   string
+	To     string
+	Stale  bool
+}
+
+func (e *StatusTransitionError) Error() string {
+	if e.Stale {
+		return fmt.Sprintf("status %s #%d sudah berubah dari %s, muat ulang data lalu coba lagi", e.Entity, e.ID, e.From)
+	}
+	return fmt.Sprintf("status %s #%d tidak dapat diubah dari %s ke %s", e.Entity, e.ID, e.From, e.To)
+}
+
+// StatusChange adalah konteks perpindahan status yang disimpan di tabel history
+type StatusChange struct {
+	ChangedBy     uint // 0 untuk aksi sistem
+	ChangedByRole string
+	Reason        string
+}
+
+// BookingStatusHistory mencatat setiap perpindahan StatusPemesanan
+type BookingStatusHistory struct {
+	ID            uint          `gorm:"primaryKey" json:"id"`
+	PemesananID   uint          `gorm:"index" json:"pemesanan_id"`
+	FromStatus    BookingStatus `json:"from_status"`
+	ToStatus      BookingStatus `json:"to_status"`
+	ChangedBy     uint          `json:"changed_by"`
+	ChangedByRole string        `json:"changed_by_role"`
+	Reason        string        `json:"reason"`
+	CreatedAt     time.Time     `gorm:"index" json:"created_at"`
+}
+
+func (BookingStatusHistory) TableName() string {
+	return "booking_status_history"
+}
+
+// PaymentStatusHistory mencatat setiap perpindahan StatusPembayaran
+type PaymentStatusHistory struct {
+	ID            uint          `gorm:"primaryKey" json:"id"`
+	PembayaranID  uint          `gorm:"index" json:"pembayaran_id"`
+	PemesananID   uint          `gorm:"index" json:"pemesanan_id"`
+	FromStatus    PaymentStatus `json:"from_status"`
+	ToStatus      PaymentStatus `json:"to_status"`
+	ChangedBy     uint          `json:"changed_by"`
+	ChangedByRole string        `json:"changed_by_role"`
+	Reason        string        `json:"reason"`
+	CreatedAt     time.Time     `gorm:"index" json:"created_at"`
+}
+
+func (PaymentStatusHistory) TableName() string {
+	return "payment_status_history"
+}
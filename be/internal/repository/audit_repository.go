@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository sengaja hanya menyediakan insert dan baca: audit log bersifat append-only
+type AuditRepository interface {
+	Create(log *models.AuditLog) error
+	FindAll(pagination *utils.Pagination, filter AuditLogFilter) ([]models.AuditLog, int64, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db}
+}
+
+func (r *auditRepository) Create(log *models.AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *auditRepository) FindAll(pagination *utils.Pagination, filter AuditLogFilter) ([]models.AuditLog, int64, error) {
+	var logs []models.AuditLog
+	var totalRows int64
+
+	query := r.db.Model(&models.AuditLog{})
+	if filter.ActorUserID != 0 {
+		query = query.Where("actor_user_id = ?", filter.ActorUserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if !filter.DateFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.DateFrom)
+	}
+	if !filter.DateTo.IsZero() {
+		query = query.Where("created_at < ?", filter.DateTo)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").
+		Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).
+		Find(&logs).Error
+	return logs, totalRows, err
+}
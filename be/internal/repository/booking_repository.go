@@ -15,12 +15,23 @@ type BookingRepository interface {
 	Update(booking *models.Pemesanan) error
 	GetPaymentsByBookingID(bookingID uint) ([]models.Pembayaran, error)
 	FindExpiredPendingBookings(expiryTime time.Time) ([]models.Pemesanan, error)
-	UpdateStatus(id uint, status string) error
+	Transition(booking *models.Pemesanan, to models.BookingStatus, change models.StatusChange) error
+	FindStatusHistory(bookingID uint) ([]models.BookingStatusHistory, error)
+	UpdateLease(booking *models.Pemesanan) error                              // Simpan data check-in/check-out saja
+	FindEndedLeases(before time.Time) ([]models.Pemesanan, error)             // Sewa aktif dengan TanggalKeluar <= before
 	FindActiveBookingByKamarID(kamarID uint) (*models.Pemesanan, error)      // Check if room has active/confirmed booking
-	FindPendingBookingByKamarID(kamarID uint) (*models.Pemesanan, error)     // NEW: Check if room has pending unconfirmed payment
+	FindPendingBookingsByKamarID(kamarID uint) ([]models.Pemesanan, error)   // Pending bookings (unconfirmed payment) on a room
+	FindOverlappingBookings(kamarID uint, from, to time.Time) ([]models.Pemesanan, error) // Bookings occupying the room within [from, to)
 	WithTx(tx *gorm.DB) BookingRepository
 }
 
+// occupyingBookingStatuses adalah status booking yang dianggap menempati kamar
+// pada rentang TanggalMulai - TanggalKeluar.
+var occupyingBookingStatuses = []models.BookingStatus{models.BookingPending, models.BookingActive, models.BookingConfirmed, models.BookingPartiallyPaid}
+
+// activeLeaseStatuses adalah status sewa yang sudah dibayar dan belum check-out/batal
+var activeLeaseStatuses = []models.BookingStatus{models.BookingActive, models.BookingConfirmed, models.BookingPartiallyPaid}
+
 type bookingRepository struct {
 	db *gorm.DB
 }
@@ -48,7 +59,7 @@ func (r *bookingRepository) FindByPenyewaIDWithPayments(penyewaID uint) ([]model
 	// After: 1 query with JOINs = 1 query total
 	// Performance improvement: ~20x faster for 10 bookings
 	err := r.db.Preload("Kamar").
-		Preload("Pembayaran"). // Load payments eagerly
+		Preload("Pembayaran.LineItems"). // Load payments (and late fees) eagerly
 		Where("penyewa_id = ?", penyewaID).
 		Order("created_at DESC").
 		Find(&bookings).Error
@@ -78,12 +89,71 @@ func (r *bookingRepository) GetPaymentsByBookingID(bookingID uint) ([]models.Pem
 func (r *bookingRepository) FindExpiredPendingBookings(expiryTime time.Time) ([]models.Pemesanan, error) {
 	var bookings []models.Pemesanan
 	// Find bookings that are 'Pending' and created before the expiryTime
-	err := r.db.Preload("Penyewa").Preload("Kamar").Where("status_pemesanan = ? AND created_at < ?", "Pending", expiryTime).Find(&bookings).Error
+	err := r.db.Preload("Penyewa").Preload("Kamar").Where("status_pemesanan = ? AND created_at < ?", models.BookingPending, expiryTime).Find(&bookings).Error
 	return bookings, err
 }
 
-func (r *bookingRepository) UpdateStatus(id uint, status string) error {
-	return r.db.Model(&models.Pemesanan{}).Where("id = ?", id).Update("status_pemesanan", status).Error
+// Transition memindahkan status booking sesuai models.BookingStatus.CanTransitionTo dan mencatat
+// booking_status_history dalam satu transaksi. Update bersyarat pada status lama mencegah dua
+// proses menimpa perubahan satu sama lain. booking.StatusPemesanan ikut diperbarui.
+func (r *bookingRepository) Transition(booking *models.Pemesanan, to models.BookingStatus, change models.StatusChange) error {
+	from := booking.StatusPemesanan
+	if from == to {
+		return nil
+	}
+	if !from.CanTransitionTo(to) {
+		return &models.StatusTransitionError{Entity: "booking", ID: booking.ID, From: string(from), To: string(to)}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Pemesanan{}).
+			Where("id = ? AND status_pemesanan = ?", booking.ID, from).
+			Update("status_pemesanan", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &models.StatusTransitionError{Entity: "booking", ID: booking.ID, From: string(from), To: string(to), Stale: true}
+		}
+
+		return tx.Create(&models.BookingStatusHistory{
+			PemesananID:   booking.ID,
+			FromStatus:    from,
+			ToStatus:      to,
+			ChangedBy:     change.ChangedBy,
+			ChangedByRole: change.ChangedByRole,
+			Reason:        change.Reason,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	booking.StatusPemesanan = to
+	return nil
+}
+
+func (r *bookingRepository) FindStatusHistory(bookingID uint) ([]models.BookingStatusHistory, error) {
+	var history []models.BookingStatusHistory
+	err := r.db.Where("pemesanan_id = ?", bookingID).Order("created_at ASC, id ASC").Find(&history).Error
+	return history, err
+}
+
+// UpdateLease hanya menyimpan kolom check-in/check-out agar relasi yang ter-preload
+// (Pembayaran, Kamar) tidak ikut tertimpa seperti pada Save.
+func (r *bookingRepository) UpdateLease(booking *models.Pemesanan) error {
+	return r.db.Model(&models.Pemesanan{ID: booking.ID}).
+		Select("tanggal_check_in", "kunci_diserahkan", "catatan_check_in",
+			"tanggal_check_out", "kondisi_kamar", "catatan_check_out", "alasan_check_out").
+		Updates(booking).Error
+}
+
+func (r *bookingRepository) FindEndedLeases(before time.Time) ([]models.Pemesanan, error) {
+	var bookings []models.Pemesanan
+	err := r.db.Preload("Kamar").Preload("Penyewa").Preload("Pembayaran").
+		Where("status_pemesanan IN (?) AND tanggal_keluar <= ?", activeLeaseStatuses, before).
+		Order("tanggal_keluar ASC").
+		Find(&bookings).Error
+	return bookings, err
 }
 
 func (r *bookingRepository) FindActiveBookingByKamarID(kamarID uint) (*models.Pemesanan, error) {
@@ -93,7 +163,7 @@ func (r *bookingRepository) FindActiveBookingByKamarID(kamarID uint) (*models.Pe
 	err := r.db.Where(
 		"kamar_id = ? AND status_pemesanan IN (?) AND tanggal_keluar > NOW()",
 		kamarID,
-		[]string{"Aktif", "Confirmed", "Partially Paid"},
+		activeLeaseStatuses,
 	).First(&booking).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil // No active booking
@@ -101,20 +171,30 @@ func (r *bookingRepository) FindActiveBookingByKamarID(kamarID uint) (*models.Pe
 	return &booking, err
 }
 
-// FindPendingBookingByKamarID mencari booking dengan status "Pending" pada kamar tertentu.
-// Digunakan saat admin menghapus kamar untuk mendeteksi pembayaran yang belum dikonfirmasi.
-// Preload Penyewa dan Pembayaran agar data lengkap tersedia untuk logika notifikasi.
-func (r *bookingRepository) FindPendingBookingByKamarID(kamarID uint) (*models.Pemesanan, error) {
-	var booking models.Pemesanan
+// FindPendingBookingsByKamarID mencari semua booking dengan status "Pending" pada kamar tertentu.
+// Digunakan saat admin menghapus kamar untuk membatalkan pembayaran yang belum dikonfirmasi.
+// Preload Penyewa dan Pembayaran agar data lengkap tersedia untuk refund dan notifikasi.
+func (r *bookingRepository) FindPendingBookingsByKamarID(kamarID uint) ([]models.Pemesanan, error) {
+	var bookings []models.Pemesanan
 	err := r.db.
 		Preload("Penyewa").
 		Preload("Pembayaran").
-		Where("kamar_id = ? AND status_pemesanan = ?", kamarID, "Pending").
-		First(&booking).Error
-	if err == gorm.ErrRecordNotFound {
-		return nil, nil // No pending booking
-	}
-	return &booking, err
+		Where("kamar_id = ? AND status_pemesanan = ?", kamarID, models.BookingPending).
+		Order("id ASC").
+		Find(&bookings).Error
+	return bookings, err
+}
+
+// FindOverlappingBookings mencari booking yang menempati kamar pada rentang [from, to).
+// Interval booking dianggap setengah terbuka: [TanggalMulai, TanggalKeluar).
+func (r *bookingRepository) FindOverlappingBookings(kamarID uint, from, to time.Time) ([]models.Pemesanan, error) {
+	var bookings []models.Pemesanan
+	err := r.db.
+		Where("kamar_id = ? AND status_pemesanan IN (?) AND tanggal_mulai < ? AND tanggal_keluar > ?",
+			kamarID, occupyingBookingStatuses, to, from).
+		Order("tanggal_mulai ASC").
+		Find(&bookings).Error
+	return bookings, err
 }
 
 func (r *bookingRepository) WithTx(tx *gorm.DB) BookingRepository {
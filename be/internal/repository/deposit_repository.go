@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DepositRepository interface {
+	FindByBookingID(bookingID uint) (*models.SecurityDeposit, error)
+	FindByBookingIDs(bookingIDs []uint) ([]models.SecurityDeposit, error)
+	FindByIDForUpdate(id uint) (*models.SecurityDeposit, error)
+	Create(deposit *models.SecurityDeposit) error
+	Update(deposit *models.SecurityDeposit) error
+	CreateDeductions(deductions []models.DepositDeduction) error
+	WithTx(tx *gorm.DB) DepositRepository
+}
+
+type depositRepository struct {
+	db *gorm.DB
+}
+
+func NewDepositRepository(db *gorm.DB) DepositRepository {
+	return &depositRepository{db}
+}
+
+// FindByBookingID mengembalikan nil tanpa error jika booking tidak punya uang jaminan
+func (r *depositRepository) FindByBookingID(bookingID uint) (*models.SecurityDeposit, error) {
+	var deposit models.SecurityDeposit
+	err := r.db.Preload("Deductions").Where("pemesanan_id = ?", bookingID).First(&deposit).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &deposit, err
+}
+
+func (r *depositRepository) FindByBookingIDs(bookingIDs []uint) ([]models.SecurityDeposit, error) {
+	var deposits []models.SecurityDeposit
+	if len(bookingIDs) == 0 {
+		return deposits, nil
+	}
+	err := r.db.Preload("Deductions").Where("pemesanan_id IN ?", bookingIDs).Find(&deposits).Error
+	return deposits, err
+}
+
+// FindByIDForUpdate mengunci baris jaminan (SELECT ... FOR UPDATE); dipakai di dalam transaksi
+func (r *depositRepository) FindByIDForUpdate(id uint) (*models.SecurityDeposit, error) {
+	var deposit models.SecurityDeposit
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&deposit, id).Error
+	return &deposit, err
+}
+
+func (r *depositRepository) Create(deposit *models.SecurityDeposit) error {
+	return r.db.Create(deposit).Error
+}
+
+// Update tidak ikut menyimpan Deductions; potongan disimpan lewat CreateDeductions
+func (r *depositRepository) Update(deposit *models.SecurityDeposit) error {
+	return r.db.Omit(clause.Associations).Save(deposit).Error
+}
+
+func (r *depositRepository) CreateDeductions(deductions []models.DepositDeduction) error {
+	if len(deductions) == 0 {
+		return nil
+	}
+	return r.db.Create(&deductions).Error
+}
+
+func (r *depositRepository) WithTx(tx *gorm.DB) DepositRepository {
+	return &depositRepository{db: tx}
+}
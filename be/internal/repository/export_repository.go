@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PaymentExportRow struct {
+	ID               uint
+	OrderID          string
+	NomorKwitansi    string
+	NamaPenyewa      string
+	NomorKamar       string
+	MetodePembayaran string
+	TipePembayaran   string
+	StatusPembayaran string
+	JumlahBayar      float64
+	TotalDenda       float64
+	TanggalBayar     time.Time
+	ConfirmedAt      time.Time
+}
+
+type BookingExportRow struct {
+	ID              uint
+	NamaPenyewa     string
+	NomorKamar      string
+	TipeKamar       string
+	TanggalMulai    time.Time
+	TanggalKeluar   time.Time
+	DurasiSewa      int
+	StatusPemesanan string
+	CreatedAt       time.Time
+}
+
+type TenantExportRow struct {
+	ID          uint
+	NamaLengkap string
+	Username    string
+	Email       string
+	NomorHP     string
+	NIK         string
+	Role        string
+	CreatedAt   time.Time
+}
+
+// ExportRepository membaca data export baris demi baris (sql.Rows) sehingga export besar
+// tidak dimuat sekaligus ke memori. Filter sama dengan endpoint daftar.
+type ExportRepository interface {
+	StreamPayments(filter PaymentFilter, fn func(PaymentExportRow) error) error
+	StreamBookings(filter BookingFilter, fn func(BookingExportRow) error) error
+	StreamTenants(filter TenantFilter, fn func(TenantExportRow) error) error
+}
+
+type exportRepository struct {
+	db *gorm.DB
+}
+
+func NewExportRepository(db *gorm.DB) ExportRepository {
+	return &exportRepository{db}
+}
+
+func (r *exportRepository) StreamPayments(filter PaymentFilter, fn func(PaymentExportRow) error) error {
+	query := applyPaymentFilter(r.db.Model(&models.Pembayaran{}), filter).
+		Joins("JOIN kamars ON kamars.id = pemesanans.kamar_id").
+		Select(`pembayarans.id, COALESCE(pembayarans.order_id, '') AS order_id,
+			COALESCE(pembayarans.nomor_kwitansi, '') AS nomor_kwitansi,
+			penyewas.nama_lengkap AS nama_penyewa, kamars.nomor_kamar,
+			pembayarans.metode_pembayaran, pembayarans.tipe_pembayaran, pembayarans.status_pembayaran,
+			pembayarans.jumlah_bayar, pembayarans.total_denda, pembayarans.tanggal_bayar, pembayarans.confirmed_at`).
+		Order(orderClause(filter.SortBy, filter.SortOrder, paymentSortColumns, "pembayarans.created_at"))
+
+	return streamRows(r.db, query, fn)
+}
+
+func (r *exportRepository) StreamBookings(filter BookingFilter, fn func(BookingExportRow) error) error {
+	query := applyBookingFilter(r.db.Model(&models.Pemesanan{}), filter).
+		Select(`pemesanans.id, penyewas.nama_lengkap AS nama_penyewa, kamars.nomor_kamar, kamars.tipe_kamar,
+			pemesanans.tanggal_mulai, pemesanans.tanggal_keluar, pemesanans.durasi_sewa,
+			pemesanans.status_pemesanan, pemesanans.created_at`).
+		Order(orderClause(filter.SortBy, filter.SortOrder, bookingSortColumns, "pemesanans.created_at"))
+
+	return streamRows(r.db, query, fn)
+}
+
+func (r *exportRepository) StreamTenants(filter TenantFilter, fn func(TenantExportRow) error) error {
+	query := applyTenantFilter(r.db.Model(&models.Penyewa{}), filter).
+		Select(`penyewas.id, penyewas.nama_lengkap, users.username, penyewas.email, penyewas.nomor_hp,
+			penyewas.nik, penyewas.role, penyewas.created_at`).
+		Order(orderClause(filter.SortBy, filter.SortOrder, tenantSortColumns, "penyewas.created_at"))
+
+	return streamRows(r.db, query, fn)
+}
+
+func streamRows[T any](db *gorm.DB, query *gorm.DB, fn func(T) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row T
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
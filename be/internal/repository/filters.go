@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentFilter adalah filter daftar pembayaran admin. Field kosong/zero berarti tidak difilter.
+type PaymentFilter struct {
+	Status     string
+	Method     string
+	Type       string
+	DateFrom   time.Time // tanggal_bayar >= DateFrom
+	DateTo     time.Time // tanggal_bayar < DateTo (eksklusif)
+	KamarID    uint
+	TenantName string
+	SortBy     string
+	SortOrder  string
+}
+
+// TenantFilter adalah filter daftar penyewa admin. Field kosong/zero berarti tidak difilter.
+type TenantFilter struct {
+	Search    string
+	Role      string
+	KamarID   uint // penyewa yang punya booking (selain Cancelled) di kamar ini
+	SortBy    string
+	SortOrder string
+	// ExcludePII: pencarian tidak mencocokkan NIK (staff tanpa tenants:read_pii)
+	ExcludePII bool
+}
+
+// BookingFilter adalah filter export booking. Field kosong/zero berarti tidak difilter.
+type BookingFilter struct {
+	Status     string
+	KamarID    uint
+	TenantName string
+	DateFrom   time.Time // tanggal_mulai >= DateFrom
+	DateTo     time.Time // tanggal_mulai < DateTo (eksklusif)
+	SortBy     string
+	SortOrder  string
+}
+
+// AuditLogFilter adalah filter daftar audit log. Field kosong/zero berarti tidak difilter.
+type AuditLogFilter struct {
+	ActorUserID uint
+	Action      string
+	EntityType  string
+	EntityID    uint
+	DateFrom    time.Time // created_at >= DateFrom
+	DateTo      time.Time // created_at < DateTo (eksklusif)
+}
+
+// MaintenanceTicketFilter adalah filter daftar tiket perbaikan. Field kosong/zero berarti tidak difilter.
+type MaintenanceTicketFilter struct {
+	Status     string
+	Prioritas  string
+	Kategori   string
+	KamarID    uint
+	AssignedTo uint
+}
+
+var paymentSortColumns = map[string]string{
+	"created_at":    "pembayarans.created_at",
+	"tanggal_bayar": "pembayarans.tanggal_bayar",
+	"confirmed_at":  "pembayarans.confirmed_at",
+	"jumlah_bayar":  "pembayarans.jumlah_bayar",
+	"status":        "pembayarans.status_pembayaran",
+	"order_id":      "pembayarans.order_id",
+	"jatuh_tempo":   "pembayarans.tanggal_jatuh_tempo",
+}
+
+var bookingSortColumns = map[string]string{
+	"created_at":    "pemesanans.created_at",
+	"tanggal_mulai": "pemesanans.tanggal_mulai",
+	"status":        "pemesanans.status_pemesanan",
+}
+
+var tenantSortColumns = map[string]string{
+	"created_at":   "penyewas.created_at",
+	"nama_lengkap": "penyewas.nama_lengkap",
+	"role":         "penyewas.role",
+}
+
+// orderClause membangun ORDER BY dari whitelist kolom agar input sort tidak bisa disisipi SQL.
+// Kolom tidak dikenal memakai defaultColumn; arah default DESC.
+func orderClause(sortBy, sortOrder string, columns map[string]string, defaultColumn string) string {
+	column, ok := columns[sortBy]
+	if !ok {
+		column = defaultColumn
+	}
+	direction := "DESC"
+	if strings.EqualFold(sortOrder, "asc") {
+		direction = "ASC"
+	}
+	return column + " " + direction
+}
+
+// applyPaymentFilter menambahkan join pemesanans/penyewas dan kondisi PaymentFilter ke query pembayarans
+func applyPaymentFilter(query *gorm.DB, filter PaymentFilter) *gorm.DB {
+	query = query.
+		Joins("JOIN pemesanans ON pemesanans.id = pembayarans.pemesanan_id").
+		Joins("JOIN penyewas ON penyewas.id = pemesanans.penyewa_id")
+
+	if filter.Status != "" {
+		query = query.Where("pembayarans.status_pembayaran = ?", filter.Status)
+	}
+	if filter.Method != "" {
+		query = query.Where("pembayarans.metode_pembayaran = ?", filter.Method)
+	}
+	if filter.Type != "" {
+		query = query.Where("pembayarans.tipe_pembayaran = ?", filter.Type)
+	}
+	if !filter.DateFrom.IsZero() {
+		query = query.Where("pembayarans.tanggal_bayar >= ?", filter.DateFrom)
+	}
+	if !filter.DateTo.IsZero() {
+		query = query.Where("pembayarans.tanggal_bayar < ?", filter.DateTo)
+	}
+	if filter.KamarID != 0 {
+		query = query.Where("pemesanans.kamar_id = ?", filter.KamarID)
+	}
+	if filter.TenantName != "" {
+		query = query.Where("penyewas.nama_lengkap ILIKE ?", "%"+filter.TenantName+"%")
+	}
+	return query
+}
+
+// applyBookingFilter menambahkan join penyewas/kamars dan kondisi BookingFilter ke query pemesanans
+func applyBookingFilter(query *gorm.DB, filter BookingFilter) *gorm.DB {
+	query = query.
+		Joins("JOIN penyewas ON penyewas.id = pemesanans.penyewa_id").
+		Joins("JOIN kamars ON kamars.id = pemesanans.kamar_id")
+
+	if filter.Status != "" {
+		query = query.Where("pemesanans.status_pemesanan = ?", filter.Status)
+	}
+	if filter.KamarID != 0 {
+		query = query.Where("pemesanans.kamar_id = ?", filter.KamarID)
+	}
+	if filter.TenantName != "" {
+		query = query.Where("penyewas.nama_lengkap ILIKE ?", "%"+filter.TenantName+"%")
+	}
+	if !filter.DateFrom.IsZero() {
+		query = query.Where("pemesanans.tanggal_mulai >= ?", filter.DateFrom)
+	}
+	if !filter.DateTo.IsZero() {
+		query = query.Where("pemesanans.tanggal_mulai < ?", filter.DateTo)
+	}
+	return query
+}
+
+// applyTenantFilter menambahkan join users dan kondisi TenantFilter ke query penyewas.
+// Akun staff (users.role owner/admin/caretaker/accountant) selalu dikecualikan dari daftar penyewa.
+func applyTenantFilter(query *gorm.DB, filter TenantFilter) *gorm.DB {
+	query = query.
+		Joins("JOIN users ON users.id = penyewas.user_id").
+		Where("users.role NOT IN ? AND penyewas.role NOT IN ?", utils.StaffRoles(), utils.StaffRoles())
+
+	if filter.Role != "" {
+		query = query.Where("penyewas.role = ?", filter.Role)
+	}
+	if filter.KamarID != 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM pemesanans WHERE pemesanans.penyewa_id = penyewas.id AND pemesanans.kamar_id = ? AND pemesanans.status_pemesanan <> ? AND pemesanans.deleted_at IS NULL)",
+			filter.KamarID, models.BookingCancelled)
+	}
+	if filter.Search != "" && filter.ExcludePII {
+		searchLike := "%" + filter.Search + "%"
+		query = query.Where("penyewas.nama_lengkap ILIKE ? OR penyewas.email ILIKE ? OR penyewas.nomor_hp ILIKE ? OR users.username ILIKE ?",
+			searchLike, searchLike, searchLike, searchLike)
+	} else if filter.Search != "" {
+		searchLike := "%" + filter.Search + "%"
+		query = query.Where("penyewas.nama_lengkap ILIKE ? OR penyewas.email ILIKE ? OR penyewas.nomor_hp ILIKE ? OR penyewas.nik ILIKE ? OR users.username ILIKE ?",
+			searchLike, searchLike, searchLike, searchLike, searchLike)
+	}
+	return query
+}
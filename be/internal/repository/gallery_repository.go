@@ -9,6 +9,7 @@ import (
 type GalleryRepository interface {
 	Create(gallery *models.Gallery) error
 	FindAll() ([]models.Gallery, error)
+	FindByID(id uint) (*models.Gallery, error)
 	Delete(id uint) error
 }
 
@@ -30,6 +31,12 @@ func (r *galleryRepository) FindAll() ([]models.Gallery, error) {
 	return galleries, err
 }
 
+func (r *galleryRepository) FindByID(id uint) (*models.Gallery, error) {
+	var gallery models.Gallery
+	err := r.db.First(&gallery, id).Error
+	return &gallery, err
+}
+
 func (r *galleryRepository) Delete(id uint) error {
 	return r.db.Delete(&models.Gallery{}, id).Error
 }
@@ -14,6 +14,7 @@ type KamarRepository interface {
 	Create(kamar *models.Kamar) error
 	Update(kamar *models.Kamar) error
 	UpdateStatus(id uint, status string) error
+	SetMaintenance(id uint, alasan string) error
 	Delete(id uint) error
 	WithTx(tx *gorm.DB) KamarRepository
 	AddImage(image *models.KamarImage) error
@@ -55,8 +56,16 @@ func (r *kamarRepository) Update(kamar *models.Kamar) error {
 	return r.db.Save(kamar).Error
 }
 
+// UpdateStatus juga menghapus alasan Maintenance sebelumnya
 func (r *kamarRepository) UpdateStatus(id uint, status string) error {
-	return r.db.Model(&models.Kamar{}).Where("id = ?", id).Update("status", status).Error
+	return r.db.Model(&models.Kamar{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "alasan_maintenance": ""}).Error
+}
+
+// SetMaintenance menandai kamar Maintenance beserta penyebabnya
+func (r *kamarRepository) SetMaintenance(id uint, alasan string) error {
+	return r.db.Model(&models.Kamar{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": "Maintenance", "alasan_maintenance": alasan}).Error
 }
 
 func (r *kamarRepository) Delete(id uint) error {
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LateFeeRepository menyimpan denda keterlambatan, level eskalasi tagihan dan penguncian kamar
+// karena tunggakan
+type LateFeeRepository interface {
+	FindOverdueBills(before time.Time, billTypes []string) ([]models.Pembayaran, error)
+	UpsertLateFee(paymentID uint, fee float64, deskripsi string) error
+	SetEscalationLevel(paymentID uint, level int) error
+	LockKamar(kamarID uint, reason string) error
+	UnlockKamar(kamarID uint) error
+	CountEscalatedBills(kamarID uint, minLevel int) (int64, error)
+	WithTx(tx *gorm.DB) LateFeeRepository
+}
+
+type lateFeeRepository struct {
+	db *gorm.DB
+}
+
+func NewLateFeeRepository(db *gorm.DB) LateFeeRepository {
+	return &lateFeeRepository{db}
+}
+
+func (r *lateFeeRepository) WithTx(tx *gorm.DB) LateFeeRepository {
+	return &lateFeeRepository{tx}
+}
+
+// FindOverdueBills mengambil tagihan yang jatuh temponya sebelum before dan belum dibayar:
+// Rejected, atau Pending yang belum ada bukti transfernya. Bukti yang sedang menunggu
+// pemeriksaan admin tidak dianggap telat.
+func (r *lateFeeRepository) FindOverdueBills(before time.Time, billTypes []string) ([]models.Pembayaran, error) {
+	var payments []models.Pembayaran
+	err := r.db.Preload("Pemesanan.Penyewa").Preload("Pemesanan.Kamar").
+		Where("tipe_pembayaran IN ? AND tanggal_jatuh_tempo > ? AND tanggal_jatuh_tempo < ?", billTypes, time.Time{}, before).
+		Where("status_pembayaran = ? OR (status_pembayaran = ? AND (bukti_transfer = '' OR bukti_transfer IS NULL))",
+			models.PaymentRejected, models.PaymentPending).
+		Find(&payments).Error
+	return payments, err
+}
+
+// UpsertLateFee menyimpan line item "late_fee" dan TotalDenda pembayaran dalam satu transaksi
+func (r *lateFeeRepository) UpsertLateFee(paymentID uint, fee float64, deskripsi string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var item models.PaymentLineItem
+		if err := tx.Where(models.PaymentLineItem{PembayaranID: paymentID, Tipe: "late_fee"}).FirstOrInit(&item).Error; err != nil {
+			return err
+		}
+		item.Jumlah = fee
+		item.Deskripsi = deskripsi
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Pembayaran{}).Where("id = ?", paymentID).UpdateColumn("total_denda", fee).Error
+	})
+}
+
+func (r *lateFeeRepository) SetEscalationLevel(paymentID uint, level int) error {
+	return r.db.Model(&models.Pembayaran{}).Where("id = ?", paymentID).UpdateColumn("escalation_level", level).Error
+}
+
+func (r *lateFeeRepository) LockKamar(kamarID uint, reason string) error {
+	return r.db.Model(&models.Kamar{}).Where("id = ?", kamarID).
+		Updates(map[string]interface{}{"is_locked": true, "locked_reason": reason}).Error
+}
+
+func (r *lateFeeRepository) UnlockKamar(kamarID uint) error {
+	return r.db.Model(&models.Kamar{}).Where("id = ?", kamarID).
+		Updates(map[string]interface{}{"is_locked": false, "locked_reason": ""}).Error
+}
+
+// CountEscalatedBills menghitung tagihan kamar yang belum lunas dengan level eskalasi >= minLevel
+func (r *lateFeeRepository) CountEscalatedBills(kamarID uint, minLevel int) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Pembayaran{}).
+		Joins("JOIN pemesanans ON pemesanans.id = pembayarans.pemesanan_id").
+		Where("pemesanans.kamar_id = ? AND pembayarans.status_pembayaran IN ? AND pembayarans.escalation_level >= ?",
+			kamarID, []models.PaymentStatus{models.PaymentPending, models.PaymentRejected}, minLevel).
+		Count(&count).Error
+	return count, err
+}
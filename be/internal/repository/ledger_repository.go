@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LedgerRepository memuat data sumber buku besar booking: pembayaran (+ line item denda),
+// refund yang sudah ditransfer, dan koreksi manual admin.
+type LedgerRepository interface {
+	FindBookingWithPayments(bookingID uint) (*models.Pemesanan, error)
+	FindPaidRefunds(bookingIDs []uint) ([]models.Refund, error)
+	FindAdjustments(bookingIDs []uint) ([]models.LedgerAdjustment, error)
+	CreateAdjustment(adjustment *models.LedgerAdjustment) error
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db}
+}
+
+func (r *ledgerRepository) FindBookingWithPayments(bookingID uint) (*models.Pemesanan, error) {
+	var booking models.Pemesanan
+	err := r.db.Preload("Kamar").Preload("Pembayaran.LineItems").First(&booking, bookingID).Error
+	return &booking, err
+}
+
+func (r *ledgerRepository) FindPaidRefunds(bookingIDs []uint) ([]models.Refund, error) {
+	var refunds []models.Refund
+	if len(bookingIDs) == 0 {
+		return refunds, nil
+	}
+	err := r.db.Where("pemesanan_id IN ? AND status_refund = ?", bookingIDs, "Paid").Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *ledgerRepository) FindAdjustments(bookingIDs []uint) ([]models.LedgerAdjustment, error) {
+	var adjustments []models.LedgerAdjustment
+	if len(bookingIDs) == 0 {
+		return adjustments, nil
+	}
+	err := r.db.Where("pemesanan_id IN ?", bookingIDs).Order("created_at ASC").Find(&adjustments).Error
+	return adjustments, err
+}
+
+func (r *ledgerRepository) CreateAdjustment(adjustment *models.LedgerAdjustment) error {
+	return r.db.Create(adjustment).Error
+}
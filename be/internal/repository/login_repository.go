@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginRepository interface {
+	FindThrottle(username string) (*models.LoginThrottle, error)
+	IncrementThrottle(key string, now time.Time, window time.Duration) (*models.LoginThrottle, error)
+	LockThrottle(key string, until time.Time) error
+	ResetThrottle(username string) error
+	CreateAttempt(attempt *models.LoginAttempt) error
+	CountSuccessful(userID uint) (int64, error)
+	CountSuccessfulFromDevice(userID uint, userAgent string) (int64, error)
+	FindAttemptsByUserID(userID uint, pagination *utils.Pagination) ([]models.LoginAttempt, int64, error)
+}
+
+type loginRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginRepository(db *gorm.DB) LoginRepository {
+	return &loginRepository{db}
+}
+
+func (r *loginRepository) FindThrottle(username string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Where("username = ?", username).First(&throttle).Error
+	return &throttle, err
+}
+
+// IncrementThrottle menambah hitungan gagal secara atomik (baris dibuat jika belum ada) dan
+// mengembalikan hitungan terbaru. Kegagalan terakhir yang lebih lama dari window tidak dihitung.
+func (r *loginRepository) IncrementThrottle(key string, now time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Raw(`INSERT INTO login_throttles (username, failed_attempts, last_failed_at, locked_until, created_at, updated_at)
+		VALUES (?, 1, ?, ?, ?, ?)
+		ON CONFLICT (username) DO UPDATE SET
+			failed_attempts = CASE WHEN login_throttles.last_failed_at < ? THEN 1 ELSE login_throttles.failed_attempts + 1 END,
+			last_failed_at = EXCLUDED.last_failed_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING *`, key, now, time.Time{}, now, now, now.Add(-window)).
+		Scan(&throttle).Error
+	return &throttle, err
+}
+
+func (r *loginRepository) LockThrottle(key string, until time.Time) error {
+	return r.db.Model(&models.LoginThrottle{}).Where("username = ?", key).Update("locked_until", until).Error
+}
+
+func (r *loginRepository) ResetThrottle(username string) error {
+	return r.db.Where("username = ?", username).Delete(&models.LoginThrottle{}).Error
+}
+
+func (r *loginRepository) CreateAttempt(attempt *models.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *loginRepository) CountSuccessful(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LoginAttempt{}).Where("user_id = ? AND success = ?", userID, true).Count(&count).Error
+	return count, err
+}
+
+func (r *loginRepository) CountSuccessfulFromDevice(userID uint, userAgent string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LoginAttempt{}).
+		Where("user_id = ? AND success = ? AND user_agent = ?", userID, true, userAgent).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *loginRepository) FindAttemptsByUserID(userID uint, pagination *utils.Pagination) ([]models.LoginAttempt, int64, error) {
+	var attempts []models.LoginAttempt
+	var totalRows int64
+
+	query := r.db.Model(&models.LoginAttempt{}).Where("user_id = ?", userID)
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).
+		Find(&attempts).Error
+	return attempts, totalRows, err
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MaintenanceRepository interface {
+	FindByID(id uint) (*models.MaintenanceTicket, error)
+	FindAll(pagination *utils.Pagination, filter MaintenanceTicketFilter) ([]models.MaintenanceTicket, int64, error)
+	FindByPenyewaID(penyewaID uint) ([]models.MaintenanceTicket, error)
+	Create(ticket *models.MaintenanceTicket) error
+	Update(ticket *models.MaintenanceTicket) error
+	AddComment(comment *models.MaintenanceComment) error
+	CountOutOfService(kamarID uint, excludeTicketID uint) (int64, error)
+}
+
+type maintenanceRepository struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceRepository(db *gorm.DB) MaintenanceRepository {
+	return &maintenanceRepository{db}
+}
+
+func (r *maintenanceRepository) FindByID(id uint) (*models.MaintenanceTicket, error) {
+	var ticket models.MaintenanceTicket
+	err := r.db.Preload("Penyewa").Preload("Kamar").Preload("Assignee").Preload("Photos").
+		Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Comments.User").
+		First(&ticket, id).Error
+	return &ticket, err
+}
+
+// FindAll mengurutkan tiket yang belum selesai dulu, lalu prioritas tertinggi dan terlama
+func (r *maintenanceRepository) FindAll(pagination *utils.Pagination, filter MaintenanceTicketFilter) ([]models.MaintenanceTicket, int64, error) {
+	var tickets []models.MaintenanceTicket
+	var totalRows int64
+
+	query := r.db.Model(&models.MaintenanceTicket{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Prioritas != "" {
+		query = query.Where("prioritas = ?", filter.Prioritas)
+	}
+	if filter.Kategori != "" {
+		query = query.Where("kategori = ?", filter.Kategori)
+	}
+	if filter.KamarID != 0 {
+		query = query.Where("kamar_id = ?", filter.KamarID)
+	}
+	if filter.AssignedTo != 0 {
+		query = query.Where("assigned_to = ?", filter.AssignedTo)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Penyewa").Preload("Kamar").Preload("Assignee").Preload("Photos").
+		Order("CASE status WHEN 'Open' THEN 0 WHEN 'In Progress' THEN 1 ELSE 2 END").
+		Order("CASE prioritas WHEN 'urgent' THEN 0 WHEN 'high' THEN 1 WHEN 'medium' THEN 2 ELSE 3 END").
+		Order("created_at ASC").
+		Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).
+		Find(&tickets).Error
+	return tickets, totalRows, err
+}
+
+func (r *maintenanceRepository) FindByPenyewaID(penyewaID uint) ([]models.MaintenanceTicket, error) {
+	var tickets []models.MaintenanceTicket
+	err := r.db.Preload("Kamar").Preload("Assignee").Preload("Photos").
+		Where("penyewa_id = ?", penyewaID).
+		Order("created_at DESC").
+		Find(&tickets).Error
+	return tickets, err
+}
+
+// Create ikut menyimpan Photos
+func (r *maintenanceRepository) Create(ticket *models.MaintenanceTicket) error {
+	return r.db.Create(ticket).Error
+}
+
+// Update tidak ikut menyimpan relasi (foto, komentar, kamar, penyewa)
+func (r *maintenanceRepository) Update(ticket *models.MaintenanceTicket) error {
+	return r.db.Omit(clause.Associations).Save(ticket).Error
+}
+
+func (r *maintenanceRepository) AddComment(comment *models.MaintenanceComment) error {
+	return r.db.Create(comment).Error
+}
+
+// CountOutOfService menghitung tiket lain yang masih menahan kamar di status Maintenance
+func (r *maintenanceRepository) CountOutOfService(kamarID uint, excludeTicketID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.MaintenanceTicket{}).
+		Where("kamar_id = ? AND kamar_out_of_service = ? AND id <> ?", kamarID, true, excludeTicketID).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MessageTemplateRepository interface {
+	FindAll() ([]models.MessageTemplate, error)
+	Find(event, channel, language string) (*models.MessageTemplate, error)
+	Save(template *models.MessageTemplate) error
+	Delete(event, channel, language string) (int64, error)
+}
+
+type messageTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageTemplateRepository(db *gorm.DB) MessageTemplateRepository {
+	return &messageTemplateRepository{db}
+}
+
+func (r *messageTemplateRepository) FindAll() ([]models.MessageTemplate, error) {
+	var templates []models.MessageTemplate
+	err := r.db.Order("event, channel, language").Find(&templates).Error
+	return templates, err
+}
+
+func (r *messageTemplateRepository) Find(event, channel, language string) (*models.MessageTemplate, error) {
+	var template models.MessageTemplate
+	err := r.db.Where("event = ? AND channel = ? AND language = ?", event, channel, language).First(&template).Error
+	return &template, err
+}
+
+// Save membuat atau menimpa override untuk kombinasi event, channel dan bahasa
+func (r *messageTemplateRepository) Save(template *models.MessageTemplate) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event"}, {Name: "channel"}, {Name: "language"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "body", "updated_at"}),
+	}).Create(template).Error
+}
+
+func (r *messageTemplateRepository) Delete(event, channel, language string) (int64, error) {
+	result := r.db.Where("event = ? AND channel = ? AND language = ?", event, channel, language).Delete(&models.MessageTemplate{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	CreateBatch(notifications []models.Notification) error
+	FindByUserID(userID uint, pagination *utils.Pagination, unreadOnly bool) ([]models.Notification, int64, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(id uint, userID uint) (int64, error)
+	MarkAllRead(userID uint) (int64, error)
+	FindAdminUserIDs() ([]uint, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db}
+}
+
+func (r *notificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *notificationRepository) CreateBatch(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.Create(&notifications).Error
+}
+
+func (r *notificationRepository) FindByUserID(userID uint, pagination *utils.Pagination, unreadOnly bool) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var totalRows int64
+
+	query := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).
+		Find(&notifications).Error
+	return notifications, totalRows, err
+}
+
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
+
+// MarkRead menandai satu notifikasi milik user sebagai dibaca. Mengembalikan jumlah baris
+// yang cocok (0 jika tidak ada atau bukan milik user).
+func (r *notificationRepository) MarkRead(id uint, userID uint) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) MarkAllRead(userID uint) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) FindAdminUserIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.User{}).Where("role IN ?", utils.StaffRoles()).Pluck("id", &ids).Error
+	return ids, err
+}
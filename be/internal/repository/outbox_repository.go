@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxFilter struct {
+	Status  string
+	Channel string
+}
+
+type OutboxRepository interface {
+	Create(message *models.OutboxMessage) error
+	Update(message *models.OutboxMessage) error
+	FindByID(id uint) (*models.OutboxMessage, error)
+	FindByProviderMessageID(provider, messageID string) (*models.OutboxMessage, error)
+	ClaimDue(now, claimUntil time.Time, limit int) ([]models.OutboxMessage, error)
+	ClaimByID(id uint, now, claimUntil time.Time) (*models.OutboxMessage, error)
+	FindAllPaginated(pagination *utils.Pagination, filter OutboxFilter) ([]models.OutboxMessage, int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db}
+}
+
+func (r *outboxRepository) Create(message *models.OutboxMessage) error {
+	return r.db.Create(message).Error
+}
+
+func (r *outboxRepository) Update(message *models.OutboxMessage) error {
+	return r.db.Save(message).Error
+}
+
+func (r *outboxRepository) FindByID(id uint) (*models.OutboxMessage, error) {
+	var message models.OutboxMessage
+	err := r.db.First(&message, id).Error
+	return &message, err
+}
+
+func (r *outboxRepository) FindByProviderMessageID(provider, messageID string) (*models.OutboxMessage, error) {
+	var message models.OutboxMessage
+	err := r.db.Where("provider = ? AND provider_message_id = ?", provider, messageID).First(&message).Error
+	return &message, err
+}
+
+// ClaimDue mengklaim pesan yang jadwal kirimnya sudah lewat (terlama dulu) secara atomik: status
+// diubah ke "Sending" dan next_attempt_at diisi claimUntil sehingga worker lain melewatinya.
+// Klaim "Sending" yang kedaluwarsa (proses mati saat mengirim) bisa diklaim ulang.
+func (r *outboxRepository) ClaimDue(now, claimUntil time.Time, limit int) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := r.db.Raw(`UPDATE outbox_messages SET status = 'Sending', next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_messages
+			WHERE status IN ('Pending', 'Sending') AND next_attempt_at <= ?
+			ORDER BY next_attempt_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, claimUntil, now, now, limit).
+		Scan(&messages).Error
+	return messages, err
+}
+
+// ClaimByID mengklaim satu pesan untuk dikirim ulang. Pesan yang sudah Sent atau sedang diklaim
+// worker lain tidak ikut; hasilnya gorm.ErrRecordNotFound.
+func (r *outboxRepository) ClaimByID(id uint, now, claimUntil time.Time) (*models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := r.db.Raw(`UPDATE outbox_messages SET status = 'Sending', next_attempt_at = ?, updated_at = ?
+		WHERE id = ? AND (status IN ('Pending', 'Dead') OR (status = 'Sending' AND next_attempt_at <= ?))
+		RETURNING *`, claimUntil, now, id, now).
+		Scan(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &messages[0], nil
+}
+
+func (r *outboxRepository) FindAllPaginated(pagination *utils.Pagination, filter OutboxFilter) ([]models.OutboxMessage, int64, error) {
+	var messages []models.OutboxMessage
+	var totalRows int64
+
+	query := r.db.Model(&models.OutboxMessage{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).
+		Find(&messages).Error
+	return messages, totalRows, err
+}
@@ -2,12 +2,14 @@ package repository
 
 import (
 	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
 
 	"gorm.io/gorm"
 )
 
 type PaymentRepository interface {
 	FindAll() ([]models.Pembayaran, error)
+	FindAllPaginated(pagination *utils.Pagination, filter PaymentFilter) ([]models.Pembayaran, int64, error)
 	FindByID(id uint) (*models.Pembayaran, error)
 	FindByOrderID(orderID string) (*models.Pembayaran, error)
 	Create(payment *models.Pembayaran) error
@@ -15,7 +17,8 @@ type PaymentRepository interface {
 	Update(payment *models.Pembayaran) error
 	DeleteByBookingID(bookingID uint) error
 	DeleteRemindersByBookingID(bookingID uint) error
-	CancelPendingPaymentsByBookingID(bookingID uint) error // NEW: Soft-cancel Pending payments when room is deleted
+	Transition(payment *models.Pembayaran, to models.PaymentStatus, change models.StatusChange) error
+	FindStatusHistoryByBookingID(bookingID uint) ([]models.PaymentStatusHistory, error)
 	WithTx(tx *gorm.DB) PaymentRepository
 }
 
@@ -29,10 +32,29 @@ func NewPaymentRepository(db *gorm.DB) PaymentRepository {
 
 func (r *paymentRepository) FindAll() ([]models.Pembayaran, error) {
 	var payments []models.Pembayaran
-	err := r.db.Preload("Pemesanan.Penyewa.User").Preload("Pemesanan.Kamar").Find(&payments).Error
+	err := r.db.Preload("Pemesanan.Penyewa.User").Preload("Pemesanan.Kamar").Preload("LineItems").Find(&payments).Error
 	return payments, err
 }
 
+func (r *paymentRepository) FindAllPaginated(pagination *utils.Pagination, filter PaymentFilter) ([]models.Pembayaran, int64, error) {
+	var payments []models.Pembayaran
+	var totalRows int64
+
+	query := applyPaymentFilter(r.db.Model(&models.Pembayaran{}), filter)
+
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("Pemesanan.Penyewa.User").Preload("Pemesanan.Kamar").Preload("LineItems").
+		Order(orderClause(filter.SortBy, filter.SortOrder, paymentSortColumns, "pembayarans.created_at")).
+		Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).
+		Find(&payments).Error
+
+	return payments, totalRows, err
+}
+
 func (r *paymentRepository) FindByID(id uint) (*models.Pembayaran, error) {
 	var payment models.Pembayaran
 	err := r.db.Preload("Pemesanan.Penyewa.User").Preload("Pemesanan.Kamar").First(&payment, id).Error
@@ -41,7 +63,7 @@ func (r *paymentRepository) FindByID(id uint) (*models.Pembayaran, error) {
 
 func (r *paymentRepository) FindByOrderID(orderID string) (*models.Pembayaran, error) {
 	var payment models.Pembayaran
-	err := r.db.Preload("Pemesanan.Penyewa.User").Preload("Pemesanan.Kamar").Where("order_id = ?", orderID).First(&payment).Error
+	err := r.db.Preload("Pemesanan.Penyewa.User").Preload("Pemesanan.Kamar").Preload("LineItems").Where("order_id = ?", orderID).First(&payment).Error
 	return &payment, err
 }
 
@@ -77,12 +99,48 @@ func (r *paymentRepository) DeleteRemindersByBookingID(bookingID uint) error {
 	).Delete(&models.PaymentReminder{}).Error
 }
 
-// CancelPendingPaymentsByBookingID membatalkan (soft-cancel) semua pembayaran
-// dengan status "Pending" pada booking tertentu.
-// Digunakan ketika kamar dihapus dan ada booking pending yang belum dikonfirmasi.
-// Soft-cancel menjaga audit trail — pembayaran tidak dihapus permanen.
-func (r *paymentRepository) CancelPendingPaymentsByBookingID(bookingID uint) error {
-	return r.db.Model(&models.Pembayaran{}).
-		Where("pemesanan_id = ? AND status_pembayaran = ?", bookingID, "Pending").
-		Update("status_pembayaran", "Cancelled").Error
+// Transition memindahkan status pembayaran sesuai models.PaymentStatus.CanTransitionTo dan mencatat
+// payment_status_history dalam satu transaksi. Update bersyarat pada status lama mencegah dua
+// proses (mis. konfirmasi dan penolakan) menimpa satu sama lain. payment.StatusPembayaran ikut diperbarui.
+func (r *paymentRepository) Transition(payment *models.Pembayaran, to models.PaymentStatus, change models.StatusChange) error {
+	from := payment.StatusPembayaran
+	if from == to {
+		return nil
+	}
+	if !from.CanTransitionTo(to) {
+		return &models.StatusTransitionError{Entity: "payment", ID: payment.ID, From: string(from), To: string(to)}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Pembayaran{}).
+			Where("id = ? AND status_pembayaran = ?", payment.ID, from).
+			Update("status_pembayaran", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &models.StatusTransitionError{Entity: "payment", ID: payment.ID, From: string(from), To: string(to), Stale: true}
+		}
+
+		return tx.Create(&models.PaymentStatusHistory{
+			PembayaranID:  payment.ID,
+			PemesananID:   payment.PemesananID,
+			FromStatus:    from,
+			ToStatus:      to,
+			ChangedBy:     change.ChangedBy,
+			ChangedByRole: change.ChangedByRole,
+			Reason:        change.Reason,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	payment.StatusPembayaran = to
+	return nil
+}
+
+func (r *paymentRepository) FindStatusHistoryByBookingID(bookingID uint) ([]models.PaymentStatusHistory, error) {
+	var history []models.PaymentStatusHistory
+	err := r.db.Where("pemesanan_id = ?", bookingID).Order("created_at ASC, id ASC").Find(&history).Error
+	return history, err
 }
@@ -17,7 +17,7 @@ type PenyewaRepository interface {
 	Update(penyewa *models.Penyewa) error
 	Delete(id uint) error
 	UpdateRole(penyewaID uint, role string) error
-	FindAllPaginated(pagination *utils.Pagination, search, role string) ([]models.Penyewa, int64, error)
+	FindAllPaginated(pagination *utils.Pagination, filter TenantFilter) ([]models.Penyewa, int64, error)
 	WithTx(tx *gorm.DB) PenyewaRepository
 }
 
@@ -75,30 +75,20 @@ func (r *penyewaRepository) UpdateRole(penyewaID uint, role string) error {
 	return r.db.Model(&models.Penyewa{}).Where("id = ?", penyewaID).Update("role", role).Error
 }
 
-func (r *penyewaRepository) FindAllPaginated(pagination *utils.Pagination, search, role string) ([]models.Penyewa, int64, error) {
+func (r *penyewaRepository) FindAllPaginated(pagination *utils.Pagination, filter TenantFilter) ([]models.Penyewa, int64, error) {
 	var penyewas []models.Penyewa
 	var totalRows int64
 
-	// Selalu kecualikan admin dari daftar penyewa
-	// Gunakan Joins untuk pencarian kolom di tabel User
-	query := r.db.Model(&models.Penyewa{}).
-		Preload("User").
-		Joins("JOIN users ON users.id = penyewas.user_id").
-		Where("penyewas.role != ?", "admin")
+	query := applyTenantFilter(r.db.Model(&models.Penyewa{}).Preload("User"), filter)
 
-	if role != "" {
-		query = query.Where("penyewas.role = ?", role)
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		return nil, 0, err
 	}
 
-	if search != "" {
-		searchLike := "%" + search + "%"
-		query = query.Where("penyewas.nama_lengkap ILIKE ? OR penyewas.email ILIKE ? OR penyewas.nomor_hp ILIKE ? OR penyewas.nik ILIKE ? OR users.username ILIKE ?", 
-			searchLike, searchLike, searchLike, searchLike, searchLike)
-	}
-
-	query.Count(&totalRows)
-
-	err := query.Scopes(utils.Paginate(models.Penyewa{}, pagination, query)).Find(&penyewas).Error
+	err := query.
+		Order(orderClause(filter.SortBy, filter.SortOrder, tenantSortColumns, "penyewas.created_at")).
+		Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).
+		Find(&penyewas).Error
 
 	return penyewas, totalRows, err
 }
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PricingPolicyRepository interface {
+	FindAll() ([]models.PricingPolicy, error)
+	FindByID(id uint) (*models.PricingPolicy, error)
+	FindByTipeKamar(tipeKamar string) (*models.PricingPolicy, error)
+	Save(policy *models.PricingPolicy) error
+	Delete(id uint) error
+}
+
+type pricingPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewPricingPolicyRepository(db *gorm.DB) PricingPolicyRepository {
+	return &pricingPolicyRepository{db}
+}
+
+func (r *pricingPolicyRepository) FindAll() ([]models.PricingPolicy, error) {
+	var policies []models.PricingPolicy
+	err := r.db.Order("tipe_kamar ASC").Find(&policies).Error
+	return policies, err
+}
+
+func (r *pricingPolicyRepository) FindByID(id uint) (*models.PricingPolicy, error) {
+	var policy models.PricingPolicy
+	err := r.db.First(&policy, id).Error
+	return &policy, err
+}
+
+// FindByTipeKamar mengembalikan nil tanpa error jika belum ada kebijakan untuk tipe tersebut.
+// Gunakan tipeKamar "" untuk kebijakan default.
+func (r *pricingPolicyRepository) FindByTipeKamar(tipeKamar string) (*models.PricingPolicy, error) {
+	var policy models.PricingPolicy
+	err := r.db.Where("tipe_kamar = ?", tipeKamar).First(&policy).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &policy, err
+}
+
+func (r *pricingPolicyRepository) Save(policy *models.PricingPolicy) error {
+	return r.db.Save(policy).Error
+}
+
+func (r *pricingPolicyRepository) Delete(id uint) error {
+	return r.db.Delete(&models.PricingPolicy{}, id).Error
+}
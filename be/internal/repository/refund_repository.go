@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundRepository interface {
+	FindAll(status string) ([]models.Refund, error)
+	FindByID(id uint) (*models.Refund, error)
+	FindByPembayaranID(pembayaranID uint) (*models.Refund, error)
+	Create(refund *models.Refund) error
+	Update(refund *models.Refund) error
+	UpdateFromStatus(refund *models.Refund, from ...string) error
+	WithTx(tx *gorm.DB) RefundRepository
+}
+
+// ErrRefundStatusChanged dikembalikan UpdateFromStatus jika status refund sudah diubah admin lain
+var ErrRefundStatusChanged = errors.New("refund status changed")
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db}
+}
+
+// FindAll mengambil semua refund, difilter berdasarkan status jika tidak kosong
+func (r *refundRepository) FindAll(status string) ([]models.Refund, error) {
+	var refunds []models.Refund
+	query := r.db.Preload("Pembayaran.Pemesanan.Penyewa").Preload("Pembayaran.Pemesanan.Kamar")
+	if status != "" {
+		query = query.Where("status_refund = ?", status)
+	}
+	err := query.Order("created_at DESC").Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepository) FindByID(id uint) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.Preload("Pembayaran.Pemesanan.Penyewa").Preload("Pembayaran.Pemesanan.Kamar").First(&refund, id).Error
+	return &refund, err
+}
+
+// FindByPembayaranID mengembalikan nil tanpa error jika pembayaran belum punya refund
+func (r *refundRepository) FindByPembayaranID(pembayaranID uint) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.Where("pembayaran_id = ?", pembayaranID).First(&refund).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &refund, err
+}
+
+func (r *refundRepository) Create(refund *models.Refund) error {
+	return r.db.Create(refund).Error
+}
+
+// Update tidak ikut menyimpan relasi Pembayaran yang di-preload
+func (r *refundRepository) Update(refund *models.Refund) error {
+	return r.db.Omit(clause.Associations).Save(refund).Error
+}
+
+// UpdateFromStatus menyimpan perubahan refund hanya jika status_refund di database masih salah satu
+// dari from (UPDATE bersyarat), sehingga dua admin tidak bisa memproses refund yang sama bersamaan
+func (r *refundRepository) UpdateFromStatus(refund *models.Refund, from ...string) error {
+	result := r.db.Model(refund).Omit(clause.Associations).
+		Where("status_refund IN ?", from).
+		Select("status_refund", "bukti_transfer", "catatan_admin", "approved_at", "paid_at", "updated_at").
+		Updates(refund)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRefundStatusChanged
+	}
+	return nil
+}
+
+func (r *refundRepository) WithTx(tx *gorm.DB) RefundRepository {
+	return &refundRepository{db: tx}
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSessionAlreadyRotated dikembalikan Rotate jika token lama sudah dicabut oleh request lain
+var ErrSessionAlreadyRotated = errors.New("session already rotated")
+
+type SessionRepository interface {
+	Create(session *models.UserSession) error
+	FindByTokenHash(hash string) (*models.UserSession, error)
+	Rotate(oldID uint, next *models.UserSession) error
+	RevokeFamily(familyID string, reason string) (int64, error)
+	RevokeUserFamily(userID uint, familyID string, reason string) (int64, error)
+	RevokeAllByUserID(userID uint, exceptFamilyID string, reason string) (int64, error)
+	FindActiveByUserID(userID uint) ([]models.UserSession, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db}
+}
+
+func revokeUpdates(reason string) map[string]interface{} {
+	return map[string]interface{}{"revoked": true, "revoked_at": time.Now(), "revoked_reason": reason}
+}
+
+func (r *sessionRepository) Create(session *models.UserSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) FindByTokenHash(hash string) (*models.UserSession, error) {
+	var session models.UserSession
+	err := r.db.Where("token_hash = ?", hash).First(&session).Error
+	return &session, err
+}
+
+// Rotate mencabut token lama dan menyimpan penggantinya dalam satu transaksi. Update bersyarat
+// (revoked = false) mencegah dua request memakai token yang sama secara bersamaan.
+func (r *sessionRepository) Rotate(oldID uint, next *models.UserSession) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserSession{}).
+			Where("id = ? AND revoked = ?", oldID, false).
+			Updates(revokeUpdates("rotated"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSessionAlreadyRotated
+		}
+		return tx.Create(next).Error
+	})
+}
+
+func (r *sessionRepository) RevokeFamily(familyID string, reason string) (int64, error) {
+	result := r.db.Model(&models.UserSession{}).
+		Where("family_id = ? AND revoked = ?", familyID, false).
+		Updates(revokeUpdates(reason))
+	return result.RowsAffected, result.Error
+}
+
+func (r *sessionRepository) RevokeUserFamily(userID uint, familyID string, reason string) (int64, error) {
+	result := r.db.Model(&models.UserSession{}).
+		Where("user_id = ? AND family_id = ? AND revoked = ?", userID, familyID, false).
+		Updates(revokeUpdates(reason))
+	return result.RowsAffected, result.Error
+}
+
+// RevokeAllByUserID mencabut semua sesi user; exceptFamilyID (opsional) mempertahankan sesi saat ini
+func (r *sessionRepository) RevokeAllByUserID(userID uint, exceptFamilyID string, reason string) (int64, error) {
+	query := r.db.Model(&models.UserSession{}).Where("user_id = ? AND revoked = ?", userID, false)
+	if exceptFamilyID != "" {
+		query = query.Where("family_id <> ?", exceptFamilyID)
+	}
+	result := query.Updates(revokeUpdates(reason))
+	return result.RowsAffected, result.Error
+}
+
+// FindActiveByUserID mengambil token aktif (satu per family/perangkat), terbaru dulu
+func (r *sessionRepository) FindActiveByUserID(userID uint) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := r.db.Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
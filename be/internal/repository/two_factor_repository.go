@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"koskosan-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository interface {
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	UseRecoveryCode(userID uint, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(userID uint) (int64, error)
+	DeleteRecoveryCodes(userID uint) error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{db}
+}
+
+// ReplaceRecoveryCodes menghapus kode lama user lalu menyimpan kode baru dalam satu transaksi
+func (r *twoFactorRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode menandai kode terpakai secara kondisional; false jika kode tidak ada atau sudah dipakai
+func (r *twoFactorRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *twoFactorRepository) CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *twoFactorRepository) DeleteRecoveryCodes(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
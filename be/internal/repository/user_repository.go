@@ -12,6 +12,8 @@ type UserRepository interface {
 	FindByResetToken(token string) (*models.User, error)
 	Create(user *models.User) error
 	Update(user *models.User) error
+	FindByRoles(roles []string) ([]models.User, error)
+	Delete(id uint) error
 	WithTx(tx *gorm.DB) UserRepository
 }
 
@@ -49,6 +51,16 @@ func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+func (r *userRepository) FindByRoles(roles []string) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("role IN ?", roles).Order("username ASC").Find(&users).Error
+	return users, err
+}
+
+func (r *userRepository) Delete(id uint) error {
+	return r.db.Delete(&models.User{}, id).Error
+}
+
 func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
 	return &userRepository{db: tx}
 }
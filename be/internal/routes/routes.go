@@ -4,6 +4,7 @@ import (
 	"koskosan-be/internal/config"
 	"koskosan-be/internal/handlers"
 	"koskosan-be/internal/middleware"
+	"koskosan-be/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	ginprometheus "github.com/zsais/go-gin-prometheus"
@@ -11,16 +12,32 @@ import (
 
 // Routes structure untuk organization yang lebih baik
 type Routes struct {
-	authHandler      *handlers.AuthHandler
-	kamarHandler     *handlers.KamarHandler
-	galleryHandler   *handlers.GalleryHandler
-	dashboardHandler *handlers.DashboardHandler
-	reviewHandler    *handlers.ReviewHandler
-	profileHandler   *handlers.ProfileHandler
-	bookingHandler   *handlers.BookingHandler
-	paymentHandler   *handlers.PaymentHandler
-	tenantHandler    *handlers.TenantHandler
-	contactHandler   *handlers.ContactHandler
+	authHandler            *handlers.AuthHandler
+	kamarHandler           *handlers.KamarHandler
+	galleryHandler         *handlers.GalleryHandler
+	dashboardHandler       *handlers.DashboardHandler
+	reviewHandler          *handlers.ReviewHandler
+	profileHandler         *handlers.ProfileHandler
+	bookingHandler         *handlers.BookingHandler
+	paymentHandler         *handlers.PaymentHandler
+	tenantHandler          *handlers.TenantHandler
+	contactHandler         *handlers.ContactHandler
+	availabilityHandler    *handlers.AvailabilityHandler
+	refundHandler          *handlers.RefundHandler
+	pricingHandler         *handlers.PricingPolicyHandler
+	ledgerHandler          *handlers.LedgerHandler
+	exportHandler          *handlers.ExportHandler
+	notificationHandler    *handlers.NotificationHandler
+	outboxHandler          *handlers.OutboxHandler
+	messageTemplateHandler *handlers.MessageTemplateHandler
+	whatsAppWebhookHandler *handlers.WhatsAppWebhookHandler
+	sessionHandler         *handlers.SessionHandler
+	twoFactorHandler       *handlers.TwoFactorHandler
+	staffHandler           *handlers.StaffHandler
+	auditLogHandler        *handlers.AuditLogHandler
+	leaseHandler           *handlers.LeaseHandler
+	depositHandler         *handlers.DepositHandler
+	maintenanceHandler     *handlers.MaintenanceHandler
 }
 
 // NewRoutes initialize routes dengan semua handlers
@@ -35,18 +52,50 @@ func NewRoutes(
 	paymentHandler *handlers.PaymentHandler,
 	tenantHandler *handlers.TenantHandler,
 	contactHandler *handlers.ContactHandler,
+	availabilityHandler *handlers.AvailabilityHandler,
+	refundHandler *handlers.RefundHandler,
+	pricingHandler *handlers.PricingPolicyHandler,
+	ledgerHandler *handlers.LedgerHandler,
+	exportHandler *handlers.ExportHandler,
+	notificationHandler *handlers.NotificationHandler,
+	outboxHandler *handlers.OutboxHandler,
+	messageTemplateHandler *handlers.MessageTemplateHandler,
+	whatsAppWebhookHandler *handlers.WhatsAppWebhookHandler,
+	sessionHandler *handlers.SessionHandler,
+	twoFactorHandler *handlers.TwoFactorHandler,
+	staffHandler *handlers.StaffHandler,
+	auditLogHandler *handlers.AuditLogHandler,
+	leaseHandler *handlers.LeaseHandler,
+	depositHandler *handlers.DepositHandler,
+	maintenanceHandler *handlers.MaintenanceHandler,
 ) *Routes {
 	return &Routes{
-		authHandler:      authHandler,
-		kamarHandler:     kamarHandler,
-		galleryHandler:   galleryHandler,
-		dashboardHandler: dashboardHandler,
-		reviewHandler:    reviewHandler,
-		profileHandler:   profileHandler,
-		bookingHandler:   bookingHandler,
-		paymentHandler:   paymentHandler,
-		tenantHandler:    tenantHandler,
-		contactHandler:   contactHandler,
+		authHandler:            authHandler,
+		kamarHandler:           kamarHandler,
+		galleryHandler:         galleryHandler,
+		dashboardHandler:       dashboardHandler,
+		reviewHandler:          reviewHandler,
+		profileHandler:         profileHandler,
+		bookingHandler:         bookingHandler,
+		paymentHandler:         paymentHandler,
+		tenantHandler:          tenantHandler,
+		contactHandler:         contactHandler,
+		availabilityHandler:    availabilityHandler,
+		refundHandler:          refundHandler,
+		pricingHandler:         pricingHandler,
+		ledgerHandler:          ledgerHandler,
+		exportHandler:          exportHandler,
+		notificationHandler:    notificationHandler,
+		outboxHandler:          outboxHandler,
+		messageTemplateHandler: messageTemplateHandler,
+		whatsAppWebhookHandler: whatsAppWebhookHandler,
+		sessionHandler:         sessionHandler,
+		twoFactorHandler:       twoFactorHandler,
+		staffHandler:           staffHandler,
+		auditLogHandler:        auditLogHandler,
+		leaseHandler:           leaseHandler,
+		depositHandler:         depositHandler,
+		maintenanceHandler:     maintenanceHandler,
 	}
 }
 
@@ -82,6 +131,8 @@ func (r *Routes) registerPublicRoutes(api *gin.RouterGroup) {
 		auth.POST("/google-login", middleware.StrictRateLimit(), r.authHandler.GoogleLogin)
 		auth.POST("/forgot-password", middleware.StrictRateLimit(), r.authHandler.ForgotPassword)
 		auth.POST("/reset-password", middleware.ModerateRateLimit(), r.authHandler.ResetPassword)
+		auth.POST("/2fa/setup", middleware.StrictRateLimit(), r.authHandler.TwoFactorSetup)
+		auth.POST("/2fa/verify", middleware.StrictRateLimit(), r.authHandler.TwoFactorVerify)
 		auth.POST("/refresh", r.authHandler.RefreshToken) // New: Token refresh endpoint
 		auth.POST("/logout", r.authHandler.Logout)        // New: Logout endpoint
 	}
@@ -89,9 +140,10 @@ func (r *Routes) registerPublicRoutes(api *gin.RouterGroup) {
 	// Kamar/Room browsing
 	kamar := api.Group("/kamar")
 	{
-		kamar.GET("", r.kamarHandler.GetKamars)               // GET /api/kamar
-		kamar.GET("/:id", r.kamarHandler.GetKamarByID)        // GET /api/kamar/:id
-		kamar.GET("/:id/reviews", r.reviewHandler.GetReviews) // GET /api/kamar/:id/reviews
+		kamar.GET("", r.kamarHandler.GetKamars)                               // GET /api/kamar
+		kamar.GET("/:id", r.kamarHandler.GetKamarByID)                        // GET /api/kamar/:id
+		kamar.GET("/:id/reviews", r.reviewHandler.GetReviews)                 // GET /api/kamar/:id/reviews
+		kamar.GET("/:id/availability", r.availabilityHandler.GetAvailability) // GET /api/kamar/:id/availability?from=&to=
 	}
 
 	// Gallery
@@ -105,6 +157,13 @@ func (r *Routes) registerPublicRoutes(api *gin.RouterGroup) {
 
 	// Public stats (for login page)
 	api.GET("/public-stats", r.dashboardHandler.GetPublicStats)
+
+	// Pricing policy yang berlaku (untuk menampilkan nominal DP)
+	api.GET("/pricing-policy", r.pricingHandler.GetEffectivePolicy) // GET /api/pricing-policy?tipe_kamar=
+
+	// Callback status pengiriman dari provider WhatsApp (diverifikasi dengan WHATSAPP_CALLBACK_TOKEN)
+	api.GET("/webhooks/whatsapp", r.whatsAppWebhookHandler.Verify)
+	api.POST("/webhooks/whatsapp", r.whatsAppWebhookHandler.StatusCallback)
 }
 
 // Protected routes (auth required)
@@ -112,9 +171,17 @@ func (r *Routes) registerProtectedRoutes(protected *gin.RouterGroup) {
 	// User Profile
 	profile := protected.Group("/profile")
 	{
-		profile.GET("", r.profileHandler.GetProfile)                     // GET /api/profile
-		profile.PUT("", r.profileHandler.UpdateProfile)                  // PUT /api/profile
-		profile.PUT("/change-password", r.profileHandler.ChangePassword) // PUT /api/profile/change-password
+		profile.GET("", r.profileHandler.GetProfile)                                    // GET /api/profile
+		profile.PUT("", r.profileHandler.UpdateProfile)                                 // PUT /api/profile
+		profile.PUT("/change-password", r.profileHandler.ChangePassword)                // PUT /api/profile/change-password
+		profile.GET("/sessions", r.sessionHandler.GetSessions)                          // GET /api/profile/sessions
+		profile.DELETE("/sessions", r.sessionHandler.RevokeOtherSessions)               // DELETE /api/profile/sessions
+		profile.DELETE("/sessions/:id", r.sessionHandler.RevokeSession)                 // DELETE /api/profile/sessions/:id
+		profile.GET("/2fa", r.twoFactorHandler.GetStatus)                               // GET /api/profile/2fa
+		profile.POST("/2fa/setup", r.twoFactorHandler.Setup)                            // POST /api/profile/2fa/setup
+		profile.POST("/2fa/enable", r.twoFactorHandler.Enable)                          // POST /api/profile/2fa/enable
+		profile.POST("/2fa/disable", r.twoFactorHandler.Disable)                        // POST /api/profile/2fa/disable
+		profile.POST("/2fa/recovery-codes", r.twoFactorHandler.RegenerateRecoveryCodes) // POST /api/profile/2fa/recovery-codes
 	}
 
 	// Bookings
@@ -125,6 +192,9 @@ func (r *Routes) registerProtectedRoutes(protected *gin.RouterGroup) {
 		bookings.POST("/with-proof", r.bookingHandler.CreateBookingWithProof) // POST /api/bookings/with-proof
 		bookings.POST("/:id/cancel", r.bookingHandler.CancelBooking)          // POST /api/bookings/:id/cancel
 		bookings.POST("/:id/extend", r.bookingHandler.ExtendBooking)          // POST /api/bookings/:id/extend
+		bookings.GET("/:id/ledger", r.ledgerHandler.GetBookingLedger)         // GET /api/bookings/:id/ledger
+		bookings.GET("/:id/history", r.bookingHandler.GetBookingHistory)      // GET /api/bookings/:id/history
+		bookings.GET("/:id/deposit", r.depositHandler.GetDeposit)             // GET /api/bookings/:id/deposit
 	}
 
 	// Payments
@@ -133,6 +203,26 @@ func (r *Routes) registerProtectedRoutes(protected *gin.RouterGroup) {
 		payments.POST("", r.paymentHandler.CreatePayment)                // POST /api/payments
 		payments.POST("/:id/proof", r.paymentHandler.UploadPaymentProof) // POST /api/payments/:id/proof
 		payments.GET("/reminders", r.paymentHandler.GetReminders)        // GET /api/payments/reminders
+		payments.GET("/:id/receipt", r.paymentHandler.DownloadReceipt)   // GET /api/payments/:id/receipt
+	}
+
+	// Notifications (pusat notifikasi in-app)
+	notifications := protected.Group("/notifications")
+	{
+		notifications.GET("", r.notificationHandler.GetNotifications)            // GET /api/notifications
+		notifications.GET("/unread-count", r.notificationHandler.GetUnreadCount) // GET /api/notifications/unread-count
+		notifications.PUT("/read-all", r.notificationHandler.MarkAllRead)        // PUT /api/notifications/read-all
+		notifications.PUT("/:id/read", r.notificationHandler.MarkRead)           // PUT /api/notifications/:id/read
+	}
+
+	// Maintenance tickets (laporan perbaikan penyewa)
+	maintenance := protected.Group("/maintenance")
+	{
+		maintenance.POST("", r.maintenanceHandler.CreateTicket)            // POST /api/maintenance (multipart: photos)
+		maintenance.GET("/mine", r.maintenanceHandler.GetMyTickets)        // GET /api/maintenance/mine
+		maintenance.GET("/:id", r.maintenanceHandler.GetTicket)            // GET /api/maintenance/:id
+		maintenance.POST("/:id/comments", r.maintenanceHandler.AddComment) // POST /api/maintenance/:id/comments
+		maintenance.POST("/:id/rating", r.maintenanceHandler.RateTicket)   // POST /api/maintenance/:id/rating
 	}
 
 	// Reviews
@@ -142,47 +232,125 @@ func (r *Routes) registerProtectedRoutes(protected *gin.RouterGroup) {
 	r.registerAdminRoutes(protected)
 }
 
-// Admin routes (auth + admin role required)
+// Admin routes (auth + staff permission required, lihat utils/permissions.go)
 func (r *Routes) registerAdminRoutes(protected *gin.RouterGroup) {
 	admin := protected.Group("")
-	admin.Use(middleware.RoleMiddleware("admin"))
 	{
 		// Kamar management
 		kamar := admin.Group("/kamar")
 		{
-			kamar.POST("", r.kamarHandler.CreateKamar)             // POST /api/kamar
-			kamar.PUT("/:id", r.kamarHandler.UpdateKamar)          // PUT /api/kamar/:id
-			kamar.PATCH("/:id/status", r.kamarHandler.UpdateKamarStatus) // PATCH /api/kamar/:id/status
-			kamar.DELETE("/:id", r.kamarHandler.DeleteKamar)       // DELETE /api/kamar/:id
+			kamar.POST("", middleware.RequirePermission(utils.PermRoomsWrite), r.kamarHandler.CreateKamar)                    // POST /api/kamar
+			kamar.PUT("/:id", middleware.RequirePermission(utils.PermRoomsWrite), r.kamarHandler.UpdateKamar)                 // PUT /api/kamar/:id
+			kamar.PATCH("/:id/status", middleware.RequirePermission(utils.PermRoomsStatus), r.kamarHandler.UpdateKamarStatus) // PATCH /api/kamar/:id/status
+			kamar.DELETE("/:id", middleware.RequirePermission(utils.PermRoomsWrite), r.kamarHandler.DeleteKamar)              // DELETE /api/kamar/:id
 		}
 
 		// Gallery management
-		galleries := admin.Group("/galleries")
+		galleries := admin.Group("/galleries", middleware.RequirePermission(utils.PermRoomsWrite))
 		{
 			galleries.POST("", r.galleryHandler.CreateGallery)       // POST /api/galleries
 			galleries.DELETE("/:id", r.galleryHandler.DeleteGallery) // DELETE /api/galleries/:id
 		}
 
 		// Dashboard
-		admin.GET("/dashboard", r.dashboardHandler.GetStats)
+		dashboard := admin.Group("", middleware.RequirePermission(utils.PermDashboardRead))
+		{
+			dashboard.GET("/dashboard", r.dashboardHandler.GetStats)
+
+			// Room occupancy & tenant rooms (enriched data)
+			dashboard.GET("/room-occupancy", r.dashboardHandler.GetRoomOccupancy)
+			dashboard.GET("/tenant-rooms", r.dashboardHandler.GetTenantRooms)
+			dashboard.GET("/room-payments/:id", r.dashboardHandler.GetPaymentsByRoom)
+			dashboard.GET("/tenant-payments/:id", r.dashboardHandler.GetPaymentsByTenant)
+		}
 
 		// Payments management
 		payments := admin.Group("/payments")
 		{
-			payments.GET("", r.paymentHandler.GetAllPayments)                       // GET /api/payments
-			payments.PUT("/:id/confirm", r.paymentHandler.ConfirmPayment)           // PUT /api/payments/:id/confirm
-			payments.PUT("/:id/reject", r.paymentHandler.RejectPayment)             // PUT /api/payments/:id/reject
-			payments.POST("/confirm-cash/:id", r.paymentHandler.ConfirmCashPayment) // POST /api/payments/confirm-cash/:id (Admin only)
+			payments.GET("", middleware.RequirePermission(utils.PermPaymentsRead), r.paymentHandler.GetAllPayments)                          // GET /api/payments
+			payments.PUT("/:id/confirm", middleware.RequirePermission(utils.PermPaymentsConfirm), r.paymentHandler.ConfirmPayment)           // PUT /api/payments/:id/confirm
+			payments.PUT("/:id/reject", middleware.RequirePermission(utils.PermPaymentsConfirm), r.paymentHandler.RejectPayment)             // PUT /api/payments/:id/reject
+			payments.POST("/confirm-cash/:id", middleware.RequirePermission(utils.PermPaymentsConfirm), r.paymentHandler.ConfirmCashPayment) // POST /api/payments/confirm-cash/:id
+		}
+
+		// Installment plan (cicilan sisa DP)
+		admin.PUT("/bookings/:id/installments", middleware.RequirePermission(utils.PermLedgerWrite), r.paymentHandler.RescheduleInstallments) // PUT /api/bookings/:id/installments
+
+		// Ledger adjustments
+		admin.POST("/bookings/:id/ledger/adjustments", middleware.RequirePermission(utils.PermLedgerWrite), r.ledgerHandler.AddAdjustment) // POST /api/bookings/:id/ledger/adjustments
+
+		// Settlement uang jaminan setelah check-out
+		admin.POST("/bookings/:id/deposit/settle", middleware.RequirePermission(utils.PermRefundsManage), r.depositHandler.SettleDeposit) // POST /api/bookings/:id/deposit/settle
+
+		// Check-in / check-out penyewa
+		admin.POST("/bookings/:id/check-in", middleware.RequirePermission(utils.PermRoomsStatus), r.leaseHandler.CheckIn)   // POST /api/bookings/:id/check-in
+		admin.POST("/bookings/:id/check-out", middleware.RequirePermission(utils.PermRoomsStatus), r.leaseHandler.CheckOut) // POST /api/bookings/:id/check-out
+
+		// Maintenance tickets management
+		maintenance := admin.Group("/maintenance", middleware.RequirePermission(utils.PermMaintenanceManage))
+		{
+			maintenance.GET("", r.maintenanceHandler.GetTickets)              // GET /api/maintenance
+			maintenance.PUT("/:id/assign", r.maintenanceHandler.AssignTicket) // PUT /api/maintenance/:id/assign
+			maintenance.PUT("/:id/status", r.maintenanceHandler.UpdateStatus) // PUT /api/maintenance/:id/status
 		}
 
-		// Tenants management
-		admin.GET("/tenants", r.tenantHandler.GetAllTenants)
-		admin.PUT("/tenants/:id/deactivate", r.tenantHandler.DeactivateTenant)
+		// Refunds management
+		refunds := admin.Group("/refunds", middleware.RequirePermission(utils.PermRefundsManage))
+		{
+			refunds.GET("", r.refundHandler.GetAllRefunds)             // GET /api/refunds?status=
+			refunds.PUT("/:id/approve", r.refundHandler.ApproveRefund) // PUT /api/refunds/:id/approve
+			refunds.PUT("/:id/reject", r.refundHandler.RejectRefund)   // PUT /api/refunds/:id/reject
+			refunds.POST("/:id/paid", r.refundHandler.MarkRefundPaid)  // POST /api/refunds/:id/paid (multipart: proof)
+		}
+
+		// Pricing policy (DP & pelunasan)
+		pricing := admin.Group("/pricing-policies", middleware.RequirePermission(utils.PermPricingManage))
+		{
+			pricing.GET("", r.pricingHandler.GetPolicies)         // GET /api/pricing-policies
+			pricing.PUT("", r.pricingHandler.SavePolicy)          // PUT /api/pricing-policies (upsert per tipe_kamar)
+			pricing.DELETE("/:id", r.pricingHandler.DeletePolicy) // DELETE /api/pricing-policies/:id
+		}
+
+		// Export CSV/XLSX (filter sama dengan endpoint daftar)
+		exports := admin.Group("/exports", middleware.RequirePermission(utils.PermExportsRead))
+		{
+			exports.GET("/payments", r.exportHandler.ExportPayments)                                                       // GET /api/exports/payments?format=csv|xlsx
+			exports.GET("/bookings", r.exportHandler.ExportBookings)                                                       // GET /api/exports/bookings?format=csv|xlsx
+			exports.GET("/tenants", middleware.RequirePermission(utils.PermTenantsReadPII), r.exportHandler.ExportTenants) // GET /api/exports/tenants?format=csv|xlsx (berisi NIK)
+		}
+
+		// Outbox (antrean WA/email & dead-letter)
+		outbox := admin.Group("/outbox", middleware.RequirePermission(utils.PermOutboxManage))
+		{
+			outbox.GET("", r.outboxHandler.GetMessages)        // GET /api/outbox?status=Dead&channel=
+			outbox.POST("/:id/resend", r.outboxHandler.Resend) // POST /api/outbox/:id/resend
+		}
+
+		// Message templates (WA/email, per bahasa)
+		templates := admin.Group("/message-templates", middleware.RequirePermission(utils.PermTemplatesManage))
+		{
+			templates.GET("", r.messageTemplateHandler.GetTemplates)             // GET /api/message-templates
+			templates.PUT("", r.messageTemplateHandler.SaveTemplate)             // PUT /api/message-templates
+			templates.DELETE("", r.messageTemplateHandler.ResetTemplate)         // DELETE /api/message-templates?event=&channel=&language=
+			templates.POST("/preview", r.messageTemplateHandler.PreviewTemplate) // POST /api/message-templates/preview
+		}
+
+		// Tenants management (NIK disamarkan tanpa tenants:read_pii)
+		admin.GET("/tenants", middleware.RequirePermission(utils.PermTenantsRead), r.tenantHandler.GetAllTenants)
+		admin.PUT("/tenants/:id/deactivate", middleware.RequirePermission(utils.PermTenantsWrite), r.tenantHandler.DeactivateTenant)
+		admin.GET("/tenants/:id/login-history", middleware.RequirePermission(utils.PermTenantsRead), r.tenantHandler.GetLoginHistory)
+
+		// Staff users & roles
+		staff := admin.Group("/staff", middleware.RequirePermission(utils.PermStaffManage))
+		{
+			staff.GET("", r.staffHandler.GetStaff)            // GET /api/staff
+			staff.GET("/roles", r.staffHandler.GetRoles)      // GET /api/staff/roles
+			staff.POST("", r.staffHandler.CreateStaff)        // POST /api/staff
+			staff.PUT("/:id/role", r.staffHandler.UpdateRole) // PUT /api/staff/:id/role
+			staff.DELETE("/:id", r.staffHandler.DeleteStaff)  // DELETE /api/staff/:id
+		}
 
-		// Room occupancy & tenant rooms (enriched data)
-		admin.GET("/room-occupancy", r.dashboardHandler.GetRoomOccupancy)
-		admin.GET("/tenant-rooms", r.dashboardHandler.GetTenantRooms)
-		admin.GET("/room-payments/:id", r.dashboardHandler.GetPaymentsByRoom)
-		admin.GET("/tenant-payments/:id", r.dashboardHandler.GetPaymentsByTenant)
+		// Audit log mutasi admin & keuangan (owner)
+		admin.GET("/audit-logs", middleware.RequirePermission(utils.PermAuditRead), r.auditLogHandler.GetLogs) // GET /api/audit-logs
 	}
 }
@@ -8,17 +8,26 @@ import (
 )
 
 type Scheduler struct {
-	cron            *cron.Cron
-	reminderService service.ReminderService
+	cron                *cron.Cron
+	reminderService     service.ReminderService
+	availabilityService service.AvailabilityService
+	lateFeeService      service.LateFeeService
+	outboxService       service.OutboxService
+	leaseService        service.LeaseService
 }
 
-func NewScheduler(reminderService service.ReminderService) *Scheduler {
+func NewScheduler(reminderService service.ReminderService, availabilityService service.AvailabilityService, lateFeeService service.LateFeeService, outboxService service.OutboxService, leaseService service.LeaseService) *Scheduler {
 	// Initialize cron with seconds precision if needed, but standard is fine.
 	// We use standard cron parser (Minute Hour Dom Month Dow)
-	c := cron.New()
+	// Job yang masih berjalan tidak dijalankan ulang (mis. outbox tiap menit saat provider lambat)
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
 	return &Scheduler{
-		cron:            c,
-		reminderService: reminderService,
+		cron:                c,
+		reminderService:     reminderService,
+		availabilityService: availabilityService,
+		lateFeeService:      lateFeeService,
+		outboxService:       outboxService,
+		leaseService:        leaseService,
 	}
 }
 
@@ -46,6 +55,46 @@ func (s *Scheduler) Start() {
 		log.Fatalf("Error adding cron job: %v", err)
 	}
 
+	// Sync room status every day at 00:05 so bookings starting today occupy their room.
+	// Leases past TanggalKeluar are checked out first so their rooms are freed.
+	_, err = s.cron.AddFunc("5 0 * * *", func() {
+		s.processExpiredLeases()
+		log.Println("[Scheduler] Syncing room status with today's bookings...")
+		if err := s.availabilityService.SyncKamarStatus(); err != nil {
+			log.Printf("[Scheduler] Error syncing room status: %v", err)
+		}
+	})
+
+	if err != nil {
+		log.Fatalf("Error adding cron job: %v", err)
+	}
+
+	// Accrue late fees & escalate overdue bills every day at 09:00 (after reminders are sent)
+	_, err = s.cron.AddFunc("0 9 * * *", func() {
+		log.Println("[Scheduler] Processing overdue payments...")
+		if err := s.lateFeeService.ProcessOverduePayments(); err != nil {
+			log.Printf("[Scheduler] Error processing overdue payments: %v", err)
+		}
+	})
+
+	if err != nil {
+		log.Fatalf("Error adding cron job: %v", err)
+	}
+
+	// Deliver queued WhatsApp/email messages (retries use exponential backoff) every minute
+	_, err = s.cron.AddFunc("* * * * *", func() {
+		sent, err := s.outboxService.ProcessDue()
+		if err != nil {
+			log.Printf("[Scheduler] Error processing outbox: %v", err)
+		} else if sent > 0 {
+			log.Printf("[Scheduler] Delivered %d outbox messages", sent)
+		}
+	})
+
+	if err != nil {
+		log.Fatalf("Error adding cron job: %v", err)
+	}
+
 	s.cron.Start()
 	log.Println("Scheduler started: Daily payment reminders at 08:00 AM")
 
@@ -59,9 +108,25 @@ func (s *Scheduler) Start() {
 		if err == nil {
 			log.Printf("[Scheduler] Sent %d reminders on startup", len(reminders))
 		}
+		s.processExpiredLeases()
+		if err := s.availabilityService.SyncKamarStatus(); err != nil {
+			log.Printf("[Scheduler] Error syncing room status on startup: %v", err)
+		}
+		if err := s.lateFeeService.ProcessOverduePayments(); err != nil {
+			log.Printf("[Scheduler] Error processing overdue payments on startup: %v", err)
+		}
 	}()
 }
 
+func (s *Scheduler) processExpiredLeases() {
+	checkedOut, err := s.leaseService.ProcessExpiredLeases()
+	if err != nil {
+		log.Printf("[Scheduler] Error processing expired leases: %v", err)
+	} else if checkedOut > 0 {
+		log.Printf("[Scheduler] Checked out %d expired leases", checkedOut)
+	}
+}
+
 func (s *Scheduler) Stop() {
 	s.cron.Stop()
 }
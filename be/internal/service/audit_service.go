@@ -0,0 +1,148 @@
+package service
+
+import (
+	"encoding/json"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"log"
+	"reflect"
+)
+
+// AuditActor adalah pelaku mutasi yang dicatat di audit log (diambil dari JWT + IP request)
+type AuditActor struct {
+	UserID    uint
+	Role      string
+	IPAddress string
+}
+
+// SystemActor dipakai untuk mutasi otomatis oleh scheduler (mis. auto-cancel booking)
+var SystemActor = AuditActor{Role: "system"}
+
+// statusChange menyusun konteks perpindahan status booking/pembayaran untuk tabel history
+func (a AuditActor) statusChange(reason string) models.StatusChange {
+	return models.StatusChange{ChangedBy: a.UserID, ChangedByRole: a.Role, Reason: reason}
+}
+
+// Field yang berubah di setiap update dan tidak relevan untuk diff
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+type AuditService interface {
+	Record(actor AuditActor, action, entityType string, entityID uint, before, after interface{})
+	GetLogs(pagination *utils.Pagination, filter repository.AuditLogFilter) ([]models.AuditLog, int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo}
+}
+
+// Record mencatat satu mutasi. Before/after boleh nil (create/delete); selain itu hanya
+// field yang berubah yang disimpan. Gagal mencatat tidak membatalkan mutasi, hanya di-log.
+func (s *auditService) Record(actor AuditActor, action, entityType string, entityID uint, before, after interface{}) {
+	beforeJSON, afterJSON, err := auditDiff(before, after)
+	if err != nil {
+		log.Printf("[WARN] Gagal membuat diff audit %s %s#%d: %v", action, entityType, entityID, err)
+	}
+
+	entry := &models.AuditLog{
+		ActorUserID: actor.UserID,
+		ActorRole:   actor.Role,
+		IPAddress:   actor.IPAddress,
+		Action:      action,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Before:      beforeJSON,
+		After:       afterJSON,
+	}
+	if err := s.repo.Create(entry); err != nil {
+		log.Printf("[WARN] Gagal mencatat audit %s %s#%d oleh user %d: %v", action, entityType, entityID, actor.UserID, err)
+	}
+}
+
+func (s *auditService) GetLogs(pagination *utils.Pagination, filter repository.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	return s.repo.FindAll(pagination, filter)
+}
+
+// recordAudit aman dipanggil dengan AuditService nil (dipakai di unit test service lain)
+func recordAudit(audit AuditService, actor AuditActor, action, entityType string, entityID uint, before, after interface{}) {
+	if audit == nil {
+		return
+	}
+	audit.Record(actor, action, entityType, entityID, before, after)
+}
+
+// auditDiff mengubah before/after menjadi JSON berisi field top-level yang berbeda.
+// Relasi yang ikut ter-preload (object/array) diabaikan agar log hanya memuat kolom entitas itu sendiri.
+func auditDiff(before, after interface{}) (string, string, error) {
+	beforeFields, err := auditSnapshot(before)
+	if err != nil {
+		return "", "", err
+	}
+	afterFields, err := auditSnapshot(after)
+	if err != nil {
+		return "", "", err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	beforeJSON, err := marshalAuditFields(beforeFields)
+	if err != nil {
+		return "", "", err
+	}
+	afterJSON, err := marshalAuditFields(afterFields)
+	if err != nil {
+		return "", "", err
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+func auditSnapshot(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			delete(fields, key)
+			continue
+		}
+		if auditIgnoredFields[key] {
+			delete(fields, key)
+		}
+	}
+	return fields, nil
+}
+
+func marshalAuditFields(fields map[string]interface{}) (string, error) {
+	if fields == nil {
+		return "", nil
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Test auditDiff - hanya field yang berubah yang disimpan; relasi dan updated_at diabaikan
+func TestAuditDiff_OnlyChangedFields(t *testing.T) {
+	before := models.Kamar{ID: 1, NomorKamar: "A1", Status: "Penuh", HargaPerBulan: 1500000,
+		Images: []models.KamarImage{{ImageURL: "a.jpg"}}, UpdatedAt: time.Now().Add(-time.Hour)}
+	after := before
+	after.Status = "Tersedia"
+	after.UpdatedAt = time.Now()
+
+	beforeJSON, afterJSON, err := auditDiff(before, &after)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"status":"Penuh"}`, beforeJSON)
+	assert.JSONEq(t, `{"status":"Tersedia"}`, afterJSON)
+}
+
+// Test Record - create hanya mengisi After, pelaku dicatat dari AuditActor
+func TestAuditService_Record_Create(t *testing.T) {
+	repo := new(MockAuditRepository)
+	repo.On("Create", mock.AnythingOfType("*models.AuditLog")).Return(nil)
+	service := NewAuditService(repo)
+
+	actor := AuditActor{UserID: 7, Role: utils.RoleCaretaker, IPAddress: "10.0.0.2"}
+	service.Record(actor, "gallery.create", "gallery", 3, nil, &models.Gallery{ID: 3, Title: "Dapur"})
+
+	entry := repo.Calls[0].Arguments.Get(0).(*models.AuditLog)
+	assert.Equal(t, uint(7), entry.ActorUserID)
+	assert.Equal(t, utils.RoleCaretaker, entry.ActorRole)
+	assert.Equal(t, "10.0.0.2", entry.IPAddress)
+	assert.Equal(t, "gallery", entry.EntityType)
+	assert.Equal(t, uint(3), entry.EntityID)
+	assert.Empty(t, entry.Before)
+
+	var after map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(entry.After), &after))
+	assert.Equal(t, "Dapur", after["title"])
+}
+
+// Test kamarService.Update - memaksa kamar "Tersedia" mencatat pembatalan booking aktif dan perubahan kamar
+func TestKamarService_Update_ForceAvailableIsAudited(t *testing.T) {
+	kamarRepo := new(MockKamarRepository)
+	bookingRepo := new(MockBookingRepository)
+	auditRepo := new(MockAuditRepository)
+
+	kamarRepo.On("FindByID", uint(1)).Return(&models.Kamar{ID: 1, NomorKamar: "A1", Status: "Penuh"}, nil)
+	kamarRepo.On("Update", mock.AnythingOfType("*models.Kamar")).Return(nil)
+	bookingRepo.On("FindActiveBookingByKamarID", uint(1)).Return(&models.Pemesanan{ID: 9, KamarID: 1, StatusPemesanan: "Confirmed"}, nil)
+	bookingRepo.On("Transition", mock.AnythingOfType("*models.Pemesanan"), models.BookingCancelled, mock.Anything).Return(nil)
+	auditRepo.On("Create", mock.AnythingOfType("*models.AuditLog")).Return(nil)
+
+	service := NewKamarService(kamarRepo, bookingRepo, nil, nil, nil, nil, "", nil, nil, NewAuditService(auditRepo), nil)
+	actor := AuditActor{UserID: 2, Role: utils.RoleAdmin, IPAddress: "127.0.0.1"}
+	err := service.Update(&models.Kamar{ID: 1, NomorKamar: "A1", Status: "Tersedia"}, actor)
+	assert.NoError(t, err)
+
+	auditRepo.AssertNumberOfCalls(t, "Create", 2)
+	cancelled := auditRepo.Calls[0].Arguments.Get(0).(*models.AuditLog)
+	assert.Equal(t, "booking.force_cancel", cancelled.Action)
+	assert.Equal(t, uint(9), cancelled.EntityID)
+	assert.JSONEq(t, `{"status_pemesanan":"Confirmed"}`, cancelled.Before)
+	assert.JSONEq(t, `{"status_pemesanan":"Cancelled"}`, cancelled.After)
+
+	updated := auditRepo.Calls[1].Arguments.Get(0).(*models.AuditLog)
+	assert.Equal(t, "kamar.update", updated.Action)
+	assert.Equal(t, uint(2), updated.ActorUserID)
+	assert.JSONEq(t, `{"status":"Penuh"}`, updated.Before)
+	assert.JSONEq(t, `{"status":"Tersedia"}`, updated.After)
+}
+
+// Test permission - audit log hanya untuk owner
+func TestRoleHasPermission_AuditReadOwnerOnly(t *testing.T) {
+	assert.True(t, utils.RoleHasPermission(utils.RoleOwner, utils.PermAuditRead))
+	assert.False(t, utils.RoleHasPermission(utils.RoleAdmin, utils.PermAuditRead))
+	assert.False(t, utils.RoleHasPermission(utils.RoleAccountant, utils.PermAuditRead))
+}
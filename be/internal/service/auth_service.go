@@ -15,11 +15,13 @@ import (
 )
 
 type AuthService interface {
-	Login(username, password string) (string, *models.User, error)
+	Login(username, password string, client LoginClient) (*models.User, error)
 	Register(username, password, role, email, phone, address, birthdate, nik, gender string) (*models.User, error)
-	GoogleLogin(idToken, username, picture string) (string, *models.User, error)
+	GoogleLogin(idToken, username, picture string, client LoginClient) (*models.User, error)
 	ForgotPassword(email string) error
 	ResetPassword(token, newPassword string) error
+	BeginTwoFactorSetup(challengeToken string) (*TwoFactorSetup, error)
+	VerifyTwoFactor(challengeToken, code string, client LoginClient) (*models.User, []string, error)
 }
 
 type authService struct {
@@ -28,37 +30,50 @@ type authService struct {
 	config         *config.Config
 	emailSender    utils.EmailSender
 	googleVerifier utils.IDTokenVerifier
+	sessionRepo    repository.SessionRepository
+	loginRepo      repository.LoginRepository
+	waSender       utils.WhatsAppSender
+	templates      MessageTemplateService
+	twoFactor      TwoFactorService
 }
 
-func NewAuthService(repo repository.UserRepository, penyewaRepo repository.PenyewaRepository, cfg *config.Config, emailSender utils.EmailSender, googleVerifier utils.IDTokenVerifier) AuthService {
-	return &authService{repo, penyewaRepo, cfg, emailSender, googleVerifier}
+func NewAuthService(repo repository.UserRepository, penyewaRepo repository.PenyewaRepository, cfg *config.Config, emailSender utils.EmailSender, googleVerifier utils.IDTokenVerifier, sessionRepo repository.SessionRepository, loginRepo repository.LoginRepository, waSender utils.WhatsAppSender, templates MessageTemplateService, twoFactor TwoFactorService) AuthService {
+	return &authService{repo, penyewaRepo, cfg, emailSender, googleVerifier, sessionRepo, loginRepo, waSender, templates, twoFactor}
 }
 
-func (s *authService) Login(username, password string) (string, *models.User, error) {
+func (s *authService) Login(username, password string, client LoginClient) (*models.User, error) {
+	if err := s.checkLockout(username, client); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.FindByUsername(username)
 	if err != nil {
-		return "", nil, errors.New("Username tidak ditemukan")
+		s.recordLoginFailure(username, 0, "password", "unknown_user", client)
+		return nil, errors.New("Username tidak ditemukan")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", nil, errors.New("Password yang Anda masukkan salah")
+		s.recordLoginFailure(username, user.ID, "password", "wrong_password", client)
+		return nil, errors.New("Password yang Anda masukkan salah")
 	}
 
 	// Check if penyewa account is non_active
 	penyewa, err := s.penyewaRepo.FindByUserID(user.ID)
 	if err == nil && penyewa.Role == "non_active" {
-		return "", nil, errors.New("akun Anda telah dinonaktifkan, silakan hubungi admin")
+		if s.loginRepo != nil {
+			s.recordAttempt(&models.LoginAttempt{UserID: user.ID, Username: user.Username, Method: "password", Reason: "deactivated"}, client)
+		}
+		return nil, errors.New("akun Anda telah dinonaktifkan, silakan hubungi admin")
 	}
 
-	// Generate token pair (access + refresh)
-	accessToken, _, err := utils.GenerateTokenPair(int(user.ID), user.Username, user.Role, s.config.JWTSecret)
-	if err != nil {
-		return "", nil, err
+	// Admin & user dengan 2FA aktif harus melewati langkah OTP sebelum token diterbitkan
+	if err := s.requireTwoFactor(user, "password"); err != nil {
+		return nil, err
 	}
 
-	// For backward compatibility, return access token
-	// This will be used to set cookies in handler
-	return accessToken, user, nil
+	// Token & sesi diterbitkan handler lewat SessionService setelah login berhasil
+	s.recordLoginSuccess(user, "password", client)
+	return user, nil
 }
 
 func (s *authService) Register(username, password, role, email, phone, address, birthdate, nik, gender string) (*models.User, error) {
@@ -114,16 +129,16 @@ func (s *authService) Register(username, password, role, email, phone, address,
 
 //auth untuk google login (function nya)
 
-func (s *authService) GoogleLogin(idToken, username, picture string) (string, *models.User, error) {
+func (s *authService) GoogleLogin(idToken, username, picture string, client LoginClient) (*models.User, error) {
 	// SECURITY FIX: Verify the ID token with Google's servers
 	claims, err := s.googleVerifier.Verify(idToken, s.config.GoogleClientID)
 	if err != nil {
-		return "", nil, fmt.Errorf("invalid google token: %v", err)
+		return nil, fmt.Errorf("invalid google token: %v", err)
 	}
 
 	email := claims.Email
 	if email == "" {
-		return "", nil, fmt.Errorf("email not found in token")
+		return nil, fmt.Errorf("email not found in token")
 	}
 
 	// Use name/picture from token if not provided
@@ -145,7 +160,7 @@ func (s *authService) GoogleLogin(idToken, username, picture string) (string, *m
 			Role:     "guest",                                          // Google users start as guests until they book
 		}
 		if err := s.repo.Create(user); err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
 		// 4. Create profile Penyewa for Google OAuth users
@@ -160,16 +175,15 @@ func (s *authService) GoogleLogin(idToken, username, picture string) (string, *m
 	// Check if penyewa account is non_active
 	existingPenyewa, pErr := s.penyewaRepo.FindByUserID(user.ID)
 	if pErr == nil && existingPenyewa.Role == "non_active" {
-		return "", nil, errors.New("akun Anda telah dinonaktifkan, silakan hubungi admin")
+		return nil, errors.New("akun Anda telah dinonaktifkan, silakan hubungi admin")
 	}
 
-	// 5. Generate JWT Token
-	accessToken, _, err := utils.GenerateTokenPair(int(user.ID), user.Username, user.Role, s.config.JWTSecret)
-	if err != nil {
-		return "", nil, err
+	if err := s.requireTwoFactor(user, "google"); err != nil {
+		return nil, err
 	}
 
-	return accessToken, user, nil
+	s.recordLoginSuccess(user, "google", client)
+	return user, nil
 }
 
 func (s *authService) ForgotPassword(email string) error {
@@ -231,5 +245,11 @@ func (s *authService) ResetPassword(token, newPassword string) error {
 	user.ResetToken = "" // Clear token
 	user.ResetTokenExpiry = time.Time{}
 
-	return s.repo.Update(user)
+	if err := s.repo.Update(user); err != nil {
+		return err
+	}
+
+	// Password di-reset: semua perangkat harus login ulang
+	revokeUserSessions(s.sessionRepo, user.ID, "", sessionRevokedPasswordReset)
+	return nil
 }
@@ -37,14 +37,13 @@ func TestLogin_Success(t *testing.T) {
 	mockUserRepo.On("FindByUsername", "testuser").Return(expectedUser, nil)
 	mockPenyewaRepo.On("FindByUserID", uint(1)).Return(&models.Penyewa{Role: "tenant"}, nil)
 
-	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil)
+	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil, nil, nil, nil, nil, nil)
 
 	// Act
-	token, user, err := authService.Login("testuser", password)
+	user, err := authService.Login("testuser", password, LoginClient{})
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
 	assert.NotNil(t, user)
 	assert.Equal(t, "testuser", user.Username)
 	assert.Equal(t, "tenant", user.Role)
@@ -60,14 +59,13 @@ func TestLogin_UserNotFound(t *testing.T) {
 
 	mockUserRepo.On("FindByUsername", "nonexistent").Return(nil, errors.New("user not found"))
 
-	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil)
+	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil, nil, nil, nil, nil, nil)
 
 	// Act
-	token, user, err := authService.Login("nonexistent", "password")
+	user, err := authService.Login("nonexistent", "password", LoginClient{})
 
 	// Assert
 	assert.Error(t, err)
-	assert.Empty(t, token)
 	assert.Nil(t, user)
 	assert.Equal(t, "Username tidak ditemukan", err.Error())
 	mockUserRepo.AssertExpectations(t)
@@ -89,14 +87,13 @@ func TestLogin_InvalidPassword(t *testing.T) {
 
 	mockUserRepo.On("FindByUsername", "testuser").Return(user, nil)
 
-	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil)
+	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil, nil, nil, nil, nil, nil)
 
 	// Act
-	token, returnedUser, err := authService.Login("testuser", "wrongPassword")
+	returnedUser, err := authService.Login("testuser", "wrongPassword", LoginClient{})
 
 	// Assert
 	assert.Error(t, err)
-	assert.Empty(t, token)
 	assert.Nil(t, returnedUser)
 	assert.Equal(t, "Password yang Anda masukkan salah", err.Error())
 	mockUserRepo.AssertExpectations(t)
@@ -120,7 +117,7 @@ func TestRegister_Success(t *testing.T) {
 	})
 	mockPenyewaRepo.On("Create", mock.AnythingOfType("*models.Penyewa")).Return(nil)
 
-	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil)
+	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil, nil, nil, nil, nil, nil)
 
 	// Act
 	user, err := authService.Register("newuser", "password", "tenant", "test@example.com", "08123456789", "Jl. Test", "2000-01-01", "1234567890", "male")
@@ -146,7 +143,7 @@ func TestRegister_UserAlreadyExists(t *testing.T) {
 	existingUser := &models.User{Username: "existinguser"}
 	mockUserRepo.On("FindByUsername", "existinguser").Return(existingUser, nil)
 
-	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil)
+	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil, nil, nil, nil, nil, nil)
 
 	// Act
 	user, err := authService.Register("existinguser", "ValidPassword123", "tenant", "", "", "", "", "", "")
@@ -172,7 +169,7 @@ func TestRegister_AdminRole(t *testing.T) {
 	})
 	// Penyewa should NOT be created for admin role
 
-	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil)
+	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil, nil, nil, nil, nil, nil)
 
 	// Act
 	user, err := authService.Register("adminuser", "AdminPass123", "admin", "", "", "", "", "", "")
@@ -218,14 +215,13 @@ func TestGoogleLogin_NewUser(t *testing.T) {
 	mockPenyewaRepo.On("Create", mock.AnythingOfType("*models.Penyewa")).Return(nil)
 	mockPenyewaRepo.On("FindByUserID", uint(1)).Return(&models.Penyewa{Role: "guest"}, nil)
 
-	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, mockGoogleVerifier)
+	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, mockGoogleVerifier, nil, nil, nil, nil, nil)
 
 	// Act
-	token, user, err := authService.GoogleLogin(idToken, username, "")
+	user, err := authService.GoogleLogin(idToken, username, "", LoginClient{})
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
 	assert.NotNil(t, user)
 	assert.Equal(t, email, user.Username)
 	assert.Equal(t, "guest", user.Role)
@@ -262,14 +258,13 @@ func TestGoogleLogin_ExistingUser(t *testing.T) {
 	mockUserRepo.On("FindByUsername", email).Return(existingUser, nil)
 	mockPenyewaRepo.On("FindByUserID", uint(1)).Return(&models.Penyewa{Role: "tenant"}, nil)
 
-	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, mockGoogleVerifier)
+	authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, mockGoogleVerifier, nil, nil, nil, nil, nil)
 
 	// Act
-	token, user, err := authService.GoogleLogin(idToken, "Some Name", "")
+	user, err := authService.GoogleLogin(idToken, "Some Name", "", LoginClient{})
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
 	assert.NotNil(t, user)
 	assert.Equal(t, email, user.Username)
 	mockGoogleVerifier.AssertExpectations(t)
@@ -336,19 +331,17 @@ func TestLogin_TableDriven(t *testing.T) {
 
 			tt.setupMock(mockUserRepo, mockPenyewaRepo)
 
-			authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil)
-			token, user, err := authService.Login(tt.username, tt.password)
+			authService := NewAuthService(mockUserRepo, mockPenyewaRepo, cfg, mockEmailSender, nil, nil, nil, nil, nil, nil)
+			user, err := authService.Login(tt.username, tt.password, LoginClient{})
 
 			if tt.expectError {
 				assert.Error(t, err)
-				assert.Empty(t, token)
 				assert.Nil(t, user)
 				if tt.expectedError != "" {
 					assert.Equal(t, tt.expectedError, err.Error())
 				}
 			} else {
 				assert.NoError(t, err)
-				assert.NotEmpty(t, token)
 				assert.NotNil(t, user)
 			}
 
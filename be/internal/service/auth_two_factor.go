@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+)
+
+// TwoFactorRequiredError dikembalikan Login/GoogleLogin saat password benar tetapi OTP masih diperlukan.
+// ChallengeToken ditukar dengan token pair lewat VerifyTwoFactor.
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+	SetupRequired  bool // user (admin) belum pernah enrol 2FA
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	if e.SetupRequired {
+		return "akun ini wajib menggunakan 2FA, silakan hubungkan aplikasi authenticator"
+	}
+	return "masukkan kode OTP dari aplikasi authenticator"
+}
+
+var ErrInvalidTwoFactorChallenge = errors.New("sesi verifikasi 2FA tidak valid atau kedaluwarsa, silakan login ulang")
+
+// requireTwoFactor mengembalikan TwoFactorRequiredError jika user wajib melewati langkah OTP
+func (s *authService) requireTwoFactor(user *models.User, method string) error {
+	if s.twoFactor == nil || !s.twoFactor.Required(user) {
+		return nil
+	}
+	challenge, err := utils.GenerateTwoFactorChallenge(int(user.ID), user.Username, user.Role, method, s.config.JWTSecret)
+	if err != nil {
+		return err
+	}
+	return &TwoFactorRequiredError{ChallengeToken: challenge, SetupRequired: !user.TwoFactorEnabled}
+}
+
+// BeginTwoFactorSetup memberi secret TOTP kepada user yang wajib 2FA tetapi belum enrol (login pertama admin)
+func (s *authService) BeginTwoFactorSetup(challengeToken string) (*TwoFactorSetup, error) {
+	claims, err := utils.ValidateTwoFactorChallenge(challengeToken, s.config.JWTSecret)
+	if err != nil || s.twoFactor == nil {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	return s.twoFactor.Setup(uint(claims.UserID))
+}
+
+// VerifyTwoFactor menyelesaikan login dengan kode OTP atau recovery code. Untuk user yang baru enrol,
+// kode pertama mengaktifkan 2FA dan recovery code dikembalikan (hanya sekali ini).
+// Kode salah dihitung ke penguncian login yang sama dengan password salah.
+func (s *authService) VerifyTwoFactor(challengeToken, code string, client LoginClient) (*models.User, []string, error) {
+	claims, err := utils.ValidateTwoFactorChallenge(challengeToken, s.config.JWTSecret)
+	if err != nil || s.twoFactor == nil {
+		return nil, nil, ErrInvalidTwoFactorChallenge
+	}
+	user, err := s.repo.FindByID(uint(claims.UserID))
+	if err != nil {
+		return nil, nil, ErrInvalidTwoFactorChallenge
+	}
+	if err := s.checkLockout(user.Username, client); err != nil {
+		return nil, nil, err
+	}
+
+	var recoveryCodes []string
+	if user.TwoFactorEnabled {
+		err = s.twoFactor.Verify(user, code)
+	} else {
+		recoveryCodes, err = s.twoFactor.Enable(user.ID, code)
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			s.recordLoginFailure(user.Username, user.ID, claims.Method, "wrong_otp", client)
+		}
+		return nil, nil, err
+	}
+
+	s.recordLoginSuccess(user, claims.Method, client)
+	return user, recoveryCodes, nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	availabilityDateLayout    = "2006-01-02"
+	defaultAvailabilityMonths = 12
+	maxAvailabilityMonths     = 24
+)
+
+// DateRange adalah rentang tanggal setengah terbuka [From, To) untuk kalender frontend.
+type DateRange struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Status string `json:"status"` // free, booked, pending
+}
+
+type RoomAvailability struct {
+	KamarID    uint        `json:"kamar_id"`
+	NomorKamar string      `json:"nomor_kamar"`
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	Available  bool        `json:"available"` // true jika seluruh rentang kosong
+	Free       []DateRange `json:"free"`
+	Booked     []DateRange `json:"booked"`
+}
+
+type AvailabilityService interface {
+	GetAvailability(kamarID uint, from, to string) (*RoomAvailability, error)
+	SyncKamarStatus() error
+}
+
+type availabilityService struct {
+	kamarRepo   repository.KamarRepository
+	bookingRepo repository.BookingRepository
+}
+
+func NewAvailabilityService(kamarRepo repository.KamarRepository, bookingRepo repository.BookingRepository) AvailabilityService {
+	return &availabilityService{kamarRepo, bookingRepo}
+}
+
+// GetAvailability menghitung rentang kosong dan terisi sebuah kamar dari interval
+// TanggalMulai/TanggalKeluar booking. Jika from kosong dipakai hari ini, jika to kosong
+// dipakai from + 12 bulan.
+func (s *availabilityService) GetAvailability(kamarID uint, from, to string) (*RoomAvailability, error) {
+	start, end, err := parseAvailabilityRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	kamar, err := s.kamarRepo.FindByID(kamarID)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := s.bookingRepo.FindOverlappingBookings(kamarID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	free, booked := computeAvailability(start, end, bookings)
+
+	return &RoomAvailability{
+		KamarID:    kamar.ID,
+		NomorKamar: kamar.NomorKamar,
+		From:       start.Format(availabilityDateLayout),
+		To:         end.Format(availabilityDateLayout),
+		Available:  len(booked) == 0 && kamar.Status != "Maintenance",
+		Free:       free,
+		Booked:     booked,
+	}, nil
+}
+
+// SyncKamarStatus menaikkan Kamar.Status untuk booking yang baru mulai hari ini
+// (booking masa depan tidak lagi mengubah status kamar saat dibuat).
+// Status hanya dinaikkan (Tersedia -> Terpesan -> Penuh), tidak pernah diturunkan,
+// agar status yang diatur manual oleh admin tidak tertimpa.
+func (s *availabilityService) SyncKamarStatus() error {
+	kamars, err := s.kamarRepo.FindAll()
+	if err != nil {
+		return err
+	}
+
+	today := truncateToDay(time.Now())
+	for _, k := range kamars {
+		if k.Status == "Maintenance" || k.Status == "Penuh" {
+			continue
+		}
+
+		bookings, err := s.bookingRepo.FindOverlappingBookings(k.ID, today, today.AddDate(0, 0, 1))
+		if err != nil {
+			log.Printf("[WARN] Gagal memeriksa okupansi kamar %s: %v", k.NomorKamar, err)
+			continue
+		}
+
+		newStatus := k.Status
+		for _, b := range bookings {
+			if b.StatusPemesanan == models.BookingPending {
+				if newStatus == "Tersedia" {
+					newStatus = "Terpesan"
+				}
+				continue
+			}
+			newStatus = "Penuh"
+		}
+
+		if newStatus != k.Status {
+			if err := s.kamarRepo.UpdateStatus(k.ID, newStatus); err != nil {
+				log.Printf("[WARN] Gagal memperbarui status kamar %s: %v", k.NomorKamar, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseAvailabilityRange(from, to string) (time.Time, time.Time, error) {
+	start := truncateToDay(time.Now())
+	if from != "" {
+		parsed, err := time.ParseInLocation(availabilityDateLayout, from, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("format tanggal 'from' tidak valid, gunakan YYYY-MM-DD")
+		}
+		start = parsed
+	}
+
+	end := start.AddDate(0, defaultAvailabilityMonths, 0)
+	if to != "" {
+		parsed, err := time.ParseInLocation(availabilityDateLayout, to, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("format tanggal 'to' tidak valid, gunakan YYYY-MM-DD")
+		}
+		end = parsed
+	}
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("tanggal 'to' harus setelah tanggal 'from'")
+	}
+	if end.After(start.AddDate(0, maxAvailabilityMonths, 0)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("rentang maksimal %d bulan", maxAvailabilityMonths)
+	}
+
+	return start, end, nil
+}
+
+// computeAvailability memotong interval booking ke [from, to), menggabungkan yang
+// bersinggungan, lalu mengembalikan celah di antaranya sebagai rentang kosong.
+func computeAvailability(from, to time.Time, bookings []models.Pemesanan) (free []DateRange, booked []DateRange) {
+	type interval struct {
+		start, end time.Time
+		status     string
+	}
+
+	var intervals []interval
+	for _, b := range bookings {
+		start, end := truncateToDay(b.TanggalMulai), truncateToDay(b.TanggalKeluar)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+		status := "booked"
+		if b.StatusPemesanan == models.BookingPending {
+			status = "pending"
+		}
+		intervals = append(intervals, interval{start, end, status})
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+
+	var merged []interval
+	for _, iv := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && !iv.start.After(merged[last].end) {
+			if iv.end.After(merged[last].end) {
+				merged[last].end = iv.end
+			}
+			// Booking yang sudah dibayar lebih "kuat" daripada pending
+			if iv.status == "booked" {
+				merged[last].status = "booked"
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+
+	free = []DateRange{}
+	booked = []DateRange{}
+	cursor := from
+	for _, iv := range merged {
+		if iv.start.After(cursor) {
+			free = append(free, DateRange{cursor.Format(availabilityDateLayout), iv.start.Format(availabilityDateLayout), "free"})
+		}
+		booked = append(booked, DateRange{iv.start.Format(availabilityDateLayout), iv.end.Format(availabilityDateLayout), iv.status})
+		cursor = iv.end
+	}
+	if to.After(cursor) {
+		free = append(free, DateRange{cursor.Format(availabilityDateLayout), to.Format(availabilityDateLayout), "free"})
+	}
+
+	return free, booked
+}
+
+// truncateToDay mengambil tanggal kalender t dan menormalkannya ke tengah malam waktu lokal,
+// sehingga TanggalMulai yang disimpan sebagai UTC tetap jatuh pada tanggal yang sama.
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
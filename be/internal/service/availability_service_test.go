@@ -0,0 +1,72 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func date(s string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+	return t
+}
+
+func TestComputeAvailability_GapsBetweenBookings(t *testing.T) {
+	bookings := []models.Pemesanan{
+		{TanggalMulai: date("2026-03-01"), TanggalKeluar: date("2026-05-01"), StatusPemesanan: "Confirmed"},
+		{TanggalMulai: date("2026-07-01"), TanggalKeluar: date("2026-08-01"), StatusPemesanan: "Pending"},
+	}
+
+	free, booked := computeAvailability(date("2026-02-01"), date("2026-09-01"), bookings)
+
+	assert.Equal(t, []DateRange{
+		{From: "2026-02-01", To: "2026-03-01", Status: "free"},
+		{From: "2026-05-01", To: "2026-07-01", Status: "free"},
+		{From: "2026-08-01", To: "2026-09-01", Status: "free"},
+	}, free)
+	assert.Equal(t, []DateRange{
+		{From: "2026-03-01", To: "2026-05-01", Status: "booked"},
+		{From: "2026-07-01", To: "2026-08-01", Status: "pending"},
+	}, booked)
+}
+
+func TestComputeAvailability_ClipsAndMergesOverlaps(t *testing.T) {
+	bookings := []models.Pemesanan{
+		{TanggalMulai: date("2026-01-01"), TanggalKeluar: date("2026-04-01"), StatusPemesanan: "Pending"},
+		{TanggalMulai: date("2026-04-01"), TanggalKeluar: date("2026-06-01"), StatusPemesanan: "Confirmed"},
+	}
+
+	free, booked := computeAvailability(date("2026-03-01"), date("2026-05-01"), bookings)
+
+	assert.Empty(t, free)
+	assert.Equal(t, []DateRange{{From: "2026-03-01", To: "2026-05-01", Status: "booked"}}, booked)
+}
+
+func TestAvailabilityService_GetAvailability_InvalidRange(t *testing.T) {
+	service := NewAvailabilityService(new(MockKamarRepository), new(MockBookingRepository))
+
+	_, err := service.GetAvailability(1, "2026-05-01", "2026-04-01")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "harus setelah")
+}
+
+func TestAvailabilityService_GetAvailability_Success(t *testing.T) {
+	mockKamarRepo := new(MockKamarRepository)
+	mockBookingRepo := new(MockBookingRepository)
+	service := NewAvailabilityService(mockKamarRepo, mockBookingRepo)
+
+	mockKamarRepo.On("FindByID", uint(1)).Return(&models.Kamar{ID: 1, NomorKamar: "A1", Status: "Tersedia"}, nil)
+	mockBookingRepo.On("FindOverlappingBookings", uint(1), mock.Anything, mock.Anything).Return([]models.Pemesanan{}, nil)
+
+	result, err := service.GetAvailability(1, "2026-01-01", "2026-02-01")
+
+	assert.NoError(t, err)
+	assert.True(t, result.Available)
+	assert.Equal(t, []DateRange{{From: "2026-01-01", To: "2026-02-01", Status: "free"}}, result.Free)
+	mockKamarRepo.AssertExpectations(t)
+	mockBookingRepo.AssertExpectations(t)
+}
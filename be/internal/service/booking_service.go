@@ -11,24 +11,36 @@ import (
 )
 
 type BookingResponse struct {
-	ID              uint                `json:"id"`
-	KamarID         uint                `json:"kamar_id"`
-	Kamar           models.Kamar        `json:"kamar"`
-	TanggalMulai    string              `json:"tanggal_mulai"`
-	DurasiSewa      int                 `json:"durasi_sewa"`
-	StatusPemesanan string              `json:"status_pemesanan"`
-	TotalBayar      float64             `json:"total_bayar"`
-	StatusBayar     string              `json:"status_bayar"`
-	Payments        []models.Pembayaran `json:"payments"`
+	ID              uint                    `json:"id"`
+	KamarID         uint                    `json:"kamar_id"`
+	Kamar           models.Kamar            `json:"kamar"`
+	TanggalMulai    string                  `json:"tanggal_mulai"`
+	DurasiSewa      int                     `json:"durasi_sewa"`
+	StatusPemesanan models.BookingStatus    `json:"status_pemesanan"`
+	TotalBayar      float64                 `json:"total_bayar"`
+	StatusBayar     models.PaymentStatus    `json:"status_bayar"`
+	PaidUntil       string                  `json:"paid_until"`  // dari buku besar
+	Outstanding     float64                 `json:"outstanding"` // sisa tagihan dari buku besar
+	Deposit         *models.SecurityDeposit `json:"deposit"`     // uang jaminan, di luar buku besar sewa (null jika tidak ada)
+	Payments        []models.Pembayaran     `json:"payments"`
 }
 
 type BookingService interface {
 	GetUserBookings(userID uint) ([]BookingResponse, error)
-	CreateBooking(userID uint, kamarID uint, tanggalMulai string, durasiSewa int) (*models.Pemesanan, error)
-	CreateBookingWithProof(userID uint, kamarID uint, tanggalMulai string, durasiSewa int, proofURL string, paymentType string, paymentMethod string) (*models.Pemesanan, error)
-	CancelBooking(id uint, userID uint) error
-	ExtendBooking(bookingID uint, months int, userID uint, paymentMethod string) (*models.Pembayaran, error)
+	CreateBooking(userID uint, kamarID uint, tanggalMulai string, durasiSewa int, actor AuditActor) (*models.Pemesanan, error)
+	CreateBookingWithProof(userID uint, kamarID uint, tanggalMulai string, durasiSewa int, proofURL string, paymentType string, paymentMethod string, actor AuditActor) (*models.Pemesanan, error)
+	CancelBooking(id uint, userID uint, actor AuditActor) error
+	ExtendBooking(bookingID uint, months int, userID uint, paymentMethod string, actor AuditActor) (*models.Pembayaran, error)
 	AutoCancelExpiredBookings() error
+	GetBookingHistory(bookingID uint, userID uint, role string) (*BookingHistory, error)
+}
+
+// BookingHistory adalah riwayat perpindahan status booking dan semua pembayarannya, terlama dulu
+type BookingHistory struct {
+	PemesananID     uint                          `json:"pemesanan_id"`
+	StatusPemesanan models.BookingStatus          `json:"status_pemesanan"`
+	Booking         []models.BookingStatusHistory `json:"booking"`
+	Payments        []models.PaymentStatusHistory `json:"payments"`
 }
 
 type bookingService struct {
@@ -37,12 +49,20 @@ type bookingService struct {
 	penyewaRepo repository.PenyewaRepository
 	kamarRepo   repository.KamarRepository
 	paymentRepo repository.PaymentRepository
+	refundRepo  repository.RefundRepository
+	policyRepo  repository.PricingPolicyRepository
+	ledgerRepo  repository.LedgerRepository
 	db          *gorm.DB // Added db for transactions
 	waSender    utils.WhatsAppSender
+	notifier    NotificationService
+	templates   MessageTemplateService
+	audit       AuditService
+	depositRepo repository.DepositRepository
+	cadence     []int // tahap reminder (REMINDER_CADENCE) untuk reminder pertama tagihan baru
 }
 
-func NewBookingService(repo repository.BookingRepository, userRepo repository.UserRepository, penyewaRepo repository.PenyewaRepository, kamarRepo repository.KamarRepository, paymentRepo repository.PaymentRepository, db *gorm.DB, waSender utils.WhatsAppSender) BookingService {
-	return &bookingService{repo, userRepo, penyewaRepo, kamarRepo, paymentRepo, db, waSender}
+func NewBookingService(repo repository.BookingRepository, userRepo repository.UserRepository, penyewaRepo repository.PenyewaRepository, kamarRepo repository.KamarRepository, paymentRepo repository.PaymentRepository, refundRepo repository.RefundRepository, policyRepo repository.PricingPolicyRepository, ledgerRepo repository.LedgerRepository, db *gorm.DB, waSender utils.WhatsAppSender, notifier NotificationService, templates MessageTemplateService, audit AuditService, depositRepo repository.DepositRepository, reminderCadence []int) BookingService {
+	return &bookingService{repo, userRepo, penyewaRepo, kamarRepo, paymentRepo, refundRepo, policyRepo, ledgerRepo, db, waSender, notifier, templates, audit, depositRepo, reminderCadence}
 }
 
 func (s *bookingService) GetUserBookings(userID uint) ([]BookingResponse, error) {
@@ -55,18 +75,27 @@ func (s *bookingService) GetUserBookings(userID uint) ([]BookingResponse, error)
 		return nil, err
 	}
 
+	// Total bayar, sisa tagihan dan paid until diturunkan dari buku besar
+	// (pembagian totalPaid / HargaPerBulan tidak akurat untuk DP dan denda)
+	ledgers, err := loadLedgers(s.ledgerRepo, bookings)
+	if err != nil {
+		return nil, err
+	}
+
+	deposits, err := loadDeposits(s.depositRepo, bookings)
+	if err != nil {
+		return nil, err
+	}
+
 	var response []BookingResponse
 	for _, b := range bookings {
 		// PERFORMANCE: Payments are already loaded via Preload - no additional query!
 		payments := b.Pembayaran
+		ledger := ledgers[b.ID]
 
-		var totalPaid float64
-		var lastStatus string
+		var lastStatus models.PaymentStatus
 		var latestPaymentID uint
 		for _, p := range payments {
-			if p.StatusPembayaran == "Confirmed" {
-				totalPaid += p.JumlahBayar
-			}
 			// Use the most recent payment's status (highest ID = newest)
 			if p.ID > latestPaymentID {
 				latestPaymentID = p.ID
@@ -74,12 +103,9 @@ func (s *bookingService) GetUserBookings(userID uint) ([]BookingResponse, error)
 			}
 		}
 
-		actualDurasi := b.DurasiSewa
-		if b.Kamar.HargaPerBulan > 0 {
-			paidMonths := int(totalPaid / b.Kamar.HargaPerBulan)
-			if paidMonths > actualDurasi {
-				actualDurasi = paidMonths
-			}
+		paidUntil := ""
+		if !ledger.PaidUntil.IsZero() {
+			paidUntil = ledger.PaidUntil.Format("2006-01-02")
 		}
 
 		response = append(response, BookingResponse{
@@ -87,10 +113,13 @@ func (s *bookingService) GetUserBookings(userID uint) ([]BookingResponse, error)
 			KamarID:         b.KamarID,
 			Kamar:           b.Kamar, // Already preloaded
 			TanggalMulai:    b.TanggalMulai.Format("2006-01-02"),
-			DurasiSewa:      actualDurasi,
+			DurasiSewa:      b.DurasiSewa,
 			StatusPemesanan: b.StatusPemesanan,
-			TotalBayar:      totalPaid,
+			TotalBayar:      ledger.TotalPayments - ledger.TotalRefunds,
 			StatusBayar:     lastStatus,
+			PaidUntil:       paidUntil,
+			Outstanding:     ledger.Outstanding,
+			Deposit:         deposits[b.ID],
 			Payments:        payments,
 		})
 	}
@@ -101,8 +130,9 @@ func (s *bookingService) GetUserBookings(userID uint) ([]BookingResponse, error)
 	return response, nil
 }
 
-func (s *bookingService) CreateBooking(userID uint, kamarID uint, tanggalMulai string, durasiSewa int) (*models.Pemesanan, error) {
+func (s *bookingService) CreateBooking(userID uint, kamarID uint, tanggalMulai string, durasiSewa int, actor AuditActor) (*models.Pemesanan, error) {
 	var booking models.Pemesanan
+	var tenantName, nomorKamar string
 
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		penyewa, err := s.penyewaRepo.WithTx(tx).FindByUserID(userID)
@@ -113,10 +143,10 @@ func (s *bookingService) CreateBooking(userID uint, kamarID uint, tanggalMulai s
 		// Check for active bookings
 		bookings, _ := s.repo.WithTx(tx).FindByPenyewaID(penyewa.ID)
 		for _, b := range bookings {
-			if b.StatusPemesanan == "Pending" {
+			if b.StatusPemesanan == models.BookingPending {
 				return fmt.Errorf("anda sudah memiliki pesanan aktif (Pending). Selesaikan pembayaran atau batalkan pesanan sebelumnya")
 			}
-			if b.StatusPemesanan == "Confirmed" || b.StatusPemesanan == "Partially Paid" {
+			if b.StatusPemesanan == models.BookingConfirmed || b.StatusPemesanan == models.BookingPartiallyPaid {
 				if time.Now().Before(b.TanggalKeluar) {
 					return fmt.Errorf("masa sewa anda masih aktif hingga %s. Tidak bisa memesan kamar lain", b.TanggalKeluar.Format("02 January 2006"))
 				}
@@ -129,10 +159,6 @@ func (s *bookingService) CreateBooking(userID uint, kamarID uint, tanggalMulai s
 			return err
 		}
 
-		if kamar.Status != "Tersedia" {
-			return fmt.Errorf("kamar %s sudah tidak tersedia (Status: %s)", kamar.NomorKamar, kamar.Status)
-		}
-
 		tm, err := time.Parse("2006-01-02", tanggalMulai)
 		if err != nil {
 			return err
@@ -141,23 +167,32 @@ func (s *bookingService) CreateBooking(userID uint, kamarID uint, tanggalMulai s
 		// Calculate TanggalKeluar explicitly
 		tanggalKeluar := tm.AddDate(0, durasiSewa, 0)
 
+		// Cek bentrok tanggal dengan booking lain selagi kamar masih di-lock
+		if err := ensureKamarAvailable(s.repo.WithTx(tx), kamar, tm, tanggalKeluar); err != nil {
+			return err
+		}
+
 		booking = models.Pemesanan{
 			PenyewaID:       penyewa.ID,
 			KamarID:         kamarID,
 			TanggalMulai:    tm,
 			TanggalKeluar:   tanggalKeluar,
 			DurasiSewa:      durasiSewa,
-			StatusPemesanan: "Pending",
+			StatusPemesanan: models.BookingPending,
 		}
 
 		if err := s.repo.WithTx(tx).Create(&booking); err != nil {
 			return err
 		}
+		tenantName, nomorKamar = penyewa.NamaLengkap, kamar.NomorKamar
 
-		// Update room status to Terpesan
-		kamar.Status = "Terpesan"
-		if err := s.kamarRepo.WithTx(tx).Update(kamar); err != nil {
-			return err
+		// Update room status to Terpesan only if the stay starts now;
+		// future bookings are picked up by AvailabilityService.SyncKamarStatus
+		if !tm.After(time.Now()) {
+			kamar.Status = "Terpesan"
+			if err := s.kamarRepo.WithTx(tx).Update(kamar); err != nil {
+				return err
+			}
 		}
 
 		return nil
@@ -167,16 +202,21 @@ func (s *bookingService) CreateBooking(userID uint, kamarID uint, tanggalMulai s
 		return nil, err
 	}
 
+	recordAudit(s.audit, actor, "booking.create", "booking", booking.ID, nil, booking)
+	emitBookingCreated(s.notifier, &booking, tenantName, nomorKamar)
+
 	return &booking, nil
 }
 
-func (s *bookingService) CreateBookingWithProof(userID uint, kamarID uint, tanggalMulai string, durasiSewa int, proofURL string, paymentType string, paymentMethod string) (*models.Pemesanan, error) {
+func (s *bookingService) CreateBookingWithProof(userID uint, kamarID uint, tanggalMulai string, durasiSewa int, proofURL string, paymentType string, paymentMethod string, actor AuditActor) (*models.Pemesanan, error) {
 	tm, err := time.Parse("2006-01-02", tanggalMulai)
 	if err != nil {
 		return nil, err
 	}
 
 	var booking *models.Pemesanan
+	var payment models.Pembayaran
+	var tenantName, nomorKamar string
 
 	err = s.db.Transaction(func(tx *gorm.DB) error {
 		txRepo := s.repo.WithTx(tx)
@@ -192,10 +232,10 @@ func (s *bookingService) CreateBookingWithProof(userID uint, kamarID uint, tangg
 		// Check for active bookings
 		existingBookings, _ := txRepo.FindByPenyewaID(penyewa.ID)
 		for _, b := range existingBookings {
-			if b.StatusPemesanan == "Pending" {
+			if b.StatusPemesanan == models.BookingPending {
 				return fmt.Errorf("anda sudah memiliki pesanan aktif (Pending). Selesaikan pembayaran atau batalkan pesanan sebelumnya")
 			}
-			if b.StatusPemesanan == "Confirmed" || b.StatusPemesanan == "Partially Paid" {
+			if b.StatusPemesanan == models.BookingConfirmed || b.StatusPemesanan == models.BookingPartiallyPaid {
 				if time.Now().Before(b.TanggalKeluar) {
 					return fmt.Errorf("masa sewa anda masih aktif hingga %s. Tidak bisa memesan kamar lain", b.TanggalKeluar.Format("02 January 2006"))
 				}
@@ -208,12 +248,13 @@ func (s *bookingService) CreateBookingWithProof(userID uint, kamarID uint, tangg
 			return err
 		}
 
-		if kamar.Status != "Tersedia" {
-			return fmt.Errorf("kamar %s sudah tidak tersedia (Status: %s)", kamar.NomorKamar, kamar.Status)
-		}
-
 		tanggalKeluar := tm.AddDate(0, durasiSewa, 0)
 
+		// Cek bentrok tanggal dengan booking lain selagi kamar masih di-lock
+		if err := ensureKamarAvailable(txRepo, kamar, tm, tanggalKeluar); err != nil {
+			return err
+		}
+
 		// 1. Create Booking
 		newBooking := models.Pemesanan{
 			PenyewaID:       penyewa.ID,
@@ -221,38 +262,42 @@ func (s *bookingService) CreateBookingWithProof(userID uint, kamarID uint, tangg
 			TanggalMulai:    tm,
 			TanggalKeluar:   tanggalKeluar,
 			DurasiSewa:      durasiSewa,
-			StatusPemesanan: "Pending",
+			StatusPemesanan: models.BookingPending,
 		}
 
 		if err := txRepo.Create(&newBooking); err != nil {
 			return err
 		}
 
-		// Update room status to Terpesan
-		kamar.Status = "Terpesan"
-		if err := txKamarRepo.Update(kamar); err != nil {
-			return err
+		// Update room status to Terpesan only if the stay starts now
+		if !tm.After(time.Now()) {
+			kamar.Status = "Terpesan"
+			if err := txKamarRepo.Update(kamar); err != nil {
+				return err
+			}
 		}
 		booking = &newBooking
+		tenantName, nomorKamar = penyewa.NamaLengkap, kamar.NomorKamar
 
 		// 2. Setup Payment
 		// kamar already loaded with lock above
 		totalAmount := float64(durasiSewa) * kamar.HargaPerBulan
+		policy := resolvePricingPolicy(s.policyRepo, kamar.TipeKamar)
 		var dpAmount float64
 		var finalAmount float64
 
 		if paymentType == "dp" {
-			dpAmount = totalAmount * 0.3
+			dpAmount = calculateDPAmount(policy, totalAmount)
 			finalAmount = dpAmount
 		} else {
 			finalAmount = totalAmount
 			dpAmount = 0
 		}
 
-		payment := models.Pembayaran{
+		payment = models.Pembayaran{
 			PemesananID:      newBooking.ID,
 			JumlahBayar:      finalAmount,
-			StatusPembayaran: "Pending",
+			StatusPembayaran: models.PaymentPending,
 			MetodePembayaran: paymentMethod, // Use the passed method
 			TipePembayaran:   paymentType,
 			JumlahDP:         dpAmount,
@@ -262,25 +307,26 @@ func (s *bookingService) CreateBookingWithProof(userID uint, kamarID uint, tangg
 		}
 
 		if paymentType == "dp" {
-			payment.TanggalJatuhTempo = tm.AddDate(0, 1, 0)
+			payment.TanggalJatuhTempo = tm.AddDate(0, 0, policy.SettlementDays)
 		}
 
-		if err := txPaymentRepo.Create(&payment); err != nil {
+		orderID, err := allocateInvoiceNumber(tx, time.Now())
+		if err != nil {
 			return err
 		}
+		payment.OrderID = orderID
 
-		// Create reminder for BOTH full and dp payments so it shows up in "My Bills"
-		var reminderAmount float64
-		if paymentType == "dp" {
-			reminderAmount = totalAmount - dpAmount // Sisa bayar setelah DP
-		} else {
-			reminderAmount = totalAmount // Full amount
+		if err := txPaymentRepo.Create(&payment); err != nil {
+			return err
 		}
 
+		// Create reminder for BOTH full and dp payments so it shows up in "My Bills".
+		// Untuk DP yang diingatkan hanya tagihan DP itu sendiri; sisanya ditagih lewat
+		// rencana cicilan (generateInstallmentPlan) setelah DP dikonfirmasi.
 		reminderDate := time.Now().AddDate(0, 0, 3) // Due in 3 days
 		reminder := models.PaymentReminder{
 			PembayaranID:    payment.ID,
-			JumlahBayar:     reminderAmount,
+			JumlahBayar:     finalAmount,
 			TanggalReminder: reminderDate,
 			StatusReminder:  "Pending",
 			IsSent:          false,
@@ -296,10 +342,15 @@ func (s *bookingService) CreateBookingWithProof(userID uint, kamarID uint, tangg
 		return nil, err
 	}
 
+	recordAudit(s.audit, actor, "booking.create", "booking", booking.ID, nil, booking)
+	recordAudit(s.audit, actor, "payment.create", "payment", payment.ID, nil, payment)
+
+	emitBookingCreated(s.notifier, booking, tenantName, nomorKamar)
+
 	return booking, nil
 }
 
-func (s *bookingService) CancelBooking(id uint, userID uint) error {
+func (s *bookingService) CancelBooking(id uint, userID uint, actor AuditActor) error {
 	// Fallback for Unit Tests passing nil DB
 	if s.db == nil {
 		booking, err := s.repo.FindByID(id)
@@ -313,13 +364,19 @@ func (s *bookingService) CancelBooking(id uint, userID uint) error {
 		if booking.PenyewaID != penyewa.ID {
 			return fmt.Errorf("unauthorized")
 		}
-		s.repo.UpdateStatus(id, "Cancelled")
+		previousStatus := booking.StatusPemesanan
+		if err := s.repo.Transition(booking, models.BookingCancelled, actor.statusChange("booking_cancelled")); err != nil {
+			return err
+		}
 		s.kamarRepo.UpdateStatus(booking.KamarID, "Tersedia")
 		s.paymentRepo.DeleteByBookingID(id)
+		recordAudit(s.audit, actor, "booking.cancel", "booking", id,
+			map[string]interface{}{"status_pemesanan": previousStatus}, map[string]interface{}{"status_pemesanan": booking.StatusPemesanan})
 		return nil
 	}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	var previousStatus models.BookingStatus
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		txBookingRepo := s.repo.WithTx(tx)
 		txKamarRepo := s.kamarRepo.WithTx(tx)
 
@@ -338,25 +395,30 @@ func (s *bookingService) CancelBooking(id uint, userID uint) error {
 			return fmt.Errorf("unauthorized: you can only cancel your own bookings")
 		}
 
-		if booking.StatusPemesanan == "Cancelled" {
+		if booking.StatusPemesanan == models.BookingCancelled {
 			return fmt.Errorf("booking is already cancelled")
 		}
+		previousStatus = booking.StatusPemesanan
 
 		// Update status to Cancelled
-		if err := txBookingRepo.UpdateStatus(id, "Cancelled"); err != nil {
+		if err := txBookingRepo.Transition(booking, models.BookingCancelled, actor.statusChange("booking_cancelled")); err != nil {
 			return err
 		}
 
-		// Update Room Status back to Available (Tersedia)
-		if err := txKamarRepo.UpdateStatus(booking.KamarID, "Tersedia"); err != nil {
+		// Update Room Status back to Available (Tersedia) unless another booking occupies it today
+		if err := releaseKamar(txBookingRepo, txKamarRepo, booking.KamarID); err != nil {
 			return fmt.Errorf("failed to reset room status: %v", err)
 		}
 
+		// Catat refund untuk uang yang sudah diterima, sebelum status pembayaran diubah
+		if _, err := createRefundsForBooking(s.refundRepo.WithTx(tx), booking.Pembayaran, "booking_cancelled"); err != nil {
+			return fmt.Errorf("failed to create refund: %v", err)
+		}
+
 		// FIX #2, #9: Soft delete payments - mark as Cancelled instead of hard delete
-		// This preserves audit trail and prevents orphaned reminders
-		if err := tx.Model(&models.Pembayaran{}).
-			Where("pemesanan_id = ?", id).
-			Update("status_pembayaran", "Cancelled").Error; err != nil {
+		// This preserves audit trail and prevents orphaned reminders.
+		// Pembayaran Confirmed tetap Confirmed; pengembaliannya dicatat lewat Refund.
+		if err := cancelOpenPayments(s.paymentRepo.WithTx(tx), booking.Pembayaran, actor.statusChange("booking_cancelled")); err != nil {
 			return fmt.Errorf("failed to cancel pending payments: %v", err)
 		}
 
@@ -367,12 +429,75 @@ func (s *bookingService) CancelBooking(id uint, userID uint) error {
 			return fmt.Errorf("failed to cancel payment reminders: %v", err)
 		}
 
+		if err := closeDepositOnCancel(tx, id); err != nil {
+			return fmt.Errorf("failed to close security deposit: %v", err)
+		}
+
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	recordAudit(s.audit, actor, "booking.cancel", "booking", id,
+		map[string]interface{}{"status_pemesanan": previousStatus}, map[string]interface{}{"status_pemesanan": models.BookingCancelled})
+	return nil
+}
+
+// GetBookingHistory mengembalikan riwayat status booking. Selain staff dengan payments:read, hanya pemilik booking yang boleh melihat.
+func (s *bookingService) GetBookingHistory(bookingID uint, userID uint, role string) (*BookingHistory, error) {
+	booking, err := s.repo.FindByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.RoleHasPermission(role, utils.PermPaymentsRead) {
+		penyewa, err := s.penyewaRepo.FindByUserID(userID)
+		if err != nil || booking.PenyewaID != penyewa.ID {
+			return nil, fmt.Errorf("unauthorized: you can only view your own bookings")
+		}
+	}
+
+	bookingHistory, err := s.repo.FindStatusHistory(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	paymentHistory, err := s.paymentRepo.FindStatusHistoryByBookingID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &BookingHistory{
+		PemesananID:     booking.ID,
+		StatusPemesanan: booking.StatusPemesanan,
+		Booking:         bookingHistory,
+		Payments:        paymentHistory,
+	}
+	if history.Booking == nil {
+		history.Booking = []models.BookingStatusHistory{}
+	}
+	if history.Payments == nil {
+		history.Payments = []models.PaymentStatusHistory{}
+	}
+	return history, nil
+}
+
+// cancelOpenPayments membatalkan pembayaran booking yang belum final (Confirmed dan Cancelled dilewati)
+func cancelOpenPayments(paymentRepo repository.PaymentRepository, payments []models.Pembayaran, change models.StatusChange) error {
+	for i := range payments {
+		p := &payments[i]
+		if p.StatusPembayaran == models.PaymentConfirmed || p.StatusPembayaran == models.PaymentCancelled {
+			continue
+		}
+		if err := paymentRepo.Transition(p, models.PaymentCancelled, change); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ExtendBooking creates a new payment for extending the lease
-func (s *bookingService) ExtendBooking(bookingID uint, months int, userID uint, paymentMethod string) (*models.Pembayaran, error) {
+func (s *bookingService) ExtendBooking(bookingID uint, months int, userID uint, paymentMethod string, actor AuditActor) (*models.Pembayaran, error) {
 	booking, err := s.repo.FindByID(bookingID)
 	if err != nil {
 		return nil, err
@@ -388,18 +513,18 @@ func (s *bookingService) ExtendBooking(bookingID uint, months int, userID uint,
 		return nil, fmt.Errorf("unauthorized: you can only extend your own bookings")
 	}
 
-	if booking.StatusPemesanan == "Partially Paid" {
+	if booking.StatusPemesanan == models.BookingPartiallyPaid {
 		return nil, fmt.Errorf("anda baru membayar DP. Harap lunasi pembayaran awal terlebih dahulu sebelum memperpanjang sewa")
 	}
 
-	if booking.StatusPemesanan != "Confirmed" {
+	if booking.StatusPemesanan != models.BookingConfirmed {
 		return nil, fmt.Errorf("hanya booking yang sudah dikonfirmasi yang bisa diperpanjang")
 	}
 
 	// Check if there's any pending or rejected payment
 	if len(booking.Pembayaran) > 0 {
 		for _, payment := range booking.Pembayaran {
-			if payment.StatusPembayaran == "Pending" || payment.StatusPembayaran == "Rejected" {
+			if payment.StatusPembayaran == models.PaymentPending || payment.StatusPembayaran == models.PaymentRejected {
 				return nil, fmt.Errorf("pengajuan gagal karena anda masih punya pembayaran yang belum terkonfirmasi")
 			}
 		}
@@ -408,19 +533,34 @@ func (s *bookingService) ExtendBooking(bookingID uint, months int, userID uint,
 	// Calculate amount
 	amount := booking.Kamar.HargaPerBulan * float64(months)
 
+	// Perpanjangan jatuh tempo saat masa sewa sekarang berakhir
+	dueDate := booking.TanggalKeluar
+	if dueDate.IsZero() {
+		dueDate = booking.TanggalMulai.AddDate(0, booking.DurasiSewa, 0)
+	}
+
 	// Create new payment record
 	payment := models.Pembayaran{
-		PemesananID:      booking.ID,
-		JumlahBayar:      amount,
-		TanggalBayar:     time.Now(),
-		StatusPembayaran: "Pending",
-		MetodePembayaran: paymentMethod, // Selected method (bank_transfer or cash)
-		TipePembayaran:   "extend",      // New type for extension
-		JumlahDP:         0,
-		IdempotencyKey:   fmt.Sprintf("PAY-E%d-%d", booking.ID, time.Now().UnixNano()),
+		PemesananID:       booking.ID,
+		JumlahBayar:       amount,
+		TanggalBayar:      time.Now(),
+		StatusPembayaran:  models.PaymentPending,
+		MetodePembayaran:  paymentMethod, // Selected method (bank_transfer or cash)
+		TipePembayaran:    "extend",      // New type for extension
+		JumlahDP:          0,
+		TanggalJatuhTempo: dueDate,
+		IdempotencyKey:    fmt.Sprintf("PAY-E%d-%d", booking.ID, time.Now().UnixNano()),
 	}
 
-	if err := s.paymentRepo.Create(&payment); err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		orderID, err := allocateInvoiceNumber(tx, time.Now())
+		if err != nil {
+			return err
+		}
+		payment.OrderID = orderID
+		return s.paymentRepo.WithTx(tx).Create(&payment)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -428,7 +568,7 @@ func (s *bookingService) ExtendBooking(bookingID uint, months int, userID uint,
 	reminder := models.PaymentReminder{
 		PembayaranID:    payment.ID,
 		JumlahBayar:     payment.JumlahBayar,
-		TanggalReminder: time.Now().AddDate(0, 0, 3), // Due in 3 days
+		TanggalReminder: firstReminderDate(dueDate, s.cadence, time.Now()),
 		StatusReminder:  "Pending",
 		IsSent:          false,
 	}
@@ -438,6 +578,8 @@ func (s *bookingService) ExtendBooking(bookingID uint, months int, userID uint,
 		// Non-critical error, payment still created
 	}
 
+	recordAudit(s.audit, actor, "booking.extend", "payment", payment.ID, nil, payment)
+
 	return &payment, nil
 }
 
@@ -451,21 +593,24 @@ func (s *bookingService) AutoCancelExpiredBookings() error {
 	}
 
 	for _, b := range expiredBookings {
+		previousStatus := b.StatusPemesanan
 		err := s.db.Transaction(func(tx *gorm.DB) error {
 			// Cancel booking
-			if err := s.repo.WithTx(tx).UpdateStatus(b.ID, "Cancelled"); err != nil {
+			if err := s.repo.WithTx(tx).Transition(&b, models.BookingCancelled, SystemActor.statusChange("payment_deadline_expired")); err != nil {
 				return err
 			}
 
 			// Update room to available
-			if err := s.kamarRepo.WithTx(tx).UpdateStatus(b.KamarID, "Tersedia"); err != nil {
+			if err := releaseKamar(s.repo.WithTx(tx), s.kamarRepo.WithTx(tx), b.KamarID); err != nil {
 				return err
 			}
 
 			// Send WA Notification
 			if b.Penyewa.NomorHP != "" {
-				msg := fmt.Sprintf("Halo %s,\n\nPesanan Anda untuk Kamar %s telah otomatis dibatalkan karena tidak ada pembayaran yang dikonfirmasi dalam waktu 7 hari.\n\nSilakan lakukan pemesanan ulang jika Anda masih berminat.\n\nTerima kasih.", b.Penyewa.NamaLengkap, b.Kamar.NomorKamar)
-				go s.waSender.SendWhatsApp(b.Penyewa.NomorHP, msg)
+				go sendTemplatedWhatsApp(s.waSender, s.templates, b.Penyewa.NomorHP, msgBookingAutoCancelled, b.Penyewa.Bahasa, map[string]interface{}{
+					"Nama":       b.Penyewa.NamaLengkap,
+					"NomorKamar": b.Kamar.NomorKamar,
+				})
 			}
 
 			return nil
@@ -473,7 +618,11 @@ func (s *bookingService) AutoCancelExpiredBookings() error {
 
 		if err != nil {
 			fmt.Printf("Failed to auto-cancel booking %d: %v\n", b.ID, err)
+			continue
 		}
+		recordAudit(s.audit, SystemActor, "booking.auto_cancel", "booking", b.ID,
+			map[string]interface{}{"status_pemesanan": previousStatus}, map[string]interface{}{"status_pemesanan": b.StatusPemesanan})
+		emitBookingAutoCancelled(s.notifier, &b)
 	}
 
 	if len(expiredBookings) > 0 {
@@ -482,3 +631,41 @@ func (s *bookingService) AutoCancelExpiredBookings() error {
 
 	return nil
 }
+
+// ensureKamarAvailable menolak booking jika kamar sedang Maintenance atau rentang
+// [tanggalMulai, tanggalKeluar) bentrok dengan booking lain. Harus dipanggil setelah
+// FindByIDForUpdate agar pengecekan dan pembuatan booking berada di bawah lock yang sama.
+func ensureKamarAvailable(bookingRepo repository.BookingRepository, kamar *models.Kamar, tanggalMulai, tanggalKeluar time.Time) error {
+	if kamar.Status == "Maintenance" {
+		return fmt.Errorf("kamar %s sedang dalam perbaikan dan belum bisa dipesan", kamar.NomorKamar)
+	}
+
+	overlapping, err := bookingRepo.FindOverlappingBookings(kamar.ID, tanggalMulai, tanggalKeluar)
+	if err != nil {
+		return err
+	}
+
+	if len(overlapping) > 0 {
+		b := overlapping[0]
+		return fmt.Errorf("kamar %s sudah dipesan pada %s - %s. Silakan pilih tanggal lain",
+			kamar.NomorKamar, b.TanggalMulai.Format("02 January 2006"), b.TanggalKeluar.Format("02 January 2006"))
+	}
+
+	return nil
+}
+
+// releaseKamar mengembalikan status kamar ke Tersedia hanya jika tidak ada booking
+// lain yang menempati kamar hari ini.
+func releaseKamar(bookingRepo repository.BookingRepository, kamarRepo repository.KamarRepository, kamarID uint) error {
+	today := truncateToDay(time.Now())
+	occupying, err := bookingRepo.FindOverlappingBookings(kamarID, today, today.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	if len(occupying) > 0 {
+		return nil
+	}
+
+	return kamarRepo.UpdateStatus(kamarID, "Tersedia")
+}
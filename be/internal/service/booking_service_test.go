@@ -17,7 +17,7 @@ func TestBookingService_CancelBooking_Success(t *testing.T) {
 	mockPaymentRepo := new(MockPaymentRepository)
 
 	mockWASender := new(MockWhatsAppSender)
-	service := NewBookingService(mockBookingRepo, mockUserRepo, mockPenyewaRepo, mockKamarRepo, mockPaymentRepo, nil, mockWASender)
+	service := NewBookingService(mockBookingRepo, mockUserRepo, mockPenyewaRepo, mockKamarRepo, mockPaymentRepo, new(MockRefundRepository), new(MockPricingPolicyRepository), new(MockLedgerRepository), nil, mockWASender, nil, nil, nil, nil, nil)
 
 	bookingID := uint(1)
 	userID := uint(1)
@@ -27,11 +27,11 @@ func TestBookingService_CancelBooking_Success(t *testing.T) {
 
 	mockBookingRepo.On("FindByID", bookingID).Return(booking, nil)
 	mockPenyewaRepo.On("FindByUserID", userID).Return(penyewa, nil)
-	mockBookingRepo.On("UpdateStatus", bookingID, "Cancelled").Return(nil)
+	mockBookingRepo.On("Transition", booking, models.BookingCancelled, mock.Anything).Return(nil)
 	mockKamarRepo.On("UpdateStatus", uint(101), "Tersedia").Return(nil)
 	mockPaymentRepo.On("DeleteByBookingID", bookingID).Return(nil)
 
-	err := service.CancelBooking(bookingID, userID)
+	err := service.CancelBooking(bookingID, userID, AuditActor{})
 
 	assert.NoError(t, err)
 	mockBookingRepo.AssertExpectations(t)
@@ -49,7 +49,7 @@ func TestBookingService_CancelBooking_IDOR_Unauthorized(t *testing.T) {
 	mockPaymentRepo := new(MockPaymentRepository)
 
 	mockWASender := new(MockWhatsAppSender)
-	service := NewBookingService(mockBookingRepo, mockUserRepo, mockPenyewaRepo, mockKamarRepo, mockPaymentRepo, nil, mockWASender)
+	service := NewBookingService(mockBookingRepo, mockUserRepo, mockPenyewaRepo, mockKamarRepo, mockPaymentRepo, new(MockRefundRepository), new(MockPricingPolicyRepository), new(MockLedgerRepository), nil, mockWASender, nil, nil, nil, nil, nil)
 
 	bookingID := uint(1)
 	attackerUserID := uint(2)
@@ -61,13 +61,13 @@ func TestBookingService_CancelBooking_IDOR_Unauthorized(t *testing.T) {
 	mockBookingRepo.On("FindByID", bookingID).Return(booking, nil)
 	mockPenyewaRepo.On("FindByUserID", attackerUserID).Return(attackerPenyewa, nil)
 
-	err := service.CancelBooking(bookingID, attackerUserID)
+	err := service.CancelBooking(bookingID, attackerUserID, AuditActor{})
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unauthorized")
 	
 	// Verify critical actions were NOT called
-	mockBookingRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+	mockBookingRepo.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything, mock.Anything)
 	mockKamarRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
 	
 	mockBookingRepo.AssertExpectations(t)
@@ -2,6 +2,7 @@ package service
 
 import (
 	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
 	"math"
 	"time"
 
@@ -27,18 +28,23 @@ type Demographic struct {
 }
 
 type DashboardStats struct {
-	TotalRevenue     float64          `json:"total_revenue"`
-	ActiveTenants    int64            `json:"active_tenants"`
-	AvailableRooms   int64            `json:"available_rooms"`
-	OccupiedRooms    int64            `json:"occupied_rooms"`
-	PendingPayments  int64            `json:"pending_payments"`
-	PendingRevenue   float64          `json:"pending_revenue"`
-	RejectedPayments int64            `json:"rejected_payments"`
-	PotentialRevenue float64          `json:"potential_revenue"`
-	MonthlyTrend     []MonthlyData    `json:"monthly_trend"`
-	TypeBreakdown    []TypeRevenue    `json:"type_breakdown"`
-	Demographics     []Demographic    `json:"demographics"`
-	RecentCheckouts  []RecentCheckout `json:"recent_checkouts"`
+	TotalRevenue      float64          `json:"total_revenue"`
+	TotalRefunded     float64          `json:"total_refunded"`
+	DepositsHeld      float64          `json:"deposits_held"`
+	DepositRefundsDue float64          `json:"deposit_refunds_due"`
+	ActiveTenants     int64            `json:"active_tenants"`
+	AvailableRooms    int64            `json:"available_rooms"`
+	OccupiedRooms     int64            `json:"occupied_rooms"`
+	TotalPayments     int64            `json:"total_payments"`
+	ConfirmedPayments int64            `json:"confirmed_payments"`
+	PendingPayments   int64            `json:"pending_payments"`
+	PendingRevenue    float64          `json:"pending_revenue"`
+	RejectedPayments  int64            `json:"rejected_payments"`
+	PotentialRevenue  float64          `json:"potential_revenue"`
+	MonthlyTrend      []MonthlyData    `json:"monthly_trend"`
+	TypeBreakdown     []TypeRevenue    `json:"type_breakdown"`
+	Demographics      []Demographic    `json:"demographics"`
+	RecentCheckouts   []RecentCheckout `json:"recent_checkouts"`
 }
 
 type RecentCheckout struct {
@@ -49,7 +55,7 @@ type RecentCheckout struct {
 }
 
 type DashboardService interface {
-	GetStats() (*DashboardStats, error)
+	GetStats(dateFrom time.Time) (*DashboardStats, error)
 	GetPublicStats() (*PublicStats, error)
 	GetRoomOccupancy() ([]RoomOccupancyInfo, error)
 	GetTenantRooms() ([]TenantRoomInfo, error)
@@ -59,6 +65,7 @@ type DashboardService interface {
 
 type PaymentRecord struct {
 	ID               uint    `json:"id"`
+	OrderID          string  `json:"order_id"`
 	JumlahBayar      float64 `json:"jumlah_bayar"`
 	StatusPembayaran string  `json:"status_pembayaran"`
 	MetodePembayaran string  `json:"metode_pembayaran"`
@@ -74,8 +81,10 @@ type RoomPaymentDetail struct {
 	NomorHP    string          `json:"nomor_hp"`
 	CheckIn    string          `json:"check_in"`
 	CheckOut   string          `json:"check_out"`
-	DurasiSewa int             `json:"durasi_sewa"`
-	Payments   []PaymentRecord `json:"payments"`
+	DurasiSewa  int             `json:"durasi_sewa"`
+	PaidUntil   string          `json:"paid_until"`
+	Outstanding float64         `json:"outstanding"`
+	Payments    []PaymentRecord `json:"payments"`
 }
 
 type TenantPaymentDetail struct {
@@ -94,6 +103,8 @@ type TenantPaymentDetail struct {
 	CheckIn       string          `json:"check_in"`
 	CheckOut      string          `json:"check_out"`
 	DurasiSewa    int             `json:"durasi_sewa"`
+	PaidUntil     string          `json:"paid_until"`
+	Outstanding   float64         `json:"outstanding"`
 	Payments      []PaymentRecord `json:"payments"`
 }
 
@@ -139,11 +150,29 @@ func formatIndonesianMonth(t time.Time) string {
 }
 
 type dashboardService struct {
-	db *gorm.DB
+	db         *gorm.DB
+	ledgerRepo repository.LedgerRepository
 }
 
-func NewDashboardService(db *gorm.DB) DashboardService {
-	return &dashboardService{db}
+func NewDashboardService(db *gorm.DB, ledgerRepo repository.LedgerRepository) DashboardService {
+	return &dashboardService{db, ledgerRepo}
+}
+
+// bookingLedgerSummary mengembalikan paid until (YYYY-MM-DD) dan sisa tagihan dari buku besar booking
+func (s *dashboardService) bookingLedgerSummary(pemesananID uint) (string, float64) {
+	booking, err := s.ledgerRepo.FindBookingWithPayments(pemesananID)
+	if err != nil {
+		return "", 0
+	}
+	ledgers, err := loadLedgers(s.ledgerRepo, []models.Pemesanan{*booking})
+	if err != nil {
+		return "", 0
+	}
+	ledger := ledgers[pemesananID]
+	if ledger.PaidUntil.IsZero() {
+		return "", ledger.Outstanding
+	}
+	return ledger.PaidUntil.Format("2006-01-02"), ledger.Outstanding
 }
 
 func (s *dashboardService) GetRoomOccupancy() ([]RoomOccupancyInfo, error) {
@@ -172,7 +201,7 @@ func (s *dashboardService) GetRoomOccupancy() ([]RoomOccupancyInfo, error) {
 
 	for _, row := range rows {
 		var payment struct {
-			StatusPembayaran string
+			StatusPembayaran models.PaymentStatus
 			JumlahBayar      float64
 			TanggalBayar     time.Time
 		}
@@ -187,9 +216,9 @@ func (s *dashboardService) GetRoomOccupancy() ([]RoomOccupancyInfo, error) {
 		paymentMonth := ""
 		if err == nil && payment.StatusPembayaran != "" {
 			switch payment.StatusPembayaran {
-			case "Confirmed":
+			case models.PaymentConfirmed:
 				status = "Lunas"
-			case "Pending":
+			case models.PaymentPending:
 				status = "Pending"
 			default:
 				status = "Belum Bayar"
@@ -239,7 +268,7 @@ func (s *dashboardService) GetTenantRooms() ([]TenantRoomInfo, error) {
 
 	for _, row := range rows {
 		var payment struct {
-			StatusPembayaran  string
+			StatusPembayaran  models.PaymentStatus
 			JumlahBayar       float64
 			TanggalJatuhTempo time.Time
 			TanggalBayar      time.Time
@@ -256,9 +285,9 @@ func (s *dashboardService) GetTenantRooms() ([]TenantRoomInfo, error) {
 		paymentMonth := ""
 		if err == nil && payment.StatusPembayaran != "" {
 			switch payment.StatusPembayaran {
-			case "Confirmed":
+			case models.PaymentConfirmed:
 				status = "Lunas"
-			case "Pending":
+			case models.PaymentPending:
 				status = "Pending"
 			default:
 				status = "Belum Bayar"
@@ -315,40 +344,27 @@ func (s *dashboardService) GetPaymentsByRoom(roomID uint) (*RoomPaymentDetail, e
 
 	// Get all payments for this booking
 	payments := s.getPaymentsForBooking(info.PemesananID)
-
-	// Calculate total paid to dynamically determine actual duration
-	var totalPaid float64
-	for _, p := range payments {
-		if p.StatusPembayaran == "Confirmed" {
-			totalPaid += p.JumlahBayar
-		}
-	}
-
-	actualDurasi := info.DurasiSewa
-	if info.HargaPerBulan > 0 {
-		paidMonths := int(totalPaid / info.HargaPerBulan)
-		if paidMonths > actualDurasi {
-			actualDurasi = paidMonths
-		}
-	}
+	paidUntil, outstanding := s.bookingLedgerSummary(info.PemesananID)
 
 	checkIn := ""
 	checkOut := ""
 	if !info.TanggalMulai.IsZero() {
 		checkIn = info.TanggalMulai.Format("2006-01-02")
-		checkOutDate := info.TanggalMulai.AddDate(0, actualDurasi, 0)
+		checkOutDate := info.TanggalMulai.AddDate(0, info.DurasiSewa, 0)
 		checkOut = checkOutDate.Format("2006-01-02")
 	}
 
 	return &RoomPaymentDetail{
-		TenantName: info.NamaLengkap,
-		PenyewaID:  info.PenyewaID,
-		Email:      info.Email,
-		NomorHP:    info.NomorHP,
-		CheckIn:    checkIn,
-		CheckOut:   checkOut,
-		DurasiSewa: actualDurasi,
-		Payments:   payments,
+		TenantName:  info.NamaLengkap,
+		PenyewaID:   info.PenyewaID,
+		Email:       info.Email,
+		NomorHP:     info.NomorHP,
+		CheckIn:     checkIn,
+		CheckOut:    checkOut,
+		DurasiSewa:  info.DurasiSewa,
+		PaidUntil:   paidUntil,
+		Outstanding: outstanding,
+		Payments:    payments,
 	}, nil
 }
 
@@ -395,20 +411,11 @@ func (s *dashboardService) GetPaymentsByTenant(penyewaID uint) (*TenantPaymentDe
 		payments = []PaymentRecord{}
 	}
 
-	// Calculate total paid to dynamically determine actual duration
-	var totalPaid float64
-	for _, p := range payments {
-		if p.StatusPembayaran == "Confirmed" {
-			totalPaid += p.JumlahBayar
-		}
-	}
-
-	actualDurasi := booking.DurasiSewa
-	if booking.HargaPerBulan > 0 {
-		paidMonths := int(totalPaid / booking.HargaPerBulan)
-		if paidMonths > actualDurasi {
-			actualDurasi = paidMonths
-		}
+	// Paid until & sisa tagihan dari buku besar
+	var paidUntil string
+	var outstanding float64
+	if booking.PemesananID > 0 {
+		paidUntil, outstanding = s.bookingLedgerSummary(booking.PemesananID)
 	}
 
 	tanggalLahir := ""
@@ -420,7 +427,7 @@ func (s *dashboardService) GetPaymentsByTenant(penyewaID uint) (*TenantPaymentDe
 	checkOut := ""
 	if !booking.TanggalMulai.IsZero() {
 		checkIn = booking.TanggalMulai.Format("2006-01-02")
-		checkOutDate := booking.TanggalMulai.AddDate(0, actualDurasi, 0)
+		checkOutDate := booking.TanggalMulai.AddDate(0, booking.DurasiSewa, 0)
 		checkOut = checkOutDate.Format("2006-01-02")
 	}
 
@@ -439,7 +446,9 @@ func (s *dashboardService) GetPaymentsByTenant(penyewaID uint) (*TenantPaymentDe
 		HargaPerBulan: booking.HargaPerBulan,
 		CheckIn:       checkIn,
 		CheckOut:      checkOut,
-		DurasiSewa:    actualDurasi,
+		DurasiSewa:    booking.DurasiSewa,
+		PaidUntil:     paidUntil,
+		Outstanding:   outstanding,
 		Payments:      payments,
 	}, nil
 }
@@ -447,6 +456,7 @@ func (s *dashboardService) GetPaymentsByTenant(penyewaID uint) (*TenantPaymentDe
 func (s *dashboardService) getPaymentsForBooking(pemesananID uint) []PaymentRecord {
 	type payRow struct {
 		ID               uint
+		OrderID          string
 		JumlahBayar      float64
 		StatusPembayaran string
 		MetodePembayaran string
@@ -455,7 +465,7 @@ func (s *dashboardService) getPaymentsForBooking(pemesananID uint) []PaymentReco
 	}
 	var rows []payRow
 	s.db.Raw(`
-		SELECT id, jumlah_bayar, status_pembayaran, metode_pembayaran, tanggal_bayar, bukti_transfer
+		SELECT id, COALESCE(order_id, '') AS order_id, jumlah_bayar, status_pembayaran, metode_pembayaran, tanggal_bayar, bukti_transfer
 		FROM pembayarans
 		WHERE pemesanan_id = ? AND deleted_at IS NULL
 		ORDER BY tanggal_bayar DESC
@@ -471,6 +481,7 @@ func (s *dashboardService) getPaymentsForBooking(pemesananID uint) []PaymentReco
 		}
 		records = append(records, PaymentRecord{
 			ID:               r.ID,
+			OrderID:          r.OrderID,
 			JumlahBayar:      r.JumlahBayar,
 			StatusPembayaran: r.StatusPembayaran,
 			MetodePembayaran: r.MetodePembayaran,
@@ -500,30 +511,78 @@ func (s *dashboardService) GetPublicStats() (*PublicStats, error) {
 	return stats, nil
 }
 
-func (s *dashboardService) GetStats() (*DashboardStats, error) {
+// sinceDate membatasi agregat ke column >= from; from zero berarti sepanjang waktu
+func sinceDate(column string, from time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if from.IsZero() {
+			return db
+		}
+		return db.Where(column+" >= ?", from)
+	}
+}
+
+// GetStats menghitung statistik dashboard. Jika dateFrom diisi, agregat pembayaran (pendapatan,
+// jumlah transaksi, tren dan pendapatan per tipe) hanya menghitung tanggal_bayar >= dateFrom;
+// statistik kamar, penyewa dan kewajiban jaminan selalu kondisi saat ini.
+func (s *dashboardService) GetStats(dateFrom time.Time) (*DashboardStats, error) {
 	var stats DashboardStats
 
-	// 1. Total Revenue (Confirmed)
-	s.db.Model(&models.Pembayaran{}).
-		Where("status_pembayaran = ?", "Confirmed").
+	// 1. Total Revenue (Confirmed). Uang jaminan adalah titipan, bukan pendapatan.
+	s.db.Model(&models.Pembayaran{}).Scopes(sinceDate("tanggal_bayar", dateFrom)).
+		Where("status_pembayaran = ? AND tipe_pembayaran <> ?", models.PaymentConfirmed, "deposit").
 		Select("COALESCE(SUM(jumlah_bayar), 0)").
 		Scan(&stats.TotalRevenue)
 
+	// Kurangi refund yang sudah ditransfer balik atas pembayaran yang terhitung di atas
+	s.db.Model(&models.Refund{}).Scopes(sinceDate("pembayarans.tanggal_bayar", dateFrom)).
+		Joins("JOIN pembayarans ON pembayarans.id = refunds.pembayaran_id").
+		Where("refunds.status_refund = ? AND pembayarans.status_pembayaran = ? AND pembayarans.tipe_pembayaran <> ?", "Paid", "Confirmed", "deposit").
+		Select("COALESCE(SUM(refunds.jumlah_refund), 0)").
+		Scan(&stats.TotalRefunded)
+	stats.TotalRevenue -= stats.TotalRefunded
+
+	// Potongan yang ditahan dari jaminan saat settlement menjadi pendapatan;
+	// kekurangannya sudah terhitung lewat pembayaran deposit_charge di atas
+	var retainedDeposits float64
+	s.db.Model(&models.SecurityDeposit{}).Scopes(sinceDate("settled_at", dateFrom)).
+		Where("status = ?", "Settled").
+		Select("COALESCE(SUM(CASE WHEN total_potongan < jumlah THEN total_potongan ELSE jumlah END), 0)").
+		Scan(&retainedDeposits)
+	stats.TotalRevenue += retainedDeposits
+
+	// Kewajiban uang jaminan: jaminan yang masih ditahan dan pengembalian yang belum ditransfer
+	s.db.Model(&models.SecurityDeposit{}).
+		Where("status = ?", "Held").
+		Select("COALESCE(SUM(jumlah), 0)").
+		Scan(&stats.DepositsHeld)
+
+	s.db.Model(&models.Refund{}).
+		Where("alasan = ? AND status_refund IN ?", "deposit_settlement", []string{"Requested", "Approved"}).
+		Select("COALESCE(SUM(jumlah_refund), 0)").
+		Scan(&stats.DepositRefundsDue)
+
 	// 2. Pending Revenue & Count
-	s.db.Model(&models.Pembayaran{}).
-		Where("status_pembayaran = ?", "Pending").
+	s.db.Model(&models.Pembayaran{}).Scopes(sinceDate("tanggal_bayar", dateFrom)).
+		Where("status_pembayaran = ?", models.PaymentPending).
 		Select("COALESCE(SUM(jumlah_bayar), 0)").
 		Scan(&stats.PendingRevenue)
 
-	s.db.Model(&models.Pembayaran{}).
-		Where("status_pembayaran = ?", "Pending").
+	s.db.Model(&models.Pembayaran{}).Scopes(sinceDate("tanggal_bayar", dateFrom)).
+		Where("status_pembayaran = ?", models.PaymentPending).
 		Count(&stats.PendingPayments)
 
-	// 3. Rejected Payments Count
-	s.db.Model(&models.Pembayaran{}).
-		Where("status_pembayaran = ?", "Rejected").
+	// 3. Rejected, Confirmed & Total Payments Count
+	s.db.Model(&models.Pembayaran{}).Scopes(sinceDate("tanggal_bayar", dateFrom)).
+		Where("status_pembayaran = ?", models.PaymentRejected).
 		Count(&stats.RejectedPayments)
 
+	s.db.Model(&models.Pembayaran{}).Scopes(sinceDate("tanggal_bayar", dateFrom)).
+		Where("status_pembayaran = ?", models.PaymentConfirmed).
+		Count(&stats.ConfirmedPayments)
+
+	s.db.Model(&models.Pembayaran{}).Scopes(sinceDate("tanggal_bayar", dateFrom)).
+		Count(&stats.TotalPayments)
+
 	// 4. Room Stats
 	s.db.Model(&models.Kamar{}).Where("status = ?", "Tersedia").Count(&stats.AvailableRooms)
 	s.db.Model(&models.Kamar{}).Where("status = ?", "Penuh").Count(&stats.OccupiedRooms)
@@ -557,7 +616,7 @@ func (s *dashboardService) GetStats() (*DashboardStats, error) {
 	// It ensures 100% compatibility.
 
 	rows, err := s.db.Model(&models.Pembayaran{}).
-		Where("status_pembayaran = 'Confirmed'").
+		Where("status_pembayaran = 'Confirmed' AND tipe_pembayaran <> 'deposit'").
 		Order("tanggal_bayar DESC").
 		Limit(100). // Limit to recent 100 payments for trend to avoid fetching all
 		Rows()
@@ -604,29 +663,38 @@ func (s *dashboardService) GetStats() (*DashboardStats, error) {
 	// If SQLite: strftime('%b', tanggal_bayar)
 	dialect := s.db.Dialector.Name()
 	query := ""
+
+	// Tanpa periode tampilkan 6 bulan terakhir; dengan periode tampilkan semua bulan di dalamnya
+	// (label bulan disertai tahun karena periode bisa lebih dari 12 bulan)
+	trendFilter, trendLimit, trendLabel := "", " LIMIT 6", "Mon"
+	var trendArgs []interface{}
+	if !dateFrom.IsZero() {
+		trendFilter, trendLimit, trendLabel = " AND tanggal_bayar >= ?", "", "Mon YYYY"
+		trendArgs = append(trendArgs, dateFrom)
+	}
 	if dialect == "postgres" {
-		query = `SELECT TO_CHAR(tanggal_bayar, 'Mon') as month, SUM(jumlah_bayar) as revenue 
-                 FROM pembayarans WHERE status_pembayaran = 'Confirmed' 
-                 GROUP BY TO_CHAR(tanggal_bayar, 'Mon'), DATE_TRUNC('month', tanggal_bayar)
-                 ORDER BY DATE_TRUNC('month', tanggal_bayar) DESC LIMIT 6`
+		query = `SELECT TO_CHAR(tanggal_bayar, '` + trendLabel + `') as month, SUM(jumlah_bayar) as revenue 
+                 FROM pembayarans WHERE status_pembayaran = 'Confirmed' AND tipe_pembayaran <> 'deposit'` + trendFilter + `
+                 GROUP BY TO_CHAR(tanggal_bayar, '` + trendLabel + `'), DATE_TRUNC('month', tanggal_bayar)
+                 ORDER BY DATE_TRUNC('month', tanggal_bayar) DESC` + trendLimit
 	} else {
 		// Fallback to SQLite
 		query = `SELECT strftime('%m', tanggal_bayar) as month_num, strftime('%Y', tanggal_bayar) as year_num, SUM(jumlah_bayar) as revenue 
-                 FROM pembayarans WHERE status_pembayaran = 'Confirmed' 
+                 FROM pembayarans WHERE status_pembayaran = 'Confirmed' AND tipe_pembayaran <> 'deposit'` + trendFilter + `
                  GROUP BY month_num, year_num
-                 ORDER BY year_num DESC, month_num DESC LIMIT 6`
+                 ORDER BY year_num DESC, month_num DESC` + trendLimit
 	}
 
 	// For SQLite we need to map numbers to names manually if using %m.
 	// Or use %b. %b is safer if system locale supports it. Start with %b.
 	if dialect == "sqlite" {
 		query = `SELECT strftime('%Y-%m', tanggal_bayar) as ym, SUM(jumlah_bayar) as revenue
-                 FROM pembayarans WHERE status_pembayaran = 'Confirmed'
+                 FROM pembayarans WHERE status_pembayaran = 'Confirmed' AND tipe_pembayaran <> 'deposit'` + trendFilter + `
                  GROUP BY ym
-                 ORDER BY ym DESC LIMIT 6`
+                 ORDER BY ym DESC` + trendLimit
 	}
 
-	rowsTrend, err := s.db.Raw(query).Rows()
+	rowsTrend, err := s.db.Raw(query, trendArgs...).Rows()
 	if err == nil {
 		defer rowsTrend.Close()
 		for rowsTrend.Next() {
@@ -671,7 +739,11 @@ func (s *dashboardService) GetStats() (*DashboardStats, error) {
 		GROUP BY tipe_kamar
 	`).Scan(&roomStats)
 
-	// Query 2: Revenue per Type
+	// Query 2: Revenue per Type (dalam periode yang sama dengan tren)
+	revFilter := ""
+	if !dateFrom.IsZero() {
+		revFilter = " AND p.tanggal_bayar >= ?"
+	}
 	type revStat struct {
 		TipeKamar string
 		Revenue   float64
@@ -684,9 +756,9 @@ func (s *dashboardService) GetStats() (*DashboardStats, error) {
 		FROM pembayarans p
 		JOIN pemesanans pm ON p.pemesanan_id = pm.id
 		JOIN kamars k ON pm.kamar_id = k.id
-		WHERE p.status_pembayaran = 'Confirmed'
+		WHERE p.status_pembayaran = 'Confirmed' AND p.tipe_pembayaran <> 'deposit'`+revFilter+`
 		GROUP BY k.tipe_kamar
-	`).Scan(&revStats)
+	`, trendArgs...).Scan(&revStats)
 
 	// Merge results efficiently in Go
 	revMap := make(map[string]float64)
@@ -758,23 +830,24 @@ func (s *dashboardService) GetStats() (*DashboardStats, error) {
 		{Name: "45+", Value: ageGroups["45+"], Color: "#8b5cf6"},
 	}
 
-	// 10. Recent Checkouts (Cancelled bookings)
-	// We count 'Cancelled' bookings as checkouts.
-	// We could also include 'Confirmed' bookings where end_date < now if we can calculate it easily in SQL.
-	// For now, let's just show explicit cancellations as they are "newly checked-out".
-	var cancelledBookings []models.Pemesanan
+	// 10. Recent Checkouts: check-out oleh admin dan sewa yang berakhir (job harian)
+	var checkedOutBookings []models.Pemesanan
 	s.db.Preload("Kamar").Preload("Penyewa").
-		Where("status_pemesanan = ?", "Cancelled").
-		Order("updated_at DESC").
+		Where("status_pemesanan = ?", models.BookingCheckedOut).
+		Order("tanggal_check_out DESC").
 		Limit(5).
-		Find(&cancelledBookings)
+		Find(&checkedOutBookings)
 
-	for _, b := range cancelledBookings {
+	for _, b := range checkedOutBookings {
+		reason := "Checked Out"
+		if b.AlasanCheckOut == checkOutLeaseExpired {
+			reason = "Lease Ended"
+		}
 		stats.RecentCheckouts = append(stats.RecentCheckouts, RecentCheckout{
 			RoomName:     b.Kamar.NomorKamar + " - " + b.Kamar.TipeKamar,
 			TenantName:   b.Penyewa.NamaLengkap,
-			CheckoutDate: b.UpdatedAt,
-			Reason:       "Cancelled",
+			CheckoutDate: b.TanggalCheckOut,
+			Reason:       reason,
 		})
 	}
 
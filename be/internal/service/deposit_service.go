@@ -0,0 +1,280 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tagihan kekurangan uang jaminan jatuh tempo H+7 setelah settlement
+const depositChargeDueDays = 7
+
+var validDeductionKategori = map[string]bool{"damage": true, "utilities": true, "cleaning": true, "other": true}
+
+// isDepositPayment: tagihan uang jaminan tidak dihitung sebagai sewa (buku besar, pendapatan)
+func isDepositPayment(tipe string) bool {
+	return tipe == "deposit" || tipe == "deposit_charge"
+}
+
+type DepositService interface {
+	GetDeposit(bookingID uint, userID uint, role string) (*models.SecurityDeposit, error)
+	SettleDeposit(bookingID uint, deductions []models.DepositDeduction, catatan string, actor AuditActor) (*models.SecurityDeposit, error)
+}
+
+type depositService struct {
+	repo        repository.DepositRepository
+	bookingRepo repository.BookingRepository
+	refundRepo  repository.RefundRepository
+	penyewaRepo repository.PenyewaRepository
+	db          *gorm.DB
+	audit       AuditService
+}
+
+func NewDepositService(repo repository.DepositRepository, bookingRepo repository.BookingRepository, refundRepo repository.RefundRepository, penyewaRepo repository.PenyewaRepository, db *gorm.DB, audit AuditService) DepositService {
+	return &depositService{repo, bookingRepo, refundRepo, penyewaRepo, db, audit}
+}
+
+// GetDeposit mengembalikan uang jaminan booking beserta potongannya. Selain staff dengan payments:read, hanya pemilik booking yang boleh melihat.
+func (s *depositService) GetDeposit(bookingID uint, userID uint, role string) (*models.SecurityDeposit, error) {
+	booking, err := s.bookingRepo.FindByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.RoleHasPermission(role, utils.PermPaymentsRead) {
+		penyewa, err := s.penyewaRepo.FindByUserID(userID)
+		if err != nil || booking.PenyewaID != penyewa.ID {
+			return nil, fmt.Errorf("unauthorized: you can only view your own bookings")
+		}
+	}
+
+	deposit, err := s.repo.FindByBookingID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if deposit == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return deposit, nil
+}
+
+// SettleDeposit menyelesaikan uang jaminan setelah check-out: potongan dicatat per item,
+// sisa jaminan dikembalikan lewat Refund (Requested) dan kekurangannya ditagih lewat
+// Pembayaran bertipe deposit_charge.
+func (s *depositService) SettleDeposit(bookingID uint, deductions []models.DepositDeduction, catatan string, actor AuditActor) (*models.SecurityDeposit, error) {
+	totalPotongan, err := sumDepositDeductions(deductions)
+	if err != nil {
+		return nil, err
+	}
+
+	booking, err := s.bookingRepo.FindByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.StatusPemesanan != models.BookingCheckedOut {
+		return nil, fmt.Errorf("uang jaminan hanya bisa diselesaikan setelah check-out")
+	}
+
+	deposit, err := s.repo.FindByBookingID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if deposit == nil {
+		return nil, fmt.Errorf("booking ini tidak memiliki uang jaminan")
+	}
+	if deposit.Status != "Held" {
+		return nil, fmt.Errorf("uang jaminan berstatus %s tidak dapat diselesaikan", deposit.Status)
+	}
+
+	before := *deposit
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txRepo := s.repo.WithTx(tx)
+
+		// Cek ulang status di bawah row lock agar dua settlement bersamaan tidak
+		// sama-sama membuat refund/tagihan
+		locked, err := txRepo.FindByIDForUpdate(deposit.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != "Held" {
+			return fmt.Errorf("uang jaminan berstatus %s tidak dapat diselesaikan", locked.Status)
+		}
+
+		for i := range deductions {
+			deductions[i].ID = 0
+			deductions[i].DepositID = deposit.ID
+		}
+		if err := txRepo.CreateDeductions(deductions); err != nil {
+			return err
+		}
+
+		deposit.Deductions = deductions
+		deposit.TotalPotongan = totalPotongan
+		deposit.Selisih = deposit.Jumlah - totalPotongan
+		deposit.CatatanSettlement = catatan
+		deposit.SettledBy = actor.UserID
+		deposit.SettledAt = time.Now()
+		deposit.Status = "Settled"
+
+		switch {
+		case deposit.Selisih > 0:
+			refund := models.Refund{
+				PembayaranID: deposit.PembayaranID,
+				PemesananID:  bookingID,
+				JumlahRefund: deposit.Selisih,
+				Alasan:       "deposit_settlement",
+				StatusRefund: "Requested",
+				CatatanAdmin: catatan,
+			}
+			if err := s.refundRepo.WithTx(tx).Create(&refund); err != nil {
+				return err
+			}
+			deposit.RefundID = refund.ID
+		case deposit.Selisih < 0:
+			charge, err := createDepositPayment(tx, bookingID, "deposit_charge", -deposit.Selisih, time.Now().AddDate(0, 0, depositChargeDueDays))
+			if err != nil {
+				return err
+			}
+			deposit.ChargePembayaranID = charge.ID
+		}
+
+		return txRepo.Update(deposit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(s.audit, actor, "deposit.settle", "deposit", deposit.ID, before, *deposit)
+	return deposit, nil
+}
+
+// sumDepositDeductions memvalidasi potongan dan mengembalikan totalnya
+func sumDepositDeductions(deductions []models.DepositDeduction) (float64, error) {
+	var total float64
+	for _, d := range deductions {
+		if !validDeductionKategori[d.Kategori] {
+			return 0, fmt.Errorf("kategori potongan harus salah satu dari: damage, utilities, cleaning, other")
+		}
+		if d.Jumlah <= 0 {
+			return 0, fmt.Errorf("jumlah potongan harus lebih dari 0")
+		}
+		if d.Keterangan == "" {
+			return 0, fmt.Errorf("keterangan potongan wajib diisi")
+		}
+		total += d.Jumlah
+	}
+	return total, nil
+}
+
+// loadDeposits memetakan uang jaminan per booking. depositRepo boleh nil (unit test service lain).
+func loadDeposits(depositRepo repository.DepositRepository, bookings []models.Pemesanan) (map[uint]*models.SecurityDeposit, error) {
+	result := make(map[uint]*models.SecurityDeposit)
+	if depositRepo == nil || len(bookings) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uint, 0, len(bookings))
+	for _, b := range bookings {
+		ids = append(ids, b.ID)
+	}
+	deposits, err := depositRepo.FindByBookingIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deposits {
+		result[deposits[i].PemesananID] = &deposits[i]
+	}
+	return result, nil
+}
+
+// ensureDepositBill menagih uang jaminan saat booking pertama kali dikonfirmasi.
+// Tidak melakukan apa-apa jika kebijakan tanpa jaminan atau tagihan sudah ada.
+func ensureDepositBill(tx *gorm.DB, booking *models.Pemesanan, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	var existing int64
+	if err := tx.Model(&models.SecurityDeposit{}).Where("pemesanan_id = ?", booking.ID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	due := booking.TanggalMulai
+	if due.Before(time.Now()) {
+		due = time.Now()
+	}
+	payment, err := createDepositPayment(tx, booking.ID, "deposit", amount, due)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.SecurityDeposit{
+		PemesananID:  booking.ID,
+		PembayaranID: payment.ID,
+		Jumlah:       amount,
+		Status:       "Pending",
+	}).Error
+}
+
+// createDepositPayment membuat tagihan jaminan beserta PaymentReminder agar langsung
+// muncul di tagihan penyewa
+func createDepositPayment(tx *gorm.DB, bookingID uint, tipe string, amount float64, due time.Time) (*models.Pembayaran, error) {
+	now := time.Now()
+	orderID, err := allocateInvoiceNumber(tx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := models.Pembayaran{
+		PemesananID:       bookingID,
+		JumlahBayar:       amount,
+		StatusPembayaran:  models.PaymentPending,
+		MetodePembayaran:  "manual",
+		TipePembayaran:    tipe,
+		TanggalJatuhTempo: due,
+		OrderID:           orderID,
+		IdempotencyKey:    fmt.Sprintf("PAY-D%d-%d", bookingID, now.UnixNano()),
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, fmt.Errorf("gagal membuat tagihan uang jaminan: %v", err)
+	}
+
+	reminder := models.PaymentReminder{
+		PembayaranID:    payment.ID,
+		JumlahBayar:     payment.JumlahBayar,
+		TanggalReminder: now,
+		StatusReminder:  "Pending",
+		IsSent:          false,
+	}
+	if err := tx.Create(&reminder).Error; err != nil {
+		return nil, fmt.Errorf("gagal membuat reminder uang jaminan: %v", err)
+	}
+	return &payment, nil
+}
+
+// markDepositHeld dipanggil saat tagihan jaminan dikonfirmasi: Pending -> Held
+func markDepositHeld(tx *gorm.DB, paymentID uint) error {
+	return tx.Model(&models.SecurityDeposit{}).
+		Where("pembayaran_id = ? AND status = ?", paymentID, "Pending").
+		Updates(map[string]interface{}{"status": "Held", "held_at": time.Now()}).Error
+}
+
+// closeDepositOnCancel menutup uang jaminan booking yang dibatalkan. Jaminan yang sudah
+// dibayar dikembalikan lewat refund pembatalan biasa (createRefundsForBooking).
+func closeDepositOnCancel(tx *gorm.DB, bookingID uint) error {
+	if err := tx.Model(&models.SecurityDeposit{}).
+		Where("pemesanan_id = ? AND status = ?", bookingID, "Pending").
+		Update("status", "Cancelled").Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.SecurityDeposit{}).
+		Where("pemesanan_id = ? AND status = ?", bookingID, "Held").
+		Update("status", "Refunded").Error
+}
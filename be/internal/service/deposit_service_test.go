@@ -0,0 +1,166 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Test SettleDeposit - jaminan baru bisa diselesaikan setelah check-out
+func TestDepositService_SettleDeposit_RequiresCheckedOut(t *testing.T) {
+	depositRepo := new(MockDepositRepository)
+	bookingRepo := new(MockBookingRepository)
+	service := NewDepositService(depositRepo, bookingRepo, new(MockRefundRepository), new(MockPenyewaRepository), nil, nil)
+
+	bookingRepo.On("FindByID", uint(4)).Return(&models.Pemesanan{ID: 4, StatusPemesanan: models.BookingConfirmed}, nil)
+
+	_, err := service.SettleDeposit(4, []models.DepositDeduction{{Kategori: "damage", Keterangan: "Kunci hilang", Jumlah: 50000}}, "", AuditActor{})
+
+	assert.Error(t, err)
+	depositRepo.AssertNotCalled(t, "FindByBookingID", mock.Anything)
+}
+
+// Test SettleDeposit - jaminan yang belum dibayar (Pending) tidak bisa diselesaikan
+func TestDepositService_SettleDeposit_RequiresHeldDeposit(t *testing.T) {
+	depositRepo := new(MockDepositRepository)
+	bookingRepo := new(MockBookingRepository)
+	service := NewDepositService(depositRepo, bookingRepo, new(MockRefundRepository), new(MockPenyewaRepository), nil, nil)
+
+	bookingRepo.On("FindByID", uint(4)).Return(&models.Pemesanan{ID: 4, StatusPemesanan: models.BookingCheckedOut}, nil)
+	depositRepo.On("FindByBookingID", uint(4)).Return(&models.SecurityDeposit{ID: 1, PemesananID: 4, Jumlah: 500000, Status: "Pending"}, nil)
+
+	_, err := service.SettleDeposit(4, nil, "", AuditActor{})
+
+	assert.Error(t, err)
+	depositRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func newSettleDepositTest(jumlah float64) (DepositService, *MockDepositRepository, *MockRefundRepository) {
+	depositRepo := new(MockDepositRepository)
+	bookingRepo := new(MockBookingRepository)
+	refundRepo := new(MockRefundRepository)
+	service := NewDepositService(depositRepo, bookingRepo, refundRepo, new(MockPenyewaRepository), newTxStubDB(), nil)
+
+	bookingRepo.On("FindByID", uint(4)).Return(&models.Pemesanan{ID: 4, StatusPemesanan: models.BookingCheckedOut}, nil)
+	depositRepo.On("FindByBookingID", uint(4)).Return(&models.SecurityDeposit{ID: 1, PemesananID: 4, PembayaranID: 20, Jumlah: jumlah, Status: "Held"}, nil)
+	return service, depositRepo, refundRepo
+}
+
+// Test SettleDeposit - potongan lebih kecil dari jaminan: sisanya menjadi refund deposit_settlement
+func TestDepositService_SettleDeposit_RefundsRemainder(t *testing.T) {
+	service, depositRepo, refundRepo := newSettleDepositTest(500000)
+
+	depositRepo.On("FindByIDForUpdate", uint(1)).Return(&models.SecurityDeposit{ID: 1, Status: "Held"}, nil)
+	depositRepo.On("CreateDeductions", mock.Anything).Return(nil)
+	depositRepo.On("Update", mock.AnythingOfType("*models.SecurityDeposit")).Return(nil)
+	refundRepo.On("Create", mock.MatchedBy(func(r *models.Refund) bool {
+		return r.PembayaranID == 20 && r.JumlahRefund == 350000 && r.Alasan == "deposit_settlement" && r.StatusRefund == "Requested"
+	})).Return(nil)
+
+	deposit, err := service.SettleDeposit(4, []models.DepositDeduction{{Kategori: "damage", Keterangan: "Kaca jendela pecah", Jumlah: 150000}}, "", AuditActor{UserID: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Settled", deposit.Status)
+	assert.Equal(t, float64(150000), deposit.TotalPotongan)
+	assert.Equal(t, float64(350000), deposit.Selisih)
+	assert.Equal(t, uint(0), deposit.ChargePembayaranID)
+	refundRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// Test SettleDeposit - potongan sama dengan jaminan: tidak ada refund maupun tagihan
+func TestDepositService_SettleDeposit_ExactlyConsumed(t *testing.T) {
+	service, depositRepo, refundRepo := newSettleDepositTest(500000)
+
+	depositRepo.On("FindByIDForUpdate", uint(1)).Return(&models.SecurityDeposit{ID: 1, Status: "Held"}, nil)
+	depositRepo.On("CreateDeductions", mock.Anything).Return(nil)
+	depositRepo.On("Update", mock.AnythingOfType("*models.SecurityDeposit")).Return(nil)
+
+	deposit, err := service.SettleDeposit(4, []models.DepositDeduction{{Kategori: "cleaning", Keterangan: "Pembersihan total", Jumlah: 500000}}, "", AuditActor{UserID: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Settled", deposit.Status)
+	assert.Equal(t, float64(0), deposit.Selisih)
+	assert.Equal(t, uint(0), deposit.RefundID)
+	assert.Equal(t, uint(0), deposit.ChargePembayaranID)
+	refundRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+// Test SettleDeposit - potongan melebihi jaminan: kekurangan ditagih sebagai deposit_charge.
+// Nomor invoice dialokasikan lewat SQL langsung sehingga di txstub pembuatan tagihan gagal;
+// yang diuji adalah jalur deposit_charge dipilih dan seluruh transaksi dibatalkan.
+func TestDepositService_SettleDeposit_ChargesShortfall(t *testing.T) {
+	service, depositRepo, refundRepo := newSettleDepositTest(500000)
+
+	depositRepo.On("FindByIDForUpdate", uint(1)).Return(&models.SecurityDeposit{ID: 1, Status: "Held"}, nil)
+	depositRepo.On("CreateDeductions", mock.Anything).Return(nil)
+
+	_, err := service.SettleDeposit(4, []models.DepositDeduction{{Kategori: "damage", Keterangan: "Kasur rusak", Jumlah: 650000}}, "", AuditActor{UserID: 2})
+
+	assert.ErrorContains(t, err, "nomor invoice")
+	refundRepo.AssertNotCalled(t, "Create", mock.Anything)
+	depositRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// Test SettleDeposit - settlement lain sudah lebih dulu selesai: status dicek ulang di bawah row lock
+func TestDepositService_SettleDeposit_ConcurrentSettlement(t *testing.T) {
+	service, depositRepo, refundRepo := newSettleDepositTest(500000)
+
+	depositRepo.On("FindByIDForUpdate", uint(1)).Return(&models.SecurityDeposit{ID: 1, Status: "Settled"}, nil)
+
+	_, err := service.SettleDeposit(4, []models.DepositDeduction{{Kategori: "damage", Keterangan: "Kaca jendela pecah", Jumlah: 150000}}, "", AuditActor{UserID: 2})
+
+	assert.Error(t, err)
+	depositRepo.AssertNotCalled(t, "CreateDeductions", mock.Anything)
+	refundRepo.AssertNotCalled(t, "Create", mock.Anything)
+	depositRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// Test sumDepositDeductions - kategori, jumlah dan keterangan divalidasi per item
+func TestSumDepositDeductions(t *testing.T) {
+	total, err := sumDepositDeductions([]models.DepositDeduction{
+		{Kategori: "damage", Keterangan: "Kaca jendela pecah", Jumlah: 150000},
+		{Kategori: "utilities", Keterangan: "Listrik bulan terakhir", Jumlah: 75000},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(225000), total)
+
+	_, err = sumDepositDeductions([]models.DepositDeduction{{Kategori: "rent", Keterangan: "Sewa", Jumlah: 1000}})
+	assert.Error(t, err)
+
+	_, err = sumDepositDeductions([]models.DepositDeduction{{Kategori: "damage", Keterangan: "Kasur", Jumlah: 0}})
+	assert.Error(t, err)
+}
+
+// Test GetDeposit - penyewa lain tidak bisa melihat jaminan booking orang lain
+func TestDepositService_GetDeposit_OwnerOnly(t *testing.T) {
+	depositRepo := new(MockDepositRepository)
+	bookingRepo := new(MockBookingRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	service := NewDepositService(depositRepo, bookingRepo, new(MockRefundRepository), penyewaRepo, nil, nil)
+
+	bookingRepo.On("FindByID", uint(4)).Return(&models.Pemesanan{ID: 4, PenyewaID: 2}, nil)
+	penyewaRepo.On("FindByUserID", uint(9)).Return(&models.Penyewa{ID: 3}, nil)
+
+	_, err := service.GetDeposit(4, 9, "tenant")
+
+	assert.Error(t, err)
+	depositRepo.AssertNotCalled(t, "FindByBookingID", mock.Anything)
+}
+
+// Test buildLedger - uang jaminan dan pengembaliannya tidak masuk buku besar sewa
+func TestBuildLedger_ExcludesDeposit(t *testing.T) {
+	booking := ledgerTestBooking(models.BookingConfirmed, 1,
+		models.Pembayaran{ID: 10, JumlahBayar: 1000000, StatusPembayaran: "Confirmed", TipePembayaran: "full", ConfirmedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)},
+		models.Pembayaran{ID: 11, JumlahBayar: 500000, StatusPembayaran: "Confirmed", TipePembayaran: "deposit", ConfirmedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)},
+	)
+	refunds := []models.Refund{{PembayaranID: 11, PemesananID: 1, JumlahRefund: 400000, StatusRefund: "Paid", Alasan: "deposit_settlement"}}
+
+	ledger := buildLedger(booking, refunds, nil)
+
+	assert.Equal(t, float64(1000000), ledger.TotalPayments)
+	assert.Equal(t, float64(0), ledger.TotalRefunds)
+	assert.Equal(t, float64(0), ledger.Outstanding)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"io"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+)
+
+// ExportService menulis export CSV/XLSX langsung ke writer (response HTTP) baris demi baris
+type ExportService interface {
+	ExportPayments(w io.Writer, format string, filter repository.PaymentFilter) error
+	ExportBookings(w io.Writer, format string, filter repository.BookingFilter) error
+	ExportTenants(w io.Writer, format string, filter repository.TenantFilter) error
+}
+
+type exportService struct {
+	repo repository.ExportRepository
+}
+
+func NewExportService(repo repository.ExportRepository) ExportService {
+	return &exportService{repo}
+}
+
+func (s *exportService) ExportPayments(w io.Writer, format string, filter repository.PaymentFilter) error {
+	table, err := utils.NewTableWriter(format, w, "Pembayaran")
+	if err != nil {
+		return err
+	}
+
+	table.WriteRow("ID", "No. Invoice", "No. Kwitansi", "Penyewa", "Kamar", "Metode", "Tipe", "Status",
+		"Jumlah", "Denda", "Tanggal Bayar", "Dikonfirmasi")
+	err = s.repo.StreamPayments(filter, func(p repository.PaymentExportRow) error {
+		return table.WriteRow(p.ID, p.OrderID, p.NomorKwitansi, p.NamaPenyewa, p.NomorKamar, p.MetodePembayaran,
+			p.TipePembayaran, p.StatusPembayaran, p.JumlahBayar, p.TotalDenda, p.TanggalBayar, p.ConfirmedAt)
+	})
+	if err != nil {
+		return err
+	}
+	return table.Close()
+}
+
+func (s *exportService) ExportBookings(w io.Writer, format string, filter repository.BookingFilter) error {
+	table, err := utils.NewTableWriter(format, w, "Booking")
+	if err != nil {
+		return err
+	}
+
+	table.WriteRow("ID", "Penyewa", "Kamar", "Tipe Kamar", "Tanggal Mulai", "Tanggal Keluar", "Durasi (bulan)", "Status", "Dibuat")
+	err = s.repo.StreamBookings(filter, func(b repository.BookingExportRow) error {
+		return table.WriteRow(b.ID, b.NamaPenyewa, b.NomorKamar, b.TipeKamar, b.TanggalMulai, b.TanggalKeluar,
+			b.DurasiSewa, b.StatusPemesanan, b.CreatedAt)
+	})
+	if err != nil {
+		return err
+	}
+	return table.Close()
+}
+
+func (s *exportService) ExportTenants(w io.Writer, format string, filter repository.TenantFilter) error {
+	table, err := utils.NewTableWriter(format, w, "Penyewa")
+	if err != nil {
+		return err
+	}
+
+	table.WriteRow("ID", "Nama Lengkap", "Username", "Email", "No. HP", "NIK", "Role", "Terdaftar")
+	err = s.repo.StreamTenants(filter, func(t repository.TenantExportRow) error {
+		return table.WriteRow(t.ID, t.NamaLengkap, t.Username, t.Email, t.NomorHP, t.NIK, t.Role, t.CreatedAt)
+	})
+	if err != nil {
+		return err
+	}
+	return table.Close()
+}
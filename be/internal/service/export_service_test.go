@@ -0,0 +1,90 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"koskosan-be/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportTestRepo() *MockExportRepository {
+	repo := &MockExportRepository{Payments: []repository.PaymentExportRow{{
+		ID: 7, OrderID: "INV/2026/10/0007", NamaPenyewa: "Budi, S.T.", NomorKamar: "A1",
+		MetodePembayaran: "transfer", TipePembayaran: "full", StatusPembayaran: "Confirmed",
+		JumlahBayar: 1500000, TanggalBayar: time.Date(2026, 10, 1, 9, 30, 0, 0, time.Local),
+	}}}
+	repo.On("StreamPayments", repository.PaymentFilter{Status: "Confirmed"}).Return(nil)
+	return repo
+}
+
+// Test ExportPayments CSV - header + baris, nilai berkoma di-quote, tanggal kosong tidak ditulis
+func TestExportService_ExportPayments_CSV(t *testing.T) {
+	repo := exportTestRepo()
+	var buf bytes.Buffer
+
+	err := NewExportService(repo).ExportPayments(&buf, "csv", repository.PaymentFilter{Status: "Confirmed"})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"ID,No. Invoice,No. Kwitansi,Penyewa,Kamar,Metode,Tipe,Status,Jumlah,Denda,Tanggal Bayar,Dikonfirmasi\n"+
+			"7,INV/2026/10/0007,,\"Budi, S.T.\",A1,transfer,full,Confirmed,1500000,0,2026-10-01 09:30,\n",
+		buf.String())
+	repo.AssertExpectations(t)
+}
+
+// Test ExportPayments XLSX - arsip zip valid dengan sheet berisi sel angka dan teks
+func TestExportService_ExportPayments_XLSX(t *testing.T) {
+	repo := exportTestRepo()
+	var buf bytes.Buffer
+
+	err := NewExportService(repo).ExportPayments(&buf, "xlsx", repository.PaymentFilter{Status: "Confirmed"})
+	assert.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(data)
+		}
+	}
+	assert.Contains(t, sheet, `<c r="A2"><v>7</v></c>`)
+	assert.Contains(t, sheet, `<c r="I2"><v>1500000</v></c>`)
+	assert.Contains(t, sheet, `Budi, S.T.`)
+	assert.Contains(t, sheet, `</sheetData></worksheet>`)
+}
+
+// Test ExportTenants CSV - teks yang diawali karakter formula diberi awalan ' agar tidak dieksekusi
+func TestExportService_ExportTenants_CSVEscapesFormulas(t *testing.T) {
+	repo := &MockExportRepository{Tenants: []repository.TenantExportRow{{
+		ID: 3, NamaLengkap: `=HYPERLINK("http://evil.example","klik")`, Username: "@budi",
+		Email: "budi@example.com", NomorHP: "+6281234", NIK: "3201010101010001", Role: "tenant",
+	}}}
+	repo.On("StreamTenants", repository.TenantFilter{}).Return(nil)
+	var buf bytes.Buffer
+
+	err := NewExportService(repo).ExportTenants(&buf, "csv", repository.TenantFilter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"ID,Nama Lengkap,Username,Email,No. HP,NIK,Role,Terdaftar\n"+
+			`3,"'=HYPERLINK(""http://evil.example"",""klik"")",'@budi,budi@example.com,'+6281234,3201010101010001,tenant,`+"\n",
+		buf.String())
+}
+
+// Test format tidak dikenal ditolak sebelum query dijalankan
+func TestExportService_UnsupportedFormat(t *testing.T) {
+	repo := new(MockExportRepository)
+	err := NewExportService(repo).ExportTenants(io.Discard, "pdf", repository.TenantFilter{})
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "StreamTenants")
+}
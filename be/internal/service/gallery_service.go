@@ -6,27 +6,40 @@ import (
 )
 
 type GalleryService interface {
-	CreateGallery(gallery *models.Gallery) error
+	CreateGallery(gallery *models.Gallery, actor AuditActor) error
 	GetAllGalleries() ([]models.Gallery, error)
-	DeleteGallery(id uint) error
+	DeleteGallery(id uint, actor AuditActor) error
 }
 
 type galleryService struct {
-	repo repository.GalleryRepository
+	repo  repository.GalleryRepository
+	audit AuditService
 }
 
-func NewGalleryService(repo repository.GalleryRepository) GalleryService {
-	return &galleryService{repo}
+func NewGalleryService(repo repository.GalleryRepository, audit AuditService) GalleryService {
+	return &galleryService{repo, audit}
 }
 
-func (s *galleryService) CreateGallery(gallery *models.Gallery) error {
-	return s.repo.Create(gallery)
+func (s *galleryService) CreateGallery(gallery *models.Gallery, actor AuditActor) error {
+	if err := s.repo.Create(gallery); err != nil {
+		return err
+	}
+	recordAudit(s.audit, actor, "gallery.create", "gallery", gallery.ID, nil, gallery)
+	return nil
 }
 
 func (s *galleryService) GetAllGalleries() ([]models.Gallery, error) {
 	return s.repo.FindAll()
 }
 
-func (s *galleryService) DeleteGallery(id uint) error {
-	return s.repo.Delete(id)
+func (s *galleryService) DeleteGallery(id uint, actor AuditActor) error {
+	gallery, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	recordAudit(s.audit, actor, "gallery.delete", "gallery", id, gallery, nil)
+	return nil
 }
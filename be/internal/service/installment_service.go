@@ -0,0 +1,99 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const maxInstallmentCount = 24
+
+// planPaymentTypes adalah tipe pembayaran yang dihitung untuk melunasi harga sewa
+// DurasiSewa bulan. Extend ikut dihitung karena DurasiSewa baru bertambah saat tagihan
+// extend dikonfirmasi, sebesar JumlahBayar / HargaPerBulan.
+var planPaymentTypes = []string{"full", "dp", "installment", "extend"}
+
+// splitInstallments membagi sisa tagihan menjadi n cicilan yang dibulatkan ke rupiah;
+// selisih pembulatan dibebankan ke cicilan terakhir.
+func splitInstallments(remaining float64, n int) []float64 {
+	if n < 1 {
+		n = 1
+	}
+	amounts := make([]float64, n)
+	base := math.Floor(remaining / float64(n))
+	for i := 0; i < n-1; i++ {
+		amounts[i] = base
+	}
+	amounts[n-1] = remaining - base*float64(n-1)
+	return amounts
+}
+
+// buildInstallmentPlan menyusun Pembayaran bertipe installment untuk setiap jumlah pada amounts.
+// Cicilan pertama jatuh tempo firstDue, berikutnya setiap bulan setelahnya.
+func buildInstallmentPlan(bookingID uint, amounts []float64, firstDue time.Time, startNo int) []models.Pembayaran {
+	now := time.Now()
+	plan := make([]models.Pembayaran, 0, len(amounts))
+	for i, amount := range amounts {
+		plan = append(plan, models.Pembayaran{
+			PemesananID:       bookingID,
+			JumlahBayar:       amount,
+			StatusPembayaran:  models.PaymentPending,
+			MetodePembayaran:  "manual",
+			TipePembayaran:    "installment",
+			CicilanKe:         startNo + i,
+			TanggalJatuhTempo: firstDue.AddDate(0, i, 0),
+			IdempotencyKey:    fmt.Sprintf("PAY-I%d-%d-%d", bookingID, startNo+i, now.UnixNano()),
+		})
+	}
+	return plan
+}
+
+// createInstallmentPlan menyimpan cicilan beserta PaymentReminder masing-masing
+// (tahap pertama reminderCadence, atau hari ini jika sudah lewat).
+func createInstallmentPlan(tx *gorm.DB, plan []models.Pembayaran, reminderCadence []int) error {
+	now := time.Now()
+	for i := range plan {
+		orderID, err := allocateInvoiceNumber(tx, now)
+		if err != nil {
+			return err
+		}
+		plan[i].OrderID = orderID
+
+		if err := tx.Create(&plan[i]).Error; err != nil {
+			return fmt.Errorf("gagal membuat cicilan ke-%d: %v", plan[i].CicilanKe, err)
+		}
+
+		reminder := models.PaymentReminder{
+			PembayaranID:    plan[i].ID,
+			JumlahBayar:     plan[i].JumlahBayar,
+			TanggalReminder: firstReminderDate(plan[i].TanggalJatuhTempo, reminderCadence, now),
+			StatusReminder:  "Pending",
+			IsSent:          false,
+		}
+		if err := tx.Create(&reminder).Error; err != nil {
+			return fmt.Errorf("gagal membuat reminder cicilan ke-%d: %v", plan[i].CicilanKe, err)
+		}
+	}
+	return nil
+}
+
+// bookingOutstanding menghitung sisa tagihan sewa: DurasiSewa x harga dikurangi pembayaran
+// full/dp/installment/extend yang sudah Confirmed.
+func bookingOutstanding(tx *gorm.DB, booking *models.Pemesanan, hargaPerBulan float64) (float64, error) {
+	var paid float64
+	if err := tx.Model(&models.Pembayaran{}).
+		Where("pemesanan_id = ? AND status_pembayaran = ? AND tipe_pembayaran IN ?", booking.ID, models.PaymentConfirmed, planPaymentTypes).
+		Select("COALESCE(SUM(jumlah_bayar), 0)").
+		Scan(&paid).Error; err != nil {
+		return 0, err
+	}
+
+	outstanding := float64(booking.DurasiSewa)*hargaPerBulan - paid
+	if outstanding < 1 { // toleransi pembulatan rupiah
+		return 0, nil
+	}
+	return outstanding, nil
+}
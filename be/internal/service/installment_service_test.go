@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test splitInstallments - sisa pembulatan masuk ke cicilan terakhir
+func TestSplitInstallments(t *testing.T) {
+	amounts := splitInstallments(1000000, 3)
+
+	assert.Equal(t, []float64{333333, 333333, 333334}, amounts)
+	assert.Equal(t, []float64{2100000}, splitInstallments(2100000, 0))
+}
+
+// Test buildInstallmentPlan - jatuh tempo bulanan dan nomor cicilan berurutan
+func TestBuildInstallmentPlan(t *testing.T) {
+	firstDue := time.Date(2026, 11, 15, 0, 0, 0, 0, time.Local)
+
+	plan := buildInstallmentPlan(7, []float64{700000, 700000}, firstDue, 2)
+
+	assert.Len(t, plan, 2)
+	assert.Equal(t, "installment", plan[0].TipePembayaran)
+	assert.Equal(t, "Pending", string(plan[0].StatusPembayaran))
+	assert.Equal(t, 2, plan[0].CicilanKe)
+	assert.Equal(t, 3, plan[1].CicilanKe)
+	assert.Equal(t, firstDue, plan[0].TanggalJatuhTempo)
+	assert.Equal(t, firstDue.AddDate(0, 1, 0), plan[1].TanggalJatuhTempo)
+	assert.NotEqual(t, plan[0].IdempotencyKey, plan[1].IdempotencyKey)
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// allocateInvoiceNumber mengambil nomor invoice berikutnya untuk tahun berjalan. Harus dipanggil
+// di dalam transaksi yang sama dengan pembuatan Pembayaran: baris sequence dikunci sehingga nomor
+// tidak dobel, dan ikut di-rollback jika pembayaran gagal dibuat sehingga tidak ada nomor yang terlewat.
+func allocateInvoiceNumber(tx *gorm.DB, at time.Time) (string, error) {
+	year := at.Year()
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.InvoiceSequence{Tahun: year}).Error; err != nil {
+		return "", fmt.Errorf("gagal menyiapkan nomor invoice: %v", err)
+	}
+
+	var seq models.InvoiceSequence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seq, "tahun = ?", year).Error; err != nil {
+		return "", fmt.Errorf("gagal mengunci nomor invoice: %v", err)
+	}
+
+	seq.LastNumber++
+	if err := tx.Model(&models.InvoiceSequence{}).Where("tahun = ?", year).Update("last_number", seq.LastNumber).Error; err != nil {
+		return "", fmt.Errorf("gagal menyimpan nomor invoice: %v", err)
+	}
+
+	return formatInvoiceNumber(at, seq.LastNumber), nil
+}
+
+// formatInvoiceNumber: INV/<tahun>/<bulan>/<nomor urut tahunan>, mis. INV/2026/10/0042
+func formatInvoiceNumber(at time.Time, number int) string {
+	return fmt.Sprintf("INV/%d/%02d/%04d", at.Year(), int(at.Month()), number)
+}
@@ -8,14 +8,16 @@ import (
 	"koskosan-be/internal/utils"
 	"log"
 	"strings"
+
+	"gorm.io/gorm"
 )
 
 type KamarService interface {
 	GetAll() ([]models.Kamar, error)
 	GetByID(id uint) (*models.Kamar, error)
-	Create(kamar *models.Kamar) error
-	Update(kamar *models.Kamar) error
-	Delete(id uint) error
+	Create(kamar *models.Kamar, actor AuditActor) error
+	Update(kamar *models.Kamar, actor AuditActor) error
+	Delete(id uint, actor AuditActor) error
 	AddImage(image *models.KamarImage) error
 	DeleteImagesByKamarID(kamarID uint) error
 	CanDeleteRoom(id uint) (bool, string, error) // Check if room can be deleted (confirmed/active booking)
@@ -25,9 +27,14 @@ type kamarService struct {
 	repo             repository.KamarRepository
 	bookingRepo      repository.BookingRepository // Check active/pending bookings
 	paymentRepo      repository.PaymentRepository // Cancel pending payments
+	refundRepo       repository.RefundRepository  // Record refunds for money already received
 	penyewaRepo      repository.PenyewaRepository // Load penyewa data for notifications
 	waSender         utils.WhatsAppSender          // Send WA notifications
 	adminPhoneNumber string                         // Admin phone number for WA alerts (env: ADMIN_PHONE_NUMBER)
+	notifier         NotificationService           // In-app notification center (optional)
+	templates        MessageTemplateService        // WA message templates (nil: built-in templates)
+	audit            AuditService                  // Audit log mutasi admin (optional)
+	db               *gorm.DB                      // Transaksi pembatalan booking pending + hapus kamar
 }
 
 // NewKamarService creates a new KamarService with all required dependencies.
@@ -36,17 +43,27 @@ func NewKamarService(
 	repo repository.KamarRepository,
 	bookingRepo repository.BookingRepository,
 	paymentRepo repository.PaymentRepository,
+	refundRepo repository.RefundRepository,
 	penyewaRepo repository.PenyewaRepository,
 	waSender utils.WhatsAppSender,
 	adminPhoneNumber string,
+	notifier NotificationService,
+	templates MessageTemplateService,
+	audit AuditService,
+	db *gorm.DB,
 ) KamarService {
 	return &kamarService{
 		repo:             repo,
 		bookingRepo:      bookingRepo,
 		paymentRepo:      paymentRepo,
+		refundRepo:       refundRepo,
 		penyewaRepo:      penyewaRepo,
 		waSender:         waSender,
 		adminPhoneNumber: adminPhoneNumber,
+		notifier:         notifier,
+		templates:        templates,
+		audit:            audit,
+		db:               db,
 	}
 }
 
@@ -58,28 +75,51 @@ func (s *kamarService) GetByID(id uint) (*models.Kamar, error) {
 	return s.repo.FindByID(id)
 }
 
-func (s *kamarService) Create(kamar *models.Kamar) error {
-	return s.repo.Create(kamar)
+func (s *kamarService) Create(kamar *models.Kamar, actor AuditActor) error {
+	if err := s.repo.Create(kamar); err != nil {
+		return err
+	}
+	recordAudit(s.audit, actor, "kamar.create", "kamar", kamar.ID, nil, kamar)
+	return nil
 }
 
-func (s *kamarService) Update(kamar *models.Kamar) error {
+func (s *kamarService) Update(kamar *models.Kamar, actor AuditActor) error {
+	before, err := s.repo.FindByID(kamar.ID)
+	if err != nil {
+		return err
+	}
+
 	// FIX #8: Prevent Admin from setting Room Status to Tersedia if occupied
 	if kamar.Status == "Tersedia" {
 		activeBooking, err := s.bookingRepo.FindActiveBookingByKamarID(kamar.ID)
 		if err == nil && activeBooking != nil {
 			// FEATURE #Eviction: Admin forced room to available. We must auto-cancel tying booking
-			s.bookingRepo.UpdateStatus(activeBooking.ID, "Cancelled")
+			previousStatus := activeBooking.StatusPemesanan
+			if err := s.bookingRepo.Transition(activeBooking, models.BookingCancelled, actor.statusChange("room_forced_available")); err != nil {
+				return err
+			}
+			recordAudit(s.audit, actor, "booking.force_cancel", "booking", activeBooking.ID,
+				map[string]interface{}{"status_pemesanan": previousStatus, "kamar_id": kamar.ID},
+				map[string]interface{}{"status_pemesanan": activeBooking.StatusPemesanan, "kamar_id": kamar.ID})
 		}
 	}
-	return s.repo.Update(kamar)
+	// Status yang diubah admin tidak lagi mengikuti penyebab Maintenance sebelumnya
+	if kamar.Status != before.Status {
+		kamar.AlasanMaintenance = ""
+	}
+	if err := s.repo.Update(kamar); err != nil {
+		return err
+	}
+	recordAudit(s.audit, actor, "kamar.update", "kamar", kamar.ID, before, kamar)
+	return nil
 }
 
 // Delete menghapus kamar dengan 2 tahap validasi:
 //  1. Jika kamar berstatus "Terpesan" (ada booking aktif/confirmed), tolak penghapusan.
-//  2. Jika ada booking "Pending" (pembayaran diajukan tapi belum dikonfirmasi admin),
-//     otomatis batalkan booking + pembayaran, lalu kirim notifikasi WA ke admin (untuk transfer)
-//     atau hanya batalkan (untuk tunai/cash).
-func (s *kamarService) Delete(id uint) error {
+//  2. Semua booking "Pending" (pembayaran diajukan tapi belum dikonfirmasi admin) dibatalkan
+//     beserta pembayaran dan refund-nya dalam transaksi yang sama dengan penghapusan kamar,
+//     lalu notifikasi WA dikirim ke admin (untuk transfer) dan penyewa.
+func (s *kamarService) Delete(id uint, actor AuditActor) error {
 	// -- Langkah 1: Ambil data kamar --
 	kamar, err := s.repo.FindByID(id)
 	if err != nil {
@@ -95,38 +135,58 @@ func (s *kamarService) Delete(id uint) error {
 		return errors.New(reason)
 	}
 
-	// -- Langkah 3: Cek apakah ada booking "Pending" (pembayaran diajukan, belum dikonfirmasi) --
-	pendingBooking, err := s.bookingRepo.FindPendingBookingByKamarID(id)
-	if err != nil {
-		return fmt.Errorf("gagal memeriksa booking pending: %w", err)
-	}
+	// -- Langkah 3: Batalkan semua booking "Pending" lalu hapus kamar dalam satu transaksi --
+	var cancelled []models.Pemesanan
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txBookingRepo := s.bookingRepo.WithTx(tx)
+		pendingBookings, err := txBookingRepo.FindPendingBookingsByKamarID(id)
+		if err != nil {
+			return fmt.Errorf("gagal memeriksa booking pending: %w", err)
+		}
 
-	if pendingBooking != nil {
-		// Ada booking pending — batalkan dan kirim notifikasi
-		if err := s.cancelPendingBookingAndNotify(kamar, pendingBooking); err != nil {
-			// Catat error notifikasi tapi jangan gagalkan penghapusan
-			log.Printf("[WARN] Gagal membatalkan booking pending atau mengirim notifikasi untuk kamar %s: %v", kamar.NomorKamar, err)
+		for i := range pendingBookings {
+			if err := s.cancelPendingBooking(tx, &pendingBookings[i], actor); err != nil {
+				return err
+			}
 		}
+		cancelled = pendingBookings
+
+		return s.repo.WithTx(tx).Delete(id)
+	})
+	if err != nil {
+		return err
 	}
 
-	// -- Langkah 4: Hapus kamar --
-	return s.repo.Delete(id)
+	// -- Langkah 4: Notifikasi setelah transaksi berhasil --
+	for i := range cancelled {
+		s.notifyPendingBookingCancelled(kamar, &cancelled[i])
+	}
+	recordAudit(s.audit, actor, "kamar.delete", "kamar", id, kamar, nil)
+	return nil
 }
 
-// cancelPendingBookingAndNotify membatalkan booking pending dan mengirim notifikasi
-// sesuai metode pembayaran (transfer vs tunai).
-func (s *kamarService) cancelPendingBookingAndNotify(kamar *models.Kamar, booking *models.Pemesanan) error {
-	// 1. Batalkan status booking
-	if err := s.bookingRepo.UpdateStatus(booking.ID, "Cancelled"); err != nil {
-		return fmt.Errorf("gagal membatalkan booking: %w", err)
+// cancelPendingBooking membatalkan booking pending, mencatat refund untuk uang yang sudah
+// diterima dan membatalkan pembayaran yang belum final di dalam transaksi tx.
+func (s *kamarService) cancelPendingBooking(tx *gorm.DB, booking *models.Pemesanan, actor AuditActor) error {
+	change := actor.statusChange("room_deleted")
+	if err := s.bookingRepo.WithTx(tx).Transition(booking, models.BookingCancelled, change); err != nil {
+		return fmt.Errorf("gagal membatalkan booking %d: %w", booking.ID, err)
 	}
 
-	// 2. Batalkan semua pembayaran pending pada booking ini
-	if err := s.paymentRepo.CancelPendingPaymentsByBookingID(booking.ID); err != nil {
-		log.Printf("[WARN] Gagal membatalkan pembayaran untuk booking %d: %v", booking.ID, err)
+	// Refund dicatat sebelum status pembayaran diubah
+	if _, err := createRefundsForBooking(s.refundRepo.WithTx(tx), booking.Pembayaran, "room_deleted"); err != nil {
+		return fmt.Errorf("gagal mencatat refund untuk booking %d: %w", booking.ID, err)
+	}
+	if err := cancelOpenPayments(s.paymentRepo.WithTx(tx), booking.Pembayaran, change); err != nil {
+		return fmt.Errorf("gagal membatalkan pembayaran untuk booking %d: %w", booking.ID, err)
 	}
+	return nil
+}
 
-	// 3. Tentukan metode pembayaran dari pembayaran terbaru
+// notifyPendingBookingCancelled mengirim notifikasi pembatalan booking pending
+// sesuai metode pembayaran (transfer vs tunai).
+func (s *kamarService) notifyPendingBookingCancelled(kamar *models.Kamar, booking *models.Pemesanan) {
+	// 1. Tentukan metode pembayaran dari pembayaran terbaru
 	paymentMethod := "tunai" // default
 	var paidAmount float64 = 0
 	var buktiTransfer string
@@ -137,7 +197,7 @@ func (s *kamarService) cancelPendingBookingAndNotify(kamar *models.Kamar, bookin
 		buktiTransfer = latestPayment.BuktiTransfer
 	}
 
-	// 4. Ambil data penyewa untuk notifikasi
+	// 2. Ambil data penyewa untuk notifikasi
 	penyewa := booking.Penyewa
 	penyewaName := penyewa.NamaLengkap
 	if penyewaName == "" {
@@ -145,7 +205,7 @@ func (s *kamarService) cancelPendingBookingAndNotify(kamar *models.Kamar, bookin
 	}
 	penyewaPhone := penyewa.NomorHP
 
-	// 5. Kirim notifikasi berdasarkan metode pembayaran
+	// 3. Kirim notifikasi berdasarkan metode pembayaran
 	isTransfer := paymentMethod == "transfer" || paymentMethod == "bank_transfer" ||
 		paymentMethod == "manual" || strings.Contains(paymentMethod, "transfer")
 
@@ -157,20 +217,24 @@ func (s *kamarService) cancelPendingBookingAndNotify(kamar *models.Kamar, bookin
 
 		// Kirim WA ke user (penyewa) bahwa pesanan dibatalkan dengan info return uang
 		if penyewaPhone != "" {
-			go s.sendUserBookingCancelledWithReturn(penyewaPhone, penyewaName, kamar.NomorKamar, paidAmount)
+			go s.sendUserBookingCancelledWithReturn(penyewa, penyewaName, kamar.NomorKamar, paidAmount)
 		}
 
 	} else {
 		// ---- Metode Tunai/Cash: Hanya kirim notifikasi pembatalan ke USER ----
 		if penyewaPhone != "" {
-			go s.sendUserBookingCancelledCash(penyewaPhone, penyewaName, kamar.NomorKamar)
+			go s.sendUserBookingCancelledCash(penyewa, penyewaName, kamar.NomorKamar)
 		}
 	}
 
+	refundAmount := 0.0
+	if isTransfer {
+		refundAmount = paidAmount
+	}
+	emitRoomDeletedBookingCancelled(s.notifier, booking, kamar.NomorKamar, refundAmount)
+
 	log.Printf("[INFO] Booking pending ID=%d untuk kamar %s telah dibatalkan karena kamar dihapus (metode: %s)",
 		booking.ID, kamar.NomorKamar, paymentMethod)
-
-	return nil
 }
 
 // sendAdminRoomDeletedNotification mengirim WA ke nomor admin bahwa kamar dengan
@@ -181,43 +245,15 @@ func (s *kamarService) sendAdminRoomDeletedNotification(kamar *models.Kamar, pen
 		return
 	}
 
-	buktiText := buktiTransfer
-	if buktiText == "" {
-		buktiText = "(Tidak ada lampiran / Belum diupload)"
-	}
-
-	msg := fmt.Sprintf(
-		"⚠️ *NOTIFIKASI SISTEM — KAMAR DIHAPUS*\n\n"+
-			"Admin secara tidak sengaja telah menghapus data kamar yang memiliki pesanan yang *belum dikonfirmasi*.\n\n"+
-			"📋 *Detail:*\n"+
-			"• Kamar: *%s*\n"+
-			"• Tipe: %s\n"+
-			"• Dipesan oleh: *%s*\n"+
-			"• No. HP Penyewa: %s\n"+
-			"• Jumlah pembayaran yang diajukan: *Rp %.0f*\n"+
-			"• Bukti Transfer: %s\n\n"+
-			"📌 *Tindakan yang diperlukan:*\n"+
-			"Harap segera hubungi penyewa *%s* melalui nomor WA berikut untuk mengembalikan dana atau konfirmasi pembatalan:\n"+
-			"wa.me/%s\n\n"+
-			"Dana sebesar *Rp %.0f* harus dikembalikan karena pesanan telah otomatis dibatalkan akibat penghapusan kamar.\n\n"+
-			"_Pesan ini dikirim otomatis oleh sistem._",
-		kamar.NomorKamar,
-		kamar.TipeKamar,
-		penyewaName,
-		func() string {
-			if penyewaPhone != "" {
-				return penyewaPhone
-			}
-			return "-"
-		}(),
-		paidAmount,
-		buktiText,
-		penyewaName,
-		penyewaPhone,
-		paidAmount,
-	)
-
-	if err := s.waSender.SendWhatsApp(s.adminPhoneNumber, msg); err != nil {
+	err := sendTemplatedWhatsApp(s.waSender, s.templates, s.adminPhoneNumber, msgRoomDeletedAdmin, languageIndonesian, map[string]interface{}{
+		"NomorKamar":    kamar.NomorKamar,
+		"TipeKamar":     kamar.TipeKamar,
+		"Nama":          penyewaName,
+		"NomorHP":       penyewaPhone,
+		"Jumlah":        paidAmount,
+		"BuktiTransfer": buktiTransfer,
+	})
+	if err != nil {
 		log.Printf("[ERROR] Gagal mengirim notifikasi WA ke admin (%s): %v", s.adminPhoneNumber, err)
 	} else {
 		log.Printf("[INFO] Notifikasi WA berhasil dikirim ke admin (%s) untuk kamar %s", s.adminPhoneNumber, kamar.NomorKamar)
@@ -226,40 +262,26 @@ func (s *kamarService) sendAdminRoomDeletedNotification(kamar *models.Kamar, pen
 
 // sendUserBookingCancelledWithReturn mengirim WA ke penyewa bahwa pesanannya dibatalkan
 // dan akan mendapatkan return dana (untuk metode transfer).
-func (s *kamarService) sendUserBookingCancelledWithReturn(phone, name, nomorKamar string, returnAmount float64) {
-	msg := fmt.Sprintf(
-		"Halo *%s*,\n\n"+
-			"Kami mohon maaf atas ketidaknyamanan ini. Kamar *%s* yang Anda pesan telah *dihapus oleh admin* secara tidak sengaja.\n\n"+
-			"Pesanan Anda telah otomatis *dibatalkan*.\n\n"+
-			"💰 *Return Dana:*\n"+
-			"Anda akan mendapatkan pengembalian uang sebesar *Rp %.0f* sesuai dengan jumlah yang Anda bayarkan saat pemesanan.\n\n"+
-			"👉 *Harap segera hubungi Admin* untuk memproses pengembalian dana atau melakukan pemesanan kamar lainnya.\n\n"+
-			"Terima kasih atas pengertian Anda. 🙏",
-		name,
-		nomorKamar,
-		returnAmount,
-	)
-
-	if err := s.waSender.SendWhatsApp(phone, msg); err != nil {
-		log.Printf("[ERROR] Gagal mengirim notifikasi WA return dana ke penyewa (%s): %v", phone, err)
+func (s *kamarService) sendUserBookingCancelledWithReturn(penyewa models.Penyewa, name, nomorKamar string, returnAmount float64) {
+	err := sendTemplatedWhatsApp(s.waSender, s.templates, penyewa.NomorHP, msgRoomDeletedTenantRefund, penyewa.Bahasa, map[string]interface{}{
+		"Nama":       name,
+		"NomorKamar": nomorKamar,
+		"Jumlah":     returnAmount,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Gagal mengirim notifikasi WA return dana ke penyewa (%s): %v", penyewa.NomorHP, err)
 	}
 }
 
 // sendUserBookingCancelledCash mengirim WA ke penyewa bahwa pesanannya dibatalkan
 // karena kamar dihapus (untuk metode tunai — tanpa return dana otomatis).
-func (s *kamarService) sendUserBookingCancelledCash(phone, name, nomorKamar string) {
-	msg := fmt.Sprintf(
-		"Halo *%s*,\n\n"+
-			"Mohon maaf atas ketidaknyamanan ini. Kamar *%s* yang Anda pesan telah *dihapus oleh admin* secara tidak sengaja.\n\n"+
-			"Pesanan Anda telah otomatis *dibatalkan*.\n\n"+
-			"👉 *Harap segera hubungi Admin* untuk informasi lebih lanjut atau untuk melakukan pemesanan ulang kamar yang tersedia.\n\n"+
-			"Terima kasih. 🙏",
-		name,
-		nomorKamar,
-	)
-
-	if err := s.waSender.SendWhatsApp(phone, msg); err != nil {
-		log.Printf("[ERROR] Gagal mengirim notifikasi WA ke penyewa (%s): %v", phone, err)
+func (s *kamarService) sendUserBookingCancelledCash(penyewa models.Penyewa, name, nomorKamar string) {
+	err := sendTemplatedWhatsApp(s.waSender, s.templates, penyewa.NomorHP, msgRoomDeletedTenant, penyewa.Bahasa, map[string]interface{}{
+		"Nama":       name,
+		"NomorKamar": nomorKamar,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Gagal mengirim notifikasi WA ke penyewa (%s): %v", penyewa.NomorHP, err)
 	}
 }
 
@@ -267,6 +289,15 @@ func (s *kamarService) sendUserBookingCancelledCash(phone, name, nomorKamar stri
 // Kamar TIDAK bisa dihapus jika ada booking aktif/confirmed (status: Aktif, Confirmed, Partially Paid).
 // Kamar BISA dihapus jika hanya ada booking Pending (akan di-cancel otomatis).
 func (s *kamarService) CanDeleteRoom(id uint) (bool, string, error) {
+	// Kamar yang dikunci (mis. tunggakan penyewa) tidak boleh dihapus
+	kamar, err := s.repo.FindByID(id)
+	if err != nil {
+		return false, "", err
+	}
+	if kamar.IsLocked {
+		return false, fmt.Sprintf("Kamar sedang dikunci (%s). Selesaikan masalah tersebut sebelum menghapus kamar", kamar.LockedReason), nil
+	}
+
 	// Cek booking aktif (Confirmed/Aktif/Partially Paid)
 	activeBooking, err := s.bookingRepo.FindActiveBookingByKamarID(id)
 	if err != nil {
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestKamarService(kamarRepo *MockKamarRepository, bookingRepo *MockBookingRepository, paymentRepo *MockPaymentRepository, refundRepo *MockRefundRepository) KamarService {
+	return NewKamarService(kamarRepo, bookingRepo, paymentRepo, refundRepo, new(MockPenyewaRepository), nil, "", nil, nil, nil, newTxStubDB())
+}
+
+// Test Delete - semua booking Pending dibatalkan (bukan hanya yang pertama) dan bukti transfer di-refund
+func TestKamarService_Delete_CancelsAllPendingBookings(t *testing.T) {
+	kamarRepo := new(MockKamarRepository)
+	bookingRepo := new(MockBookingRepository)
+	paymentRepo := new(MockPaymentRepository)
+	refundRepo := new(MockRefundRepository)
+	service := newTestKamarService(kamarRepo, bookingRepo, paymentRepo, refundRepo)
+
+	kamarRepo.On("FindByID", uint(1)).Return(&models.Kamar{ID: 1, NomorKamar: "A1", Status: "Terpesan"}, nil)
+	kamarRepo.On("WithTx", mock.Anything).Return(kamarRepo)
+	kamarRepo.On("Delete", uint(1)).Return(nil)
+	bookingRepo.On("FindActiveBookingByKamarID", uint(1)).Return(nil, nil)
+	bookingRepo.On("WithTx", mock.Anything).Return(bookingRepo)
+	bookingRepo.On("FindPendingBookingsByKamarID", uint(1)).Return([]models.Pemesanan{
+		{ID: 4, KamarID: 1, StatusPemesanan: models.BookingPending, Pembayaran: []models.Pembayaran{
+			{ID: 40, PemesananID: 4, JumlahBayar: 500000, StatusPembayaran: models.PaymentPending, BuktiTransfer: "/uploads/bukti.jpg", MetodePembayaran: "transfer"},
+		}},
+		{ID: 5, KamarID: 1, StatusPemesanan: models.BookingPending, Pembayaran: []models.Pembayaran{
+			{ID: 50, PemesananID: 5, JumlahBayar: 500000, StatusPembayaran: models.PaymentPending, MetodePembayaran: "cash"},
+		}},
+	}, nil)
+	bookingRepo.On("Transition", mock.AnythingOfType("*models.Pemesanan"), models.BookingCancelled, mock.Anything).Return(nil)
+	refundRepo.On("FindByPembayaranID", uint(40)).Return(nil, nil)
+	refundRepo.On("Create", mock.MatchedBy(func(r *models.Refund) bool {
+		return r.PembayaranID == 40 && r.Alasan == "room_deleted"
+	})).Return(nil)
+	paymentRepo.On("Transition", mock.AnythingOfType("*models.Pembayaran"), models.PaymentCancelled, mock.Anything).Return(nil)
+
+	err := service.Delete(1, AuditActor{UserID: 2, Role: "admin"})
+
+	assert.NoError(t, err)
+	bookingRepo.AssertNumberOfCalls(t, "Transition", 2)
+	paymentRepo.AssertNumberOfCalls(t, "Transition", 2)
+	refundRepo.AssertNumberOfCalls(t, "Create", 1)
+	kamarRepo.AssertCalled(t, "Delete", uint(1))
+}
+
+// Test Delete - gagal membatalkan booking pending membatalkan penghapusan kamar
+func TestKamarService_Delete_RollsBackWhenCancelFails(t *testing.T) {
+	kamarRepo := new(MockKamarRepository)
+	bookingRepo := new(MockBookingRepository)
+	service := newTestKamarService(kamarRepo, bookingRepo, new(MockPaymentRepository), new(MockRefundRepository))
+
+	kamarRepo.On("FindByID", uint(1)).Return(&models.Kamar{ID: 1, NomorKamar: "A1"}, nil)
+	bookingRepo.On("FindActiveBookingByKamarID", uint(1)).Return(nil, nil)
+	bookingRepo.On("WithTx", mock.Anything).Return(bookingRepo)
+	bookingRepo.On("FindPendingBookingsByKamarID", uint(1)).Return([]models.Pemesanan{{ID: 4, KamarID: 1, StatusPemesanan: models.BookingPending}}, nil)
+	bookingRepo.On("Transition", mock.AnythingOfType("*models.Pemesanan"), models.BookingCancelled, mock.Anything).Return(errors.New("db down"))
+
+	err := service.Delete(1, AuditActor{})
+
+	assert.Error(t, err)
+	kamarRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
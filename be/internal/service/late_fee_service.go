@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"log"
+	"time"
+)
+
+const lockReasonNonPayment = "tenant_non_payment"
+
+// overdueBillTypes adalah tagihan yang punya jatuh tempo nyata. DP/full tidak termasuk
+// karena booking yang belum dibayar sudah ditangani AutoCancelExpiredBookings.
+var overdueBillTypes = []string{"extend", "installment"}
+
+type LateFeeService interface {
+	ProcessOverduePayments() error
+}
+
+type lateFeeService struct {
+	lateFeeRepo repository.LateFeeRepository
+	policyRepo  repository.PricingPolicyRepository
+	waSender    utils.WhatsAppSender
+	templates   MessageTemplateService
+}
+
+func NewLateFeeService(lateFeeRepo repository.LateFeeRepository, policyRepo repository.PricingPolicyRepository, waSender utils.WhatsAppSender, templates MessageTemplateService) LateFeeService {
+	return &lateFeeService{lateFeeRepo, policyRepo, waSender, templates}
+}
+
+// ProcessOverduePayments mencari tagihan Pending/Rejected yang sudah lewat jatuh tempo, lalu:
+//  1. menghitung ulang denda keterlambatan sebagai line item "late_fee",
+//  2. mengirim WA eskalasi ke penyewa (sekali saat pertama telat),
+//  3. mengunci kamar jika keterlambatan melewati LockGraceDays.
+//
+// Tagihan Pending yang sudah ada bukti transfernya sedang menunggu pemeriksaan admin,
+// jadi tidak dihitung telat.
+func (s *lateFeeService) ProcessOverduePayments() error {
+	today := truncateToDay(time.Now())
+
+	payments, err := s.lateFeeRepo.FindOverdueBills(today, overdueBillTypes)
+	if err != nil {
+		return err
+	}
+
+	for i := range payments {
+		if err := s.processOverduePayment(&payments[i], today); err != nil {
+			log.Printf("[WARN] Gagal memproses tagihan telat %d: %v", payments[i].ID, err)
+		}
+	}
+
+	if len(payments) > 0 {
+		log.Printf("[INFO] Memproses %d tagihan yang lewat jatuh tempo", len(payments))
+	}
+
+	return nil
+}
+
+func (s *lateFeeService) processOverduePayment(p *models.Pembayaran, today time.Time) error {
+	booking := p.Pemesanan
+	kamar := booking.Kamar
+	tenant := booking.Penyewa
+	policy := resolvePricingPolicy(s.policyRepo, kamar.TipeKamar)
+	daysOverdue := int(today.Sub(truncateToDay(p.TanggalJatuhTempo)).Hours() / 24)
+
+	// 1. Denda keterlambatan
+	fee := calculateLateFee(policy, daysOverdue)
+	if fee != p.TotalDenda {
+		if err := s.upsertLateFee(p, fee, daysOverdue); err != nil {
+			return err
+		}
+	}
+
+	// 2. Notifikasi telat bayar (sekali)
+	if p.EscalationLevel < 1 {
+		s.notifyTenant(tenant, msgPaymentOverdue, map[string]interface{}{
+			"Nama":       tenant.NamaLengkap,
+			"NomorKamar": kamar.NomorKamar,
+			"Jumlah":     p.JumlahBayar,
+			"JatuhTempo": p.TanggalJatuhTempo,
+			"Denda":      policy.LateFeeAmount,
+			"TipeDenda":  policy.LateFeeType,
+		})
+
+		if err := s.setEscalationLevel(p, 1); err != nil {
+			return err
+		}
+	}
+
+	// 3. Kunci kamar setelah masa tenggang
+	if policy.LockGraceDays > 0 && daysOverdue > policy.LockGraceDays && p.EscalationLevel < 2 {
+		if err := s.lateFeeRepo.LockKamar(kamar.ID, lockReasonNonPayment); err != nil {
+			return err
+		}
+
+		s.notifyTenant(tenant, msgRoomLocked, map[string]interface{}{
+			"Nama":       tenant.NamaLengkap,
+			"NomorKamar": kamar.NomorKamar,
+			"HariTelat":  daysOverdue,
+			"Total":      p.JumlahBayar + fee,
+		})
+
+		if err := s.setEscalationLevel(p, 2); err != nil {
+			return err
+		}
+		log.Printf("[INFO] Kamar %s dikunci karena tagihan %d telat %d hari", kamar.NomorKamar, p.ID, daysOverdue)
+	}
+
+	return nil
+}
+
+func (s *lateFeeService) upsertLateFee(p *models.Pembayaran, fee float64, daysOverdue int) error {
+	if err := s.lateFeeRepo.UpsertLateFee(p.ID, fee, fmt.Sprintf("Denda keterlambatan %d hari", daysOverdue)); err != nil {
+		return err
+	}
+	p.TotalDenda = fee
+	return nil
+}
+
+func (s *lateFeeService) setEscalationLevel(p *models.Pembayaran, level int) error {
+	if err := s.lateFeeRepo.SetEscalationLevel(p.ID, level); err != nil {
+		return err
+	}
+	p.EscalationLevel = level
+	return nil
+}
+
+func (s *lateFeeService) notifyTenant(tenant models.Penyewa, event string, data map[string]interface{}) {
+	if tenant.NomorHP == "" {
+		return
+	}
+	go func() {
+		if err := sendTemplatedWhatsApp(s.waSender, s.templates, tenant.NomorHP, event, tenant.Bahasa, data); err != nil {
+			log.Printf("[WARN] Gagal mengirim WA keterlambatan ke %s: %v", tenant.NomorHP, err)
+		}
+	}()
+}
+
+// calculateLateFee: daily = nominal x hari telat, flat = nominal sekali.
+func calculateLateFee(policy models.PricingPolicy, daysOverdue int) float64 {
+	if daysOverdue <= 0 || policy.LateFeeAmount <= 0 {
+		return 0
+	}
+	if policy.LateFeeType == "flat" {
+		return policy.LateFeeAmount
+	}
+	return policy.LateFeeAmount * float64(daysOverdue)
+}
+
+// unlockKamarIfSettled membuka kunci kamar (tenant_non_payment) jika sudah tidak ada lagi
+// tagihan telat yang menyebabkan penguncian pada kamar tersebut.
+func unlockKamarIfSettled(kamarRepo repository.KamarRepository, lateFeeRepo repository.LateFeeRepository, kamarID uint) error {
+	kamar, err := kamarRepo.FindByID(kamarID)
+	if err != nil {
+		return err
+	}
+	if !kamar.IsLocked || kamar.LockedReason != lockReasonNonPayment {
+		return nil
+	}
+
+	stillOverdue, err := lateFeeRepo.CountEscalatedBills(kamarID, 2)
+	if err != nil {
+		return err
+	}
+	if stillOverdue > 0 {
+		return nil
+	}
+
+	return lateFeeRepo.UnlockKamar(kamarID)
+}
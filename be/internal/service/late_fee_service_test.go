@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Test calculateLateFee - denda harian vs flat
+func TestCalculateLateFee(t *testing.T) {
+	daily := models.PricingPolicy{LateFeeType: "daily", LateFeeAmount: 10000}
+	flat := models.PricingPolicy{LateFeeType: "flat", LateFeeAmount: 50000}
+
+	assert.Equal(t, float64(0), calculateLateFee(daily, 0))
+	assert.Equal(t, float64(50000), calculateLateFee(daily, 5))
+	assert.Equal(t, float64(50000), calculateLateFee(flat, 1))
+	assert.Equal(t, float64(50000), calculateLateFee(flat, 30))
+	assert.Equal(t, float64(0), calculateLateFee(models.PricingPolicy{LateFeeType: "daily"}, 10)) // tanpa denda
+}
+
+func overdueBill(id uint, daysOverdue int, escalation int, denda float64) models.Pembayaran {
+	return models.Pembayaran{
+		ID:                id,
+		JumlahBayar:       1500000,
+		StatusPembayaran:  models.PaymentPending,
+		TipePembayaran:    "extend",
+		TanggalJatuhTempo: time.Now().AddDate(0, 0, -daysOverdue),
+		EscalationLevel:   escalation,
+		TotalDenda:        denda,
+		Pemesanan: models.Pemesanan{
+			KamarID: 3,
+			Kamar:   models.Kamar{ID: 3, NomorKamar: "B3", TipeKamar: "Standard"},
+		},
+	}
+}
+
+func newTestLateFeeService(lateFeeRepo *MockLateFeeRepository) LateFeeService {
+	policyRepo := new(MockPricingPolicyRepository)
+	policyRepo.On("FindByTipeKamar", "Standard").Return(&models.PricingPolicy{
+		TipeKamar: "Standard", LateFeeType: "daily", LateFeeAmount: 10000, LockGraceDays: 7,
+	}, nil)
+	return NewLateFeeService(lateFeeRepo, policyRepo, new(MockWhatsAppSender), nil)
+}
+
+// Test ProcessOverduePayments - tagihan telat melewati masa tenggang: denda, eskalasi, lalu kamar dikunci
+func TestLateFeeService_ProcessOverduePayments_LocksAfterGrace(t *testing.T) {
+	lateFeeRepo := new(MockLateFeeRepository)
+	service := newTestLateFeeService(lateFeeRepo)
+
+	lateFeeRepo.On("FindOverdueBills", mock.AnythingOfType("time.Time"), overdueBillTypes).
+		Return([]models.Pembayaran{overdueBill(10, 10, 0, 0)}, nil)
+	lateFeeRepo.On("UpsertLateFee", uint(10), float64(100000), "Denda keterlambatan 10 hari").Return(nil)
+	lateFeeRepo.On("SetEscalationLevel", uint(10), 1).Return(nil)
+	lateFeeRepo.On("LockKamar", uint(3), lockReasonNonPayment).Return(nil)
+	lateFeeRepo.On("SetEscalationLevel", uint(10), 2).Return(nil)
+
+	err := service.ProcessOverduePayments()
+
+	assert.NoError(t, err)
+	lateFeeRepo.AssertExpectations(t)
+}
+
+// Test ProcessOverduePayments - denda tidak berubah dan sudah dieskalasi: tidak ada yang ditulis ulang
+func TestLateFeeService_ProcessOverduePayments_AlreadyEscalated(t *testing.T) {
+	lateFeeRepo := new(MockLateFeeRepository)
+	service := newTestLateFeeService(lateFeeRepo)
+
+	lateFeeRepo.On("FindOverdueBills", mock.AnythingOfType("time.Time"), overdueBillTypes).
+		Return([]models.Pembayaran{overdueBill(10, 10, 2, 100000)}, nil)
+
+	err := service.ProcessOverduePayments()
+
+	assert.NoError(t, err)
+	lateFeeRepo.AssertNotCalled(t, "UpsertLateFee", mock.Anything, mock.Anything, mock.Anything)
+	lateFeeRepo.AssertNotCalled(t, "SetEscalationLevel", mock.Anything, mock.Anything)
+	lateFeeRepo.AssertNotCalled(t, "LockKamar", mock.Anything, mock.Anything)
+}
+
+// Test ProcessOverduePayments - gagal menyimpan denda tidak menghentikan tagihan lain
+func TestLateFeeService_ProcessOverduePayments_ContinuesAfterFailure(t *testing.T) {
+	lateFeeRepo := new(MockLateFeeRepository)
+	service := newTestLateFeeService(lateFeeRepo)
+
+	lateFeeRepo.On("FindOverdueBills", mock.AnythingOfType("time.Time"), overdueBillTypes).
+		Return([]models.Pembayaran{overdueBill(10, 2, 0, 0), overdueBill(11, 2, 1, 0)}, nil)
+	lateFeeRepo.On("UpsertLateFee", uint(10), float64(20000), mock.Anything).Return(errors.New("db down"))
+	lateFeeRepo.On("UpsertLateFee", uint(11), float64(20000), mock.Anything).Return(nil)
+
+	err := service.ProcessOverduePayments()
+
+	assert.NoError(t, err)
+	lateFeeRepo.AssertCalled(t, "UpsertLateFee", uint(11), float64(20000), mock.Anything)
+	lateFeeRepo.AssertNotCalled(t, "SetEscalationLevel", uint(10), mock.Anything)
+}
+
+// Test ProcessOverduePayments - error query diteruskan ke pemanggil
+func TestLateFeeService_ProcessOverduePayments_QueryError(t *testing.T) {
+	lateFeeRepo := new(MockLateFeeRepository)
+	service := newTestLateFeeService(lateFeeRepo)
+
+	lateFeeRepo.On("FindOverdueBills", mock.AnythingOfType("time.Time"), overdueBillTypes).Return(nil, errors.New("db down"))
+
+	assert.Error(t, service.ProcessOverduePayments())
+}
+
+// Test upsertLateFee - TotalDenda hanya diperbarui jika tersimpan
+func TestLateFeeService_UpsertLateFee(t *testing.T) {
+	lateFeeRepo := new(MockLateFeeRepository)
+	service := &lateFeeService{lateFeeRepo: lateFeeRepo}
+
+	lateFeeRepo.On("UpsertLateFee", uint(10), float64(30000), "Denda keterlambatan 3 hari").Return(nil).Once()
+	p := &models.Pembayaran{ID: 10}
+	assert.NoError(t, service.upsertLateFee(p, 30000, 3))
+	assert.Equal(t, float64(30000), p.TotalDenda)
+
+	lateFeeRepo.On("UpsertLateFee", uint(10), float64(40000), "Denda keterlambatan 4 hari").Return(errors.New("db down")).Once()
+	assert.Error(t, service.upsertLateFee(p, 40000, 4))
+	assert.Equal(t, float64(30000), p.TotalDenda)
+}
+
+// Test unlockKamarIfSettled - kunci hanya dibuka jika tidak ada lagi tagihan tereskalasi
+func TestUnlockKamarIfSettled(t *testing.T) {
+	t.Run("not locked for non-payment", func(t *testing.T) {
+		kamarRepo := new(MockKamarRepository)
+		lateFeeRepo := new(MockLateFeeRepository)
+		kamarRepo.On("FindByID", uint(3)).Return(&models.Kamar{ID: 3, IsLocked: true, LockedReason: "maintenance"}, nil)
+
+		assert.NoError(t, unlockKamarIfSettled(kamarRepo, lateFeeRepo, 3))
+		lateFeeRepo.AssertNotCalled(t, "CountEscalatedBills", mock.Anything, mock.Anything)
+		lateFeeRepo.AssertNotCalled(t, "UnlockKamar", mock.Anything)
+	})
+
+	t.Run("other bills still overdue", func(t *testing.T) {
+		kamarRepo := new(MockKamarRepository)
+		lateFeeRepo := new(MockLateFeeRepository)
+		kamarRepo.On("FindByID", uint(3)).Return(&models.Kamar{ID: 3, IsLocked: true, LockedReason: lockReasonNonPayment}, nil)
+		lateFeeRepo.On("CountEscalatedBills", uint(3), 2).Return(int64(1), nil)
+
+		assert.NoError(t, unlockKamarIfSettled(kamarRepo, lateFeeRepo, 3))
+		lateFeeRepo.AssertNotCalled(t, "UnlockKamar", mock.Anything)
+	})
+
+	t.Run("settled", func(t *testing.T) {
+		kamarRepo := new(MockKamarRepository)
+		lateFeeRepo := new(MockLateFeeRepository)
+		kamarRepo.On("FindByID", uint(3)).Return(&models.Kamar{ID: 3, IsLocked: true, LockedReason: lockReasonNonPayment}, nil)
+		lateFeeRepo.On("CountEscalatedBills", uint(3), 2).Return(int64(0), nil)
+		lateFeeRepo.On("UnlockKamar", uint(3)).Return(nil)
+
+		assert.NoError(t, unlockKamarIfSettled(kamarRepo, lateFeeRepo, 3))
+		lateFeeRepo.AssertCalled(t, "UnlockKamar", uint(3))
+	})
+}
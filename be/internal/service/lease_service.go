@@ -0,0 +1,241 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Alasan check-out yang disimpan di Pemesanan.AlasanCheckOut
+const (
+	checkOutByAdmin      = "check_out"
+	checkOutLeaseExpired = "lease_expired"
+)
+
+// Kondisi kamar saat check-out; selain "baik" kamar masuk Maintenance sampai diperbaiki
+var validKondisiKamar = map[string]bool{"baik": true, "perlu_perbaikan": true, "rusak": true}
+
+type CheckInInput struct {
+	Tanggal         time.Time // zero: hari ini
+	KunciDiserahkan bool
+	Catatan         string
+}
+
+type CheckOutInput struct {
+	Tanggal      time.Time // zero: hari ini
+	KondisiKamar string
+	Catatan      string
+}
+
+type LeaseService interface {
+	CheckIn(bookingID uint, input CheckInInput, actor AuditActor) (*models.Pemesanan, error)
+	CheckOut(bookingID uint, input CheckOutInput, actor AuditActor) (*models.Pemesanan, error)
+	ProcessExpiredLeases() (int, error)
+}
+
+type leaseService struct {
+	bookingRepo repository.BookingRepository
+	kamarRepo   repository.KamarRepository
+	penyewaRepo repository.PenyewaRepository
+	audit       AuditService
+	db          *gorm.DB
+}
+
+func NewLeaseService(bookingRepo repository.BookingRepository, kamarRepo repository.KamarRepository, penyewaRepo repository.PenyewaRepository, audit AuditService, db *gorm.DB) LeaseService {
+	return &leaseService{bookingRepo, kamarRepo, penyewaRepo, audit, db}
+}
+
+// CheckIn mencatat tanggal masuk sebenarnya dan serah terima kunci. Status booking tidak
+// berubah (tetap Confirmed/Partially Paid); kamar ditandai Penuh.
+func (s *leaseService) CheckIn(bookingID uint, input CheckInInput, actor AuditActor) (*models.Pemesanan, error) {
+	booking, err := s.bookingRepo.FindByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !booking.StatusPemesanan.IsLeaseActive() {
+		return nil, fmt.Errorf("booking dengan status %s tidak dapat check-in", booking.StatusPemesanan)
+	}
+	if !booking.TanggalCheckIn.IsZero() {
+		return nil, fmt.Errorf("booking sudah check-in pada %s", booking.TanggalCheckIn.Format("02 January 2006"))
+	}
+
+	tanggal := input.Tanggal
+	if tanggal.IsZero() {
+		tanggal = time.Now()
+	}
+	if !booking.TanggalKeluar.IsZero() && !tanggal.Before(booking.TanggalKeluar) {
+		return nil, fmt.Errorf("tanggal check-in harus sebelum tanggal keluar %s", booking.TanggalKeluar.Format("02 January 2006"))
+	}
+
+	before := *booking
+	booking.TanggalCheckIn = tanggal
+	booking.KunciDiserahkan = input.KunciDiserahkan
+	booking.CatatanCheckIn = input.Catatan
+	if err := s.bookingRepo.UpdateLease(booking); err != nil {
+		return nil, err
+	}
+
+	if booking.Kamar.Status != "Maintenance" && booking.Kamar.Status != "Penuh" {
+		if err := s.kamarRepo.UpdateStatus(booking.KamarID, "Penuh"); err != nil {
+			log.Printf("[WARN] Gagal menandai kamar %d Penuh saat check-in booking %d: %v", booking.KamarID, booking.ID, err)
+		}
+	}
+
+	recordAudit(s.audit, actor, "booking.check_in", "booking", booking.ID, before, *booking)
+	return booking, nil
+}
+
+// CheckOut mencatat tanggal keluar sebenarnya dan kondisi kamar, memindahkan booking ke
+// Checked Out, membebaskan kamar dan menjadikan penyewa former_tenant jika tidak punya sewa
+// aktif lain. Booking yang sudah di-check-out otomatis oleh job harian boleh di-check-out
+// ulang untuk mencatat hasil pemeriksaan kamar.
+func (s *leaseService) CheckOut(bookingID uint, input CheckOutInput, actor AuditActor) (*models.Pemesanan, error) {
+	if !validKondisiKamar[input.KondisiKamar] {
+		return nil, fmt.Errorf("kondisi_kamar harus salah satu dari: baik, perlu_perbaikan, rusak")
+	}
+
+	booking, err := s.bookingRepo.FindByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if booking.StatusPemesanan == models.BookingCheckedOut && booking.KondisiKamar != "" {
+		return nil, fmt.Errorf("booking sudah check-out pada %s", booking.TanggalCheckOut.Format("02 January 2006"))
+	}
+
+	tanggal := input.Tanggal
+	if tanggal.IsZero() {
+		tanggal = time.Now()
+	}
+	if !booking.TanggalCheckIn.IsZero() && tanggal.Before(truncateToDay(booking.TanggalCheckIn)) {
+		return nil, fmt.Errorf("tanggal check-out tidak boleh sebelum tanggal check-in")
+	}
+
+	before := *booking
+	if booking.StatusPemesanan != models.BookingCheckedOut {
+		booking.TanggalCheckOut = tanggal
+		booking.AlasanCheckOut = checkOutByAdmin
+	}
+	booking.KondisiKamar = input.KondisiKamar
+	booking.CatatanCheckOut = input.Catatan
+
+	if err := s.finishLease(booking, actor.statusChange(checkOutByAdmin)); err != nil {
+		return nil, err
+	}
+
+	recordAudit(s.audit, actor, "booking.check_out", "booking", booking.ID, before, *booking)
+	return booking, nil
+}
+
+// ProcessExpiredLeases dijalankan harian: sewa aktif yang TanggalKeluar-nya sudah lewat
+// di-check-out otomatis (kondisi kamar dicatat belakangan lewat CheckOut). Sewa yang masih
+// punya tagihan perpanjangan terbuka dilewati karena Checked Out tidak bisa diaktifkan lagi;
+// tagihan yang tidak dibayar ditangani denda keterlambatan sampai penyewa membayar atau
+// di-check-out oleh admin.
+func (s *leaseService) ProcessExpiredLeases() (int, error) {
+	today := truncateToDay(time.Now())
+	bookings, err := s.bookingRepo.FindEndedLeases(today)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range bookings {
+		booking := &bookings[i]
+		if hasOpenExtendPayment(booking) {
+			continue
+		}
+		before := *booking
+		booking.TanggalCheckOut = booking.TanggalKeluar
+		booking.AlasanCheckOut = checkOutLeaseExpired
+
+		if err := s.finishLease(booking, SystemActor.statusChange(checkOutLeaseExpired)); err != nil {
+			log.Printf("[WARN] Gagal check-out otomatis booking %d: %v", booking.ID, err)
+			continue
+		}
+		recordAudit(s.audit, SystemActor, "booking.lease_expired", "booking", booking.ID, before, *booking)
+		processed++
+	}
+	return processed, nil
+}
+
+// hasOpenExtendPayment: tagihan perpanjangan yang belum dibayar atau menunggu konfirmasi admin
+func hasOpenExtendPayment(booking *models.Pemesanan) bool {
+	for _, p := range booking.Pembayaran {
+		if p.TipePembayaran != "extend" {
+			continue
+		}
+		if p.StatusPembayaran == models.PaymentPending || p.StatusPembayaran == models.PaymentAwaitingConfirmation {
+			return true
+		}
+	}
+	return false
+}
+
+// finishLease memindahkan booking ke Checked Out dan menyimpan data check-out dalam satu
+// transaksi, lalu membebaskan kamar dan memperbarui role penyewa.
+func (s *leaseService) finishLease(booking *models.Pemesanan, change models.StatusChange) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		txRepo := s.bookingRepo.WithTx(tx)
+		if err := txRepo.Transition(booking, models.BookingCheckedOut, change); err != nil {
+			return err
+		}
+		return txRepo.UpdateLease(booking)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.releaseKamar(booking)
+	s.demoteFormerTenant(booking.PenyewaID)
+	return nil
+}
+
+// releaseKamar: kamar kembali Tersedia (atau Maintenance jika kondisinya tidak baik),
+// kecuali sudah ada booking lain yang menempatinya hari ini.
+func (s *leaseService) releaseKamar(booking *models.Pemesanan) {
+	today := truncateToDay(time.Now())
+	occupying, err := s.bookingRepo.FindOverlappingBookings(booking.KamarID, today, today.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("[WARN] Gagal memeriksa okupansi kamar %d setelah check-out: %v", booking.KamarID, err)
+		return
+	}
+	if len(occupying) > 0 {
+		return
+	}
+
+	if booking.KondisiKamar != "" && booking.KondisiKamar != "baik" {
+		// Dicatat sebagai penyebab agar tiket perbaikan yang selesai tidak mengaktifkan kamar ini
+		err = s.kamarRepo.SetMaintenance(booking.KamarID, kamarMaintenanceCheckOut)
+	} else if booking.Kamar.Status == "Maintenance" {
+		return
+	} else {
+		err = s.kamarRepo.UpdateStatus(booking.KamarID, "Tersedia")
+	}
+	if err != nil {
+		log.Printf("[WARN] Gagal memperbarui status kamar %d setelah check-out: %v", booking.KamarID, err)
+	}
+}
+
+// demoteFormerTenant menjadikan penyewa former_tenant jika tidak ada sewa aktif lain
+func (s *leaseService) demoteFormerTenant(penyewaID uint) {
+	bookings, err := s.bookingRepo.FindByPenyewaID(penyewaID)
+	if err != nil {
+		log.Printf("[WARN] Gagal memeriksa sewa penyewa %d: %v", penyewaID, err)
+		return
+	}
+	for _, b := range bookings {
+		if b.StatusPemesanan.IsLeaseActive() {
+			return
+		}
+	}
+
+	if err := s.penyewaRepo.UpdateRole(penyewaID, "former_tenant"); err != nil {
+		log.Printf("[WARN] Gagal memperbarui role penyewa %d ke former_tenant: %v", penyewaID, err)
+	}
+}
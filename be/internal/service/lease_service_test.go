@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Test CheckOut - booking Checked Out, kamar Tersedia, penyewa tanpa sewa lain jadi former_tenant
+func TestLeaseService_CheckOut_FreesRoomAndDemotesTenant(t *testing.T) {
+	bookingRepo := new(MockBookingRepository)
+	kamarRepo := new(MockKamarRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	service := NewLeaseService(bookingRepo, kamarRepo, penyewaRepo, nil, newTxStubDB())
+
+	booking := &models.Pemesanan{ID: 4, PenyewaID: 2, KamarID: 7, StatusPemesanan: models.BookingConfirmed,
+		Kamar: models.Kamar{ID: 7, Status: "Penuh"}}
+	bookingRepo.On("FindByID", uint(4)).Return(booking, nil)
+	bookingRepo.On("WithTx", mock.Anything).Return(bookingRepo)
+	bookingRepo.On("Transition", booking, models.BookingCheckedOut, mock.Anything).Return(nil)
+	bookingRepo.On("UpdateLease", booking).Return(nil)
+	bookingRepo.On("FindOverlappingBookings", uint(7), mock.Anything, mock.Anything).Return([]models.Pemesanan{}, nil)
+	bookingRepo.On("FindByPenyewaID", uint(2)).Return([]models.Pemesanan{{ID: 4, StatusPemesanan: models.BookingCheckedOut}}, nil)
+	kamarRepo.On("UpdateStatus", uint(7), "Tersedia").Return(nil)
+	penyewaRepo.On("UpdateRole", uint(2), "former_tenant").Return(nil)
+
+	result, err := service.CheckOut(4, CheckOutInput{KondisiKamar: "baik"}, AuditActor{UserID: 1, Role: "caretaker"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.BookingCheckedOut, result.StatusPemesanan)
+	assert.Equal(t, "check_out", result.AlasanCheckOut)
+	assert.False(t, result.TanggalCheckOut.IsZero())
+	kamarRepo.AssertExpectations(t)
+	penyewaRepo.AssertExpectations(t)
+}
+
+// Test CheckOut - kamar rusak masuk Maintenance
+func TestLeaseService_CheckOut_DamagedRoomGoesToMaintenance(t *testing.T) {
+	bookingRepo := new(MockBookingRepository)
+	kamarRepo := new(MockKamarRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	service := NewLeaseService(bookingRepo, kamarRepo, penyewaRepo, nil, newTxStubDB())
+
+	booking := &models.Pemesanan{ID: 4, PenyewaID: 2, KamarID: 7, StatusPemesanan: models.BookingConfirmed}
+	other := models.Pemesanan{ID: 5, PenyewaID: 2, KamarID: 8, StatusPemesanan: models.BookingConfirmed}
+	bookingRepo.On("FindByID", uint(4)).Return(booking, nil)
+	bookingRepo.On("WithTx", mock.Anything).Return(bookingRepo)
+	bookingRepo.On("Transition", booking, models.BookingCheckedOut, mock.Anything).Return(nil)
+	bookingRepo.On("UpdateLease", booking).Return(nil)
+	bookingRepo.On("FindOverlappingBookings", uint(7), mock.Anything, mock.Anything).Return([]models.Pemesanan{}, nil)
+	bookingRepo.On("FindByPenyewaID", uint(2)).Return([]models.Pemesanan{other}, nil)
+	kamarRepo.On("SetMaintenance", uint(7), "check_out").Return(nil)
+
+	_, err := service.CheckOut(4, CheckOutInput{KondisiKamar: "rusak", Catatan: "Kaca jendela pecah"}, AuditActor{})
+
+	assert.NoError(t, err)
+	kamarRepo.AssertExpectations(t)
+	// Masih punya sewa aktif di kamar lain: role tidak berubah
+	penyewaRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+}
+
+// Test CheckIn - booking yang belum dibayar tidak bisa check-in
+func TestLeaseService_CheckIn_RequiresPaidBooking(t *testing.T) {
+	bookingRepo := new(MockBookingRepository)
+	service := NewLeaseService(bookingRepo, new(MockKamarRepository), new(MockPenyewaRepository), nil, newTxStubDB())
+
+	bookingRepo.On("FindByID", uint(4)).Return(&models.Pemesanan{ID: 4, StatusPemesanan: models.BookingPending}, nil)
+
+	_, err := service.CheckIn(4, CheckInInput{KunciDiserahkan: true}, AuditActor{})
+
+	assert.Error(t, err)
+	bookingRepo.AssertNotCalled(t, "UpdateLease", mock.Anything)
+}
+
+// Test ProcessExpiredLeases - sewa lewat TanggalKeluar di-check-out otomatis oleh sistem
+func TestLeaseService_ProcessExpiredLeases(t *testing.T) {
+	bookingRepo := new(MockBookingRepository)
+	kamarRepo := new(MockKamarRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	service := NewLeaseService(bookingRepo, kamarRepo, penyewaRepo, nil, newTxStubDB())
+
+	keluar := truncateToDay(time.Now()).AddDate(0, 0, -1)
+	expired := models.Pemesanan{ID: 9, PenyewaID: 3, KamarID: 2, StatusPemesanan: models.BookingConfirmed, TanggalKeluar: keluar}
+	bookingRepo.On("FindEndedLeases", truncateToDay(time.Now())).Return([]models.Pemesanan{expired}, nil)
+	bookingRepo.On("WithTx", mock.Anything).Return(bookingRepo)
+	bookingRepo.On("Transition", mock.AnythingOfType("*models.Pemesanan"), models.BookingCheckedOut,
+		models.StatusChange{ChangedByRole: "system", Reason: "lease_expired"}).Return(nil)
+	bookingRepo.On("UpdateLease", mock.AnythingOfType("*models.Pemesanan")).Return(nil)
+	bookingRepo.On("FindOverlappingBookings", uint(2), mock.Anything, mock.Anything).Return([]models.Pemesanan{}, nil)
+	bookingRepo.On("FindByPenyewaID", uint(3)).Return([]models.Pemesanan{{ID: 9, StatusPemesanan: models.BookingCheckedOut}}, nil)
+	kamarRepo.On("UpdateStatus", uint(2), "Tersedia").Return(nil)
+	penyewaRepo.On("UpdateRole", uint(3), "former_tenant").Return(nil)
+
+	processed, err := service.ProcessExpiredLeases()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	saved := bookingRepo.Calls[3].Arguments.Get(0).(*models.Pemesanan)
+	assert.Equal(t, keluar, saved.TanggalCheckOut)
+	assert.Equal(t, "lease_expired", saved.AlasanCheckOut)
+	kamarRepo.AssertExpectations(t)
+	penyewaRepo.AssertExpectations(t)
+}
+
+// Test ProcessExpiredLeases - sewa dengan tagihan perpanjangan terbuka tidak di-check-out
+func TestLeaseService_ProcessExpiredLeases_SkipsOpenExtension(t *testing.T) {
+	bookingRepo := new(MockBookingRepository)
+	service := NewLeaseService(bookingRepo, new(MockKamarRepository), new(MockPenyewaRepository), nil, newTxStubDB())
+
+	keluar := truncateToDay(time.Now()).AddDate(0, 0, -1)
+	bookingRepo.On("FindEndedLeases", truncateToDay(time.Now())).Return([]models.Pemesanan{
+		{ID: 9, KamarID: 2, StatusPemesanan: models.BookingConfirmed, TanggalKeluar: keluar,
+			Pembayaran: []models.Pembayaran{{ID: 31, TipePembayaran: "extend", StatusPembayaran: models.PaymentAwaitingConfirmation}}},
+		{ID: 10, KamarID: 3, StatusPemesanan: models.BookingConfirmed, TanggalKeluar: keluar,
+			Pembayaran: []models.Pembayaran{{ID: 32, TipePembayaran: "extend", StatusPembayaran: models.PaymentPending}}},
+	}, nil)
+
+	processed, err := service.ProcessExpiredLeases()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, processed)
+	bookingRepo.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test CheckOut - gagal menyimpan data check-out membatalkan perpindahan status
+func TestLeaseService_CheckOut_UpdateLeaseFailure(t *testing.T) {
+	bookingRepo := new(MockBookingRepository)
+	kamarRepo := new(MockKamarRepository)
+	service := NewLeaseService(bookingRepo, kamarRepo, new(MockPenyewaRepository), nil, newTxStubDB())
+
+	booking := &models.Pemesanan{ID: 4, PenyewaID: 2, KamarID: 7, StatusPemesanan: models.BookingConfirmed}
+	bookingRepo.On("FindByID", uint(4)).Return(booking, nil)
+	bookingRepo.On("WithTx", mock.Anything).Return(bookingRepo)
+	bookingRepo.On("Transition", booking, models.BookingCheckedOut, mock.Anything).Return(nil)
+	bookingRepo.On("UpdateLease", booking).Return(errors.New("db down"))
+
+	_, err := service.CheckOut(4, CheckOutInput{KondisiKamar: "baik"}, AuditActor{})
+
+	assert.EqualError(t, err, "db down")
+	kamarRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"sort"
+	"time"
+)
+
+// LedgerEntry adalah satu baris buku besar. Debit menambah tagihan penyewa
+// (sewa, denda, refund yang dikembalikan), Kredit menguranginya (pembayaran).
+type LedgerEntry struct {
+	Tanggal      time.Time `json:"tanggal"`
+	Tipe         string    `json:"tipe"` // enum: charge, late_fee, payment, refund, adjustment
+	Keterangan   string    `json:"keterangan"`
+	Debit        float64   `json:"debit"`
+	Kredit       float64   `json:"kredit"`
+	Saldo        float64   `json:"saldo"` // saldo berjalan; positif = penyewa masih berutang
+	PembayaranID uint      `json:"pembayaran_id,omitempty"`
+}
+
+type BookingLedger struct {
+	PemesananID   uint          `json:"pemesanan_id"`
+	NomorKamar    string        `json:"nomor_kamar"`
+	Entries       []LedgerEntry `json:"entries"`
+	TotalCharges  float64       `json:"total_charges"`
+	TotalPayments float64       `json:"total_payments"`
+	TotalRefunds  float64       `json:"total_refunds"`
+	Outstanding   float64       `json:"outstanding"` // saldo akhir
+	PaidUntil     time.Time     `json:"paid_until"`  // sewa sudah tertutup pembayaran sampai tanggal ini
+}
+
+type LedgerService interface {
+	GetBookingLedger(bookingID uint, userID uint, role string) (*BookingLedger, error)
+	AddAdjustment(bookingID uint, jumlah float64, keterangan string, actor AuditActor) (*models.LedgerAdjustment, error)
+}
+
+type ledgerService struct {
+	repo        repository.LedgerRepository
+	penyewaRepo repository.PenyewaRepository
+	audit       AuditService
+}
+
+func NewLedgerService(repo repository.LedgerRepository, penyewaRepo repository.PenyewaRepository, audit AuditService) LedgerService {
+	return &ledgerService{repo, penyewaRepo, audit}
+}
+
+// GetBookingLedger mengembalikan buku besar booking. Selain staff dengan payments:read, hanya pemilik booking yang boleh melihat.
+func (s *ledgerService) GetBookingLedger(bookingID uint, userID uint, role string) (*BookingLedger, error) {
+	booking, err := s.repo.FindBookingWithPayments(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.RoleHasPermission(role, utils.PermPaymentsRead) {
+		penyewa, err := s.penyewaRepo.FindByUserID(userID)
+		if err != nil || booking.PenyewaID != penyewa.ID {
+			return nil, fmt.Errorf("unauthorized: you can only view your own bookings")
+		}
+	}
+
+	ledgers, err := loadLedgers(s.repo, []models.Pemesanan{*booking})
+	if err != nil {
+		return nil, err
+	}
+	ledger := ledgers[booking.ID]
+	return &ledger, nil
+}
+
+func (s *ledgerService) AddAdjustment(bookingID uint, jumlah float64, keterangan string, actor AuditActor) (*models.LedgerAdjustment, error) {
+	if jumlah == 0 {
+		return nil, fmt.Errorf("jumlah koreksi tidak boleh 0")
+	}
+	if keterangan == "" {
+		return nil, fmt.Errorf("keterangan koreksi wajib diisi")
+	}
+	if _, err := s.repo.FindBookingWithPayments(bookingID); err != nil {
+		return nil, err
+	}
+
+	adjustment := &models.LedgerAdjustment{
+		PemesananID: bookingID,
+		Jumlah:      jumlah,
+		Keterangan:  keterangan,
+		CreatedBy:   actor.UserID,
+	}
+	if err := s.repo.CreateAdjustment(adjustment); err != nil {
+		return nil, err
+	}
+	recordAudit(s.audit, actor, "ledger.adjustment", "booking", bookingID, nil, adjustment)
+	return adjustment, nil
+}
+
+// loadLedgers membangun buku besar untuk beberapa booking sekaligus. Booking harus sudah
+// memuat Kamar dan Pembayaran.LineItems.
+func loadLedgers(repo repository.LedgerRepository, bookings []models.Pemesanan) (map[uint]BookingLedger, error) {
+	ids := make([]uint, 0, len(bookings))
+	for _, b := range bookings {
+		ids = append(ids, b.ID)
+	}
+
+	refunds, err := repo.FindPaidRefunds(ids)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := repo.FindAdjustments(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	refundsByBooking := make(map[uint][]models.Refund)
+	for _, r := range refunds {
+		refundsByBooking[r.PemesananID] = append(refundsByBooking[r.PemesananID], r)
+	}
+	adjustmentsByBooking := make(map[uint][]models.LedgerAdjustment)
+	for _, a := range adjustments {
+		adjustmentsByBooking[a.PemesananID] = append(adjustmentsByBooking[a.PemesananID], a)
+	}
+
+	ledgers := make(map[uint]BookingLedger, len(bookings))
+	for _, b := range bookings {
+		ledgers[b.ID] = buildLedger(b, refundsByBooking[b.ID], adjustmentsByBooking[b.ID])
+	}
+	return ledgers, nil
+}
+
+// ledgerTypeOrder: pada tanggal yang sama tagihan dicatat sebelum pembayaran
+var ledgerTypeOrder = map[string]int{"charge": 0, "late_fee": 1, "adjustment": 2, "refund": 3, "payment": 4}
+
+// buildLedger menurunkan buku besar dari data sumber:
+//   - charge: sewa per bulan selama DurasiSewa (kecuali booking Cancelled) dan tagihan extend yang belum lunas
+//   - late_fee: line item denda pada tagihan yang tidak dibatalkan
+//   - payment: pembayaran Confirmed (termasuk denda yang ikut dilunasi)
+//   - refund: refund berstatus Paid
+//   - adjustment: koreksi manual admin
+//
+// Uang jaminan (deposit, deposit_charge) dan refund-nya tidak masuk buku besar sewa.
+func buildLedger(booking models.Pemesanan, refunds []models.Refund, adjustments []models.LedgerAdjustment) BookingLedger {
+	var entries []LedgerEntry
+	harga := booking.Kamar.HargaPerBulan
+
+	if booking.StatusPemesanan != models.BookingCancelled {
+		for i := 0; i < booking.DurasiSewa; i++ {
+			month := booking.TanggalMulai.AddDate(0, i, 0)
+			entries = append(entries, LedgerEntry{
+				Tanggal:    month,
+				Tipe:       "charge",
+				Keterangan: fmt.Sprintf("Sewa Kamar %s - %s", booking.Kamar.NomorKamar, formatIndonesianMonth(month)),
+				Debit:      harga,
+			})
+		}
+	}
+
+	depositPayments := make(map[uint]bool)
+	for _, p := range booking.Pembayaran {
+		if isDepositPayment(p.TipePembayaran) {
+			depositPayments[p.ID] = true
+			continue
+		}
+
+		switch p.StatusPembayaran {
+		case models.PaymentCancelled:
+			continue
+		case models.PaymentConfirmed:
+			entries = append(entries, LedgerEntry{
+				Tanggal:      paymentLedgerDate(p),
+				Tipe:         "payment",
+				Keterangan:   fmt.Sprintf("Pembayaran %s #%d", p.TipePembayaran, p.ID),
+				Kredit:       p.JumlahBayar + p.TotalDenda,
+				PembayaranID: p.ID,
+			})
+		default:
+			// Tagihan extend baru menambah DurasiSewa setelah dikonfirmasi, jadi selama
+			// belum lunas dicatat sebagai tagihan tersendiri.
+			if p.TipePembayaran == "extend" && booking.StatusPemesanan != models.BookingCancelled {
+				entries = append(entries, LedgerEntry{
+					Tanggal:      p.TanggalJatuhTempo,
+					Tipe:         "charge",
+					Keterangan:   fmt.Sprintf("Tagihan perpanjangan #%d", p.ID),
+					Debit:        p.JumlahBayar,
+					PembayaranID: p.ID,
+				})
+			}
+		}
+
+		for _, item := range p.LineItems {
+			entries = append(entries, LedgerEntry{
+				Tanggal:      item.CreatedAt,
+				Tipe:         item.Tipe,
+				Keterangan:   item.Deskripsi,
+				Debit:        item.Jumlah,
+				PembayaranID: p.ID,
+			})
+		}
+	}
+
+	for _, r := range refunds {
+		if depositPayments[r.PembayaranID] {
+			continue
+		}
+		entries = append(entries, LedgerEntry{
+			Tanggal:      r.PaidAt,
+			Tipe:         "refund",
+			Keterangan:   fmt.Sprintf("Refund pembayaran #%d", r.PembayaranID),
+			Debit:        r.JumlahRefund,
+			PembayaranID: r.PembayaranID,
+		})
+	}
+
+	for _, a := range adjustments {
+		entry := LedgerEntry{Tanggal: a.CreatedAt, Tipe: "adjustment", Keterangan: a.Keterangan}
+		if a.Jumlah > 0 {
+			entry.Debit = a.Jumlah
+		} else {
+			entry.Kredit = -a.Jumlah
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Tanggal.Equal(entries[j].Tanggal) {
+			return entries[i].Tanggal.Before(entries[j].Tanggal)
+		}
+		return ledgerTypeOrder[entries[i].Tipe] < ledgerTypeOrder[entries[j].Tipe]
+	})
+
+	ledger := BookingLedger{
+		PemesananID: booking.ID,
+		NomorKamar:  booking.Kamar.NomorKamar,
+		Entries:     entries,
+	}
+
+	var totalDebit, totalKredit float64
+	for i := range ledger.Entries {
+		e := &ledger.Entries[i]
+		totalDebit += e.Debit
+		totalKredit += e.Kredit
+		e.Saldo = totalDebit - totalKredit
+
+		switch e.Tipe {
+		case "payment":
+			ledger.TotalPayments += e.Kredit
+		case "refund":
+			ledger.TotalRefunds += e.Debit
+		case "charge", "late_fee":
+			ledger.TotalCharges += e.Debit
+		}
+	}
+	ledger.Outstanding = totalDebit - totalKredit
+	if ledger.Entries == nil {
+		ledger.Entries = []LedgerEntry{}
+	}
+
+	// Paid until: kredit dialokasikan ke tagihan secara kronologis (sewa, denda, koreksi, refund).
+	// Tagihan extend yang belum lunas dilewati karena belum termasuk DurasiSewa.
+	if harga > 0 && !booking.TanggalMulai.IsZero() {
+		pool := totalKredit
+		months := 0
+		for _, e := range ledger.Entries {
+			if e.Debit == 0 || (e.Tipe == "charge" && e.PembayaranID != 0) {
+				continue
+			}
+			if pool+0.5 < e.Debit {
+				break
+			}
+			pool -= e.Debit
+			if e.Tipe == "charge" {
+				months++
+			}
+		}
+		ledger.PaidUntil = booking.TanggalMulai.AddDate(0, months, 0)
+	}
+
+	return ledger
+}
+
+func paymentLedgerDate(p models.Pembayaran) time.Time {
+	if !p.ConfirmedAt.IsZero() {
+		return p.ConfirmedAt
+	}
+	if !p.TanggalBayar.IsZero() {
+		return p.TanggalBayar
+	}
+	return p.CreatedAt
+}
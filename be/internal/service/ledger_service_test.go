@@ -0,0 +1,70 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ledgerTestBooking(status models.BookingStatus, durasi int, payments ...models.Pembayaran) models.Pemesanan {
+	return models.Pemesanan{
+		ID:              1,
+		TanggalMulai:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+		DurasiSewa:      durasi,
+		StatusPemesanan: status,
+		Kamar:           models.Kamar{NomorKamar: "A1", HargaPerBulan: 1000000},
+		Pembayaran:      payments,
+	}
+}
+
+// Test buildLedger - DP 30% dari 3 bulan hanya menutup 0 bulan penuh
+func TestBuildLedger_DPDoesNotCoverFullMonth(t *testing.T) {
+	booking := ledgerTestBooking("Partially Paid", 3,
+		models.Pembayaran{ID: 10, JumlahBayar: 900000, StatusPembayaran: "Confirmed", TipePembayaran: "dp", ConfirmedAt: time.Date(2025, 12, 20, 0, 0, 0, 0, time.Local)},
+		models.Pembayaran{ID: 11, JumlahBayar: 2100000, StatusPembayaran: "Pending", TipePembayaran: "installment"},
+	)
+
+	ledger := buildLedger(booking, nil, nil)
+
+	assert.Equal(t, float64(3000000), ledger.TotalCharges)
+	assert.Equal(t, float64(900000), ledger.TotalPayments)
+	assert.Equal(t, float64(2100000), ledger.Outstanding)
+	assert.Equal(t, booking.TanggalMulai, ledger.PaidUntil)
+	assert.Len(t, ledger.Entries, 4) // 3 sewa + 1 pembayaran, cicilan pending bukan tagihan baru
+	assert.Equal(t, float64(-900000), ledger.Entries[0].Saldo)
+}
+
+// Test buildLedger - denda dilunasi bersama tagihan, paid until maju sesuai sewa yang tertutup
+func TestBuildLedger_LateFeeAndPaidUntil(t *testing.T) {
+	booking := ledgerTestBooking("Confirmed", 2,
+		models.Pembayaran{ID: 10, JumlahBayar: 1000000, StatusPembayaran: "Confirmed", TipePembayaran: "full", ConfirmedAt: time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)},
+		models.Pembayaran{
+			ID: 11, JumlahBayar: 1000000, TotalDenda: 20000, StatusPembayaran: "Confirmed", TipePembayaran: "extend",
+			ConfirmedAt: time.Date(2026, 2, 5, 0, 0, 0, 0, time.Local),
+			LineItems:   []models.PaymentLineItem{{Tipe: "late_fee", Jumlah: 20000, CreatedAt: time.Date(2026, 2, 3, 0, 0, 0, 0, time.Local)}},
+		},
+		models.Pembayaran{ID: 12, JumlahBayar: 1000000, StatusPembayaran: "Pending", TipePembayaran: "extend", TanggalJatuhTempo: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+	)
+
+	ledger := buildLedger(booking, nil, nil)
+
+	assert.Equal(t, float64(1000000), ledger.Outstanding) // hanya tagihan extend yang belum dibayar
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), ledger.PaidUntil)
+}
+
+// Test buildLedger - booking dibatalkan: tidak ada tagihan sewa, refund menutup pembayaran
+func TestBuildLedger_CancelledWithRefund(t *testing.T) {
+	booking := ledgerTestBooking("Cancelled", 3,
+		models.Pembayaran{ID: 10, JumlahBayar: 900000, StatusPembayaran: "Confirmed", TipePembayaran: "dp"},
+	)
+	refunds := []models.Refund{{PembayaranID: 10, PemesananID: 1, JumlahRefund: 900000, StatusRefund: "Paid"}}
+	adjustments := []models.LedgerAdjustment{{PemesananID: 1, Jumlah: 50000, Keterangan: "Biaya administrasi"}}
+
+	ledger := buildLedger(booking, refunds, adjustments)
+
+	assert.Equal(t, float64(0), ledger.TotalCharges)
+	assert.Equal(t, float64(900000), ledger.TotalRefunds)
+	assert.Equal(t, float64(50000), ledger.Outstanding)
+}
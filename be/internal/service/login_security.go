@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// Penguncian login per username: setelah loginMaxAttempts kegagalan berturut-turut akun dikunci
+// 1 menit, lalu 2, 4, ... menit untuk setiap kegagalan berikutnya (maks. 1 jam). Hitungan direset
+// setelah login berhasil atau jika kegagalan terakhir lebih dari 24 jam yang lalu.
+const (
+	loginMaxAttempts   = 5
+	loginLockoutBase   = time.Minute
+	loginLockoutMax    = time.Hour
+	loginFailureWindow = 24 * time.Hour
+)
+
+// LoginClient adalah informasi perangkat yang melakukan login
+type LoginClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+// AccountLockedError dikembalikan saat username sedang dikunci sementara
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	minutes := int(math.Ceil(time.Until(e.Until).Minutes()))
+	if minutes < 1 {
+		minutes = 1
+	}
+	return fmt.Sprintf("akun dikunci sementara karena terlalu banyak percobaan login gagal, coba lagi dalam %d menit", minutes)
+}
+
+// loginLockoutDuration mengembalikan lama penguncian setelah failedAttempts kegagalan (0 = belum dikunci)
+func loginLockoutDuration(failedAttempts int) time.Duration {
+	if failedAttempts < loginMaxAttempts {
+		return 0
+	}
+	lockout := loginLockoutBase
+	for i := loginMaxAttempts; i < failedAttempts; i++ {
+		lockout *= 2
+		if lockout >= loginLockoutMax {
+			return loginLockoutMax
+		}
+	}
+	return lockout
+}
+
+func loginThrottleKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// loginIPThrottleKey adalah key hitungan gagal untuk username yang tidak terdaftar
+func loginIPThrottleKey(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return "ip:" + ip
+}
+
+// checkLockout menolak login selama username atau IP pengirim masih dikunci
+func (s *authService) checkLockout(username string, client LoginClient) error {
+	if s.loginRepo == nil {
+		return nil
+	}
+	for _, key := range []string{loginThrottleKey(username), loginIPThrottleKey(client.IPAddress)} {
+		if key == "" {
+			continue
+		}
+		throttle, err := s.loginRepo.FindThrottle(key)
+		if err != nil || !time.Now().Before(throttle.LockedUntil) {
+			continue
+		}
+		s.recordAttempt(&models.LoginAttempt{Username: username, Method: "password", Reason: "locked"}, client)
+		return &AccountLockedError{Until: throttle.LockedUntil}
+	}
+	return nil
+}
+
+// recordLoginFailure menambah hitungan gagal (dan mengunci jika melewati batas) lalu mencatat riwayat.
+// Hitungan dinaikkan secara atomik sehingga percobaan paralel tidak saling menimpa.
+func (s *authService) recordLoginFailure(username string, userID uint, method, reason string, client LoginClient) {
+	if s.loginRepo == nil {
+		return
+	}
+	s.recordAttempt(&models.LoginAttempt{UserID: userID, Username: username, Method: method, Reason: reason}, client)
+
+	key := loginThrottleKey(username)
+	if userID == 0 {
+		key = loginIPThrottleKey(client.IPAddress)
+		if key == "" {
+			return
+		}
+	}
+
+	now := time.Now()
+	throttle, err := s.loginRepo.IncrementThrottle(key, now, loginFailureWindow)
+	if err != nil {
+		log.Printf("[WARN] Failed to update login throttle for %s: %v", key, err)
+		return
+	}
+	if lockout := loginLockoutDuration(throttle.FailedAttempts); lockout > 0 {
+		if err := s.loginRepo.LockThrottle(key, now.Add(lockout)); err != nil {
+			log.Printf("[WARN] Failed to lock login throttle for %s: %v", key, err)
+			return
+		}
+		log.Printf("[WARN] Login for %s locked for %s after %d failed attempts (last from %s)", key, lockout, throttle.FailedAttempts, client.IPAddress)
+	}
+}
+
+// recordLoginSuccess mereset hitungan gagal, mencatat riwayat dan memberi tahu user jika login
+// berasal dari perangkat (user agent) yang belum pernah berhasil login sebelumnya.
+func (s *authService) recordLoginSuccess(user *models.User, method string, client LoginClient) {
+	if s.loginRepo == nil {
+		return
+	}
+	if method == "password" {
+		if err := s.loginRepo.ResetThrottle(loginThrottleKey(user.Username)); err != nil {
+			log.Printf("[WARN] Failed to reset login throttle for %s: %v", user.Username, err)
+		}
+	}
+
+	// Login pertama kali tidak dianggap perangkat baru
+	newDevice := false
+	if total, err := s.loginRepo.CountSuccessful(user.ID); err == nil && total > 0 {
+		if fromDevice, err := s.loginRepo.CountSuccessfulFromDevice(user.ID, client.UserAgent); err == nil && fromDevice == 0 {
+			newDevice = true
+		}
+	}
+
+	s.recordAttempt(&models.LoginAttempt{UserID: user.ID, Username: user.Username, Method: method, Success: true, NewDevice: newDevice}, client)
+	if newDevice {
+		s.alertNewDevice(user, client)
+	}
+}
+
+func (s *authService) recordAttempt(attempt *models.LoginAttempt, client LoginClient) {
+	attempt.IPAddress = client.IPAddress
+	attempt.UserAgent = client.UserAgent
+	if err := s.loginRepo.CreateAttempt(attempt); err != nil {
+		log.Printf("[WARN] Failed to record login attempt for %s: %v", attempt.Username, err)
+	}
+}
+
+// alertNewDevice mengirim peringatan login via email (jika ada) atau WhatsApp
+func (s *authService) alertNewDevice(user *models.User, client LoginClient) {
+	penyewa, err := s.penyewaRepo.FindByUserID(user.ID)
+	if err != nil {
+		return
+	}
+
+	nama := penyewa.NamaLengkap
+	if nama == "" {
+		nama = user.Username
+	}
+	data := map[string]interface{}{
+		"Nama":      nama,
+		"IP":        client.IPAddress,
+		"Perangkat": client.UserAgent,
+		"Waktu":     time.Now(),
+	}
+
+	if penyewa.Email != "" && s.emailSender != nil {
+		msg, err := renderMessage(s.templates, msgLoginNewDevice, messageChannelEmail, penyewa.Bahasa, data)
+		if err == nil {
+			err = s.emailSender.SendEmail(penyewa.Email, msg.Subject, msg.Body, nil)
+		}
+		if err != nil {
+			log.Printf("[WARN] Failed to send new device alert email to user %d: %v", user.ID, err)
+		}
+		return
+	}
+
+	if phone := utils.NormalizePhoneNumber(penyewa.NomorHP); phone != "" && s.waSender != nil {
+		if err := sendTemplatedWhatsApp(s.waSender, s.templates, phone, msgLoginNewDevice, penyewa.Bahasa, data); err != nil {
+			log.Printf("[WARN] Failed to send new device alert WhatsApp to user %d: %v", user.ID, err)
+		}
+	}
+}
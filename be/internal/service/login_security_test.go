@@ -0,0 +1,186 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/config"
+	"koskosan-be/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var loginTestConfig = &config.Config{JWTSecret: "test-secret-key-32-characters-long"}
+
+var loginTestClient = LoginClient{IPAddress: "10.0.0.9", UserAgent: "Firefox"}
+
+func newLoginTestUser(password string) *models.User {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	user := &models.User{Username: "budi", Password: string(hashed), Role: "tenant"}
+	user.ID = 7
+	return user
+}
+
+// Test loginLockoutDuration - 1 menit setelah 5 kegagalan, lalu berlipat ganda sampai 1 jam
+func TestLoginLockoutDuration(t *testing.T) {
+	assert.Equal(t, time.Duration(0), loginLockoutDuration(4))
+	assert.Equal(t, time.Minute, loginLockoutDuration(5))
+	assert.Equal(t, 2*time.Minute, loginLockoutDuration(6))
+	assert.Equal(t, 8*time.Minute, loginLockoutDuration(8))
+	assert.Equal(t, time.Hour, loginLockoutDuration(20))
+}
+
+// Test Login - akun yang sedang dikunci ditolak tanpa memeriksa password
+func TestLogin_LockedAccount(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	loginRepo := new(MockLoginRepository)
+	service := NewAuthService(userRepo, new(MockPenyewaRepository), loginTestConfig, nil, nil, nil, loginRepo, nil, nil, nil)
+
+	until := time.Now().Add(4 * time.Minute)
+	loginRepo.On("FindThrottle", "budi").Return(&models.LoginThrottle{Username: "budi", FailedAttempts: 7, LockedUntil: until}, nil)
+	loginRepo.On("CreateAttempt", mock.MatchedBy(func(a *models.LoginAttempt) bool {
+		return a.Reason == "locked" && !a.Success && a.IPAddress == "10.0.0.9"
+	})).Return(nil)
+
+	_, err := service.Login("Budi", "whatever", loginTestClient)
+
+	var locked *AccountLockedError
+	assert.True(t, errors.As(err, &locked))
+	assert.Equal(t, until, locked.Until)
+	assert.Contains(t, err.Error(), "4 menit")
+	userRepo.AssertNotCalled(t, "FindByUsername", mock.Anything)
+	loginRepo.AssertExpectations(t)
+}
+
+// Test Login - kegagalan ke-5 mengunci akun selama 1 menit
+func TestLogin_WrongPasswordLocksAfterMaxAttempts(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	loginRepo := new(MockLoginRepository)
+	service := NewAuthService(userRepo, new(MockPenyewaRepository), loginTestConfig, nil, nil, nil, loginRepo, nil, nil, nil)
+
+	userRepo.On("FindByUsername", "budi").Return(newLoginTestUser("secret123"), nil)
+	loginRepo.On("FindThrottle", "budi").Return(&models.LoginThrottle{ID: 3, Username: "budi", FailedAttempts: 4, LastFailedAt: time.Now().Add(-time.Minute)}, nil)
+	loginRepo.On("FindThrottle", "ip:10.0.0.9").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("CreateAttempt", mock.MatchedBy(func(a *models.LoginAttempt) bool {
+		return a.Reason == "wrong_password" && a.UserID == 7
+	})).Return(nil)
+	loginRepo.On("IncrementThrottle", "budi").Return(&models.LoginThrottle{ID: 3, Username: "budi", FailedAttempts: 5}, nil)
+
+	var lockedUntil time.Time
+	loginRepo.On("LockThrottle", "budi", mock.Anything).Run(func(args mock.Arguments) {
+		lockedUntil = args.Get(1).(time.Time)
+	}).Return(nil)
+
+	_, err := service.Login("budi", "wrong", loginTestClient)
+
+	assert.EqualError(t, err, "Password yang Anda masukkan salah")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), lockedUntil, 5*time.Second)
+	loginRepo.AssertExpectations(t)
+}
+
+// Test Login - username tidak terdaftar dihitung per IP, bukan per username
+func TestLogin_UnknownUserCountedPerIP(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	loginRepo := new(MockLoginRepository)
+	service := NewAuthService(userRepo, new(MockPenyewaRepository), loginTestConfig, nil, nil, nil, loginRepo, nil, nil, nil)
+
+	userRepo.On("FindByUsername", "ghost").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("FindThrottle", "ghost").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("FindThrottle", "ip:10.0.0.9").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("CreateAttempt", mock.MatchedBy(func(a *models.LoginAttempt) bool {
+		return a.Reason == "unknown_user" && a.UserID == 0
+	})).Return(nil)
+	loginRepo.On("IncrementThrottle", "ip:10.0.0.9").Return(&models.LoginThrottle{ID: 4, Username: "ip:10.0.0.9", FailedAttempts: 2}, nil)
+
+	_, err := service.Login("ghost", "x", loginTestClient)
+
+	assert.Error(t, err)
+	loginRepo.AssertNotCalled(t, "IncrementThrottle", "ghost")
+	loginRepo.AssertNotCalled(t, "LockThrottle", mock.Anything, mock.Anything)
+	loginRepo.AssertExpectations(t)
+}
+
+// Test Login - IP yang dikunci karena menebak username ditolak
+func TestLogin_LockedIP(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	loginRepo := new(MockLoginRepository)
+	service := NewAuthService(userRepo, new(MockPenyewaRepository), loginTestConfig, nil, nil, nil, loginRepo, nil, nil, nil)
+
+	until := time.Now().Add(2 * time.Minute)
+	loginRepo.On("FindThrottle", "budi").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("FindThrottle", "ip:10.0.0.9").Return(&models.LoginThrottle{Username: "ip:10.0.0.9", FailedAttempts: 6, LockedUntil: until}, nil)
+	loginRepo.On("CreateAttempt", mock.MatchedBy(func(a *models.LoginAttempt) bool {
+		return a.Reason == "locked"
+	})).Return(nil)
+
+	_, err := service.Login("budi", "secret123", loginTestClient)
+
+	var locked *AccountLockedError
+	assert.True(t, errors.As(err, &locked))
+	assert.Equal(t, until, locked.Until)
+	userRepo.AssertNotCalled(t, "FindByUsername", mock.Anything)
+}
+
+// Test Login - berhasil dari perangkat baru mereset hitungan dan mengirim peringatan email
+func TestLogin_NewDeviceSendsAlert(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	loginRepo := new(MockLoginRepository)
+	emailSender := new(MockEmailSender)
+	waSender := new(MockWhatsAppSender)
+	service := NewAuthService(userRepo, penyewaRepo, loginTestConfig, emailSender, nil, nil, loginRepo, waSender, nil, nil)
+
+	userRepo.On("FindByUsername", "budi").Return(newLoginTestUser("secret123"), nil)
+	penyewaRepo.On("FindByUserID", uint(7)).Return(&models.Penyewa{NamaLengkap: "Budi", Email: "budi@example.com", Role: "tenant"}, nil)
+	loginRepo.On("FindThrottle", "budi").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("FindThrottle", "ip:10.0.0.9").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("ResetThrottle", "budi").Return(nil)
+	loginRepo.On("CountSuccessful", uint(7)).Return(3, nil)
+	loginRepo.On("CountSuccessfulFromDevice", uint(7), "Firefox").Return(0, nil)
+	loginRepo.On("CreateAttempt", mock.MatchedBy(func(a *models.LoginAttempt) bool {
+		return a.Success && a.NewDevice && a.Method == "password"
+	})).Return(nil)
+	emailSender.On("SendEmail", "budi@example.com", mock.Anything, mock.MatchedBy(func(body string) bool {
+		return assert.Contains(t, body, "10.0.0.9") && assert.Contains(t, body, "Firefox")
+	}), mock.Anything).Return(nil)
+
+	user, err := service.Login("budi", "secret123", loginTestClient)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "budi", user.Username)
+	loginRepo.AssertExpectations(t)
+	emailSender.AssertExpectations(t)
+	waSender.AssertNotCalled(t, "SendWhatsApp", mock.Anything, mock.Anything)
+}
+
+// Test Login - login pertama kali dan perangkat yang sudah dikenal tidak memicu peringatan
+func TestLogin_KnownDeviceNoAlert(t *testing.T) {
+	for name, counts := range map[string][2]int{"first login": {0, 0}, "known device": {4, 2}} {
+		t.Run(name, func(t *testing.T) {
+			userRepo := new(MockUserRepository)
+			penyewaRepo := new(MockPenyewaRepository)
+			loginRepo := new(MockLoginRepository)
+			emailSender := new(MockEmailSender)
+			service := NewAuthService(userRepo, penyewaRepo, loginTestConfig, emailSender, nil, nil, loginRepo, nil, nil, nil)
+
+			userRepo.On("FindByUsername", "budi").Return(newLoginTestUser("secret123"), nil)
+			penyewaRepo.On("FindByUserID", uint(7)).Return(&models.Penyewa{Email: "budi@example.com", Role: "tenant"}, nil)
+			loginRepo.On("FindThrottle", "budi").Return(nil, gorm.ErrRecordNotFound)
+			loginRepo.On("FindThrottle", "ip:10.0.0.9").Return(nil, gorm.ErrRecordNotFound)
+			loginRepo.On("ResetThrottle", "budi").Return(nil)
+			loginRepo.On("CountSuccessful", uint(7)).Return(counts[0], nil)
+			loginRepo.On("CountSuccessfulFromDevice", uint(7), "Firefox").Return(counts[1], nil)
+			loginRepo.On("CreateAttempt", mock.MatchedBy(func(a *models.LoginAttempt) bool {
+				return a.Success && !a.NewDevice
+			})).Return(nil)
+
+			_, err := service.Login("budi", "secret123", loginTestClient)
+
+			assert.NoError(t, err)
+			emailSender.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
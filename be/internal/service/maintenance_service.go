@@ -0,0 +1,440 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	ticketOpen       = "Open"
+	ticketInProgress = "In Progress"
+	ticketResolved   = "Resolved"
+	ticketClosed     = "Closed"
+
+	maxTicketPhotos = 5
+
+	// Penyebab kamar berstatus Maintenance (Kamar.AlasanMaintenance)
+	kamarMaintenanceCheckOut = "check_out"
+	kamarMaintenanceTicket   = "maintenance_ticket"
+)
+
+var (
+	validTicketKategori  = map[string]bool{"ac": true, "plumbing": true, "electrical": true, "furniture": true, "internet": true, "other": true}
+	validTicketPrioritas = map[string]bool{"low": true, "medium": true, "high": true, "urgent": true}
+)
+
+// Perpindahan status tiket oleh staff. Resolved boleh dibuka lagi jika perbaikan belum tuntas;
+// penyewa menutup tiket Resolved lewat RateTicket.
+var ticketTransitions = map[string][]string{
+	ticketOpen:       {ticketInProgress, ticketResolved, ticketClosed},
+	ticketInProgress: {ticketOpen, ticketResolved, ticketClosed},
+	ticketResolved:   {ticketInProgress, ticketClosed},
+}
+
+func canTransitionTicket(from, to string) bool {
+	for _, next := range ticketTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateTicketInput struct {
+	KamarID   uint // opsional jika penyewa hanya punya satu sewa aktif
+	Judul     string
+	Deskripsi string
+	Kategori  string
+	Prioritas string
+	PhotoURLs []string
+}
+
+type UpdateTicketStatusInput struct {
+	Status            string // kosong: status tidak berubah
+	Catatan           string // disimpan sebagai komentar staff
+	KamarOutOfService *bool  // nil: tidak berubah
+}
+
+type MaintenanceService interface {
+	CanCreateTicket(userID uint, input CreateTicketInput) error
+	CreateTicket(userID uint, input CreateTicketInput) (*models.MaintenanceTicket, error)
+	GetMyTickets(userID uint) ([]models.MaintenanceTicket, error)
+	GetTickets(pagination *utils.Pagination, filter repository.MaintenanceTicketFilter) ([]models.MaintenanceTicket, int64, error)
+	GetTicket(id uint, userID uint, role string) (*models.MaintenanceTicket, error)
+	AddComment(id uint, userID uint, role string, pesan string) (*models.MaintenanceComment, error)
+	AssignTicket(id uint, assigneeID uint, actor AuditActor) (*models.MaintenanceTicket, error)
+	UpdateStatus(id uint, input UpdateTicketStatusInput, actor AuditActor) (*models.MaintenanceTicket, error)
+	RateTicket(id uint, userID uint, rating int, komentar string) (*models.MaintenanceTicket, error)
+}
+
+type maintenanceService struct {
+	repo        repository.MaintenanceRepository
+	bookingRepo repository.BookingRepository
+	kamarRepo   repository.KamarRepository
+	penyewaRepo repository.PenyewaRepository
+	userRepo    repository.UserRepository
+	waSender    utils.WhatsAppSender
+	notifier    NotificationService
+	templates   MessageTemplateService
+	audit       AuditService
+}
+
+func NewMaintenanceService(repo repository.MaintenanceRepository, bookingRepo repository.BookingRepository, kamarRepo repository.KamarRepository, penyewaRepo repository.PenyewaRepository, userRepo repository.UserRepository, waSender utils.WhatsAppSender, notifier NotificationService, templates MessageTemplateService, audit AuditService) MaintenanceService {
+	return &maintenanceService{repo, bookingRepo, kamarRepo, penyewaRepo, userRepo, waSender, notifier, templates, audit}
+}
+
+// CanCreateTicket memeriksa isi laporan dan sewa aktif penyewa tanpa menyimpan apa pun, agar
+// foto tidak diunggah untuk laporan yang pasti ditolak
+func (s *maintenanceService) CanCreateTicket(userID uint, input CreateTicketInput) error {
+	_, _, err := s.prepareTicket(userID, &input)
+	return err
+}
+
+// CreateTicket membuat laporan perbaikan untuk kamar yang sedang disewa penyewa
+func (s *maintenanceService) CreateTicket(userID uint, input CreateTicketInput) (*models.MaintenanceTicket, error) {
+	penyewa, booking, err := s.prepareTicket(userID, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := models.MaintenanceTicket{
+		PenyewaID:   penyewa.ID,
+		KamarID:     booking.KamarID,
+		PemesananID: booking.ID,
+		Judul:       input.Judul,
+		Deskripsi:   strings.TrimSpace(input.Deskripsi),
+		Kategori:    input.Kategori,
+		Prioritas:   input.Prioritas,
+		Status:      ticketOpen,
+	}
+	for _, url := range input.PhotoURLs {
+		ticket.Photos = append(ticket.Photos, models.MaintenancePhoto{URL: url})
+	}
+	if err := s.repo.Create(&ticket); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.FindByID(ticket.ID)
+	if err != nil {
+		return nil, err
+	}
+	emitTicketCreated(s.notifier, created)
+	return created, nil
+}
+
+// prepareTicket memvalidasi (dan menormalkan) input lalu mencari penyewa dan sewa aktifnya
+func (s *maintenanceService) prepareTicket(userID uint, input *CreateTicketInput) (*models.Penyewa, *models.Pemesanan, error) {
+	input.Judul = strings.TrimSpace(input.Judul)
+	if input.Judul == "" {
+		return nil, nil, fmt.Errorf("judul wajib diisi")
+	}
+	if !validTicketKategori[input.Kategori] {
+		return nil, nil, fmt.Errorf("kategori harus salah satu dari: ac, plumbing, electrical, furniture, internet, other")
+	}
+	if input.Prioritas == "" {
+		input.Prioritas = "medium"
+	}
+	if !validTicketPrioritas[input.Prioritas] {
+		return nil, nil, fmt.Errorf("prioritas harus salah satu dari: low, medium, high, urgent")
+	}
+	if len(input.PhotoURLs) > maxTicketPhotos {
+		return nil, nil, fmt.Errorf("maksimal %d foto per laporan", maxTicketPhotos)
+	}
+
+	penyewa, err := s.penyewaRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("penyewa profile not found")
+	}
+	booking, err := s.activeLease(penyewa.ID, input.KamarID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return penyewa, booking, nil
+}
+
+// activeLease memilih sewa aktif penyewa. kamarID wajib jika penyewa menyewa lebih dari satu kamar.
+func (s *maintenanceService) activeLease(penyewaID uint, kamarID uint) (*models.Pemesanan, error) {
+	bookings, err := s.bookingRepo.FindByPenyewaID(penyewaID)
+	if err != nil {
+		return nil, err
+	}
+
+	var active []models.Pemesanan
+	for _, b := range bookings {
+		if b.StatusPemesanan.IsLeaseActive() && (kamarID == 0 || b.KamarID == kamarID) {
+			active = append(active, b)
+		}
+	}
+	switch {
+	case len(active) == 0:
+		return nil, fmt.Errorf("laporan perbaikan hanya bisa dibuat untuk kamar yang sedang Anda sewa")
+	case len(active) > 1 && kamarID == 0:
+		return nil, fmt.Errorf("kamar_id wajib diisi karena Anda menyewa lebih dari satu kamar")
+	}
+	return &active[0], nil
+}
+
+func (s *maintenanceService) GetMyTickets(userID uint) ([]models.MaintenanceTicket, error) {
+	penyewa, err := s.penyewaRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("penyewa profile not found")
+	}
+	return s.repo.FindByPenyewaID(penyewa.ID)
+}
+
+func (s *maintenanceService) GetTickets(pagination *utils.Pagination, filter repository.MaintenanceTicketFilter) ([]models.MaintenanceTicket, int64, error) {
+	return s.repo.FindAll(pagination, filter)
+}
+
+// GetTicket: staff dengan maintenance:manage atau penyewa pemilik tiket
+func (s *maintenanceService) GetTicket(id uint, userID uint, role string) (*models.MaintenanceTicket, error) {
+	ticket, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ticket, userID, role); err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+func (s *maintenanceService) authorize(ticket *models.MaintenanceTicket, userID uint, role string) error {
+	if utils.RoleHasPermission(role, utils.PermMaintenanceManage) {
+		return nil
+	}
+	penyewa, err := s.penyewaRepo.FindByUserID(userID)
+	if err != nil || penyewa.ID != ticket.PenyewaID {
+		return fmt.Errorf("unauthorized: you can only access your own maintenance tickets")
+	}
+	return nil
+}
+
+// AddComment menambah balasan penyewa atau staff. Tiket yang sudah Closed tidak bisa dibalas.
+func (s *maintenanceService) AddComment(id uint, userID uint, role string, pesan string) (*models.MaintenanceComment, error) {
+	pesan = strings.TrimSpace(pesan)
+	if pesan == "" {
+		return nil, fmt.Errorf("pesan wajib diisi")
+	}
+
+	ticket, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ticket, userID, role); err != nil {
+		return nil, err
+	}
+	if ticket.Status == ticketClosed {
+		return nil, fmt.Errorf("tiket sudah ditutup")
+	}
+
+	comment := models.MaintenanceComment{TicketID: ticket.ID, UserID: userID, Role: role, Pesan: pesan}
+	if err := s.repo.AddComment(&comment); err != nil {
+		return nil, err
+	}
+
+	if utils.RoleHasPermission(role, utils.PermMaintenanceManage) {
+		emitTicketUpdated(s.notifier, ticket, fmt.Sprintf("Pengelola membalas laporan \"%s\": %s", ticket.Judul, pesan))
+	} else {
+		emitTicketForStaff(s.notifier, ticket, fmt.Sprintf("%s membalas laporan \"%s\" di kamar %s", ticket.Penyewa.NamaLengkap, ticket.Judul, ticket.Kamar.NomorKamar))
+	}
+	return &comment, nil
+}
+
+// AssignTicket menunjuk staff yang memiliki permission maintenance:manage sebagai penanggung jawab
+func (s *maintenanceService) AssignTicket(id uint, assigneeID uint, actor AuditActor) (*models.MaintenanceTicket, error) {
+	ticket, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Status == ticketClosed {
+		return nil, fmt.Errorf("tiket sudah ditutup")
+	}
+
+	assignee, err := s.userRepo.FindByID(assigneeID)
+	if err != nil {
+		return nil, fmt.Errorf("staff tidak ditemukan")
+	}
+	if !utils.RoleHasPermission(assignee.Role, utils.PermMaintenanceManage) {
+		return nil, fmt.Errorf("staff dengan role %s tidak dapat menangani tiket perbaikan", assignee.Role)
+	}
+
+	before := *ticket
+	ticket.AssignedTo = assignee.ID
+	ticket.Assignee = assignee
+	if err := s.repo.Update(ticket); err != nil {
+		return nil, err
+	}
+	recordAudit(s.audit, actor, "maintenance.assign", "maintenance_ticket", ticket.ID, before, *ticket)
+
+	emitTicketUpdated(s.notifier, ticket, fmt.Sprintf("Laporan \"%s\" ditangani oleh %s", ticket.Judul, assignee.Username))
+	emitTicketForStaff(s.notifier, ticket, fmt.Sprintf("Anda ditugaskan menangani laporan \"%s\" di kamar %s", ticket.Judul, ticket.Kamar.NomorKamar))
+	return ticket, nil
+}
+
+// UpdateStatus mengubah status tiket dan/atau menandai kamar out-of-service (status kamar Maintenance).
+// Kamar dikembalikan otomatis saat tiket Resolved/Closed.
+func (s *maintenanceService) UpdateStatus(id uint, input UpdateTicketStatusInput, actor AuditActor) (*models.MaintenanceTicket, error) {
+	ticket, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *ticket
+	status := ticket.Status
+	if input.Status != "" && input.Status != ticket.Status {
+		if !canTransitionTicket(ticket.Status, input.Status) {
+			return nil, fmt.Errorf("status tiket tidak dapat diubah dari %s ke %s", ticket.Status, input.Status)
+		}
+		status = input.Status
+	}
+
+	outOfService := ticket.KamarOutOfService
+	if input.KamarOutOfService != nil {
+		outOfService = *input.KamarOutOfService
+	}
+	if status == ticketResolved || status == ticketClosed {
+		if input.KamarOutOfService != nil && *input.KamarOutOfService {
+			return nil, fmt.Errorf("kamar tidak dapat ditandai out-of-service untuk tiket yang sudah selesai")
+		}
+		outOfService = false
+	}
+
+	if outOfService && !ticket.KamarOutOfService {
+		if err := s.markKamarOutOfService(ticket.KamarID); err != nil {
+			return nil, err
+		}
+	} else if !outOfService && ticket.KamarOutOfService {
+		if err := s.restoreKamar(ticket); err != nil {
+			return nil, err
+		}
+	}
+	ticket.KamarOutOfService = outOfService
+
+	statusChanged := status != ticket.Status
+	if statusChanged {
+		ticket.Status = status
+		switch status {
+		case ticketResolved:
+			ticket.ResolvedAt = time.Now()
+		case ticketClosed:
+			ticket.ClosedAt = time.Now()
+		}
+	}
+	if err := s.repo.Update(ticket); err != nil {
+		return nil, err
+	}
+
+	catatan := strings.TrimSpace(input.Catatan)
+	if catatan != "" {
+		comment := models.MaintenanceComment{TicketID: ticket.ID, UserID: actor.UserID, Role: actor.Role, Pesan: catatan}
+		if err := s.repo.AddComment(&comment); err != nil {
+			log.Printf("[WARN] Gagal menyimpan catatan tiket %d: %v", ticket.ID, err)
+		}
+	}
+	recordAudit(s.audit, actor, "maintenance.status", "maintenance_ticket", ticket.ID, before, *ticket)
+
+	if statusChanged {
+		emitTicketUpdated(s.notifier, ticket, fmt.Sprintf("Status laporan \"%s\" berubah menjadi %s", ticket.Judul, ticket.Status))
+		s.sendStatusWhatsApp(ticket, catatan)
+	}
+	return ticket, nil
+}
+
+// markKamarOutOfService menandai kamar Maintenance karena tiket. Kamar yang sudah Maintenance
+// (mis. rusak saat check-out) dibiarkan dengan penyebab aslinya.
+func (s *maintenanceService) markKamarOutOfService(kamarID uint) error {
+	kamar, err := s.kamarRepo.FindByID(kamarID)
+	if err != nil {
+		return err
+	}
+	if kamar.Status == "Maintenance" {
+		return nil
+	}
+	return s.kamarRepo.SetMaintenance(kamarID, kamarMaintenanceTicket)
+}
+
+// restoreKamar mengembalikan kamar dari Maintenance jika tiket perbaikan yang menahannya dan tidak
+// ada tiket lain yang masih menahannya: Penuh jika sedang disewa, selain itu Tersedia. Kamar yang
+// Maintenance karena check-out atau diatur admin tetap Maintenance.
+func (s *maintenanceService) restoreKamar(ticket *models.MaintenanceTicket) error {
+	others, err := s.repo.CountOutOfService(ticket.KamarID, ticket.ID)
+	if err != nil {
+		return err
+	}
+	if others > 0 {
+		return nil
+	}
+
+	kamar, err := s.kamarRepo.FindByID(ticket.KamarID)
+	if err != nil {
+		return err
+	}
+	if kamar.Status != "Maintenance" || kamar.AlasanMaintenance != kamarMaintenanceTicket {
+		return nil
+	}
+
+	today := truncateToDay(time.Now())
+	occupying, err := s.bookingRepo.FindOverlappingBookings(ticket.KamarID, today, today.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	status := "Tersedia"
+	if len(occupying) > 0 {
+		status = "Penuh"
+	}
+	return s.kamarRepo.UpdateStatus(ticket.KamarID, status)
+}
+
+func (s *maintenanceService) sendStatusWhatsApp(ticket *models.MaintenanceTicket, catatan string) {
+	tenant := ticket.Penyewa
+	if tenant.NomorHP == "" || s.waSender == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"Nama":       tenant.NamaLengkap,
+		"NomorKamar": ticket.Kamar.NomorKamar,
+		"Judul":      ticket.Judul,
+		"Status":     ticket.Status,
+		"Catatan":    catatan,
+	}
+	go func() {
+		if err := sendTemplatedWhatsApp(s.waSender, s.templates, tenant.NomorHP, msgMaintenanceUpdated, tenant.Bahasa, data); err != nil {
+			log.Printf("[WARN] Gagal mengirim update tiket perbaikan %d: %v", ticket.ID, err)
+		}
+	}()
+}
+
+// RateTicket: penyewa menilai perbaikan (1-5) dan menutup tiket yang sudah Resolved
+func (s *maintenanceService) RateTicket(id uint, userID uint, rating int, komentar string) (*models.MaintenanceTicket, error) {
+	if rating < 1 || rating > 5 {
+		return nil, fmt.Errorf("rating harus di antara 1 dan 5")
+	}
+
+	ticket, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	penyewa, err := s.penyewaRepo.FindByUserID(userID)
+	if err != nil || penyewa.ID != ticket.PenyewaID {
+		return nil, fmt.Errorf("unauthorized: you can only rate your own maintenance tickets")
+	}
+	if ticket.Status != ticketResolved {
+		return nil, fmt.Errorf("tiket hanya bisa dinilai setelah berstatus Resolved")
+	}
+
+	ticket.Rating = rating
+	ticket.RatingKomentar = strings.TrimSpace(komentar)
+	ticket.Status = ticketClosed
+	ticket.ClosedAt = time.Now()
+	if err := s.repo.Update(ticket); err != nil {
+		return nil, err
+	}
+
+	emitTicketForStaff(s.notifier, ticket, fmt.Sprintf("%s memberi rating %d untuk perbaikan \"%s\" di kamar %s", ticket.Penyewa.NamaLengkap, rating, ticket.Judul, ticket.Kamar.NomorKamar))
+	return ticket, nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestMaintenanceService(repo *MockMaintenanceRepository, bookingRepo *MockBookingRepository, kamarRepo *MockKamarRepository, penyewaRepo *MockPenyewaRepository, userRepo *MockUserRepository) MaintenanceService {
+	return NewMaintenanceService(repo, bookingRepo, kamarRepo, penyewaRepo, userRepo, nil, nil, nil, nil)
+}
+
+// Test CreateTicket - laporan hanya untuk kamar yang sedang disewa
+func TestMaintenanceService_CreateTicket_RequiresActiveLease(t *testing.T) {
+	repo := new(MockMaintenanceRepository)
+	bookingRepo := new(MockBookingRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	service := newTestMaintenanceService(repo, bookingRepo, new(MockKamarRepository), penyewaRepo, new(MockUserRepository))
+
+	penyewaRepo.On("FindByUserID", uint(9)).Return(&models.Penyewa{ID: 2, UserID: 9}, nil)
+	bookingRepo.On("FindByPenyewaID", uint(2)).Return([]models.Pemesanan{{ID: 4, KamarID: 7, StatusPemesanan: models.BookingCheckedOut}}, nil)
+
+	_, err := service.CreateTicket(9, CreateTicketInput{Judul: "AC bocor", Kategori: "ac"})
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+// Test CanCreateTicket - kategori tidak valid dan penyewa tanpa sewa aktif ditolak sebelum foto diunggah
+func TestMaintenanceService_CanCreateTicket(t *testing.T) {
+	bookingRepo := new(MockBookingRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	service := newTestMaintenanceService(new(MockMaintenanceRepository), bookingRepo, new(MockKamarRepository), penyewaRepo, new(MockUserRepository))
+
+	penyewaRepo.On("FindByUserID", uint(9)).Return(&models.Penyewa{ID: 2, UserID: 9}, nil)
+	bookingRepo.On("FindByPenyewaID", uint(2)).Return([]models.Pemesanan{{ID: 4, KamarID: 7, StatusPemesanan: models.BookingConfirmed}}, nil)
+
+	assert.Error(t, service.CanCreateTicket(9, CreateTicketInput{Judul: "AC bocor", Kategori: "atap"}))
+	assert.Error(t, service.CanCreateTicket(9, CreateTicketInput{Judul: "AC bocor", Kategori: "ac", KamarID: 8}))
+	assert.NoError(t, service.CanCreateTicket(9, CreateTicketInput{Judul: "AC bocor", Kategori: "ac"}))
+}
+
+// Test CreateTicket - kamar diambil dari sewa aktif, prioritas default medium, foto ikut tersimpan
+func TestMaintenanceService_CreateTicket(t *testing.T) {
+	repo := new(MockMaintenanceRepository)
+	bookingRepo := new(MockBookingRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	service := newTestMaintenanceService(repo, bookingRepo, new(MockKamarRepository), penyewaRepo, new(MockUserRepository))
+
+	penyewaRepo.On("FindByUserID", uint(9)).Return(&models.Penyewa{ID: 2, UserID: 9}, nil)
+	bookingRepo.On("FindByPenyewaID", uint(2)).Return([]models.Pemesanan{
+		{ID: 3, KamarID: 5, StatusPemesanan: models.BookingCheckedOut},
+		{ID: 4, KamarID: 7, StatusPemesanan: models.BookingConfirmed},
+	}, nil)
+	repo.On("Create", mock.AnythingOfType("*models.MaintenanceTicket")).Return(nil)
+	repo.On("FindByID", uint(0)).Return(&models.MaintenanceTicket{KamarID: 7, Status: "Open"}, nil)
+
+	_, err := service.CreateTicket(9, CreateTicketInput{Judul: "AC bocor", Kategori: "ac", PhotoURLs: []string{"https://example.com/ac.jpg"}})
+
+	assert.NoError(t, err)
+	created := repo.Calls[0].Arguments.Get(0).(*models.MaintenanceTicket)
+	assert.Equal(t, uint(7), created.KamarID)
+	assert.Equal(t, uint(4), created.PemesananID)
+	assert.Equal(t, "medium", created.Prioritas)
+	assert.Equal(t, "Open", created.Status)
+	assert.Len(t, created.Photos, 1)
+}
+
+// Test UpdateStatus - tiket Resolved mengembalikan kamar out-of-service; kamar masih disewa jadi Penuh
+func TestMaintenanceService_UpdateStatus_ResolvedRestoresRoom(t *testing.T) {
+	repo := new(MockMaintenanceRepository)
+	bookingRepo := new(MockBookingRepository)
+	kamarRepo := new(MockKamarRepository)
+	service := newTestMaintenanceService(repo, bookingRepo, kamarRepo, new(MockPenyewaRepository), new(MockUserRepository))
+
+	ticket := &models.MaintenanceTicket{ID: 1, KamarID: 7, Judul: "Pipa bocor", Status: "In Progress", KamarOutOfService: true}
+	repo.On("FindByID", uint(1)).Return(ticket, nil)
+	repo.On("CountOutOfService", uint(7), uint(1)).Return(0, nil)
+	kamarRepo.On("FindByID", uint(7)).Return(&models.Kamar{ID: 7, Status: "Maintenance", AlasanMaintenance: "maintenance_ticket"}, nil)
+	bookingRepo.On("FindOverlappingBookings", uint(7), mock.Anything, mock.Anything).Return([]models.Pemesanan{{ID: 4, KamarID: 7}}, nil)
+	kamarRepo.On("UpdateStatus", uint(7), "Penuh").Return(nil)
+	repo.On("Update", ticket).Return(nil)
+	repo.On("AddComment", mock.AnythingOfType("*models.MaintenanceComment")).Return(nil)
+
+	result, err := service.UpdateStatus(1, UpdateTicketStatusInput{Status: "Resolved", Catatan: "Pipa sudah diganti"}, AuditActor{UserID: 3, Role: "caretaker"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Resolved", result.Status)
+	assert.False(t, result.KamarOutOfService)
+	assert.False(t, result.ResolvedAt.IsZero())
+	kamarRepo.AssertExpectations(t)
+	repo.AssertCalled(t, "AddComment", mock.AnythingOfType("*models.MaintenanceComment"))
+}
+
+// Test UpdateStatus - kamar yang Maintenance karena rusak saat check-out tidak diaktifkan kembali
+func TestMaintenanceService_UpdateStatus_KeepsCheckOutMaintenance(t *testing.T) {
+	repo := new(MockMaintenanceRepository)
+	kamarRepo := new(MockKamarRepository)
+	service := newTestMaintenanceService(repo, new(MockBookingRepository), kamarRepo, new(MockPenyewaRepository), new(MockUserRepository))
+
+	ticket := &models.MaintenanceTicket{ID: 1, KamarID: 7, Judul: "Pipa bocor", Status: "In Progress", KamarOutOfService: true}
+	repo.On("FindByID", uint(1)).Return(ticket, nil)
+	repo.On("CountOutOfService", uint(7), uint(1)).Return(0, nil)
+	kamarRepo.On("FindByID", uint(7)).Return(&models.Kamar{ID: 7, Status: "Maintenance", AlasanMaintenance: "check_out"}, nil)
+	repo.On("Update", ticket).Return(nil)
+
+	result, err := service.UpdateStatus(1, UpdateTicketStatusInput{Status: "Resolved"}, AuditActor{UserID: 3, Role: "caretaker"})
+
+	assert.NoError(t, err)
+	assert.False(t, result.KamarOutOfService)
+	kamarRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+// Test UpdateStatus - kamar yang sudah Maintenance tidak diambil alih oleh tiket
+func TestMaintenanceService_UpdateStatus_OutOfServiceKeepsExistingReason(t *testing.T) {
+	repo := new(MockMaintenanceRepository)
+	kamarRepo := new(MockKamarRepository)
+	service := newTestMaintenanceService(repo, new(MockBookingRepository), kamarRepo, new(MockPenyewaRepository), new(MockUserRepository))
+
+	outOfService := true
+	ticket := &models.MaintenanceTicket{ID: 1, KamarID: 7, Judul: "Pipa bocor", Status: "Open"}
+	repo.On("FindByID", uint(1)).Return(ticket, nil)
+	kamarRepo.On("FindByID", uint(7)).Return(&models.Kamar{ID: 7, Status: "Maintenance", AlasanMaintenance: "check_out"}, nil)
+	repo.On("Update", ticket).Return(nil)
+
+	result, err := service.UpdateStatus(1, UpdateTicketStatusInput{KamarOutOfService: &outOfService}, AuditActor{})
+
+	assert.NoError(t, err)
+	assert.True(t, result.KamarOutOfService)
+	kamarRepo.AssertNotCalled(t, "SetMaintenance", mock.Anything, mock.Anything)
+}
+
+// Test UpdateStatus - tiket Closed tidak bisa dibuka lagi
+func TestMaintenanceService_UpdateStatus_InvalidTransition(t *testing.T) {
+	repo := new(MockMaintenanceRepository)
+	service := newTestMaintenanceService(repo, new(MockBookingRepository), new(MockKamarRepository), new(MockPenyewaRepository), new(MockUserRepository))
+
+	repo.On("FindByID", uint(1)).Return(&models.MaintenanceTicket{ID: 1, Status: "Closed"}, nil)
+
+	_, err := service.UpdateStatus(1, UpdateTicketStatusInput{Status: "Open"}, AuditActor{})
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// Test AssignTicket - hanya staff dengan maintenance:manage yang bisa ditugaskan
+func TestMaintenanceService_AssignTicket_RequiresMaintenanceStaff(t *testing.T) {
+	repo := new(MockMaintenanceRepository)
+	userRepo := new(MockUserRepository)
+	service := newTestMaintenanceService(repo, new(MockBookingRepository), new(MockKamarRepository), new(MockPenyewaRepository), userRepo)
+
+	repo.On("FindByID", uint(1)).Return(&models.MaintenanceTicket{ID: 1, Status: "Open"}, nil)
+	userRepo.On("FindByID", uint(5)).Return(&models.User{ID: 5, Role: "accountant"}, nil)
+
+	_, err := service.AssignTicket(1, 5, AuditActor{})
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// Test RateTicket - penyewa menilai tiket Resolved dan tiket tertutup
+func TestMaintenanceService_RateTicket(t *testing.T) {
+	repo := new(MockMaintenanceRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	service := newTestMaintenanceService(repo, new(MockBookingRepository), new(MockKamarRepository), penyewaRepo, new(MockUserRepository))
+
+	ticket := &models.MaintenanceTicket{ID: 1, PenyewaID: 2, Status: "Resolved"}
+	repo.On("FindByID", uint(1)).Return(ticket, nil)
+	penyewaRepo.On("FindByUserID", uint(9)).Return(&models.Penyewa{ID: 2, UserID: 9}, nil)
+	repo.On("Update", ticket).Return(nil)
+
+	_, err := service.RateTicket(1, 9, 6, "")
+	assert.Error(t, err)
+
+	result, err := service.RateTicket(1, 9, 5, "Cepat dan rapi")
+	assert.NoError(t, err)
+	assert.Equal(t, "Closed", result.Status)
+	assert.Equal(t, 5, result.Rating)
+}
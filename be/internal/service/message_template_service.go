@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// RenderedMessage adalah hasil render template; Subject hanya terisi untuk email
+type RenderedMessage struct {
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body"`
+}
+
+// MessageTemplateView adalah template yang berlaku (bawaan atau override admin) beserta variabelnya
+type MessageTemplateView struct {
+	models.MessageTemplate
+	Customized bool     `json:"customized"`
+	Variables  []string `json:"variables"`
+}
+
+// MessageTemplateService merender pesan WA/email per event dan bahasa. Override admin di database
+// diutamakan; jika tidak ada dipakai template bawaan, lalu template bahasa Indonesia.
+type MessageTemplateService interface {
+	Render(event, channel, language string, data map[string]interface{}) (*RenderedMessage, error)
+	ListTemplates() ([]MessageTemplateView, error)
+	SaveTemplate(input models.MessageTemplate) (*models.MessageTemplate, error)
+	ResetTemplate(event, channel, language string) error
+	Preview(input models.MessageTemplate, data map[string]interface{}) (*RenderedMessage, error)
+}
+
+type messageTemplateService struct {
+	repo repository.MessageTemplateRepository
+}
+
+func NewMessageTemplateService(repo repository.MessageTemplateRepository) MessageTemplateService {
+	return &messageTemplateService{repo}
+}
+
+// normalizeLanguage memetakan preferensi bahasa ke bahasa yang didukung (default Indonesia)
+func normalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	for _, supported := range supportedLanguages {
+		if strings.HasPrefix(language, supported) {
+			return supported
+		}
+	}
+	return languageIndonesian
+}
+
+func findDefaultTemplate(event, channel, language string) (models.MessageTemplate, bool) {
+	for _, tpl := range defaultMessageTemplates {
+		if tpl.Event == event && tpl.Channel == channel && tpl.Language == language {
+			return tpl, true
+		}
+	}
+	return models.MessageTemplate{}, false
+}
+
+func formatEnglishDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2 January 2006")
+}
+
+func messageTemplateFuncs(language string) map[string]interface{} {
+	date := formatIndonesianDate
+	if language == languageEnglish {
+		date = formatEnglishDate
+	}
+	return map[string]interface{}{
+		"rupiah": formatRupiah,
+		"date":   date,
+		"datetime": func(t time.Time) string {
+			return date(t) + " " + t.Format("15:04")
+		},
+	}
+}
+
+// renderMessageTemplate: subject & body WA memakai text/template, body email memakai
+// html/template sehingga data dari pengguna (mis. pesan contact form) di-escape.
+func renderMessageTemplate(tpl models.MessageTemplate, data map[string]interface{}) (*RenderedMessage, error) {
+	funcs := messageTemplateFuncs(tpl.Language)
+	var out RenderedMessage
+
+	if tpl.Subject != "" {
+		subject, err := texttemplate.New("subject").Funcs(funcs).Option("missingkey=zero").Parse(tpl.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject template: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := subject.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render subject: %w", err)
+		}
+		out.Subject = buf.String()
+	}
+
+	var buf bytes.Buffer
+	if tpl.Channel == messageChannelEmail {
+		body, err := htmltemplate.New("body").Funcs(funcs).Option("missingkey=zero").Parse(tpl.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %w", err)
+		}
+		if err := body.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render body: %w", err)
+		}
+	} else {
+		body, err := texttemplate.New("body").Funcs(funcs).Option("missingkey=zero").Parse(tpl.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %w", err)
+		}
+		if err := body.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render body: %w", err)
+		}
+	}
+	out.Body = buf.String()
+	return &out, nil
+}
+
+// renderMessage merender pesan lewat service template; tanpa service (mis. di test) template
+// bawaan yang dipakai.
+func renderMessage(templates MessageTemplateService, event, channel, language string, data map[string]interface{}) (*RenderedMessage, error) {
+	if templates != nil {
+		return templates.Render(event, channel, language, data)
+	}
+	language = normalizeLanguage(language)
+	tpl, ok := findDefaultTemplate(event, channel, language)
+	if !ok {
+		if tpl, ok = findDefaultTemplate(event, channel, languageIndonesian); !ok {
+			return nil, fmt.Errorf("no %s template for event %s", channel, event)
+		}
+	}
+	return renderMessageTemplate(tpl, data)
+}
+
+func (s *messageTemplateService) resolve(event, channel, language string) (models.MessageTemplate, error) {
+	for _, lang := range []string{language, languageIndonesian} {
+		if override, err := s.repo.Find(event, channel, lang); err == nil {
+			return *override, nil
+		}
+		if tpl, ok := findDefaultTemplate(event, channel, lang); ok {
+			return tpl, nil
+		}
+	}
+	return models.MessageTemplate{}, fmt.Errorf("no %s template for event %s", channel, event)
+}
+
+func (s *messageTemplateService) Render(event, channel, language string, data map[string]interface{}) (*RenderedMessage, error) {
+	tpl, err := s.resolve(event, channel, normalizeLanguage(language))
+	if err != nil {
+		return nil, err
+	}
+	return renderMessageTemplate(tpl, data)
+}
+
+func templateVariables(event string) []string {
+	variables := make([]string, 0, len(messageTemplateSamples[event]))
+	for key := range messageTemplateSamples[event] {
+		variables = append(variables, key)
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+func (s *messageTemplateService) ListTemplates() ([]MessageTemplateView, error) {
+	overrides, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]MessageTemplateView, 0, len(defaultMessageTemplates))
+	for _, tpl := range defaultMessageTemplates {
+		view := MessageTemplateView{MessageTemplate: tpl, Variables: templateVariables(tpl.Event)}
+		for _, override := range overrides {
+			if override.Event == tpl.Event && override.Channel == tpl.Channel && override.Language == tpl.Language {
+				view.MessageTemplate = override
+				view.Customized = true
+			}
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// validateTemplate memastikan template dikenal dan bisa dirender dengan data contoh
+func validateTemplate(input models.MessageTemplate) error {
+	if _, ok := findDefaultTemplate(input.Event, input.Channel, input.Language); !ok {
+		return fmt.Errorf("unknown template: %s/%s/%s", input.Event, input.Channel, input.Language)
+	}
+	if strings.TrimSpace(input.Body) == "" {
+		return errors.New("body is required")
+	}
+	if input.Channel == messageChannelEmail && strings.TrimSpace(input.Subject) == "" {
+		return errors.New("subject is required for email templates")
+	}
+	if input.Channel != messageChannelEmail && input.Subject != "" {
+		return errors.New("subject is only used by email templates")
+	}
+	_, err := renderMessageTemplate(input, messageTemplateSamples[input.Event])
+	return err
+}
+
+func (s *messageTemplateService) SaveTemplate(input models.MessageTemplate) (*models.MessageTemplate, error) {
+	tpl := models.MessageTemplate{
+		Event:    input.Event,
+		Channel:  input.Channel,
+		Language: input.Language,
+		Subject:  input.Subject,
+		Body:     input.Body,
+	}
+	if err := validateTemplate(tpl); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Save(&tpl); err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+func (s *messageTemplateService) ResetTemplate(event, channel, language string) error {
+	if _, err := s.repo.Delete(event, channel, language); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Preview merender draft template (atau template yang berlaku jika body kosong) dengan data
+// contoh; nilai di data menimpa data contoh.
+func (s *messageTemplateService) Preview(input models.MessageTemplate, data map[string]interface{}) (*RenderedMessage, error) {
+	samples, ok := messageTemplateSamples[input.Event]
+	if !ok {
+		return nil, fmt.Errorf("unknown template event: %s", input.Event)
+	}
+	input.Language = normalizeLanguage(input.Language)
+
+	merged := make(map[string]interface{}, len(samples)+len(data))
+	for key, value := range samples {
+		merged[key] = value
+	}
+	for key, value := range data {
+		// Tanggal dari JSON berupa string; ubah ke time.Time agar fungsi date tetap bisa dipakai
+		if _, isDate := samples[key].(time.Time); isDate {
+			if str, ok := value.(string); ok {
+				if parsed, err := time.Parse("2006-01-02", str); err == nil {
+					value = parsed
+				} else if parsed, err := time.Parse(time.RFC3339, str); err == nil {
+					value = parsed
+				} else {
+					return nil, fmt.Errorf("invalid date for %s: %s", key, str)
+				}
+			}
+		}
+		merged[key] = value
+	}
+
+	if strings.TrimSpace(input.Body) == "" {
+		return s.Render(input.Event, input.Channel, input.Language, merged)
+	}
+	if err := validateTemplate(input); err != nil {
+		return nil, err
+	}
+	return renderMessageTemplate(input, merged)
+}
+
+// sendTemplatedWhatsApp merender template WA untuk event lalu mengirimnya
+func sendTemplatedWhatsApp(sender utils.WhatsAppSender, templates MessageTemplateService, phone, event, language string, data map[string]interface{}) error {
+	msg, err := renderMessage(templates, event, messageChannelWhatsApp, language, data)
+	if err != nil {
+		return err
+	}
+	return sender.SendWhatsApp(phone, msg.Body)
+}
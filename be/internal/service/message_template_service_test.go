@@ -0,0 +1,107 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Semua template bawaan harus bisa dirender dengan data contoh dan tersedia dalam dua bahasa
+func TestDefaultMessageTemplates_Render(t *testing.T) {
+	for _, tpl := range defaultMessageTemplates {
+		rendered, err := renderMessageTemplate(tpl, messageTemplateSamples[tpl.Event])
+		assert.NoError(t, err, "%s/%s/%s", tpl.Event, tpl.Channel, tpl.Language)
+		assert.NotContains(t, rendered.Body, "<no value>", "%s/%s/%s", tpl.Event, tpl.Channel, tpl.Language)
+
+		for _, lang := range supportedLanguages {
+			_, ok := findDefaultTemplate(tpl.Event, tpl.Channel, lang)
+			assert.True(t, ok, "missing %s/%s/%s", tpl.Event, tpl.Channel, lang)
+		}
+	}
+}
+
+// Test renderMessage - bahasa Inggris, format tanggal per bahasa, bahasa tidak dikenal jatuh ke Indonesia
+func TestRenderMessage_Language(t *testing.T) {
+	data := map[string]interface{}{
+		"Nama": "Budi", "NomorKamar": "A1", "Jumlah": 1500000.0, "SisaTagihan": 0.0,
+		"JatuhTempo": time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local),
+	}
+
+	en, err := renderMessage(nil, msgPaymentReminder, messageChannelWhatsApp, "en-US", data)
+	assert.NoError(t, err)
+	assert.Contains(t, en.Body, "is due on *1 November 2026*")
+	assert.Contains(t, en.Body, "Rp 1.500.000")
+	assert.NotContains(t, en.Body, "outstanding balance")
+
+	id, err := renderMessage(nil, msgPaymentReminder, messageChannelWhatsApp, "fr", data)
+	assert.NoError(t, err)
+	assert.Contains(t, id.Body, "jatuh tempo pada *1 November 2026*")
+}
+
+// Test contact email - input pengunjung di-escape oleh html/template
+func TestRenderMessage_EmailEscapesInput(t *testing.T) {
+	msg, err := renderMessage(nil, msgContactMessage, messageChannelEmail, languageIndonesian, map[string]interface{}{
+		"Nama": "Budi", "Email": "budi@example.com", "Pesan": "<script>alert(1)</script>",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Pesan Baru dari Budi - Contact Form Koskosan", msg.Subject)
+	assert.Contains(t, msg.Body, "&lt;script&gt;")
+	assert.False(t, strings.Contains(msg.Body, "<script>"))
+}
+
+// Test Render - override admin di database diutamakan
+func TestMessageTemplateService_RenderOverride(t *testing.T) {
+	repo := new(MockMessageTemplateRepository)
+	service := NewMessageTemplateService(repo)
+
+	repo.On("Find", msgRefundPaid, messageChannelWhatsApp, languageEnglish).Return(&models.MessageTemplate{
+		Event: msgRefundPaid, Channel: messageChannelWhatsApp, Language: languageEnglish,
+		Body: "Refund {{rupiah .Jumlah}} sent, {{.Nama}}",
+	}, nil)
+
+	msg, err := service.Render(msgRefundPaid, messageChannelWhatsApp, "en", map[string]interface{}{"Nama": "Budi", "Jumlah": 250000.0})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Refund Rp 250.000 sent, Budi", msg.Body)
+}
+
+// Test SaveTemplate - template yang tidak valid atau tidak dikenal ditolak
+func TestMessageTemplateService_SaveTemplate_Invalid(t *testing.T) {
+	repo := new(MockMessageTemplateRepository)
+	service := NewMessageTemplateService(repo)
+
+	_, err := service.SaveTemplate(models.MessageTemplate{Event: msgRefundPaid, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: "Halo {{.Nama"})
+	assert.Error(t, err)
+
+	_, err = service.SaveTemplate(models.MessageTemplate{Event: "unknown.event", Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: "Halo"})
+	assert.Error(t, err)
+
+	_, err = service.SaveTemplate(models.MessageTemplate{Event: msgPaymentConfirmed, Channel: messageChannelEmail, Language: languageIndonesian, Body: "<p>Halo</p>"})
+	assert.EqualError(t, err, "subject is required for email templates")
+
+	repo.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+// Test Preview - draft dirender dengan data contoh, tanggal dari JSON diterima sebagai string
+func TestMessageTemplateService_Preview(t *testing.T) {
+	repo := new(MockMessageTemplateRepository)
+	service := NewMessageTemplateService(repo)
+	repo.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+	msg, err := service.Preview(models.MessageTemplate{
+		Event: msgPaymentOverdue, Channel: messageChannelWhatsApp, Language: languageIndonesian,
+		Body: "{{.Nama}} telat sejak {{date .JatuhTempo}}",
+	}, map[string]interface{}{"JatuhTempo": "2026-10-05"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Budi Santoso telat sejak 5 Oktober 2026", msg.Body)
+
+	current, err := service.Preview(models.MessageTemplate{Event: msgRefundPaid, Channel: messageChannelWhatsApp, Language: languageEnglish}, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, current.Body, "We have transferred your refund of *Rp 1.500.000*")
+}
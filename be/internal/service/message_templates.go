@@ -0,0 +1,449 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"time"
+)
+
+// Template pesan bawaan per event, channel dan bahasa. Admin dapat menimpanya lewat
+// /api/message-templates; penghapusan override mengembalikan template di bawah ini.
+// Fungsi template: rupiah (Rp 1.500.000), date (2 Januari 2006 / 2 January 2006), datetime.
+
+const (
+	messageChannelWhatsApp = "whatsapp"
+	messageChannelEmail    = "email"
+
+	languageIndonesian = "id"
+	languageEnglish    = "en"
+)
+
+var supportedLanguages = []string{languageIndonesian, languageEnglish}
+
+const (
+	msgRoomDeletedAdmin        = "room_deleted.admin"
+	msgRoomDeletedTenantRefund = "room_deleted.tenant_refund"
+	msgRoomDeletedTenant       = "room_deleted.tenant"
+	msgBookingAutoCancelled    = "booking.auto_cancelled"
+	msgPaymentConfirmed        = "payment.confirmed"
+	msgPaymentReminder         = "payment.reminder"
+	msgPaymentOverdue          = "payment.overdue"
+	msgRoomLocked              = "room.locked"
+	msgRefundPaid              = "refund.paid"
+	msgMaintenanceUpdated      = "maintenance.updated"
+	msgContactMessage          = "contact.message"
+	msgLoginNewDevice          = "login.new_device"
+)
+
+var defaultMessageTemplates = []models.MessageTemplate{
+	// ---- Kamar dihapus saat ada booking pending ----
+	{Event: msgRoomDeletedAdmin, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `⚠️ *NOTIFIKASI SISTEM — KAMAR DIHAPUS*
+
+Admin secara tidak sengaja telah menghapus data kamar yang memiliki pesanan yang *belum dikonfirmasi*.
+
+📋 *Detail:*
+• Kamar: *{{.NomorKamar}}*
+• Tipe: {{.TipeKamar}}
+• Dipesan oleh: *{{.Nama}}*
+• No. HP Penyewa: {{if .NomorHP}}{{.NomorHP}}{{else}}-{{end}}
+• Jumlah pembayaran yang diajukan: *{{rupiah .Jumlah}}*
+• Bukti Transfer: {{if .BuktiTransfer}}{{.BuktiTransfer}}{{else}}(Tidak ada lampiran / Belum diupload){{end}}
+
+📌 *Tindakan yang diperlukan:*
+Harap segera hubungi penyewa *{{.Nama}}* melalui nomor WA berikut untuk mengembalikan dana atau konfirmasi pembatalan:
+wa.me/{{.NomorHP}}
+
+Dana sebesar *{{rupiah .Jumlah}}* harus dikembalikan karena pesanan telah otomatis dibatalkan akibat penghapusan kamar.
+
+_Pesan ini dikirim otomatis oleh sistem._`},
+	{Event: msgRoomDeletedAdmin, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `⚠️ *SYSTEM NOTICE — ROOM DELETED*
+
+A room with an *unconfirmed* booking has been deleted.
+
+📋 *Details:*
+• Room: *{{.NomorKamar}}*
+• Type: {{.TipeKamar}}
+• Booked by: *{{.Nama}}*
+• Tenant phone: {{if .NomorHP}}{{.NomorHP}}{{else}}-{{end}}
+• Amount submitted: *{{rupiah .Jumlah}}*
+• Transfer proof: {{if .BuktiTransfer}}{{.BuktiTransfer}}{{else}}(No attachment / not uploaded yet){{end}}
+
+📌 *Action required:*
+Please contact *{{.Nama}}* on WhatsApp to refund the payment or confirm the cancellation:
+wa.me/{{.NomorHP}}
+
+*{{rupiah .Jumlah}}* must be refunded because the booking was cancelled automatically when the room was deleted.
+
+_This message was sent automatically._`},
+	{Event: msgRoomDeletedTenantRefund, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `Halo *{{.Nama}}*,
+
+Kami mohon maaf atas ketidaknyamanan ini. Kamar *{{.NomorKamar}}* yang Anda pesan telah *dihapus oleh admin* secara tidak sengaja.
+
+Pesanan Anda telah otomatis *dibatalkan*.
+
+💰 *Return Dana:*
+Anda akan mendapatkan pengembalian uang sebesar *{{rupiah .Jumlah}}* sesuai dengan jumlah yang Anda bayarkan saat pemesanan.
+
+👉 *Harap segera hubungi Admin* untuk memproses pengembalian dana atau melakukan pemesanan kamar lainnya.
+
+Terima kasih atas pengertian Anda. 🙏`},
+	{Event: msgRoomDeletedTenantRefund, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `Hello *{{.Nama}}*,
+
+We apologise for the inconvenience. Room *{{.NomorKamar}}* that you booked has been *removed by the admin*.
+
+Your booking has been *cancelled* automatically.
+
+💰 *Refund:*
+You will receive a refund of *{{rupiah .Jumlah}}*, the amount you paid when booking.
+
+👉 *Please contact the Admin* to process the refund or to book another room.
+
+Thank you for your understanding. 🙏`},
+	{Event: msgRoomDeletedTenant, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `Halo *{{.Nama}}*,
+
+Mohon maaf atas ketidaknyamanan ini. Kamar *{{.NomorKamar}}* yang Anda pesan telah *dihapus oleh admin* secara tidak sengaja.
+
+Pesanan Anda telah otomatis *dibatalkan*.
+
+👉 *Harap segera hubungi Admin* untuk informasi lebih lanjut atau untuk melakukan pemesanan ulang kamar yang tersedia.
+
+Terima kasih. 🙏`},
+	{Event: msgRoomDeletedTenant, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `Hello *{{.Nama}}*,
+
+We apologise for the inconvenience. Room *{{.NomorKamar}}* that you booked has been *removed by the admin*.
+
+Your booking has been *cancelled* automatically.
+
+👉 *Please contact the Admin* for more information or to book another available room.
+
+Thank you. 🙏`},
+
+	// ---- Booking ----
+	{Event: msgBookingAutoCancelled, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `Halo {{.Nama}},
+
+Pesanan Anda untuk Kamar {{.NomorKamar}} telah otomatis dibatalkan karena tidak ada pembayaran yang dikonfirmasi dalam waktu 7 hari.
+
+Silakan lakukan pemesanan ulang jika Anda masih berminat.
+
+Terima kasih.`},
+	{Event: msgBookingAutoCancelled, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `Hello {{.Nama}},
+
+Your booking for Room {{.NomorKamar}} has been cancelled automatically because no payment was confirmed within 7 days.
+
+Please book again if you are still interested.
+
+Thank you.`},
+
+	// ---- Pembayaran ----
+	{Event: msgPaymentConfirmed, Channel: messageChannelWhatsApp, Language: languageIndonesian,
+		Body: `Terima kasih {{.Nama}}! Pembayaran sebesar {{rupiah .Jumlah}} untuk kamar {{.NomorKamar}} telah kami terima.`},
+	{Event: msgPaymentConfirmed, Channel: messageChannelWhatsApp, Language: languageEnglish,
+		Body: `Thank you {{.Nama}}! We have received your payment of {{rupiah .Jumlah}} for room {{.NomorKamar}}.`},
+	{Event: msgPaymentConfirmed, Channel: messageChannelEmail, Language: languageIndonesian,
+		Subject: `Konfirmasi Pembayaran - Kost Putra Rahmat ZAW`,
+		Body: `<div style="font-family: Arial, sans-serif; padding: 20px; color: #333;">
+	<h2 style="color: #4CAF50;">Pembayaran Berhasil!</h2>
+	<p>Halo, <strong>{{.Nama}}</strong>,</p>
+	<p>Terima kasih, pembayaran Anda telah kami terima.</p>
+	<table style="width: 100%; max-width: 400px; margin: 20px 0; border-collapse: collapse;">
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Jumlah</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd; font-weight: bold;">{{rupiah .Jumlah}}</td>
+		</tr>
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Tanggal</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">{{datetime .Tanggal}}</td>
+		</tr>
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Status</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd; color: green;">Lunas</td>
+		</tr>
+	</table>
+	<p>Kwitansi pembayaran terlampir. Simpan email ini sebagai bukti pembayaran yang sah.</p>
+	<br/>
+	<p style="font-size: 12px; color: #888;">Kost Putra Rahmat ZAW Management</p>
+</div>`},
+	{Event: msgPaymentConfirmed, Channel: messageChannelEmail, Language: languageEnglish,
+		Subject: `Payment Confirmation - Kost Putra Rahmat ZAW`,
+		Body: `<div style="font-family: Arial, sans-serif; padding: 20px; color: #333;">
+	<h2 style="color: #4CAF50;">Payment Received!</h2>
+	<p>Hello, <strong>{{.Nama}}</strong>,</p>
+	<p>Thank you, we have received your payment.</p>
+	<table style="width: 100%; max-width: 400px; margin: 20px 0; border-collapse: collapse;">
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Amount</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd; font-weight: bold;">{{rupiah .Jumlah}}</td>
+		</tr>
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Date</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">{{datetime .Tanggal}}</td>
+		</tr>
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Status</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd; color: green;">Paid</td>
+		</tr>
+	</table>
+	<p>Your receipt is attached. Please keep this email as proof of payment.</p>
+	<br/>
+	<p style="font-size: 12px; color: #888;">Kost Putra Rahmat ZAW Management</p>
+</div>`},
+	{Event: msgPaymentReminder, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `Halo {{.Nama}} 👋
+
+Ini adalah pesan dari sistem Kost.
+Mengingatkan bahwa tagihan sewa Kamar {{.NomorKamar}} Bapak/Ibu sebesar *{{rupiah .Jumlah}}* akan jatuh tempo pada *{{date .JatuhTempo}}*.{{if gt .SisaTagihan .Jumlah}}
+Total sisa tagihan Anda saat ini: *{{rupiah .SisaTagihan}}*.{{end}}
+
+Mohon segera melunasi pembayaran bulan ini melalui website Kost agar sewa kamar tetap aktif.
+Terima kasih!`},
+	{Event: msgPaymentReminder, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `Hello {{.Nama}} 👋
+
+This is a message from the Kost system.
+A reminder that your rent for Room {{.NomorKamar}} of *{{rupiah .Jumlah}}* is due on *{{date .JatuhTempo}}*.{{if gt .SisaTagihan .Jumlah}}
+Your total outstanding balance is *{{rupiah .SisaTagihan}}*.{{end}}
+
+Please settle this month's payment on the Kost website to keep your room active.
+Thank you!`},
+	{Event: msgPaymentReminder, Channel: messageChannelEmail, Language: languageIndonesian,
+		Subject: `Tagihan Belum Dibayar - Kost Putra Rahmat ZAW`,
+		Body: `<div style="font-family: Arial, sans-serif; padding: 20px; color: #333;">
+	<h2 style="color: #F44336;">Pengingat Tagihan</h2>
+	<p>Halo, <strong>{{.Nama}}</strong>,</p>
+	<p>Ini adalah pengingat untuk tagihan sewa kamar {{.NomorKamar}} yang belum dibayar.</p>
+	<table style="width: 100%; max-width: 400px; margin: 20px 0; border-collapse: collapse;">
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Total Tagihan</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd; font-weight: bold;">{{rupiah .Jumlah}}</td>
+		</tr>
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Jatuh Tempo</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd; color: #F44336;">{{date .JatuhTempo}}</td>
+		</tr>
+	</table>
+	<p>Mohon segera lakukan pembayaran sebelum tanggal jatuh tempo untuk menghindari denda.</p>
+	<div style="margin: 30px 0;">
+		<a href="{{.LinkPembayaran}}" style="background-color: #2196F3; color: white; padding: 12px 24px; text-decoration: none; border-radius: 4px; font-weight: bold;">Bayar Sekarang</a>
+	</div>
+	<p style="font-size: 12px; color: #888;">Jika Anda sudah melakukan pembayaran, mohon abaikan email ini.</p>
+</div>`},
+	{Event: msgPaymentReminder, Channel: messageChannelEmail, Language: languageEnglish,
+		Subject: `Unpaid Bill - Kost Putra Rahmat ZAW`,
+		Body: `<div style="font-family: Arial, sans-serif; padding: 20px; color: #333;">
+	<h2 style="color: #F44336;">Payment Reminder</h2>
+	<p>Hello, <strong>{{.Nama}}</strong>,</p>
+	<p>This is a reminder that your rent for room {{.NomorKamar}} has not been paid yet.</p>
+	<table style="width: 100%; max-width: 400px; margin: 20px 0; border-collapse: collapse;">
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Amount Due</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd; font-weight: bold;">{{rupiah .Jumlah}}</td>
+		</tr>
+		<tr>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd;">Due Date</td>
+			<td style="padding: 8px; border-bottom: 1px solid #ddd; color: #F44336;">{{date .JatuhTempo}}</td>
+		</tr>
+	</table>
+	<p>Please pay before the due date to avoid late fees.</p>
+	<div style="margin: 30px 0;">
+		<a href="{{.LinkPembayaran}}" style="background-color: #2196F3; color: white; padding: 12px 24px; text-decoration: none; border-radius: 4px; font-weight: bold;">Pay Now</a>
+	</div>
+	<p style="font-size: 12px; color: #888;">If you have already paid, please ignore this email.</p>
+</div>`},
+	{Event: msgPaymentOverdue, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `Halo {{.Nama}},
+
+Tagihan sewa Kamar {{.NomorKamar}} sebesar *{{rupiah .Jumlah}}* telah melewati jatuh tempo ({{date .JatuhTempo}}).{{if gt .Denda 0.0}}{{if eq .TipeDenda "flat"}}
+Dikenakan denda keterlambatan sebesar *{{rupiah .Denda}}*.{{else}}
+Denda keterlambatan *{{rupiah .Denda}}/hari* berlaku sampai tagihan dilunasi.{{end}}{{end}}
+
+Mohon segera melakukan pembayaran melalui website Kost. Terima kasih.`},
+	{Event: msgPaymentOverdue, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `Hello {{.Nama}},
+
+Your rent for Room {{.NomorKamar}} of *{{rupiah .Jumlah}}* is past its due date ({{date .JatuhTempo}}).{{if gt .Denda 0.0}}{{if eq .TipeDenda "flat"}}
+A late fee of *{{rupiah .Denda}}* has been applied.{{else}}
+A late fee of *{{rupiah .Denda}}/day* applies until the bill is paid.{{end}}{{end}}
+
+Please pay on the Kost website as soon as possible. Thank you.`},
+	{Event: msgRoomLocked, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `Halo {{.Nama}},
+
+Tagihan sewa Kamar {{.NomorKamar}} sudah terlambat {{.HariTelat}} hari sehingga kamar kami tandai *terkunci* karena belum ada pembayaran.
+
+Segera lunasi tagihan sebesar *{{rupiah .Total}}* untuk membuka kembali akses kamar.`},
+	{Event: msgRoomLocked, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `Hello {{.Nama}},
+
+Your rent for Room {{.NomorKamar}} is {{.HariTelat}} days overdue, so the room has been marked as *locked*.
+
+Please pay *{{rupiah .Total}}* to unlock the room.`},
+
+	// ---- Refund ----
+	{Event: msgRefundPaid, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `Halo {{.Nama}},
+
+Pengembalian dana sebesar *{{rupiah .Jumlah}}* untuk pesanan Kamar {{.NomorKamar}} telah kami transfer.
+
+Terima kasih atas pengertian Anda. 🙏`},
+	{Event: msgRefundPaid, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `Hello {{.Nama}},
+
+We have transferred your refund of *{{rupiah .Jumlah}}* for the Room {{.NomorKamar}} booking.
+
+Thank you for your understanding. 🙏`},
+
+	// ---- Tiket perbaikan ----
+	{Event: msgMaintenanceUpdated, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `Halo {{.Nama}},
+
+Laporan perbaikan Anda untuk Kamar {{.NomorKamar}} (*{{.Judul}}*) {{if eq .Status "In Progress"}}sedang dikerjakan{{else if eq .Status "Resolved"}}sudah selesai diperbaiki{{else if eq .Status "Closed"}}telah ditutup{{else}}dibuka kembali{{end}}.{{if .Catatan}}
+
+Catatan pengelola: {{.Catatan}}{{end}}{{if eq .Status "Resolved"}}
+
+Mohon beri penilaian atas perbaikan ini di menu Perbaikan pada website Kost.{{end}}
+
+Terima kasih.`},
+	{Event: msgMaintenanceUpdated, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `Hello {{.Nama}},
+
+Your maintenance request for Room {{.NomorKamar}} (*{{.Judul}}*) {{if eq .Status "In Progress"}}is being worked on{{else if eq .Status "Resolved"}}has been resolved{{else if eq .Status "Closed"}}has been closed{{else}}has been reopened{{end}}.{{if .Catatan}}
+
+Note from the management: {{.Catatan}}{{end}}{{if eq .Status "Resolved"}}
+
+Please rate the repair from the Maintenance menu on the Kost website.{{end}}
+
+Thank you.`},
+
+	// ---- Contact form (dikirim ke email pengelola) ----
+	{Event: msgContactMessage, Channel: messageChannelEmail, Language: languageIndonesian,
+		Subject: `Pesan Baru dari {{.Nama}} - Contact Form Koskosan`,
+		Body: `<!DOCTYPE html>
+<html>
+<head>
+	<style>
+		body { font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f4f4; margin: 0; padding: 0; }
+		.container { max-width: 600px; margin: 40px auto; background: white; border-radius: 12px; overflow: hidden; box-shadow: 0 4px 6px rgba(0,0,0,0.1); }
+		.header { background: linear-gradient(135deg, #292524 0%, #44403c 100%); color: white; padding: 30px; text-align: center; }
+		.header h1 { margin: 0; font-size: 24px; font-weight: 700; }
+		.header p { margin: 10px 0 0 0; opacity: 0.9; font-size: 14px; }
+		.content { padding: 40px 30px; }
+		.info-row { margin-bottom: 20px; }
+		.info-row label { display: block; color: #78716c; font-size: 12px; font-weight: 600; text-transform: uppercase; letter-spacing: 0.5px; margin-bottom: 5px; }
+		.info-row .value { color: #292524; font-size: 16px; font-weight: 500; }
+		.message-box { background-color: #fafaf9; border-left: 4px solid #78716c; padding: 20px; border-radius: 8px; margin-top: 10px; }
+		.message-box p { margin: 0; color: #292524; line-height: 1.6; white-space: pre-wrap; }
+		.footer { background-color: #fafaf9; padding: 20px; text-align: center; border-top: 1px solid #e7e5e4; }
+		.footer p { margin: 5px 0; color: #78716c; font-size: 13px; }
+	</style>
+</head>
+<body>
+	<div class="container">
+		<div class="header">
+			<h1>📬 Pesan Baru dari Contact Form</h1>
+			<p>Koskosan Rahmat ZAW - Malang</p>
+		</div>
+		<div class="content">
+			<div class="info-row">
+				<label>Dari</label>
+				<div class="value">{{.Nama}}</div>
+			</div>
+			<div class="info-row">
+				<label>Email</label>
+				<div class="value">{{.Email}}</div>
+			</div>
+			<div class="info-row">
+				<label>Pesan</label>
+				<div class="message-box">
+					<p>{{.Pesan}}</p>
+				</div>
+			</div>
+		</div>
+		<div class="footer">
+			<p>Email ini dikirim secara otomatis dari website Koskosan Rahmat ZAW</p>
+			<p>Untuk membalas, klik "Reply" atau email ke: {{.Email}}</p>
+		</div>
+	</div>
+</body>
+</html>`},
+	{Event: msgContactMessage, Channel: messageChannelEmail, Language: languageEnglish,
+		Subject: `New message from {{.Nama}} - Koskosan Contact Form`,
+		Body: `<div style="font-family: Arial, sans-serif; padding: 20px; color: #333;">
+	<h2>📬 New Contact Form Message</h2>
+	<p><strong>From:</strong> {{.Nama}}</p>
+	<p><strong>Email:</strong> {{.Email}}</p>
+	<div style="background-color: #fafaf9; border-left: 4px solid #78716c; padding: 20px; white-space: pre-wrap;">{{.Pesan}}</div>
+	<p style="font-size: 12px; color: #888;">Reply to this email or write to {{.Email}}.</p>
+</div>`},
+
+	// ---- Keamanan akun ----
+	{Event: msgLoginNewDevice, Channel: messageChannelWhatsApp, Language: languageIndonesian, Body: `🔐 *Login dari perangkat baru*
+
+Halo {{.Nama}}, akun Anda baru saja login dari perangkat yang belum pernah dipakai sebelumnya.
+
+• Waktu: {{datetime .Waktu}}
+• IP: {{.IP}}
+• Perangkat: {{.Perangkat}}
+
+Jika ini bukan Anda, segera ganti password dan keluarkan perangkat tersebut dari menu Profil > Sesi.`},
+	{Event: msgLoginNewDevice, Channel: messageChannelWhatsApp, Language: languageEnglish, Body: `🔐 *Sign-in from a new device*
+
+Hello {{.Nama}}, your account was just used to sign in from a device we have not seen before.
+
+• Time: {{datetime .Waktu}}
+• IP: {{.IP}}
+• Device: {{.Perangkat}}
+
+If this was not you, change your password right away and sign the device out from Profile > Sessions.`},
+	{Event: msgLoginNewDevice, Channel: messageChannelEmail, Language: languageIndonesian,
+		Subject: `Login dari perangkat baru - Kost Putra Rahmat ZAW`,
+		Body: `<div style="font-family: Arial, sans-serif; padding: 20px; color: #333;">
+	<h2>Login dari perangkat baru</h2>
+	<p>Halo, <strong>{{.Nama}}</strong>,</p>
+	<p>Akun Anda baru saja login dari perangkat yang belum pernah dipakai sebelumnya.</p>
+	<ul>
+		<li>Waktu: {{datetime .Waktu}}</li>
+		<li>IP: {{.IP}}</li>
+		<li>Perangkat: {{.Perangkat}}</li>
+	</ul>
+	<p>Jika ini bukan Anda, segera ganti password dan keluarkan perangkat tersebut dari menu Profil &gt; Sesi.</p>
+	<p style="font-size: 12px; color: #888;">Kost Putra Rahmat ZAW Management</p>
+</div>`},
+	{Event: msgLoginNewDevice, Channel: messageChannelEmail, Language: languageEnglish,
+		Subject: `Sign-in from a new device - Kost Putra Rahmat ZAW`,
+		Body: `<div style="font-family: Arial, sans-serif; padding: 20px; color: #333;">
+	<h2>Sign-in from a new device</h2>
+	<p>Hello, <strong>{{.Nama}}</strong>,</p>
+	<p>Your account was just used to sign in from a device we have not seen before.</p>
+	<ul>
+		<li>Time: {{datetime .Waktu}}</li>
+		<li>IP: {{.IP}}</li>
+		<li>Device: {{.Perangkat}}</li>
+	</ul>
+	<p>If this was not you, change your password right away and sign the device out from Profile &gt; Sessions.</p>
+	<p style="font-size: 12px; color: #888;">Kost Putra Rahmat ZAW Management</p>
+</div>`},
+}
+
+// messageTemplateSamples adalah data contoh untuk preview dan validasi template. Kuncinya sekaligus
+// daftar variabel yang tersedia bagi admin.
+var messageTemplateSamples = map[string]map[string]interface{}{
+	msgRoomDeletedAdmin: {
+		"Nama": "Budi Santoso", "NomorHP": "6281234567890", "NomorKamar": "A1", "TipeKamar": "Standard",
+		"Jumlah": 1500000.0, "BuktiTransfer": "https://example.com/bukti.jpg",
+	},
+	msgRoomDeletedTenantRefund: {"Nama": "Budi Santoso", "NomorKamar": "A1", "Jumlah": 1500000.0},
+	msgRoomDeletedTenant:       {"Nama": "Budi Santoso", "NomorKamar": "A1"},
+	msgBookingAutoCancelled:    {"Nama": "Budi Santoso", "NomorKamar": "A1"},
+	msgPaymentConfirmed: {
+		"Nama": "Budi Santoso", "NomorKamar": "A1", "Jumlah": 1500000.0,
+		"Tanggal": time.Date(2026, 10, 1, 9, 30, 0, 0, time.Local),
+	},
+	msgPaymentReminder: {
+		"Nama": "Budi Santoso", "NomorKamar": "A1", "Jumlah": 1500000.0, "SisaTagihan": 1650000.0,
+		"JatuhTempo": time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local), "LinkPembayaran": "https://example.com/dashboard/payments/1",
+	},
+	msgPaymentOverdue: {
+		"Nama": "Budi Santoso", "NomorKamar": "A1", "Jumlah": 1500000.0, "Denda": 25000.0, "TipeDenda": "daily",
+		"JatuhTempo": time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+	},
+	msgRoomLocked:     {"Nama": "Budi Santoso", "NomorKamar": "A1", "HariTelat": 10, "Total": 1750000.0},
+	msgRefundPaid:     {"Nama": "Budi Santoso", "NomorKamar": "A1", "Jumlah": 1500000.0},
+	msgContactMessage: {"Nama": "Budi Santoso", "Email": "budi@example.com", "Pesan": "Apakah masih ada kamar kosong bulan depan?"},
+	msgLoginNewDevice: {
+		"Nama": "Budi Santoso", "IP": "203.0.113.7", "Perangkat": "Mozilla/5.0 (Android 14; Mobile)",
+		"Waktu": time.Date(2026, 10, 1, 21, 15, 0, 0, time.Local),
+	},
+	msgMaintenanceUpdated: {
+		"Nama": "Budi Santoso", "NomorKamar": "A1", "Judul": "AC tidak dingin", "Status": "Resolved",
+		"Catatan": "Freon sudah diisi ulang",
+	},
+}
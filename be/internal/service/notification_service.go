@@ -0,0 +1,116 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// NotificationService menyimpan notifikasi in-app (riwayat + status dibaca) dan, jika aktif,
+// meneruskannya ke Socket.io. Semua titik notifikasi (konfirmasi pembayaran, auto-cancel,
+// penghapusan kamar, reminder) menulis lewat service ini.
+type NotificationService interface {
+	Notify(userID uint, event utils.SocketEvent) error
+	NotifyAdmins(event utils.SocketEvent) error
+	GetNotifications(userID uint, pagination *utils.Pagination, unreadOnly bool) ([]models.Notification, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(id uint, userID uint) error
+	MarkAllRead(userID uint) (int64, error)
+}
+
+type notificationService struct {
+	repo     repository.NotificationRepository
+	realtime bool
+}
+
+// NewNotificationService: realtime=true juga mengirim event ke socket user/admin yang terhubung
+func NewNotificationService(repo repository.NotificationRepository, realtime bool) NotificationService {
+	return &notificationService{repo, realtime}
+}
+
+func newNotification(userID uint, event utils.SocketEvent) models.Notification {
+	notification := models.Notification{
+		UserID:  userID,
+		Event:   event.Event,
+		Title:   event.Title,
+		Message: event.Message,
+		Type:    event.Type,
+	}
+	if event.Data != nil {
+		if data, err := json.Marshal(event.Data); err == nil {
+			notification.Data = string(data)
+		}
+	}
+	return notification
+}
+
+func (s *notificationService) Notify(userID uint, event utils.SocketEvent) error {
+	if userID == 0 {
+		return errors.New("notification recipient is required")
+	}
+
+	notification := newNotification(userID, event)
+	if err := s.repo.Create(&notification); err != nil {
+		return err
+	}
+
+	if s.realtime {
+		event.NotificationID = notification.ID
+		utils.EmitToUser(userID, event)
+	}
+	return nil
+}
+
+func (s *notificationService) NotifyAdmins(event utils.SocketEvent) error {
+	adminIDs, err := s.repo.FindAdminUserIDs()
+	if err != nil {
+		return err
+	}
+
+	notifications := make([]models.Notification, 0, len(adminIDs))
+	for _, id := range adminIDs {
+		notifications = append(notifications, newNotification(id, event))
+	}
+	if err := s.repo.CreateBatch(notifications); err != nil {
+		return err
+	}
+
+	if s.realtime {
+		// Room admin dibagi bersama, jadi tidak ada satu NotificationID yang berlaku untuk semua
+		utils.EmitToAdmins(event)
+	}
+	return nil
+}
+
+func (s *notificationService) GetNotifications(userID uint, pagination *utils.Pagination, unreadOnly bool) ([]models.Notification, error) {
+	notifications, total, err := s.repo.FindByUserID(userID, pagination, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	pagination.SetTotal(total)
+	return notifications, nil
+}
+
+func (s *notificationService) CountUnread(userID uint) (int64, error) {
+	return s.repo.CountUnread(userID)
+}
+
+func (s *notificationService) MarkRead(id uint, userID uint) error {
+	affected, err := s.repo.MarkRead(id, userID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// Notifikasi milik user lain diperlakukan sama dengan tidak ada
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *notificationService) MarkAllRead(userID uint) (int64, error) {
+	return s.repo.MarkAllRead(userID)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Test Notify - notifikasi disimpan dengan data referensi dalam JSON
+func TestNotificationService_Notify(t *testing.T) {
+	repo := new(MockNotificationRepository)
+	service := NewNotificationService(repo, true)
+
+	repo.On("Create", mock.MatchedBy(func(n *models.Notification) bool {
+		return n.UserID == 5 && n.Event == "payment.confirmed" && n.Type == "success" && n.Data == `{"payment_id":9}` && !n.IsRead && n.ReadAt == nil
+	})).Return(nil)
+
+	err := service.Notify(5, utils.SocketEvent{
+		Event:   "payment.confirmed",
+		Title:   "Pembayaran Dikonfirmasi",
+		Message: "Pembayaran telah dikonfirmasi",
+		Type:    "success",
+		Data:    map[string]interface{}{"payment_id": 9},
+	})
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+// Test NotifyAdmins - satu baris notifikasi per admin
+func TestNotificationService_NotifyAdmins(t *testing.T) {
+	repo := new(MockNotificationRepository)
+	service := NewNotificationService(repo, false)
+
+	repo.On("FindAdminUserIDs").Return([]uint{1, 2}, nil)
+	repo.On("CreateBatch", mock.MatchedBy(func(ns []models.Notification) bool {
+		return len(ns) == 2 && ns[0].UserID == 1 && ns[1].UserID == 2 && ns[1].Event == "booking.created"
+	})).Return(nil)
+
+	err := service.NotifyAdmins(utils.SocketEvent{Event: "booking.created", Title: "Booking Baru", Type: "info"})
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+// Test GetNotifications - total baris diisi ke pagination
+func TestNotificationService_GetNotifications(t *testing.T) {
+	repo := new(MockNotificationRepository)
+	service := NewNotificationService(repo, false)
+
+	pagination := &utils.Pagination{Page: 1, Limit: 2}
+	repo.On("FindByUserID", uint(5), pagination, true).Return([]models.Notification{{ID: 1}, {ID: 2}}, 3, nil)
+
+	notifications, err := service.GetNotifications(5, pagination, true)
+
+	assert.NoError(t, err)
+	assert.Len(t, notifications, 2)
+	assert.Equal(t, int64(3), pagination.TotalRows)
+	assert.Equal(t, 2, pagination.TotalPages)
+}
+
+// Test MarkRead - notifikasi milik user lain dianggap tidak ditemukan
+func TestNotificationService_MarkRead_NotOwned(t *testing.T) {
+	repo := new(MockNotificationRepository)
+	service := NewNotificationService(repo, false)
+
+	repo.On("MarkRead", uint(10), uint(5)).Return(0, nil)
+
+	err := service.MarkRead(10, 5)
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	repo.AssertExpectations(t)
+}
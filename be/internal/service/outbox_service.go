@@ -0,0 +1,278 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	outboxChannelWhatsApp = "whatsapp"
+	outboxChannelEmail    = "email"
+
+	outboxKindWhatsApp = "whatsapp"
+	outboxKindEmail    = "email"
+
+	outboxDefaultMaxAttempts = 5
+	outboxBaseBackoff        = time.Minute
+	outboxMaxBackoff         = 6 * time.Hour
+	outboxBatchSize          = 50
+	// pesan "Sending" yang tidak selesai dalam waktu ini dianggap ditinggal worker dan diklaim ulang
+	outboxClaimTimeout = 10 * time.Minute
+)
+
+// OutboxService mengirim pesan WhatsApp/email yang tersimpan di tabel outbox. Pesan gagal
+// dijadwalkan ulang dengan backoff eksponensial dan menjadi "Dead" setelah MaxAttempts.
+type OutboxService interface {
+	ProcessDue() (int, error)
+	GetMessages(pagination *utils.Pagination, filter repository.OutboxFilter) ([]models.OutboxMessage, error)
+	Resend(id uint) (*models.OutboxMessage, error)
+	RecordDeliveryStatuses(provider string, statuses []utils.WhatsAppDeliveryStatus) (int, error)
+}
+
+type outboxService struct {
+	repo        repository.OutboxRepository
+	waSender    utils.WhatsAppSender // pengirim asli (Fonnte / simulasi)
+	emailSender utils.EmailSender    // pengirim asli (SMTP / simulasi)
+}
+
+func NewOutboxService(repo repository.OutboxRepository, waSender utils.WhatsAppSender, emailSender utils.EmailSender) OutboxService {
+	return &outboxService{repo, waSender, emailSender}
+}
+
+// outboxBackoff: 1m, 2m, 4m, ... dibatasi 6 jam
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return delay
+}
+
+// ProcessDue mengirim pesan yang sudah jatuh tempo dan mengembalikan jumlah yang terkirim.
+// Baris diklaim secara atomik, jadi aman dijalankan beberapa instance sekaligus.
+func (s *outboxService) ProcessDue() (int, error) {
+	now := time.Now()
+	messages, err := s.repo.ClaimDue(now, now.Add(outboxClaimTimeout), outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range messages {
+		if s.attempt(&messages[i]) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// attempt mengirim satu pesan dan menyimpan hasilnya (Sent, dijadwalkan ulang, atau Dead)
+func (s *outboxService) attempt(message *models.OutboxMessage) bool {
+	message.Attempts++
+	err := s.deliver(message)
+
+	now := time.Now()
+	if err == nil {
+		message.Status = "Sent"
+		message.SentAt = now
+		message.LastError = ""
+	} else {
+		message.LastError = err.Error()
+		maxAttempts := message.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = outboxDefaultMaxAttempts
+		}
+		if message.Attempts >= maxAttempts {
+			message.Status = "Dead"
+			log.Printf("[WARN] Outbox message %d (%s ke %s) gagal %d kali, dipindah ke dead-letter: %v", message.ID, message.Kind, message.Recipient, message.Attempts, err)
+		} else {
+			message.Status = "Pending"
+			message.NextAttemptAt = now.Add(outboxBackoff(message.Attempts))
+		}
+	}
+
+	if updateErr := s.repo.Update(message); updateErr != nil {
+		log.Printf("[WARN] Gagal menyimpan status outbox message %d: %v", message.ID, updateErr)
+	}
+	return err == nil
+}
+
+func (s *outboxService) deliver(message *models.OutboxMessage) error {
+	switch message.Kind {
+	case outboxKindWhatsApp:
+		var payload outboxWhatsAppPayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return err
+		}
+		// Provider yang mengembalikan ID pesan memungkinkan callback status dicocokkan ke pesan ini
+		if provider, ok := s.waSender.(utils.WhatsAppProvider); ok {
+			messageID, err := provider.Send(message.Recipient, payload.Message)
+			if err != nil {
+				return err
+			}
+			message.Provider = provider.Name()
+			message.ProviderMessageID = messageID
+			message.DeliveryStatus = utils.WhatsAppStatusSent
+			message.DeliveryUpdatedAt = time.Now()
+			return nil
+		}
+		return s.waSender.SendWhatsApp(message.Recipient, payload.Message)
+	case outboxKindEmail:
+		var payload outboxEmailPayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return err
+		}
+		return s.emailSender.SendEmail(message.Recipient, payload.Subject, payload.Body, payload.Attachment)
+	default:
+		return fmt.Errorf("unknown outbox message kind: %s", message.Kind)
+	}
+}
+
+func (s *outboxService) GetMessages(pagination *utils.Pagination, filter repository.OutboxFilter) ([]models.OutboxMessage, error) {
+	messages, total, err := s.repo.FindAllPaginated(pagination, filter)
+	if err != nil {
+		return nil, err
+	}
+	pagination.SetTotal(total)
+	return messages, nil
+}
+
+// Resend mengirim ulang pesan yang gagal (Dead atau masih menunggu retry) sekarang juga.
+// Jumlah percobaan direset sehingga pesan kembali mendapat jatah retry penuh.
+func (s *outboxService) Resend(id uint) (*models.OutboxMessage, error) {
+	message, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if message.Status == "Sent" {
+		return nil, errors.New("message has already been sent")
+	}
+
+	// Klaim dulu agar tidak terkirim ganda bersama worker outbox
+	now := time.Now()
+	message, err = s.repo.ClaimByID(id, now, now.Add(outboxClaimTimeout))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("message is already being sent")
+		}
+		return nil, err
+	}
+
+	message.Attempts = 0
+	s.attempt(message)
+	return message, nil
+}
+
+// urutan status pengiriman; callback yang datang terlambat tidak boleh menurunkan status
+var deliveryStatusRank = map[string]int{
+	utils.WhatsAppStatusSent:      1,
+	utils.WhatsAppStatusDelivered: 2,
+	utils.WhatsAppStatusRead:      3,
+	utils.WhatsAppStatusFailed:    4,
+}
+
+// RecordDeliveryStatuses menyimpan status pengiriman dari callback provider WA ke pesan outbox
+// yang cocok dan mengembalikan jumlah pesan yang diperbarui. ID yang tidak dikenal diabaikan.
+func (s *outboxService) RecordDeliveryStatuses(provider string, statuses []utils.WhatsAppDeliveryStatus) (int, error) {
+	updated := 0
+	for _, status := range statuses {
+		message, err := s.repo.FindByProviderMessageID(provider, status.MessageID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return updated, err
+		}
+		if deliveryStatusRank[status.Status] < deliveryStatusRank[message.DeliveryStatus] {
+			continue
+		}
+
+		message.DeliveryStatus = status.Status
+		message.DeliveryUpdatedAt = status.Timestamp
+		if status.Status == utils.WhatsAppStatusFailed {
+			message.LastError = status.Error
+			log.Printf("[WARN] Outbox message %d gagal terkirim menurut %s: %s", message.ID, provider, status.Error)
+		}
+		if err := s.repo.Update(message); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// ---- Pengirim yang menulis ke outbox (dipakai service bisnis) ----
+
+type outboxWhatsAppPayload struct {
+	Message string `json:"message"`
+}
+
+type outboxEmailPayload struct {
+	Subject    string                 `json:"subject"`
+	Body       string                 `json:"body"`
+	Attachment *utils.EmailAttachment `json:"attachment,omitempty"`
+}
+
+func enqueueOutbox(repo repository.OutboxRepository, channel, kind, recipient string, payload interface{}) error {
+	if recipient == "" {
+		return errors.New("outbox recipient is required")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return repo.Create(&models.OutboxMessage{
+		Channel:       channel,
+		Kind:          kind,
+		Recipient:     recipient,
+		Payload:       string(body),
+		Status:        "Pending",
+		MaxAttempts:   outboxDefaultMaxAttempts,
+		NextAttemptAt: time.Now(),
+	})
+}
+
+// OutboxWhatsAppSender mengantrekan pesan WA ke outbox; nil berarti pesan sudah tersimpan, bukan terkirim
+type OutboxWhatsAppSender struct {
+	repo repository.OutboxRepository
+}
+
+func NewOutboxWhatsAppSender(repo repository.OutboxRepository) *OutboxWhatsAppSender {
+	return &OutboxWhatsAppSender{repo: repo}
+}
+
+func (s *OutboxWhatsAppSender) SendWhatsApp(to, message string) error {
+	return enqueueOutbox(s.repo, outboxChannelWhatsApp, outboxKindWhatsApp, to, outboxWhatsAppPayload{Message: message})
+}
+
+// OutboxEmailSender mengantrekan email notifikasi ke outbox. Email reset password tetap dikirim
+// langsung (lihat direct) karena interaktif dan token tidak boleh tersimpan di tabel outbox.
+type OutboxEmailSender struct {
+	repo   repository.OutboxRepository
+	direct utils.EmailSender
+}
+
+func NewOutboxEmailSender(repo repository.OutboxRepository, direct utils.EmailSender) *OutboxEmailSender {
+	return &OutboxEmailSender{repo: repo, direct: direct}
+}
+
+func (s *OutboxEmailSender) SendResetPasswordEmail(toEmail, token string) error {
+	return s.direct.SendResetPasswordEmail(toEmail, token)
+}
+
+func (s *OutboxEmailSender) SendEmail(toEmail, subject, htmlBody string, attachment *utils.EmailAttachment) error {
+	return enqueueOutbox(s.repo, outboxChannelEmail, outboxKindEmail, toEmail, outboxEmailPayload{
+		Subject: subject, Body: htmlBody, Attachment: attachment,
+	})
+}
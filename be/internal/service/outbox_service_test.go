@@ -0,0 +1,207 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Test OutboxWhatsAppSender - pesan disimpan sebagai Pending, bukan langsung dikirim
+func TestOutboxWhatsAppSender_Enqueue(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	repo.On("Create", mock.MatchedBy(func(m *models.OutboxMessage) bool {
+		return m.Channel == "whatsapp" && m.Recipient == "08123" && m.Status == "Pending" &&
+			m.Payload == `{"message":"Halo"}` && m.MaxAttempts == outboxDefaultMaxAttempts
+	})).Return(nil)
+
+	err := NewOutboxWhatsAppSender(repo).SendWhatsApp("08123", "Halo")
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+// Test ProcessDue - sukses ditandai Sent, gagal dijadwalkan ulang, percobaan terakhir jadi Dead
+func TestOutboxService_ProcessDue(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	wa := new(MockWhatsAppSender)
+	service := NewOutboxService(repo, wa, new(MockEmailSender))
+
+	repo.On("ClaimDue", outboxBatchSize).Return([]models.OutboxMessage{
+		{ID: 1, Kind: "whatsapp", Recipient: "0811", Payload: `{"message":"ok"}`, Status: "Sending", MaxAttempts: 5},
+		{ID: 2, Kind: "whatsapp", Recipient: "0812", Payload: `{"message":"retry"}`, Status: "Sending", Attempts: 1, MaxAttempts: 5},
+		{ID: 3, Kind: "whatsapp", Recipient: "0813", Payload: `{"message":"dead"}`, Status: "Sending", Attempts: 4, MaxAttempts: 5},
+	}, nil)
+	wa.On("SendWhatsApp", "0811", "ok").Return(nil)
+	wa.On("SendWhatsApp", "0812", "retry").Return(errors.New("fonnte down"))
+	wa.On("SendWhatsApp", "0813", "dead").Return(errors.New("fonnte down"))
+
+	var saved []models.OutboxMessage
+	repo.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, *args.Get(0).(*models.OutboxMessage))
+	}).Return(nil)
+
+	before := time.Now()
+	sent, err := service.ProcessDue()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, saved, 3)
+
+	assert.Equal(t, "Sent", saved[0].Status)
+	assert.Equal(t, 1, saved[0].Attempts)
+
+	// Percobaan ke-2 gagal: backoff 2 menit
+	assert.Equal(t, "Pending", saved[1].Status)
+	assert.Equal(t, 2, saved[1].Attempts)
+	assert.Equal(t, "fonnte down", saved[1].LastError)
+	assert.WithinDuration(t, before.Add(2*time.Minute), saved[1].NextAttemptAt, 5*time.Second)
+
+	assert.Equal(t, "Dead", saved[2].Status)
+	assert.Equal(t, 5, saved[2].Attempts)
+}
+
+// Test Resend - pesan dead-letter dikirim ulang dengan jatah percobaan baru
+func TestOutboxService_Resend(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	wa := new(MockWhatsAppSender)
+	service := NewOutboxService(repo, wa, new(MockEmailSender))
+
+	repo.On("FindByID", uint(3)).Return(&models.OutboxMessage{ID: 3, Kind: "whatsapp", Recipient: "0813", Payload: `{"message":"dead"}`, Status: "Dead", Attempts: 5, MaxAttempts: 5}, nil)
+	repo.On("ClaimByID", uint(3)).Return(&models.OutboxMessage{ID: 3, Kind: "whatsapp", Recipient: "0813", Payload: `{"message":"dead"}`, Status: "Sending", Attempts: 5, MaxAttempts: 5}, nil)
+	wa.On("SendWhatsApp", "0813", "dead").Return(nil)
+	repo.On("Update", mock.Anything).Return(nil)
+
+	message, err := service.Resend(3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Sent", message.Status)
+	assert.Equal(t, 1, message.Attempts)
+}
+
+// Test Resend - pesan yang sedang diklaim worker outbox tidak dikirim ganda
+func TestOutboxService_Resend_AlreadyClaimed(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	wa := new(MockWhatsAppSender)
+	service := NewOutboxService(repo, wa, new(MockEmailSender))
+
+	repo.On("FindByID", uint(3)).Return(&models.OutboxMessage{ID: 3, Kind: "whatsapp", Status: "Sending"}, nil)
+	repo.On("ClaimByID", uint(3)).Return(nil, gorm.ErrRecordNotFound)
+
+	message, err := service.Resend(3)
+
+	assert.Nil(t, message)
+	assert.EqualError(t, err, "message is already being sent")
+	wa.AssertNotCalled(t, "SendWhatsApp", mock.Anything, mock.Anything)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, outboxBackoff(1))
+	assert.Equal(t, 8*time.Minute, outboxBackoff(4))
+	assert.Equal(t, outboxMaxBackoff, outboxBackoff(20))
+}
+
+// Test ProcessDue dengan provider - ID pesan dari provider disimpan untuk callback status
+func TestOutboxService_ProcessDue_StoresProviderMessageID(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	fake := utils.NewFakeWhatsAppProvider()
+	service := NewOutboxService(repo, fake, new(MockEmailSender))
+
+	repo.On("ClaimDue", outboxBatchSize).Return([]models.OutboxMessage{
+		{ID: 1, Kind: "whatsapp", Recipient: "6281", Payload: `{"message":"Halo"}`, Status: "Sending", MaxAttempts: 5},
+	}, nil)
+	var saved models.OutboxMessage
+	repo.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+		saved = *args.Get(0).(*models.OutboxMessage)
+	}).Return(nil)
+
+	sent, err := service.ProcessDue()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []utils.FakeWhatsAppMessage{{ID: "fake-1", To: "6281", Message: "Halo"}}, fake.Sent())
+	assert.Equal(t, "fake", saved.Provider)
+	assert.Equal(t, "fake-1", saved.ProviderMessageID)
+	assert.Equal(t, "sent", saved.DeliveryStatus)
+}
+
+// Test RecordDeliveryStatuses - status tidak turun, ID tidak dikenal diabaikan, failed mengisi LastError
+func TestOutboxService_RecordDeliveryStatuses(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	service := NewOutboxService(repo, new(MockWhatsAppSender), new(MockEmailSender))
+
+	read := &models.OutboxMessage{ID: 1, Provider: "cloudapi", ProviderMessageID: "wamid.1", DeliveryStatus: "read"}
+	sent := &models.OutboxMessage{ID: 2, Provider: "cloudapi", ProviderMessageID: "wamid.2", DeliveryStatus: "sent"}
+	repo.On("FindByProviderMessageID", "cloudapi", "wamid.1").Return(read, nil)
+	repo.On("FindByProviderMessageID", "cloudapi", "wamid.2").Return(sent, nil)
+	repo.On("FindByProviderMessageID", "cloudapi", "wamid.x").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("Update", sent).Return(nil)
+
+	updated, err := service.RecordDeliveryStatuses("cloudapi", []utils.WhatsAppDeliveryStatus{
+		{MessageID: "wamid.1", Status: "delivered"},
+		{MessageID: "wamid.x", Status: "read"},
+		{MessageID: "wamid.2", Status: "failed", Error: "131026: Message undeliverable"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, "read", read.DeliveryStatus)
+	assert.Equal(t, "failed", sent.DeliveryStatus)
+	assert.Equal(t, "131026: Message undeliverable", sent.LastError)
+	repo.AssertNotCalled(t, "Update", read)
+}
+
+// Test provider webhook generik terhadap stub server (FakeWhatsAppProvider sebagai gateway HTTP)
+func TestWebhookWASender_StubServer(t *testing.T) {
+	fake := utils.NewFakeWhatsAppProvider()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	sender := utils.NewWebhookWASender(server.URL, "secret")
+	id, err := sender.Send("6281332314854", "Tagihan jatuh tempo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "fake-1", id)
+	assert.Equal(t, "Tagihan jatuh tempo", fake.Sent()[0].Message)
+
+	fake.FailWith(errors.New("gateway down"))
+	_, err = sender.Send("6281332314854", "lagi")
+	assert.Error(t, err)
+}
+
+// Test format WhatsApp Cloud API - request pesan teks dan parsing callback statuses
+func TestCloudAPIWASender(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/12345/messages", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.ABC"}]}`))
+	}))
+	defer server.Close()
+
+	sender := utils.NewCloudAPIWASender(server.URL, "12345", "token")
+	id, err := sender.Send("6281332314854", "Halo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "wamid.ABC", id)
+	assert.Equal(t, "whatsapp", received["messaging_product"])
+	assert.Equal(t, map[string]interface{}{"preview_url": false, "body": "Halo"}, received["text"])
+
+	statuses, err := sender.ParseStatusCallback([]byte(`{"object":"whatsapp_business_account","entry":[{"changes":[{"value":{"statuses":[
+		{"id":"wamid.ABC","status":"delivered","timestamp":"1760000000"},
+		{"id":"wamid.DEF","status":"failed","timestamp":"1760000001","errors":[{"code":131026,"title":"Message undeliverable"}]}]}}]}]}`))
+
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, utils.WhatsAppDeliveryStatus{MessageID: "wamid.ABC", Status: "delivered", Timestamp: time.Unix(1760000000, 0)}, statuses[0])
+	assert.Equal(t, "131026: Message undeliverable", statuses[1].Error)
+}
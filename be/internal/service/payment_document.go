@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var paymentTypeLabels = map[string]string{
+	"full":           "Pembayaran Penuh",
+	"dp":             "Uang Muka (DP)",
+	"installment":    "Cicilan",
+	"extend":         "Perpanjangan Sewa",
+	"deposit":        "Uang Jaminan",
+	"deposit_charge": "Kekurangan Uang Jaminan",
+}
+
+// invoiceNumber mengembalikan nomor invoice pembayaran. OrderID dipakai jika sudah terisi.
+func invoiceNumber(p models.Pembayaran) string {
+	if p.OrderID != "" {
+		return p.OrderID
+	}
+	return fmt.Sprintf("INV-%06d", p.ID)
+}
+
+// receiptNumber membentuk nomor kwitansi dari bulan konfirmasi dan ID pembayaran,
+// mis. KWT/2026/10/000042.
+func receiptNumber(p models.Pembayaran) string {
+	return fmt.Sprintf("KWT/%s/%06d", p.ConfirmedAt.Format("2006/01"), p.ID)
+}
+
+// paymentDocumentFilename: nama file PDF yang aman untuk URL dan lampiran email
+func paymentDocumentFilename(p models.Pembayaran, receipt bool) string {
+	number := invoiceNumber(p)
+	if receipt {
+		number = p.NomorKwitansi
+	}
+	return strings.NewReplacer("/", "-", " ", "-").Replace(number) + ".pdf"
+}
+
+func formatRupiah(amount float64) string {
+	s := fmt.Sprintf("%.0f", amount)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	if neg {
+		return "-Rp " + b.String()
+	}
+	return "Rp " + b.String()
+}
+
+func formatIndonesianDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%d %s", t.Day(), formatIndonesianMonth(t))
+}
+
+// renderPaymentPDF merender invoice (tagihan) atau kwitansi (bukti lunas) pembayaran.
+// Pembayaran harus sudah memuat Pemesanan.Penyewa, Pemesanan.Kamar dan LineItems.
+func renderPaymentPDF(p models.Pembayaran, receipt bool) []byte {
+	doc := utils.NewPDFDocument()
+	booking := p.Pemesanan
+
+	title := "INVOICE"
+	if receipt {
+		title = "KWITANSI"
+	}
+
+	doc.Text(50, 60, 16, true, "Kost Putra Rahmat ZAW")
+	doc.Text(400, 60, 18, true, title)
+	doc.Line(50, 75, 545, 75)
+
+	y := 100.0
+	row := func(label, value string) {
+		doc.Text(50, y, 10, false, label)
+		doc.Text(170, y, 10, false, ": "+value)
+		y += 16
+	}
+
+	if receipt {
+		row("No. Kwitansi", p.NomorKwitansi)
+		row("No. Invoice", invoiceNumber(p))
+		row("Tanggal Lunas", formatIndonesianDate(p.ConfirmedAt))
+	} else {
+		row("No. Invoice", invoiceNumber(p))
+		row("Tanggal", formatIndonesianDate(p.CreatedAt))
+		row("Jatuh Tempo", formatIndonesianDate(p.TanggalJatuhTempo))
+	}
+	row("Nama Penyewa", booking.Penyewa.NamaLengkap)
+	row("No. HP", booking.Penyewa.NomorHP)
+	row("Kamar", fmt.Sprintf("%s (%s)", booking.Kamar.NomorKamar, booking.Kamar.TipeKamar))
+	row("Metode", p.MetodePembayaran)
+
+	y += 10
+	doc.Line(50, y, 545, y)
+	y += 16
+	doc.Text(50, y, 10, true, "Keterangan")
+	doc.Text(420, y, 10, true, "Jumlah")
+	y += 8
+	doc.Line(50, y, 545, y)
+	y += 16
+
+	label := paymentTypeLabels[p.TipePembayaran]
+	if label == "" {
+		label = "Pembayaran"
+	}
+	if p.TipePembayaran == "installment" && p.CicilanKe > 0 {
+		label = fmt.Sprintf("%s ke-%d", label, p.CicilanKe)
+	}
+	items := []models.PaymentLineItem{{Deskripsi: fmt.Sprintf("%s - Kamar %s", label, booking.Kamar.NomorKamar), Jumlah: p.JumlahBayar}}
+	items = append(items, p.LineItems...)
+
+	var total float64
+	for _, item := range items {
+		doc.Text(50, y, 10, false, item.Deskripsi)
+		doc.Text(420, y, 10, false, formatRupiah(item.Jumlah))
+		total += item.Jumlah
+		y += 16
+	}
+
+	doc.Line(50, y, 545, y)
+	y += 18
+	doc.Text(300, y, 11, true, "TOTAL")
+	doc.Text(420, y, 11, true, formatRupiah(total))
+
+	y += 40
+	if receipt {
+		doc.Text(50, y, 10, false, "Telah diterima pembayaran di atas dengan status LUNAS.")
+		doc.Text(50, y+16, 10, false, "Kwitansi ini sah tanpa tanda tangan karena dibuat oleh sistem.")
+	} else {
+		doc.Text(50, y, 10, false, fmt.Sprintf("Status: %s", p.StatusPembayaran))
+		doc.Text(50, y+16, 10, false, "Mohon lakukan pembayaran sebelum tanggal jatuh tempo.")
+	}
+
+	return doc.Bytes()
+}
+
+func loadPaymentForDocument(db *gorm.DB, paymentID uint) (*models.Pembayaran, error) {
+	var payment models.Pembayaran
+	err := db.Preload("Pemesanan.Penyewa").Preload("Pemesanan.Kamar").Preload("LineItems").First(&payment, paymentID).Error
+	return &payment, err
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test formatRupiah - pemisah ribuan gaya Indonesia
+func TestFormatRupiah(t *testing.T) {
+	assert.Equal(t, "Rp 0", formatRupiah(0))
+	assert.Equal(t, "Rp 950", formatRupiah(950))
+	assert.Equal(t, "Rp 1.500.000", formatRupiah(1500000))
+	assert.Equal(t, "-Rp 50.000", formatRupiah(-50000))
+}
+
+// Test renderPaymentPDF - kwitansi memuat nomor, denda dan total; nama file aman
+func TestRenderPaymentPDF_Receipt(t *testing.T) {
+	payment := models.Pembayaran{
+		ID:               42,
+		JumlahBayar:      1000000,
+		StatusPembayaran: "Confirmed",
+		TipePembayaran:   "extend",
+		ConfirmedAt:      time.Date(2026, 10, 5, 0, 0, 0, 0, time.Local),
+		Pemesanan: models.Pemesanan{
+			Penyewa: models.Penyewa{NamaLengkap: "Budi (Andi)"},
+			Kamar:   models.Kamar{NomorKamar: "A1", TipeKamar: "Standard"},
+		},
+		LineItems: []models.PaymentLineItem{{Tipe: "late_fee", Deskripsi: "Denda keterlambatan", Jumlah: 20000}},
+	}
+	payment.NomorKwitansi = receiptNumber(payment)
+
+	assert.Equal(t, "KWT/2026/10/000042", payment.NomorKwitansi)
+	assert.Equal(t, "KWT-2026-10-000042.pdf", paymentDocumentFilename(payment, true))
+	assert.Equal(t, "INV-000042.pdf", paymentDocumentFilename(payment, false))
+
+	pdf := string(renderPaymentPDF(payment, true))
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	assert.Contains(t, pdf, "(KWITANSI)")
+	assert.Contains(t, pdf, "KWT/2026/10/000042")
+	assert.Contains(t, pdf, `Budi \(Andi\)`)
+	assert.Contains(t, pdf, "Rp 1.020.000")
+}
@@ -5,6 +5,8 @@ import (
 	"koskosan-be/internal/models"
 	"koskosan-be/internal/repository"
 	"koskosan-be/internal/utils"
+	"math"
+	"strings"
 	"time"
 
 	"gorm.io/gorm/clause"
@@ -13,13 +15,17 @@ import (
 
 type PaymentService interface {
 	GetAllPayments() ([]models.Pembayaran, error)
-	ConfirmPayment(paymentID uint) error
-	RejectPayment(paymentID uint) error
-	CreatePaymentSession(pemesananID uint, paymentType string, userID uint) (*models.Pembayaran, error)
-	ConfirmCashPayment(paymentID uint) error
+	GetPaymentsPaginated(pagination *utils.Pagination, filter repository.PaymentFilter) ([]models.Pembayaran, int64, error)
+	GetPaymentByOrderID(orderID string) (*models.Pembayaran, error)
+	ConfirmPayment(paymentID uint, actor AuditActor) error
+	RejectPayment(paymentID uint, actor AuditActor) error
+	CreatePaymentSession(pemesananID uint, paymentType string, userID uint, actor AuditActor) (*models.Pembayaran, error)
+	ConfirmCashPayment(paymentID uint, actor AuditActor) error
 	GetPaymentReminders(userID uint) ([]models.PaymentReminder, error)
 	CreatePaymentReminder(pembayaranID uint, jumlahBayar float64, daysUntilDue int) error
-	UploadPaymentProof(paymentID uint, buktiTransfer string, userID uint) error
+	UploadPaymentProof(paymentID uint, buktiTransfer string, userID uint, actor AuditActor) error
+	RescheduleInstallments(bookingID uint, amounts []float64, firstDue time.Time, actor AuditActor) ([]models.Pembayaran, error)
+	GetPaymentDocument(paymentID uint, userID uint, role string, docType string) ([]byte, string, error)
 }
 
 type paymentService struct {
@@ -27,21 +33,35 @@ type paymentService struct {
 	bookingRepo repository.BookingRepository
 	kamarRepo   repository.KamarRepository
 	penyewaRepo repository.PenyewaRepository
+	policyRepo  repository.PricingPolicyRepository
 	db          *gorm.DB
 	emailSender utils.EmailSender
 	waSender    utils.WhatsAppSender
+	notifier    NotificationService
+	templates   MessageTemplateService
+	audit       AuditService
+	cadence     []int // tahap reminder (REMINDER_CADENCE) untuk reminder pertama tagihan baru
 }
 
-func NewPaymentService(repo repository.PaymentRepository, bookingRepo repository.BookingRepository, kamarRepo repository.KamarRepository, penyewaRepo repository.PenyewaRepository, db *gorm.DB, emailSender utils.EmailSender, waSender utils.WhatsAppSender) PaymentService {
-	return &paymentService{repo, bookingRepo, kamarRepo, penyewaRepo, db, emailSender, waSender}
+func NewPaymentService(repo repository.PaymentRepository, bookingRepo repository.BookingRepository, kamarRepo repository.KamarRepository, penyewaRepo repository.PenyewaRepository, policyRepo repository.PricingPolicyRepository, db *gorm.DB, emailSender utils.EmailSender, waSender utils.WhatsAppSender, notifier NotificationService, templates MessageTemplateService, audit AuditService, reminderCadence []int) PaymentService {
+	return &paymentService{repo, bookingRepo, kamarRepo, penyewaRepo, policyRepo, db, emailSender, waSender, notifier, templates, audit, reminderCadence}
 }
 
 func (s *paymentService) GetAllPayments() ([]models.Pembayaran, error) {
 	return s.repo.FindAll()
 }
 
-func (s *paymentService) ConfirmPayment(paymentID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+func (s *paymentService) GetPaymentsPaginated(pagination *utils.Pagination, filter repository.PaymentFilter) ([]models.Pembayaran, int64, error) {
+	return s.repo.FindAllPaginated(pagination, filter)
+}
+
+func (s *paymentService) GetPaymentByOrderID(orderID string) (*models.Pembayaran, error) {
+	return s.repo.FindByOrderID(strings.TrimSpace(orderID))
+}
+
+func (s *paymentService) ConfirmPayment(paymentID uint, actor AuditActor) error {
+	var before, after models.Pembayaran
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		txRepo := s.repo.WithTx(tx)
 		txBookingRepo := s.bookingRepo.WithTx(tx)
 		txKamarRepo := s.kamarRepo.WithTx(tx)
@@ -52,23 +72,36 @@ func (s *paymentService) ConfirmPayment(paymentID uint) error {
 		}
 
 		// FIX #19: Idempotency check - prevent duplicate confirmation
-		if payment.StatusPembayaran == "Confirmed" {
+		if payment.StatusPembayaran == models.PaymentConfirmed {
 			return fmt.Errorf("pembayaran sudah dikonfirmasi sebelumnya pada %s", payment.ConfirmedAt.Format("02 January 2006 15:04"))
 		}
+		before = *payment
 
-		payment.StatusPembayaran = "Confirmed"
+		if err := txRepo.Transition(payment, models.PaymentConfirmed, actor.statusChange("payment_confirmed")); err != nil {
+			return err
+		}
 		payment.ConfirmedAt = time.Now() // FIX #1: Track exact confirmation time
 		payment.TanggalBayar = time.Now() // Set payment date to now if not set
+		payment.NomorKwitansi = receiptNumber(*payment)
 
 		if err := txRepo.Update(payment); err != nil {
 			return err
 		}
+		after = *payment
 
 		// Update the reminder status to Paid
 		if err := tx.Model(&models.PaymentReminder{}).Where("pembayaran_id = ?", payment.ID).Update("status_reminder", "Paid").Error; err != nil {
 			return err
 		}
 
+		// Uang jaminan tidak mengubah status booking maupun kamar
+		switch payment.TipePembayaran {
+		case "deposit":
+			return markDepositHeld(tx, payment.ID)
+		case "deposit_charge":
+			return nil
+		}
+
 		// Also update booking status if needed
 		booking, err := txBookingRepo.FindByID(payment.PemesananID)
 		if err == nil {
@@ -91,13 +124,34 @@ func (s *paymentService) ConfirmPayment(paymentID uint) error {
 				}
 			}
 
-			// FIX #10: Check if it's a DP payment without full payment
-			if payment.TipePembayaran == "dp" && payment.StatusPembayaran == "Confirmed" {
-				// We can check if there are any other Confirmed non-dp payments for this booking
-				// Simplest way: For DP, just set status to Partially Paid to block move-in
-				booking.StatusPemesanan = "Partially Paid"
-			} else {
-				booking.StatusPemesanan = "Confirmed"
+			// FIX #10: DP/cicilan -> Partially Paid sampai sisa tagihan sewa awal lunas
+			target := models.BookingConfirmed
+			switch payment.TipePembayaran {
+			case "dp", "installment":
+				outstanding, err := bookingOutstanding(tx, booking, booking.Kamar.HargaPerBulan)
+				if err != nil {
+					return err
+				}
+				if outstanding > 0 {
+					target = models.BookingPartiallyPaid
+					if payment.TipePembayaran == "dp" {
+						if err := s.generateInstallmentPlan(tx, booking, outstanding); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			firstConfirmation := booking.StatusPemesanan == models.BookingPending
+			if err := txBookingRepo.Transition(booking, target, actor.statusChange("payment_confirmed")); err != nil {
+				return err
+			}
+
+			// Uang jaminan ditagih terpisah saat booking pertama kali dikonfirmasi
+			if firstConfirmation {
+				policy := resolvePricingPolicy(s.policyRepo, booking.Kamar.TipeKamar)
+				if err := ensureDepositBill(tx, booking, policy.DepositAmount); err != nil {
+					return err
+				}
 			}
 
 			// Ensure TanggalKeluar is calculated if for some reason it's zero
@@ -109,13 +163,21 @@ func (s *paymentService) ConfirmPayment(paymentID uint) error {
 				return err
 			}
 
+			// Buka kunci kamar jika penguncian karena tunggakan sudah terlunasi
+			if err := unlockKamarIfSettled(txKamarRepo, repository.NewLateFeeRepository(tx), booking.KamarID); err != nil {
+				return err
+			}
+
 			// FIX #1: Atomic room status update - use pessimistic lock
-			// Only mark Room as "Penuh" if booking is truly Confirmed or securing it with DP
+			// Only mark Room as "Penuh" if booking is truly Confirmed or securing it with DP,
+			// and the stay has already started (future stays are synced by the scheduler)
 			var lockedKamar models.Kamar
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lockedKamar, booking.KamarID).Error; err == nil {
-				lockedKamar.Status = "Penuh"
-				if err := txKamarRepo.Update(&lockedKamar); err != nil {
-					return err
+			if !booking.TanggalMulai.After(time.Now()) {
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lockedKamar, booking.KamarID).Error; err == nil {
+					lockedKamar.Status = "Penuh"
+					if err := txKamarRepo.Update(&lockedKamar); err != nil {
+						return err
+					}
 				}
 			}
 
@@ -128,7 +190,7 @@ func (s *paymentService) ConfirmPayment(paymentID uint) error {
 						var confirmedPaymentCount int64
 						tx.Table("pembayaran").
 							Joins("JOIN pemesanan ON pemesanan.id = pembayaran.pemesanan_id").
-							Where("pemesanan.penyewa_id = ? AND pembayaran.status_pembayaran = ?", booking.PenyewaID, "Confirmed").
+							Where("pemesanan.penyewa_id = ? AND pembayaran.status_pembayaran = ?", booking.PenyewaID, models.PaymentConfirmed).
 							Count(&confirmedPaymentCount)
 
 						if confirmedPaymentCount <= 1 { // This is the first confirmed payment
@@ -141,24 +203,154 @@ func (s *paymentService) ConfirmPayment(paymentID uint) error {
 			}
 		}
 
-		// Send Notifications
-		go s.sendSuccessNotifications(payment.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	recordAudit(s.audit, actor, "payment.confirm", "payment", paymentID, before, after)
+
+	// Send Notifications (setelah commit agar kwitansi terbaca dari database)
+	go s.sendSuccessNotifications(paymentID)
 
+	return nil
+}
+
+// generateInstallmentPlan membuat cicilan bulanan untuk sisa tagihan setelah DP dikonfirmasi,
+// sesuai InstallmentCount dan SettlementDays pada pricing policy. Tidak membuat ulang jika
+// booking sudah memiliki rencana cicilan.
+func (s *paymentService) generateInstallmentPlan(tx *gorm.DB, booking *models.Pemesanan, outstanding float64) error {
+	var existing int64
+	if err := tx.Model(&models.Pembayaran{}).
+		Where("pemesanan_id = ? AND tipe_pembayaran = ? AND status_pembayaran <> ?", booking.ID, "installment", models.PaymentCancelled).
+		Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
 		return nil
+	}
+
+	policy := resolvePricingPolicy(s.policyRepo, booking.Kamar.TipeKamar)
+	amounts := splitInstallments(outstanding, policy.InstallmentCount)
+	firstDue := booking.TanggalMulai.AddDate(0, 0, policy.SettlementDays)
+
+	return createInstallmentPlan(tx, buildInstallmentPlan(booking.ID, amounts, firstDue, 1), s.cadence)
+}
+
+// RescheduleInstallments mengganti cicilan yang belum dibayar dengan pembagian kustom dari admin.
+// Total amounts harus sama dengan sisa tagihan. Jika firstDue kosong, dipakai jatuh tempo
+// cicilan lama yang paling awal.
+func (s *paymentService) RescheduleInstallments(bookingID uint, amounts []float64, firstDue time.Time, actor AuditActor) ([]models.Pembayaran, error) {
+	if len(amounts) == 0 || len(amounts) > maxInstallmentCount {
+		return nil, fmt.Errorf("jumlah cicilan harus di antara 1 dan %d", maxInstallmentCount)
+	}
+	var total float64
+	for _, a := range amounts {
+		if a <= 0 {
+			return nil, fmt.Errorf("nominal cicilan harus lebih dari 0")
+		}
+		total += a
+	}
+
+	var plan, cancelled []models.Pembayaran
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		booking, err := s.bookingRepo.WithTx(tx).FindByID(bookingID)
+		if err != nil {
+			return err
+		}
+		if booking.StatusPemesanan != models.BookingPartiallyPaid {
+			return fmt.Errorf("cicilan hanya bisa diatur ulang untuk booking berstatus Partially Paid")
+		}
+
+		var open []models.Pembayaran
+		if err := tx.Where("pemesanan_id = ? AND tipe_pembayaran = ? AND status_pembayaran IN ?", bookingID, "installment", []models.PaymentStatus{models.PaymentPending, models.PaymentRejected, models.PaymentAwaitingConfirmation}).
+			Order("cicilan_ke ASC").Find(&open).Error; err != nil {
+			return err
+		}
+
+		var openIDs []uint
+		for _, p := range open {
+			if p.StatusPembayaran == models.PaymentAwaitingConfirmation || (p.StatusPembayaran == models.PaymentPending && p.BuktiTransfer != "") {
+				return fmt.Errorf("cicilan ke-%d sedang menunggu konfirmasi, konfirmasi atau tolak terlebih dahulu", p.CicilanKe)
+			}
+			openIDs = append(openIDs, p.ID)
+		}
+		cancelled = open
+
+		outstanding, err := bookingOutstanding(tx, booking, booking.Kamar.HargaPerBulan)
+		if err != nil {
+			return err
+		}
+		if math.Abs(total-outstanding) >= 1 {
+			return fmt.Errorf("total cicilan (Rp %.0f) harus sama dengan sisa tagihan (Rp %.0f)", total, outstanding)
+		}
+
+		policy := resolvePricingPolicy(s.policyRepo, booking.Kamar.TipeKamar)
+		if firstDue.IsZero() {
+			if len(open) > 0 {
+				firstDue = open[0].TanggalJatuhTempo
+			} else {
+				firstDue = booking.TanggalMulai.AddDate(0, 0, policy.SettlementDays)
+			}
+		}
+
+		if len(openIDs) > 0 {
+			txRepo := s.repo.WithTx(tx)
+			for i := range open {
+				if err := txRepo.Transition(&open[i], models.PaymentCancelled, actor.statusChange("installments_rescheduled")); err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&models.PaymentReminder{}).Where("pembayaran_id IN ?", openIDs).Update("status_reminder", "Cancelled").Error; err != nil {
+				return err
+			}
+		}
+
+		var lastNo int
+		if err := tx.Model(&models.Pembayaran{}).
+			Where("pemesanan_id = ? AND tipe_pembayaran = ?", bookingID, "installment").
+			Select("COALESCE(MAX(cicilan_ke), 0)").Scan(&lastNo).Error; err != nil {
+			return err
+		}
+
+		plan = buildInstallmentPlan(bookingID, amounts, firstDue, lastNo+1)
+		return createInstallmentPlan(tx, plan, s.cadence)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(s.audit, actor, "payment.reschedule_installments", "booking", bookingID,
+		map[string]interface{}{"cicilan": installmentSummary(cancelled)},
+		map[string]interface{}{"cicilan": installmentSummary(plan)})
+
+	return plan, nil
+}
+
+// installmentSummary meringkas rencana cicilan untuk audit log, contoh: "#2 Rp 500000 (2026-01-05)"
+func installmentSummary(plan []models.Pembayaran) string {
+	parts := make([]string, 0, len(plan))
+	for _, p := range plan {
+		parts = append(parts, fmt.Sprintf("#%d Rp %.0f (%s)", p.CicilanKe, p.JumlahBayar, p.TanggalJatuhTempo.Format("2006-01-02")))
+	}
+	return strings.Join(parts, ", ")
 }
 
-func (s *paymentService) RejectPayment(paymentID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+func (s *paymentService) RejectPayment(paymentID uint, actor AuditActor) error {
+	var rejected *models.Pembayaran
+	var before models.Pembayaran
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		txRepo := s.repo.WithTx(tx)
 
 		payment, err := txRepo.FindByID(paymentID)
 		if err != nil {
 			return err
 		}
+		rejected = payment
+		before = *payment
 
-		payment.StatusPembayaran = "Rejected"
-		if err := txRepo.Update(payment); err != nil {
+		if err := txRepo.Transition(payment, models.PaymentRejected, actor.statusChange("payment_rejected")); err != nil {
 			return err
 		}
 
@@ -167,10 +359,17 @@ func (s *paymentService) RejectPayment(paymentID uint) error {
 			Where("pembayaran_id = ?", payment.ID).
 			Update("status_reminder", "Rejected").Error
 	})
+	if err != nil {
+		return err
+	}
+
+	recordAudit(s.audit, actor, "payment.reject", "payment", paymentID, before, *rejected)
+	emitPaymentRejected(s.notifier, rejected)
+	return nil
 }
 
 // CreatePaymentSession now only creates a Pending Manual payment
-func (s *paymentService) CreatePaymentSession(pemesananID uint, paymentType string, userID uint) (*models.Pembayaran, error) {
+func (s *paymentService) CreatePaymentSession(pemesananID uint, paymentType string, userID uint, actor AuditActor) (*models.Pembayaran, error) {
 	booking, err := s.bookingRepo.FindByID(pemesananID)
 	if err != nil {
 		return nil, err
@@ -193,12 +392,13 @@ func (s *paymentService) CreatePaymentSession(pemesananID uint, paymentType stri
 
 	// Hitung total amount
 	totalAmount := float64(booking.DurasiSewa) * kamar.HargaPerBulan
+	policy := resolvePricingPolicy(s.policyRepo, kamar.TipeKamar)
 	var dpAmount float64
 	var finalAmount float64
 
 	if paymentType == "dp" {
-		// DP = 30% dari total
-		dpAmount = totalAmount * 0.3
+		// DP sesuai pricing policy (default 30% dari total)
+		dpAmount = calculateDPAmount(policy, totalAmount)
 		finalAmount = dpAmount
 	} else {
 		// Full payment
@@ -210,7 +410,7 @@ func (s *paymentService) CreatePaymentSession(pemesananID uint, paymentType stri
 	payment := models.Pembayaran{
 		PemesananID:      pemesananID,
 		JumlahBayar:      finalAmount,
-		StatusPembayaran: "Pending",
+		StatusPembayaran: models.PaymentPending,
 		MetodePembayaran: "manual", // Forced to manual
 		TipePembayaran:   paymentType,
 		JumlahDP:         dpAmount,
@@ -219,24 +419,29 @@ func (s *paymentService) CreatePaymentSession(pemesananID uint, paymentType stri
 
 	// Set jatuh tempo untuk pembayaran cicilan
 	if paymentType == "dp" {
-		// Cicilan berikutnya 1 bulan setelah move in
-		payment.TanggalJatuhTempo = booking.TanggalMulai.AddDate(0, 1, 0)
+		// Pelunasan sisa DP paling lambat SettlementDays setelah move in
+		payment.TanggalJatuhTempo = booking.TanggalMulai.AddDate(0, 0, policy.SettlementDays)
 	}
 
-	if err := s.repo.Create(&payment); err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		orderID, err := allocateInvoiceNumber(tx, time.Now())
+		if err != nil {
+			return err
+		}
+		payment.OrderID = orderID
+		return s.repo.WithTx(tx).Create(&payment)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Create payment reminder untuk dp
-	if paymentType == "dp" {
-		s.CreatePaymentReminder(payment.ID, totalAmount-dpAmount, 30) // Assuming 30 days later
-	}
-
+	// Sisa setelah DP ditagih lewat rencana cicilan (generateInstallmentPlan) saat DP dikonfirmasi
+	recordAudit(s.audit, actor, "payment.create", "payment", payment.ID, nil, payment)
 	return &payment, nil
 }
 
 // UploadPaymentProof allows user to upload receipt with ownership check
-func (s *paymentService) UploadPaymentProof(paymentID uint, buktiTransfer string, userID uint) error {
+func (s *paymentService) UploadPaymentProof(paymentID uint, buktiTransfer string, userID uint, actor AuditActor) error {
 	payment, err := s.repo.FindByID(paymentID)
 	if err != nil {
 		return err
@@ -257,10 +462,14 @@ func (s *paymentService) UploadPaymentProof(paymentID uint, buktiTransfer string
 		return fmt.Errorf("unauthorized: you can only upload proof for your own payments")
 	}
 
-	payment.BuktiTransfer = buktiTransfer
 	// Reset status to Pending so admin can process the new proof.
-	// This handles the re-upload case where payment was previously Rejected.
-	payment.StatusPembayaran = "Pending"
+	// This handles the re-upload case where payment was previously Rejected;
+	// pembayaran Confirmed/Cancelled ditolak oleh state machine.
+	before := *payment
+	if err := s.repo.Transition(payment, models.PaymentPending, actor.statusChange("proof_uploaded")); err != nil {
+		return err
+	}
+	payment.BuktiTransfer = buktiTransfer
 
 	if err := s.repo.Update(payment); err != nil {
 		return err
@@ -273,27 +482,37 @@ func (s *paymentService) UploadPaymentProof(paymentID uint, buktiTransfer string
 			Update("status_reminder", "Pending")
 	}
 
+	recordAudit(s.audit, actor, "payment.upload_proof", "payment", payment.ID, before, *payment)
+	emitProofUploaded(s.notifier, payment)
+
 	return nil
 }
 
-func (s *paymentService) ConfirmCashPayment(paymentID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+func (s *paymentService) ConfirmCashPayment(paymentID uint, actor AuditActor) error {
+	var before, after models.Pembayaran
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		txRepo := s.repo.WithTx(tx)
 
 		payment, err := txRepo.FindByID(paymentID)
 		if err != nil {
 			return err
 		}
+		before = *payment
 
 		// Update status pembayaran menjadi Menunggu Konfirmasi Admin
-		payment.StatusPembayaran = "Menunggu Konfirmasi Admin"
-
-		if err := txRepo.Update(payment); err != nil {
+		if err := txRepo.Transition(payment, models.PaymentAwaitingConfirmation, actor.statusChange("cash_payment")); err != nil {
 			return err
 		}
+		after = *payment
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	recordAudit(s.audit, actor, "payment.confirm_cash", "payment", paymentID, before, after)
+	return nil
 }
 
 func (s *paymentService) GetPaymentReminders(userID uint) ([]models.PaymentReminder, error) {
@@ -347,26 +566,60 @@ func (s *paymentService) CreatePaymentReminder(pembayaranID uint, jumlahBayar fl
 
 // Helper to send notifications
 func (s *paymentService) sendSuccessNotifications(paymentID uint) {
-	var payment models.Pembayaran
-	if err := s.db.Preload("Pemesanan.Penyewa").Preload("Pemesanan.Kamar").First(&payment, paymentID).Error; err != nil {
+	payment, err := loadPaymentForDocument(s.db, paymentID)
+	if err != nil {
 		return
 	}
 
 	tenant := payment.Pemesanan.Penyewa
+	emitPaymentConfirmed(s.notifier, payment)
+
+	data := map[string]interface{}{
+		"Nama":       tenant.NamaLengkap,
+		"NomorKamar": payment.Pemesanan.Kamar.NomorKamar,
+		"Jumlah":     payment.JumlahBayar,
+		"Tanggal":    time.Now(),
+	}
 
-	// Email
+	// Email (kwitansi PDF dilampirkan)
 	if tenant.Email != "" {
-		if err := s.emailSender.SendPaymentSuccessEmail(tenant.Email, tenant.NamaLengkap, payment.JumlahBayar, time.Now()); err != nil {
+		receipt := &utils.EmailAttachment{
+			Filename: paymentDocumentFilename(*payment, true),
+			Content:  renderPaymentPDF(*payment, true),
+		}
+		msg, err := renderMessage(s.templates, msgPaymentConfirmed, messageChannelEmail, tenant.Bahasa, data)
+		if err == nil {
+			err = s.emailSender.SendEmail(tenant.Email, msg.Subject, msg.Body, receipt)
+		}
+		if err != nil {
 			fmt.Printf("[Warning] FIX #18: Failed to send Email notification for payment %d: %v\n", payment.ID, err)
 		}
 	}
 
 	// WhatsApp
 	if tenant.NomorHP != "" {
-		msg := fmt.Sprintf("Terima kasih %s! Pembayaran sebesar Rp %.0f untuk kamar %s telah kami terima.",
-			tenant.NamaLengkap, payment.JumlahBayar, payment.Pemesanan.Kamar.NomorKamar)
-		if err := s.waSender.SendWhatsApp(tenant.NomorHP, msg); err != nil {
+		if err := sendTemplatedWhatsApp(s.waSender, s.templates, tenant.NomorHP, msgPaymentConfirmed, tenant.Bahasa, data); err != nil {
 			fmt.Printf("[Warning] FIX #18: Failed to send WA notification for payment %d: %v\n", payment.ID, err)
 		}
 	}
 }
+
+// GetPaymentDocument merender PDF pembayaran untuk diunduh. docType "receipt" (default) hanya
+// tersedia untuk pembayaran Confirmed; pembayaran yang belum lunas mendapat invoice.
+// Selain staff dengan payments:read, hanya pemilik booking yang boleh mengunduh.
+func (s *paymentService) GetPaymentDocument(paymentID uint, userID uint, role string, docType string) ([]byte, string, error) {
+	payment, err := loadPaymentForDocument(s.db, paymentID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !utils.RoleHasPermission(role, utils.PermPaymentsRead) {
+		penyewa, err := s.penyewaRepo.FindByUserID(userID)
+		if err != nil || payment.Pemesanan.PenyewaID != penyewa.ID {
+			return nil, "", fmt.Errorf("unauthorized: you can only download your own payment documents")
+		}
+	}
+
+	receipt := docType != "invoice" && payment.StatusPembayaran == models.PaymentConfirmed && payment.NomorKwitansi != ""
+	return renderPaymentPDF(*payment, receipt), paymentDocumentFilename(*payment, receipt), nil
+}
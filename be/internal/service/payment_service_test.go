@@ -3,6 +3,8 @@ package service
 import (
 	"errors"
 	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
 	"testing"
 	"time"
 
@@ -24,9 +26,13 @@ func TestPaymentService_GetAllPayments_Success(t *testing.T) {
 		mockBookingRepo,
 		mockKamarRepo,
 		mockPenyewaRepo,
+		new(MockPricingPolicyRepository),
 		nil, // db not needed for this test
 		mockEmailSender,
 		mockWASender,
+		nil,
+		nil,
+		nil,		nil,
 	)
 
 	expectedPayments := []models.Pembayaran{
@@ -70,9 +76,13 @@ func TestPaymentService_GetAllPayments_Error(t *testing.T) {
 		mockBookingRepo,
 		mockKamarRepo,
 		mockPenyewaRepo,
+		new(MockPricingPolicyRepository),
 		nil,
 		mockEmailSender,
 		mockWASender,
+		nil,
+		nil,
+		nil,		nil,
 	)
 
 	mockRepo.On("FindAll").Return(nil, errors.New("database error"))
@@ -99,9 +109,13 @@ func TestPaymentService_GetAllPayments_EmptyResult(t *testing.T) {
 		mockBookingRepo,
 		mockKamarRepo,
 		mockPenyewaRepo,
+		new(MockPricingPolicyRepository),
 		nil,
 		mockEmailSender,
 		mockWASender,
+		nil,
+		nil,
+		nil,		nil,
 	)
 
 	emptyPayments := []models.Pembayaran{}
@@ -115,6 +129,70 @@ func TestPaymentService_GetAllPayments_EmptyResult(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// Test GetPaymentsPaginated - filter dan pagination diteruskan ke repository
+func TestPaymentService_GetPaymentsPaginated(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+
+	service := NewPaymentService(
+		mockRepo,
+		new(MockBookingRepository),
+		new(MockKamarRepository),
+		new(MockPenyewaRepository),
+		new(MockPricingPolicyRepository),
+		nil,
+		new(MockEmailSender),
+		new(MockWhatsAppSender),
+		nil,
+		nil,
+		nil,		nil,
+	)
+
+	pagination := &utils.Pagination{Page: 2, Limit: 10}
+	filter := repository.PaymentFilter{Status: "Pending", KamarID: 3, TenantName: "budi", SortBy: "jumlah_bayar", SortOrder: "asc"}
+	mockRepo.On("FindAllPaginated", pagination, filter).Return([]models.Pembayaran{{ID: 11}}, 11, nil)
+
+	payments, total, err := service.GetPaymentsPaginated(pagination, filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, payments, 1)
+	assert.Equal(t, int64(11), total)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test GetPaymentByOrderID - pencarian nomor invoice (spasi di-trim)
+func TestPaymentService_GetPaymentByOrderID(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+
+	service := NewPaymentService(
+		mockRepo,
+		new(MockBookingRepository),
+		new(MockKamarRepository),
+		new(MockPenyewaRepository),
+		new(MockPricingPolicyRepository),
+		nil,
+		new(MockEmailSender),
+		new(MockWhatsAppSender),
+		nil,
+		nil,
+		nil,		nil,
+	)
+
+	expected := &models.Pembayaran{ID: 42, OrderID: "INV/2026/10/0042"}
+	mockRepo.On("FindByOrderID", "INV/2026/10/0042").Return(expected, nil)
+
+	payment, err := service.GetPaymentByOrderID(" INV/2026/10/0042 ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), payment.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test formatInvoiceNumber - nomor urut tahunan dengan bulan terbit
+func TestFormatInvoiceNumber(t *testing.T) {
+	assert.Equal(t, "INV/2026/10/0042", formatInvoiceNumber(time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local), 42))
+	assert.Equal(t, "INV/2027/01/12345", formatInvoiceNumber(time.Date(2027, 1, 2, 0, 0, 0, 0, time.Local), 12345))
+}
+
 // Test UploadPaymentProof - Success
 func TestPaymentService_UploadPaymentProof_Success(t *testing.T) {
 	mockRepo := new(MockPaymentRepository)
@@ -129,9 +207,13 @@ func TestPaymentService_UploadPaymentProof_Success(t *testing.T) {
 		mockBookingRepo,
 		mockKamarRepo,
 		mockPenyewaRepo,
+		new(MockPricingPolicyRepository),
 		nil,
 		mockEmailSender,
 		mockWASender,
+		nil,
+		nil,
+		nil,		nil,
 	)
 
 	payment := &models.Pembayaran{
@@ -156,11 +238,12 @@ func TestPaymentService_UploadPaymentProof_Success(t *testing.T) {
 	mockRepo.On("FindByID", uint(1)).Return(payment, nil)
 	mockBookingRepo.On("FindByID", uint(1)).Return(booking, nil)
 	mockPenyewaRepo.On("FindByUserID", uint(1)).Return(penyewa, nil)
+	mockRepo.On("Transition", mock.AnythingOfType("*models.Pembayaran"), models.PaymentPending, mock.Anything).Return(nil)
 	mockRepo.On("Update", mock.MatchedBy(func(p *models.Pembayaran) bool {
 		return p.ID == 1 && p.BuktiTransfer == buktiPath
 	})).Return(nil)
 
-	err := service.UploadPaymentProof(1, buktiPath, 1)
+	err := service.UploadPaymentProof(1, buktiPath, 1, AuditActor{})
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -182,14 +265,18 @@ func TestPaymentService_UploadPaymentProof_NotFound(t *testing.T) {
 		mockBookingRepo,
 		mockKamarRepo,
 		mockPenyewaRepo,
+		new(MockPricingPolicyRepository),
 		nil,
 		mockEmailSender,
 		mockWASender,
+		nil,
+		nil,
+		nil,		nil,
 	)
 
 	mockRepo.On("FindByID", uint(999)).Return(nil, errors.New("record not found"))
 
-	err := service.UploadPaymentProof(999, "/path/to/proof.jpg", 1)
+	err := service.UploadPaymentProof(999, "/path/to/proof.jpg", 1, AuditActor{})
 
 	assert.Error(t, err)
 	assert.Equal(t, "record not found", err.Error())
@@ -210,9 +297,13 @@ func TestPaymentService_UploadPaymentProof_UpdateError(t *testing.T) {
 		mockBookingRepo,
 		mockKamarRepo,
 		mockPenyewaRepo,
+		new(MockPricingPolicyRepository),
 		nil,
 		mockEmailSender,
 		mockWASender,
+		nil,
+		nil,
+		nil,		nil,
 	)
 
 	payment := &models.Pembayaran{
@@ -234,9 +325,10 @@ func TestPaymentService_UploadPaymentProof_UpdateError(t *testing.T) {
 	mockRepo.On("FindByID", uint(1)).Return(payment, nil)
 	mockBookingRepo.On("FindByID", uint(1)).Return(booking, nil)
 	mockPenyewaRepo.On("FindByUserID", uint(1)).Return(penyewa, nil)
+	mockRepo.On("Transition", mock.AnythingOfType("*models.Pembayaran"), models.PaymentPending, mock.Anything).Return(nil)
 	mockRepo.On("Update", mock.Anything).Return(errors.New("database update failed"))
 
-	err := service.UploadPaymentProof(1, "/path/to/proof.jpg", 1)
+	err := service.UploadPaymentProof(1, "/path/to/proof.jpg", 1, AuditActor{})
 
 	assert.Error(t, err)
 	assert.Equal(t, "database update failed", err.Error())
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"log"
+	"math"
+)
+
+// defaultPricingPolicy dipakai jika admin belum menyimpan kebijakan apa pun,
+// sama dengan perilaku lama (DP 30%, sisa dilunasi sekali 1 bulan kemudian, tagihan H-7, reminder H-3,
+// tanpa denda). Kamar dikunci jika tagihan telat lebih dari 14 hari.
+var defaultPricingPolicy = models.PricingPolicy{
+	DPPercentage:     30,
+	MinDPAmount:      0,
+	SettlementDays:   30,
+	BillingLeadDays:  7,
+	ReminderLeadDays: 3,
+	InstallmentCount: 1,
+	LateFeeType:      "daily",
+	LateFeeAmount:    0,
+	LockGraceDays:    14,
+}
+
+type PricingPolicyService interface {
+	GetAllPolicies() ([]models.PricingPolicy, error)
+	GetEffectivePolicy(tipeKamar string) (*models.PricingPolicy, error)
+	SavePolicy(input models.PricingPolicy, actor AuditActor) (*models.PricingPolicy, error)
+	DeletePolicy(id uint, actor AuditActor) error
+}
+
+type pricingPolicyService struct {
+	repo  repository.PricingPolicyRepository
+	audit AuditService
+}
+
+func NewPricingPolicyService(repo repository.PricingPolicyRepository, audit AuditService) PricingPolicyService {
+	return &pricingPolicyService{repo, audit}
+}
+
+func (s *pricingPolicyService) GetAllPolicies() ([]models.PricingPolicy, error) {
+	return s.repo.FindAll()
+}
+
+func (s *pricingPolicyService) GetEffectivePolicy(tipeKamar string) (*models.PricingPolicy, error) {
+	policy := resolvePricingPolicy(s.repo, tipeKamar)
+	return &policy, nil
+}
+
+// SavePolicy membuat atau memperbarui kebijakan untuk input.TipeKamar ("" = default)
+func (s *pricingPolicyService) SavePolicy(input models.PricingPolicy, actor AuditActor) (*models.PricingPolicy, error) {
+	if input.DPPercentage <= 0 || input.DPPercentage > 100 {
+		return nil, fmt.Errorf("dp_percentage harus di antara 0 dan 100")
+	}
+	if input.MinDPAmount < 0 {
+		return nil, fmt.Errorf("min_dp_amount tidak boleh negatif")
+	}
+	if input.SettlementDays <= 0 {
+		return nil, fmt.Errorf("settlement_days harus lebih dari 0")
+	}
+	if input.BillingLeadDays < 0 || input.ReminderLeadDays < 0 {
+		return nil, fmt.Errorf("billing_lead_days dan reminder_lead_days tidak boleh negatif")
+	}
+	if input.InstallmentCount == 0 {
+		input.InstallmentCount = 1
+	}
+	if input.InstallmentCount < 0 || input.InstallmentCount > maxInstallmentCount {
+		return nil, fmt.Errorf("installment_count harus di antara 1 dan %d", maxInstallmentCount)
+	}
+	if input.LateFeeType == "" {
+		input.LateFeeType = "daily"
+	}
+	if input.LateFeeType != "daily" && input.LateFeeType != "flat" {
+		return nil, fmt.Errorf("late_fee_type harus 'daily' atau 'flat'")
+	}
+	if input.LateFeeAmount < 0 || input.LockGraceDays < 0 {
+		return nil, fmt.Errorf("late_fee_amount dan lock_grace_days tidak boleh negatif")
+	}
+	if input.DepositAmount < 0 {
+		return nil, fmt.Errorf("deposit_amount tidak boleh negatif")
+	}
+
+	existing, err := s.repo.FindByTipeKamar(input.TipeKamar)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &input
+	if existing != nil {
+		policy.ID = existing.ID
+		policy.CreatedAt = existing.CreatedAt
+	} else {
+		policy.ID = 0
+	}
+
+	if err := s.repo.Save(policy); err != nil {
+		return nil, err
+	}
+	recordAudit(s.audit, actor, "pricing_policy.save", "pricing_policy", policy.ID, existing, policy)
+	return policy, nil
+}
+
+func (s *pricingPolicyService) DeletePolicy(id uint, actor AuditActor) error {
+	policy, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	recordAudit(s.audit, actor, "pricing_policy.delete", "pricing_policy", id, policy, nil)
+	return nil
+}
+
+// resolvePricingPolicy mencari override tipe kamar, lalu kebijakan default yang tersimpan,
+// lalu defaultPricingPolicy. Error DB tidak menggagalkan transaksi pembayaran.
+func resolvePricingPolicy(repo repository.PricingPolicyRepository, tipeKamar string) models.PricingPolicy {
+	candidates := []string{""}
+	if tipeKamar != "" {
+		candidates = []string{tipeKamar, ""}
+	}
+
+	for _, tipe := range candidates {
+		policy, err := repo.FindByTipeKamar(tipe)
+		if err != nil {
+			log.Printf("[WARN] Gagal memuat pricing policy %q, memakai default: %v", tipe, err)
+			break
+		}
+		if policy != nil {
+			return *policy
+		}
+	}
+
+	return defaultPricingPolicy
+}
+
+// calculateDPAmount menghitung DP dari total sewa: persentase kebijakan, minimal MinDPAmount,
+// dan tidak pernah melebihi total.
+func calculateDPAmount(policy models.PricingPolicy, totalAmount float64) float64 {
+	dp := math.Round(totalAmount * policy.DPPercentage / 100)
+	if dp < policy.MinDPAmount {
+		dp = policy.MinDPAmount
+	}
+	if dp > totalAmount {
+		dp = totalAmount
+	}
+	return dp
+}
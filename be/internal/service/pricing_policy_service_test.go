@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Test calculateDPAmount - persentase, minimal DP, dan batas total
+func TestCalculateDPAmount(t *testing.T) {
+	policy := models.PricingPolicy{DPPercentage: 30, MinDPAmount: 500000}
+
+	assert.Equal(t, float64(900000), calculateDPAmount(policy, 3000000))
+	assert.Equal(t, float64(500000), calculateDPAmount(policy, 1000000)) // 30% < minimal DP
+	assert.Equal(t, float64(400000), calculateDPAmount(policy, 400000))  // tidak melebihi total
+}
+
+// Test resolvePricingPolicy - override tipe kamar, default tersimpan, lalu default bawaan
+func TestResolvePricingPolicy_Fallback(t *testing.T) {
+	mockRepo := new(MockPricingPolicyRepository)
+
+	deluxe := &models.PricingPolicy{TipeKamar: "Deluxe", DPPercentage: 50, SettlementDays: 14}
+	mockRepo.On("FindByTipeKamar", "Deluxe").Return(deluxe, nil)
+	mockRepo.On("FindByTipeKamar", "Standard").Return(nil, nil)
+	mockRepo.On("FindByTipeKamar", "").Return(nil, nil)
+
+	assert.Equal(t, float64(50), resolvePricingPolicy(mockRepo, "Deluxe").DPPercentage)
+	assert.Equal(t, defaultPricingPolicy, resolvePricingPolicy(mockRepo, "Standard"))
+
+	errRepo := new(MockPricingPolicyRepository)
+	errRepo.On("FindByTipeKamar", "Standard").Return(nil, errors.New("db down"))
+	assert.Equal(t, defaultPricingPolicy, resolvePricingPolicy(errRepo, "Standard"))
+}
+
+// Test SavePolicy - update kebijakan yang sudah ada berdasarkan tipe kamar
+func TestPricingPolicyService_SavePolicy_UpdatesExisting(t *testing.T) {
+	mockRepo := new(MockPricingPolicyRepository)
+	service := NewPricingPolicyService(mockRepo, nil)
+
+	mockRepo.On("FindByTipeKamar", "").Return(&models.PricingPolicy{ID: 3, DPPercentage: 30, SettlementDays: 30}, nil)
+	mockRepo.On("Save", mock.MatchedBy(func(p *models.PricingPolicy) bool {
+		return p.ID == 3 && p.DPPercentage == 40
+	})).Return(nil)
+
+	policy, err := service.SavePolicy(models.PricingPolicy{DPPercentage: 40, SettlementDays: 30, BillingLeadDays: 7, ReminderLeadDays: 3}, AuditActor{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), policy.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test SavePolicy - persentase tidak valid
+func TestPricingPolicyService_SavePolicy_InvalidPercentage(t *testing.T) {
+	mockRepo := new(MockPricingPolicyRepository)
+	service := NewPricingPolicyService(mockRepo, nil)
+
+	_, err := service.SavePolicy(models.PricingPolicy{DPPercentage: 120, SettlementDays: 30}, AuditActor{})
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+}
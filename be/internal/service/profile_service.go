@@ -11,16 +11,17 @@ import (
 type ProfileService interface {
 	GetProfile(userID uint) (*models.User, *models.Penyewa, error)
 	UpdateProfile(userID uint, input models.Penyewa) (*models.Penyewa, error)
-	ChangePassword(userID uint, oldPassword, newPassword string) error
+	ChangePassword(userID uint, oldPassword, newPassword, currentSessionID string) error
 }
 
 type profileService struct {
 	userRepo    repository.UserRepository
 	penyewaRepo repository.PenyewaRepository
+	sessionRepo repository.SessionRepository
 }
 
-func NewProfileService(userRepo repository.UserRepository, penyewaRepo repository.PenyewaRepository) ProfileService {
-	return &profileService{userRepo, penyewaRepo}
+func NewProfileService(userRepo repository.UserRepository, penyewaRepo repository.PenyewaRepository, sessionRepo repository.SessionRepository) ProfileService {
+	return &profileService{userRepo, penyewaRepo, sessionRepo}
 }
 
 func (s *profileService) GetProfile(userID uint) (*models.User, *models.Penyewa, error) {
@@ -49,6 +50,16 @@ func (s *profileService) UpdateProfile(userID uint, input models.Penyewa) (*mode
 	penyewa.NomorHP = input.NomorHP
 	penyewa.AlamatAsal = input.AlamatAsal
 	penyewa.JenisKelamin = input.JenisKelamin
+	if input.Bahasa != "" {
+		penyewa.Bahasa = normalizeLanguage(input.Bahasa)
+	}
+	if input.PreferensiReminder != "" {
+		preference, err := parseReminderPreference(input.PreferensiReminder)
+		if err != nil {
+			return nil, err
+		}
+		penyewa.PreferensiReminder = preference
+	}
 	if input.FotoProfil != "" {
 		penyewa.FotoProfil = input.FotoProfil
 	}
@@ -74,7 +85,8 @@ func (s *profileService) UpdateProfile(userID uint, input models.Penyewa) (*mode
 	return penyewa, nil
 }
 
-func (s *profileService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+// ChangePassword mengganti password lalu mencabut sesi di perangkat lain (sesi saat ini tetap aktif)
+func (s *profileService) ChangePassword(userID uint, oldPassword, newPassword, currentSessionID string) error {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return err
@@ -92,5 +104,10 @@ func (s *profileService) ChangePassword(userID uint, oldPassword, newPassword st
 	}
 
 	user.Password = string(hashedPassword)
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	revokeUserSessions(s.sessionRepo, userID, currentSessionID, sessionRevokedPasswordChanged)
+	return nil
 }
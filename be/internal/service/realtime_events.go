@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"log"
+)
+
+// Event notifikasi in-app + real-time. Admin menerima event operasional (booking baru, bukti transfer),
+// penyewa menerima event yang menyangkut booking/pembayarannya sendiri. Event disimpan lewat
+// NotificationService; tanpa notifier (mis. di test) event hanya dikirim ke socket.
+
+func notifyUser(notifier NotificationService, userID uint, event utils.SocketEvent) {
+	if notifier == nil {
+		utils.EmitToUser(userID, event)
+		return
+	}
+	if err := notifier.Notify(userID, event); err != nil {
+		log.Printf("[WARN] Gagal menyimpan notifikasi %s untuk user %d: %v", event.Event, userID, err)
+	}
+}
+
+func notifyAdmins(notifier NotificationService, event utils.SocketEvent) {
+	if notifier == nil {
+		utils.EmitToAdmins(event)
+		return
+	}
+	if err := notifier.NotifyAdmins(event); err != nil {
+		log.Printf("[WARN] Gagal menyimpan notifikasi admin %s: %v", event.Event, err)
+	}
+}
+
+func emitBookingCreated(notifier NotificationService, booking *models.Pemesanan, tenantName, nomorKamar string) {
+	notifyAdmins(notifier, utils.SocketEvent{
+		Event:   "booking.created",
+		Title:   "Booking Baru",
+		Message: fmt.Sprintf("%s memesan kamar %s mulai %s", tenantName, nomorKamar, booking.TanggalMulai.Format("02 Jan 2006")),
+		Type:    "info",
+		Data:    map[string]interface{}{"booking_id": booking.ID, "kamar_id": booking.KamarID},
+	})
+}
+
+func emitProofUploaded(notifier NotificationService, payment *models.Pembayaran) {
+	notifyAdmins(notifier, utils.SocketEvent{
+		Event:   "payment.proof_uploaded",
+		Title:   "Bukti Transfer Baru",
+		Message: fmt.Sprintf("%s mengunggah bukti pembayaran %s untuk kamar %s", payment.Pemesanan.Penyewa.NamaLengkap, invoiceNumber(*payment), payment.Pemesanan.Kamar.NomorKamar),
+		Type:    "info",
+		Data:    map[string]interface{}{"payment_id": payment.ID, "booking_id": payment.PemesananID},
+	})
+}
+
+func emitPaymentConfirmed(notifier NotificationService, payment *models.Pembayaran) {
+	notifyUser(notifier, payment.Pemesanan.Penyewa.UserID, utils.SocketEvent{
+		Event:   "payment.confirmed",
+		Title:   "Pembayaran Dikonfirmasi",
+		Message: fmt.Sprintf("Pembayaran %s sebesar %s telah dikonfirmasi", invoiceNumber(*payment), formatRupiah(payment.JumlahBayar)),
+		Type:    "success",
+		Data:    map[string]interface{}{"payment_id": payment.ID, "booking_id": payment.PemesananID},
+	})
+}
+
+func emitPaymentRejected(notifier NotificationService, payment *models.Pembayaran) {
+	notifyUser(notifier, payment.Pemesanan.Penyewa.UserID, utils.SocketEvent{
+		Event:   "payment.rejected",
+		Title:   "Pembayaran Ditolak",
+		Message: fmt.Sprintf("Bukti pembayaran %s ditolak. Silakan unggah ulang bukti transfer yang valid", invoiceNumber(*payment)),
+		Type:    "error",
+		Data:    map[string]interface{}{"payment_id": payment.ID, "booking_id": payment.PemesananID},
+	})
+}
+
+func emitBookingAutoCancelled(notifier NotificationService, booking *models.Pemesanan) {
+	notifyUser(notifier, booking.Penyewa.UserID, utils.SocketEvent{
+		Event:   "booking.auto_cancelled",
+		Title:   "Booking Dibatalkan",
+		Message: fmt.Sprintf("Pesanan kamar %s dibatalkan otomatis karena belum ada pembayaran terkonfirmasi dalam 7 hari", booking.Kamar.NomorKamar),
+		Type:    "error",
+		Data:    map[string]interface{}{"booking_id": booking.ID},
+	})
+}
+
+func emitRoomDeletedBookingCancelled(notifier NotificationService, booking *models.Pemesanan, nomorKamar string, refundAmount float64) {
+	message := fmt.Sprintf("Kamar %s dihapus oleh pengelola sehingga pesanan Anda dibatalkan", nomorKamar)
+	if refundAmount > 0 {
+		message += fmt.Sprintf(". Dana %s akan dikembalikan", formatRupiah(refundAmount))
+	}
+	notifyUser(notifier, booking.Penyewa.UserID, utils.SocketEvent{
+		Event:   "booking.room_deleted",
+		Title:   "Booking Dibatalkan",
+		Message: message,
+		Type:    "error",
+		Data:    map[string]interface{}{"booking_id": booking.ID, "kamar_id": booking.KamarID},
+	})
+}
+
+func emitPaymentReminder(notifier NotificationService, reminder *models.PaymentReminder, userID uint) {
+	notifyUser(notifier, userID, utils.SocketEvent{
+		Event:   "payment.reminder",
+		Title:   "Pengingat Pembayaran",
+		Message: fmt.Sprintf("Tagihan %s jatuh tempo %s", formatRupiah(reminder.JumlahBayar), formatIndonesianDate(reminder.TanggalReminder)),
+		Type:    "info",
+		Data:    map[string]interface{}{"payment_id": reminder.PembayaranID, "reminder_id": reminder.ID},
+	})
+}
+
+func emitTicketCreated(notifier NotificationService, ticket *models.MaintenanceTicket) {
+	notifyAdmins(notifier, utils.SocketEvent{
+		Event:   "maintenance.created",
+		Title:   "Laporan Perbaikan Baru",
+		Message: fmt.Sprintf("%s melaporkan %s di kamar %s (prioritas %s)", ticket.Penyewa.NamaLengkap, ticket.Judul, ticket.Kamar.NomorKamar, ticket.Prioritas),
+		Type:    "info",
+		Data:    map[string]interface{}{"ticket_id": ticket.ID, "kamar_id": ticket.KamarID},
+	})
+}
+
+// emitTicketUpdated memberi tahu penyewa pemilik tiket (status berubah, petugas ditunjuk, balasan staff)
+func emitTicketUpdated(notifier NotificationService, ticket *models.MaintenanceTicket, message string) {
+	notifyUser(notifier, ticket.Penyewa.UserID, utils.SocketEvent{
+		Event:   "maintenance.updated",
+		Title:   "Update Laporan Perbaikan",
+		Message: message,
+		Type:    "info",
+		Data:    map[string]interface{}{"ticket_id": ticket.ID, "status": ticket.Status},
+	})
+}
+
+// emitTicketForStaff memberi tahu petugas yang ditunjuk, atau semua admin jika tiket belum ditugaskan
+func emitTicketForStaff(notifier NotificationService, ticket *models.MaintenanceTicket, message string) {
+	event := utils.SocketEvent{
+		Event:   "maintenance.activity",
+		Title:   "Aktivitas Laporan Perbaikan",
+		Message: message,
+		Type:    "info",
+		Data:    map[string]interface{}{"ticket_id": ticket.ID, "kamar_id": ticket.KamarID},
+	}
+	if ticket.AssignedTo != 0 {
+		notifyUser(notifier, ticket.AssignedTo, event)
+		return
+	}
+	notifyAdmins(notifier, event)
+}
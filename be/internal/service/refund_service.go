@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"log"
+	"time"
+)
+
+type RefundService interface {
+	GetAllRefunds(status string) ([]models.Refund, error)
+	ApproveRefund(id uint, catatan string, actor AuditActor) (*models.Refund, error)
+	RejectRefund(id uint, catatan string, actor AuditActor) (*models.Refund, error)
+	MarkRefundPaid(id uint, buktiTransfer string, actor AuditActor) (*models.Refund, error)
+}
+
+type refundService struct {
+	repo     repository.RefundRepository
+	waSender  utils.WhatsAppSender
+	templates MessageTemplateService
+	audit     AuditService
+}
+
+func NewRefundService(repo repository.RefundRepository, waSender utils.WhatsAppSender, templates MessageTemplateService, audit AuditService) RefundService {
+	return &refundService{repo, waSender, templates, audit}
+}
+
+func (s *refundService) GetAllRefunds(status string) ([]models.Refund, error) {
+	return s.repo.FindAll(status)
+}
+
+// ApproveRefund: Requested -> Approved
+func (s *refundService) ApproveRefund(id uint, catatan string, actor AuditActor) (*models.Refund, error) {
+	refund, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.StatusRefund != "Requested" {
+		return nil, fmt.Errorf("refund dengan status %s tidak dapat disetujui", refund.StatusRefund)
+	}
+
+	before := *refund
+	refund.StatusRefund = "Approved"
+	refund.ApprovedAt = time.Now()
+	if catatan != "" {
+		refund.CatatanAdmin = catatan
+	}
+
+	if err := s.repo.UpdateFromStatus(refund, "Requested"); err != nil {
+		return nil, err
+	}
+	recordAudit(s.audit, actor, "refund.approve", "refund", refund.ID, before, *refund)
+
+	return refund, nil
+}
+
+// RejectRefund: Requested/Approved -> Rejected
+func (s *refundService) RejectRefund(id uint, catatan string, actor AuditActor) (*models.Refund, error) {
+	refund, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.StatusRefund != "Requested" && refund.StatusRefund != "Approved" {
+		return nil, fmt.Errorf("refund dengan status %s tidak dapat ditolak", refund.StatusRefund)
+	}
+
+	before := *refund
+	refund.StatusRefund = "Rejected"
+	refund.CatatanAdmin = catatan
+
+	if err := s.repo.UpdateFromStatus(refund, "Requested", "Approved"); err != nil {
+		return nil, err
+	}
+	recordAudit(s.audit, actor, "refund.reject", "refund", refund.ID, before, *refund)
+
+	return refund, nil
+}
+
+// MarkRefundPaid: Approved -> Paid, disertai bukti transfer pengembalian dana
+func (s *refundService) MarkRefundPaid(id uint, buktiTransfer string, actor AuditActor) (*models.Refund, error) {
+	refund, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.StatusRefund != "Approved" {
+		return nil, fmt.Errorf("refund harus disetujui terlebih dahulu sebelum ditandai lunas (status: %s)", refund.StatusRefund)
+	}
+
+	before := *refund
+	refund.StatusRefund = "Paid"
+	refund.BuktiTransfer = buktiTransfer
+	refund.PaidAt = time.Now()
+
+	if err := s.repo.UpdateFromStatus(refund, "Approved"); err != nil {
+		return nil, err
+	}
+	recordAudit(s.audit, actor, "refund.mark_paid", "refund", refund.ID, before, *refund)
+
+	tenant := refund.Pembayaran.Pemesanan.Penyewa
+	if tenant.NomorHP != "" {
+		data := map[string]interface{}{
+			"Nama":       tenant.NamaLengkap,
+			"NomorKamar": refund.Pembayaran.Pemesanan.Kamar.NomorKamar,
+			"Jumlah":     refund.JumlahRefund,
+		}
+		go func() {
+			if err := sendTemplatedWhatsApp(s.waSender, s.templates, tenant.NomorHP, msgRefundPaid, tenant.Bahasa, data); err != nil {
+				log.Printf("[WARN] Gagal mengirim notifikasi refund %d: %v", refund.ID, err)
+			}
+		}()
+	}
+
+	return refund, nil
+}
+
+// isRefundablePayment: uang dianggap sudah diterima jika pembayaran Confirmed,
+// atau masih Pending tetapi penyewa sudah mengunggah bukti transfer.
+func isRefundablePayment(p models.Pembayaran) bool {
+	return p.StatusPembayaran == models.PaymentConfirmed || (p.StatusPembayaran == models.PaymentPending && p.BuktiTransfer != "")
+}
+
+// createRefundsForBooking membuat refund berstatus Requested untuk setiap pembayaran
+// booking yang uangnya sudah diterima. Harus dipanggil dengan status pembayaran
+// SEBELUM dibatalkan. Pembayaran yang sudah punya refund dilewati.
+func createRefundsForBooking(refundRepo repository.RefundRepository, payments []models.Pembayaran, alasan string) ([]models.Refund, error) {
+	var refunds []models.Refund
+	for _, p := range payments {
+		if !isRefundablePayment(p) {
+			continue
+		}
+
+		existing, err := refundRepo.FindByPembayaranID(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			continue
+		}
+
+		refund := models.Refund{
+			PembayaranID: p.ID,
+			PemesananID:  p.PemesananID,
+			JumlahRefund: p.JumlahBayar,
+			Alasan:       alasan,
+			StatusRefund: "Requested",
+		}
+		if err := refundRepo.Create(&refund); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, nil
+}
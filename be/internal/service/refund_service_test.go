@@ -0,0 +1,96 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Test createRefundsForBooking - hanya pembayaran yang uangnya sudah diterima
+func TestCreateRefundsForBooking_OnlyReceivedPayments(t *testing.T) {
+	mockRefundRepo := new(MockRefundRepository)
+
+	payments := []models.Pembayaran{
+		{ID: 1, PemesananID: 10, JumlahBayar: 500000, StatusPembayaran: "Confirmed"},
+		{ID: 2, PemesananID: 10, JumlahBayar: 300000, StatusPembayaran: "Pending", BuktiTransfer: "proofs/a.jpg"},
+		{ID: 3, PemesananID: 10, JumlahBayar: 300000, StatusPembayaran: "Pending"},
+		{ID: 4, PemesananID: 10, JumlahBayar: 300000, StatusPembayaran: "Rejected", BuktiTransfer: "proofs/b.jpg"},
+	}
+
+	mockRefundRepo.On("FindByPembayaranID", uint(1)).Return(nil, nil)
+	mockRefundRepo.On("FindByPembayaranID", uint(2)).Return(&models.Refund{ID: 7, PembayaranID: 2}, nil)
+	mockRefundRepo.On("Create", mock.MatchedBy(func(r *models.Refund) bool {
+		return r.PembayaranID == 1 && r.JumlahRefund == 500000 && r.StatusRefund == "Requested" && r.Alasan == "booking_cancelled"
+	})).Return(nil)
+
+	refunds, err := createRefundsForBooking(mockRefundRepo, payments, "booking_cancelled")
+
+	assert.NoError(t, err)
+	assert.Len(t, refunds, 1)
+	mockRefundRepo.AssertExpectations(t)
+	mockRefundRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// Test MarkRefundPaid - harus Approved terlebih dahulu
+func TestRefundService_MarkRefundPaid_RequiresApproval(t *testing.T) {
+	mockRefundRepo := new(MockRefundRepository)
+	mockWASender := new(MockWhatsAppSender)
+
+	service := NewRefundService(mockRefundRepo, mockWASender, nil, nil)
+
+	mockRefundRepo.On("FindByID", uint(1)).Return(&models.Refund{ID: 1, StatusRefund: "Requested"}, nil)
+
+	refund, err := service.MarkRefundPaid(1, "refunds/proof.jpg", AuditActor{})
+
+	assert.Error(t, err)
+	assert.Nil(t, refund)
+	mockRefundRepo.AssertNotCalled(t, "UpdateFromStatus", mock.Anything, mock.Anything)
+}
+
+// Test ApproveRefund lalu MarkRefundPaid - Success
+func TestRefundService_ApproveThenPay_Success(t *testing.T) {
+	mockRefundRepo := new(MockRefundRepository)
+	mockWASender := new(MockWhatsAppSender)
+
+	service := NewRefundService(mockRefundRepo, mockWASender, nil, nil)
+
+	stored := &models.Refund{ID: 1, StatusRefund: "Requested", JumlahRefund: 500000}
+	mockRefundRepo.On("FindByID", uint(1)).Return(stored, nil)
+	mockRefundRepo.On("UpdateFromStatus", stored, []string{"Requested"}).Return(nil).Once()
+	mockRefundRepo.On("UpdateFromStatus", stored, []string{"Approved"}).Return(nil).Once()
+
+	approved, err := service.ApproveRefund(1, "", AuditActor{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Approved", approved.StatusRefund)
+	assert.False(t, approved.ApprovedAt.IsZero())
+
+	// Tanpa nomor HP penyewa, tidak ada WA yang dikirim
+	paid, err := service.MarkRefundPaid(1, "refunds/proof.jpg", AuditActor{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Paid", paid.StatusRefund)
+	assert.Equal(t, "refunds/proof.jpg", paid.BuktiTransfer)
+	mockWASender.AssertNotCalled(t, "SendWhatsApp", mock.Anything, mock.Anything)
+	mockRefundRepo.AssertExpectations(t)
+}
+
+// Test MarkRefundPaid - admin lain sudah memproses refund lebih dulu (UPDATE bersyarat gagal)
+func TestRefundService_MarkRefundPaid_ConcurrentUpdate(t *testing.T) {
+	mockRefundRepo := new(MockRefundRepository)
+	mockWASender := new(MockWhatsAppSender)
+
+	service := NewRefundService(mockRefundRepo, mockWASender, nil, nil)
+
+	stored := &models.Refund{ID: 1, StatusRefund: "Approved", JumlahRefund: 500000}
+	stored.Pembayaran.Pemesanan.Penyewa.NomorHP = "081234567890"
+	mockRefundRepo.On("FindByID", uint(1)).Return(stored, nil)
+	mockRefundRepo.On("UpdateFromStatus", stored, []string{"Approved"}).Return(repository.ErrRefundStatusChanged)
+
+	refund, err := service.MarkRefundPaid(1, "refunds/proof.jpg", AuditActor{})
+
+	assert.ErrorIs(t, err, repository.ErrRefundStatusChanged)
+	assert.Nil(t, refund)
+	mockWASender.AssertNotCalled(t, "SendWhatsApp", mock.Anything, mock.Anything)
+}
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"koskosan-be/internal/models"
 	"koskosan-be/internal/repository"
 	"koskosan-be/internal/utils"
+	"sort"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -20,87 +23,94 @@ type ReminderService interface {
 
 type reminderService struct {
 	paymentRepo repository.PaymentRepository
+	policyRepo  repository.PricingPolicyRepository
+	ledgerRepo  repository.LedgerRepository
 	db          *gorm.DB
 	emailSender utils.EmailSender
 	waSender    utils.WhatsAppSender
+	notifier    NotificationService
+	templates   MessageTemplateService
+	cadence     []int  // tahap reminder, hari relatif terhadap jatuh tempo (urut naik)
+	frontendURL string // untuk link pembayaran di email reminder
 }
 
-func NewReminderService(paymentRepo repository.PaymentRepository, db *gorm.DB, emailSender utils.EmailSender, waSender utils.WhatsAppSender) ReminderService {
-	return &reminderService{paymentRepo, db, emailSender, waSender}
+func NewReminderService(paymentRepo repository.PaymentRepository, policyRepo repository.PricingPolicyRepository, ledgerRepo repository.LedgerRepository, db *gorm.DB, emailSender utils.EmailSender, waSender utils.WhatsAppSender, notifier NotificationService, templates MessageTemplateService, cadence []int, frontendURL string) ReminderService {
+	sorted := append([]int(nil), cadence...)
+	sort.Ints(sorted)
+	return &reminderService{paymentRepo, policyRepo, ledgerRepo, db, emailSender, waSender, notifier, templates, sorted, frontendURL}
+}
+
+const (
+	reminderPreferenceWhatsApp = "whatsapp"
+	reminderPreferenceEmail    = "email"
+	reminderPreferenceBoth     = "both"
+	reminderPreferenceNone     = "none"
+)
+
+// ErrInvalidReminderPreference dikembalikan jika preferensi_reminder bukan whatsapp, email, both atau none
+var ErrInvalidReminderPreference = errors.New("preferensi_reminder must be one of: whatsapp, email, both, none")
+
+// parseReminderPreference memvalidasi preferensi channel reminder; kosong berarti WhatsApp (default)
+func parseReminderPreference(preference string) (string, error) {
+	switch preference = strings.ToLower(strings.TrimSpace(preference)); preference {
+	case "":
+		return reminderPreferenceWhatsApp, nil
+	case reminderPreferenceWhatsApp, reminderPreferenceEmail, reminderPreferenceBoth, reminderPreferenceNone:
+		return preference, nil
+	}
+	return "", ErrInvalidReminderPreference
 }
 
 // CreateMonthlyReminders membuat reminder untuk tagihan sewa bulanan (extend) otomatis
 func (s *reminderService) CreateMonthlyReminders() error {
 	// Ambil semua pemesanan yang berstatus Confirmed beserta Kamar dan Pembayaran-nya
 	var bookings []models.Pemesanan
-	if err := s.db.Preload("Kamar").Preload("Pembayaran").Where("status_pemesanan = ?", "Confirmed").Find(&bookings).Error; err != nil {
+	if err := s.db.Preload("Kamar").Preload("Pembayaran.LineItems").Where("status_pemesanan = ?", models.BookingConfirmed).Find(&bookings).Error; err != nil {
+		return err
+	}
+
+	ledgers, err := loadLedgers(s.ledgerRepo, bookings)
+	if err != nil {
 		return err
 	}
 
 	now := time.Now()
 	for _, b := range bookings {
-		// Cek apakah ada tagihan yang masih menunggu (Pending atau Rejected).
-		// - Pending  : user belum bayar, jangan buat tagihan baru.
-		// - Rejected : admin menolak bukti, user perlu upload ulang ke tagihan YANG SAMA,
-		//              jangan buat tagihan baru (nanti duplikat).
-		hasActionablePayment := false
-		var confirmedMonths int
-
-		for _, p := range b.Pembayaran {
-			switch p.StatusPembayaran {
-			case "Pending", "Rejected":
-				hasActionablePayment = true
-			case "Confirmed":
-				confirmedMonths++
-			}
-		}
-
-		// Kalau ada tagihan Pending/Rejected, jangan buat tagihan baru
-		if hasActionablePayment {
-			continue
-		}
-
-		// Pastikan kamar memiliki harga yang valid untuk mencegah pembagian dengan 0
-		if b.Kamar.HargaPerBulan <= 0 {
-			continue
-		}
+		// Paid Until diambil dari buku besar: pembayaran dialokasikan ke sewa bulan demi bulan,
+		// sehingga DP, cicilan, denda dan refund ikut diperhitungkan.
+		paidUntil := ledgers[b.ID].PaidUntil
+		policy := resolvePricingPolicy(s.policyRepo, b.Kamar.TipeKamar)
 
-		// Hitung Paid Until Date berdasarkan jumlah bulan yang sudah Confirmed.
-		// Ini lebih akurat daripada membagi total uang dengan harga per bulan,
-		// karena pembayaran DP (30%) tidak akan menghasilkan angka bulan yang bulat.
-		paidUntil := b.TanggalMulai.AddDate(0, confirmedMonths, 0)
-
-		// Buat billing baru jika batas waktu (paidUntil) sudah H-7 dari hari ini atau sudah lewat
-		billingTriggerDate := paidUntil.AddDate(0, 0, -7)
-
-		if now.After(billingTriggerDate) || now.Equal(billingTriggerDate) {
+		if needsMonthlyBill(b, paidUntil, policy.BillingLeadDays, now) {
 			// Buat record Pembayaran baru untuk bulan berikutnya (1 bulan extend)
 			payment := models.Pembayaran{
 				PemesananID:       b.ID,
 				JumlahBayar:       b.Kamar.HargaPerBulan,
 				TanggalBayar:      now,
-				StatusPembayaran:  "Pending",
+				StatusPembayaran:  models.PaymentPending,
 				MetodePembayaran:  "manual",
 				TipePembayaran:    "extend",
 				JumlahDP:          0,
 				TanggalJatuhTempo: paidUntil,
 			}
 
-			if err := s.db.Create(&payment).Error; err != nil {
+			err := s.db.Transaction(func(tx *gorm.DB) error {
+				orderID, err := allocateInvoiceNumber(tx, now)
+				if err != nil {
+					return err
+				}
+				payment.OrderID = orderID
+				return tx.Create(&payment).Error
+			})
+			if err != nil {
 				fmt.Printf("Warning: Failed to create auto-payment for booking %d: %v\n", b.ID, err)
 				continue
 			}
 
-			// Tentukan tanggal reminder (H-3 sebelum masa habis, atau langsung hari ini jika sudah lewat)
-			reminderDate := paidUntil.AddDate(0, 0, -3)
-			if now.After(reminderDate) {
-				reminderDate = now
-			}
-
 			reminder := models.PaymentReminder{
 				PembayaranID:    payment.ID,
 				JumlahBayar:     payment.JumlahBayar,
-				TanggalReminder: reminderDate,
+				TanggalReminder: firstReminderDate(paidUntil, s.cadence, now),
 				StatusReminder:  "Pending",
 				IsSent:          false,
 			}
@@ -116,7 +126,34 @@ func (s *reminderService) CreateMonthlyReminders() error {
 	return nil
 }
 
-// SendPendingReminders mengirim reminders yang sudah jatuh tempo
+// needsMonthlyBill true jika booking perlu tagihan sewa bulanan baru: belum ada tagihan sewa
+// yang masih Pending/Rejected dan paidUntil sudah masuk H-billingLeadDays. Tagihan uang jaminan
+// (deposit/deposit_charge) tidak ikut dihitung karena bukan tagihan sewa.
+func needsMonthlyBill(b models.Pemesanan, paidUntil time.Time, billingLeadDays int, now time.Time) bool {
+	// - Pending  : user belum bayar, jangan buat tagihan baru.
+	// - Rejected : admin menolak bukti, user perlu upload ulang ke tagihan YANG SAMA,
+	//              jangan buat tagihan baru (nanti duplikat).
+	for _, p := range b.Pembayaran {
+		if isDepositPayment(p.TipePembayaran) {
+			continue
+		}
+		if p.StatusPembayaran == models.PaymentPending || p.StatusPembayaran == models.PaymentRejected {
+			return false
+		}
+	}
+
+	// Pastikan kamar memiliki harga yang valid dan buku besar punya masa sewa terbayar
+	if b.Kamar.HargaPerBulan <= 0 || paidUntil.IsZero() {
+		return false
+	}
+
+	billingTriggerDate := paidUntil.AddDate(0, 0, -billingLeadDays)
+	return !now.Before(billingTriggerDate)
+}
+
+// SendPendingReminders mengirim reminder yang sudah jatuh tempo ke nomor HP / email penyewa sesuai
+// PreferensiReminder, lalu menjadwalkan tahap berikutnya dari cadence (mis. H-7, H-3, H-0, H+1).
+// Reminder ditandai IsSent setelah tahap terakhir terkirim.
 func (s *reminderService) SendPendingReminders() ([]models.PaymentReminder, error) {
 	var reminders []models.PaymentReminder
 
@@ -126,47 +163,153 @@ func (s *reminderService) SendPendingReminders() ([]models.PaymentReminder, erro
 		return nil, err
 	}
 
+	sent := make([]models.PaymentReminder, 0, len(reminders))
 	for i := range reminders {
 		// Use Preloaded data to get tenant details
 		var reminder models.PaymentReminder
+		if err := s.db.Preload("Pembayaran.Pemesanan.Penyewa").Preload("Pembayaran.Pemesanan.Kamar").First(&reminder, reminders[i].ID).Error; err != nil {
+			fmt.Printf("Warning: Failed to load Reminder ID %d: %v\n", reminders[i].ID, err)
+			continue
+		}
 
-		if err := s.db.Preload("Pembayaran.Pemesanan.Penyewa").Preload("Pembayaran.Pemesanan.Kamar").First(&reminder, reminders[i].ID).Error; err == nil {
-
-			tenant := reminder.Pembayaran.Pemesanan.Penyewa
-			kamar := reminder.Pembayaran.Pemesanan.Kamar
-
-			// 1. Send Email (Dinonaktifkan sesuai permintaan)
-			// if tenant.Email != "" {
-			// 	frontendURL := os.Getenv("FRONTEND_URL")
-			// 	if frontendURL == "" {
-			// 		frontendURL = "http://localhost:3000"
-			// 	}
-			// 	paymentLink := fmt.Sprintf("%s/dashboard/payments/%d", frontendURL, reminder.PembayaranID)
-			// 	go func(email, name string, amount float64, due time.Time, link string) {
-			// 		s.emailSender.SendPaymentReminderEmail(email, name, amount, due, link)
-			// 	}(tenant.Email, tenant.NamaLengkap, reminder.JumlahBayar, reminder.Pembayaran.TanggalJatuhTempo, paymentLink)
-			// }
-
-			// 2. Send WhatsApp
-			// Nomor hardcode sesuai permintaan
-			phone := "081332314854"
-			dueDateFormated := reminder.Pembayaran.TanggalJatuhTempo.Format("02 Jan 2006")
-			msg := fmt.Sprintf("Halo %s 👋\n\nIni adalah pesan dari sistem Kost.\nMengingatkan bahwa tagihan sewa Kamar %s Bapak/Ibu sebesar *Rp %.0f* akan jatuh tempo pada *%s*.\n\nMohon segera melunasi pembayaran bulan ini melalui website Kost agar sewa kamar tetap aktif.\nTerima kasih!",
-				tenant.NamaLengkap, kamar.NomorKamar, reminder.JumlahBayar, dueDateFormated)
-
-			go func(phone, message string) {
-				s.waSender.SendWhatsApp(phone, message)
-			}(phone, msg)
+		tenant := reminder.Pembayaran.Pemesanan.Penyewa
+		delivered, err := s.deliverReminder(&reminder)
+		if err != nil {
+			// Tidak ada channel yang berhasil; reminder dicoba lagi di jadwal berikutnya
+			fmt.Printf("Warning: Failed to queue Reminder ID %d: %v\n", reminder.ID, err)
+			continue
+		}
 
+		// Notifikasi in-app tetap dikirim meski penyewa memilih tanpa WA/email
+		emitPaymentReminder(s.notifier, &reminder, tenant.UserID)
+		if delivered {
+			reminders[i].SentCount++
+			reminders[i].LastSentAt = today
 			fmt.Printf("Sent notifications for Reminder ID %d\n", reminder.ID)
 		}
 
-		// Update status
-		reminders[i].IsSent = true
+		// Jadwalkan tahap berikutnya; tahap yang terlewat (mis. scheduler mati) tidak dikirim ulang
+		next := nextReminderDate(reminder.Pembayaran.TanggalJatuhTempo, s.cadence, today)
+		if next.IsZero() {
+			reminders[i].IsSent = true
+		} else {
+			reminders[i].TanggalReminder = next
+		}
 		s.db.Save(&reminders[i])
+
+		if delivered {
+			sent = append(sent, reminders[i])
+		}
+	}
+
+	return sent, nil
+}
+
+// deliverReminder mengantrekan reminder ke WA dan/atau email sesuai preferensi penyewa.
+// delivered false (tanpa error) berarti penyewa memilih "none".
+func (s *reminderService) deliverReminder(reminder *models.PaymentReminder) (bool, error) {
+	tenant := reminder.Pembayaran.Pemesanan.Penyewa
+	kamar := reminder.Pembayaran.Pemesanan.Kamar
+
+	preference, err := parseReminderPreference(tenant.PreferensiReminder)
+	if err != nil {
+		preference = reminderPreferenceWhatsApp
+	}
+	if preference == reminderPreferenceNone {
+		return false, nil
+	}
+
+	// Template payment.reminder; SisaTagihan (total tunggakan dari buku besar) hanya
+	// ditampilkan jika lebih besar dari tagihan ini (mis. ada denda)
+	data := map[string]interface{}{
+		"Nama":           tenant.NamaLengkap,
+		"NomorKamar":     kamar.NomorKamar,
+		"Jumlah":         reminder.JumlahBayar,
+		"JatuhTempo":     reminder.Pembayaran.TanggalJatuhTempo,
+		"SisaTagihan":    s.bookingOutstandingBalance(reminder.Pembayaran.PemesananID),
+		"LinkPembayaran": fmt.Sprintf("%s/dashboard/payments/%d", s.frontendURL, reminder.PembayaranID),
+	}
+
+	// Pesan masuk outbox (dikirim ulang otomatis jika gagal). Reminder dianggap terkirim jika
+	// minimal satu channel berhasil diantrekan.
+	var errs []string
+	queued := false
+
+	if preference == reminderPreferenceWhatsApp || preference == reminderPreferenceBoth {
+		phone := utils.NormalizePhoneNumber(tenant.NomorHP)
+		if phone == "" {
+			errs = append(errs, fmt.Sprintf("invalid phone number %q", tenant.NomorHP))
+		} else if err := sendTemplatedWhatsApp(s.waSender, s.templates, phone, msgPaymentReminder, tenant.Bahasa, data); err != nil {
+			errs = append(errs, "whatsapp: "+err.Error())
+		} else {
+			queued = true
+		}
+	}
+
+	if preference == reminderPreferenceEmail || preference == reminderPreferenceBoth {
+		if tenant.Email == "" {
+			errs = append(errs, "email is empty")
+		} else if msg, err := renderMessage(s.templates, msgPaymentReminder, messageChannelEmail, tenant.Bahasa, data); err != nil {
+			errs = append(errs, "email: "+err.Error())
+		} else if err := s.emailSender.SendEmail(tenant.Email, msg.Subject, msg.Body, nil); err != nil {
+			errs = append(errs, "email: "+err.Error())
+		} else {
+			queued = true
+		}
 	}
 
-	return reminders, nil
+	if len(errs) > 0 {
+		if !queued {
+			return false, errors.New(strings.Join(errs, "; "))
+		}
+		fmt.Printf("Warning: Reminder ID %d partially queued: %s\n", reminder.ID, strings.Join(errs, "; "))
+	}
+	return true, nil
+}
+
+// firstReminderDate mengembalikan tahap pertama cadence (jatuh tempo + offset terkecil, dihitung
+// dari awal hari), atau now jika tahap itu sudah lewat. Tanpa cadence reminder dijadwalkan pada
+// hari jatuh tempo.
+func firstReminderDate(dueDate time.Time, cadence []int, now time.Time) time.Time {
+	first := 0
+	for i, offset := range cadence {
+		if i == 0 || offset < first {
+			first = offset
+		}
+	}
+	dueDay := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, dueDate.Location())
+	if stage := dueDay.AddDate(0, 0, first); stage.After(now) {
+		return stage
+	}
+	return now
+}
+
+// nextReminderDate mengembalikan tahap cadence berikutnya setelah now (hari relatif terhadap
+// jatuh tempo, dihitung dari awal hari). Zero jika tidak ada tahap tersisa.
+func nextReminderDate(dueDate time.Time, cadence []int, now time.Time) time.Time {
+	if dueDate.IsZero() {
+		return time.Time{}
+	}
+	dueDay := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, dueDate.Location())
+	for _, offset := range cadence {
+		if stage := dueDay.AddDate(0, 0, offset); stage.After(now) {
+			return stage
+		}
+	}
+	return time.Time{}
+}
+
+// bookingOutstandingBalance mengembalikan saldo akhir buku besar booking (0 jika gagal dimuat)
+func (s *reminderService) bookingOutstandingBalance(pemesananID uint) float64 {
+	booking, err := s.ledgerRepo.FindBookingWithPayments(pemesananID)
+	if err != nil {
+		return 0
+	}
+	ledgers, err := loadLedgers(s.ledgerRepo, []models.Pemesanan{*booking})
+	if err != nil {
+		return 0
+	}
+	return ledgers[pemesananID].Outstanding
 }
 
 // MarkReminderAsSent tandai reminder sudah dikirim
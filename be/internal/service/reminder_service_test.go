@@ -0,0 +1,100 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test nextReminderDate - tahap cadence H-7, H-3, H-0, H+1
+func TestNextReminderDate(t *testing.T) {
+	cadence := []int{-7, -3, 0, 1}
+	due := time.Date(2026, 11, 10, 14, 30, 0, 0, time.Local)
+
+	// Kirim H-7 pukul 08:00 -> tahap berikutnya H-3 (awal hari)
+	now := time.Date(2026, 11, 3, 8, 0, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2026, 11, 7, 0, 0, 0, 0, time.Local), nextReminderDate(due, cadence, now))
+
+	// Tahap yang terlewat tidak dikirim ulang: H-1 -> langsung ke H-0
+	now = time.Date(2026, 11, 9, 8, 0, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2026, 11, 10, 0, 0, 0, 0, time.Local), nextReminderDate(due, cadence, now))
+
+	// Setelah H+1 tidak ada tahap lagi
+	now = time.Date(2026, 11, 11, 8, 0, 0, 0, time.Local)
+	assert.True(t, nextReminderDate(due, cadence, now).IsZero())
+
+	// Tanpa jatuh tempo hanya dikirim sekali
+	assert.True(t, nextReminderDate(time.Time{}, cadence, now).IsZero())
+}
+
+// Test firstReminderDate - reminder pertama pada tahap cadence terawal, tidak pernah di masa lalu
+func TestFirstReminderDate(t *testing.T) {
+	due := time.Date(2026, 11, 10, 14, 30, 0, 0, time.Local)
+
+	// Tagihan dibuat jauh sebelum jatuh tempo -> H-7 (awal hari)
+	now := time.Date(2026, 10, 20, 8, 0, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2026, 11, 3, 0, 0, 0, 0, time.Local), firstReminderDate(due, []int{-3, -7, 0, 1}, now))
+
+	// Tahap pertama sudah lewat -> langsung hari ini
+	now = time.Date(2026, 11, 5, 8, 0, 0, 0, time.Local)
+	assert.Equal(t, now, firstReminderDate(due, []int{-7, -3, 0, 1}, now))
+
+	// Tanpa cadence -> hari jatuh tempo
+	now = time.Date(2026, 10, 20, 8, 0, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2026, 11, 10, 0, 0, 0, 0, time.Local), firstReminderDate(due, nil, now))
+}
+
+// Test parseReminderPreference - default WhatsApp, nilai lain ditolak
+func TestParseReminderPreference(t *testing.T) {
+	for input, expected := range map[string]string{"": "whatsapp", "Email": "email", " both ": "both", "none": "none"} {
+		preference, err := parseReminderPreference(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, preference)
+	}
+
+	_, err := parseReminderPreference("sms")
+	assert.ErrorIs(t, err, ErrInvalidReminderPreference)
+}
+
+// Test NormalizePhoneNumber - nomor reminder dikirim dalam format 62xxx
+func TestNormalizePhoneNumber(t *testing.T) {
+	assert.Equal(t, "6281332314854", utils.NormalizePhoneNumber("081332314854"))
+	assert.Equal(t, "6281332314854", utils.NormalizePhoneNumber("+62 813-3231-4854"))
+	assert.Equal(t, "6281332314854", utils.NormalizePhoneNumber("81332314854"))
+	assert.Equal(t, "6281332314854", utils.NormalizePhoneNumber("6281332314854"))
+	assert.Equal(t, "", utils.NormalizePhoneNumber(""))
+	assert.Equal(t, "", utils.NormalizePhoneNumber("12345"))
+}
+
+// Test needsMonthlyBill - tagihan deposit yang belum dibayar tidak menahan tagihan sewa bulanan
+func TestNeedsMonthlyBill_IgnoresUnpaidDeposit(t *testing.T) {
+	now := time.Date(2026, 11, 5, 8, 0, 0, 0, time.Local)
+	paidUntil := time.Date(2026, 11, 8, 0, 0, 0, 0, time.Local)
+	booking := models.Pemesanan{
+		Kamar: models.Kamar{HargaPerBulan: 1500000},
+		Pembayaran: []models.Pembayaran{
+			{TipePembayaran: "full", StatusPembayaran: models.PaymentConfirmed},
+			{TipePembayaran: "deposit", StatusPembayaran: models.PaymentPending},
+			{TipePembayaran: "deposit_charge", StatusPembayaran: models.PaymentRejected},
+		},
+	}
+
+	assert.True(t, needsMonthlyBill(booking, paidUntil, 7, now))
+
+	// Tagihan sewa yang masih Pending tetap menahan tagihan baru
+	booking.Pembayaran = append(booking.Pembayaran, models.Pembayaran{TipePembayaran: "extend", StatusPembayaran: models.PaymentPending})
+	assert.False(t, needsMonthlyBill(booking, paidUntil, 7, now))
+}
+
+// Test needsMonthlyBill - tagihan baru baru dibuat mulai H-BillingLeadDays
+func TestNeedsMonthlyBill_BeforeLeadWindow(t *testing.T) {
+	paidUntil := time.Date(2026, 11, 30, 0, 0, 0, 0, time.Local)
+	booking := models.Pemesanan{Kamar: models.Kamar{HargaPerBulan: 1500000}}
+
+	assert.False(t, needsMonthlyBill(booking, paidUntil, 7, time.Date(2026, 11, 22, 23, 0, 0, 0, time.Local)))
+	assert.True(t, needsMonthlyBill(booking, paidUntil, 7, time.Date(2026, 11, 23, 0, 0, 0, 0, time.Local)))
+	assert.False(t, needsMonthlyBill(booking, time.Time{}, 7, time.Date(2026, 11, 23, 0, 0, 0, 0, time.Local)))
+}
@@ -38,7 +38,7 @@ func (s *reviewService) CreateReview(review *models.Review, userID uint) error {
 
 	hasStayed := false
 	for _, booking := range bookings {
-		if booking.KamarID == review.KamarID && booking.StatusPemesanan == "Confirmed" {
+		if booking.KamarID == review.KamarID && (booking.StatusPemesanan == models.BookingConfirmed || booking.StatusPemesanan == models.BookingCheckedOut) {
 			hasStayed = true
 			break
 		}
@@ -1,12 +1,16 @@
 package service
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"koskosan-be/internal/models"
 	"koskosan-be/internal/repository"
 	"koskosan-be/internal/utils"
 	"time"
 
 	"github.com/stretchr/testify/mock"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -33,6 +37,19 @@ func (m *MockUserRepository) FindByUsername(username string) (*models.User, erro
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindByRoles(roles []string) ([]models.User, error) {
+	args := m.Called(roles)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) FindByID(id uint) (*models.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -111,8 +128,8 @@ func (m *MockPenyewaRepository) UpdateRole(penyewaID uint, role string) error {
 	return args.Error(0)
 }
 
-func (m *MockPenyewaRepository) FindAllPaginated(pagination *utils.Pagination, search, role string) ([]models.Penyewa, int64, error) {
-	args := m.Called(pagination, search, role)
+func (m *MockPenyewaRepository) FindAllPaginated(pagination *utils.Pagination, filter repository.TenantFilter) ([]models.Penyewa, int64, error) {
+	args := m.Called(pagination, filter)
 	if args.Get(0) == nil {
 		return nil, 0, args.Error(2)
 	}
@@ -221,11 +238,35 @@ func (m *MockBookingRepository) FindExpiredPendingBookings(expiryTime time.Time)
 	return args.Get(0).([]models.Pemesanan), args.Error(1)
 }
 
-func (m *MockBookingRepository) UpdateStatus(id uint, status string) error {
-	args := m.Called(id, status)
+func (m *MockBookingRepository) Transition(booking *models.Pemesanan, to models.BookingStatus, change models.StatusChange) error {
+	args := m.Called(booking, to, change)
+	if args.Error(0) == nil {
+		booking.StatusPemesanan = to
+	}
 	return args.Error(0)
 }
 
+func (m *MockBookingRepository) UpdateLease(booking *models.Pemesanan) error {
+	args := m.Called(booking)
+	return args.Error(0)
+}
+
+func (m *MockBookingRepository) FindEndedLeases(before time.Time) ([]models.Pemesanan, error) {
+	args := m.Called(before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Pemesanan), args.Error(1)
+}
+
+func (m *MockBookingRepository) FindStatusHistory(bookingID uint) ([]models.BookingStatusHistory, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BookingStatusHistory), args.Error(1)
+}
+
 func (m *MockBookingRepository) FindActiveBookingByKamarID(kamarID uint) (*models.Pemesanan, error) {
 	args := m.Called(kamarID)
 	if args.Get(0) == nil {
@@ -242,12 +283,20 @@ func (m *MockBookingRepository) WithTx(tx *gorm.DB) repository.BookingRepository
 	return args.Get(0).(repository.BookingRepository)
 }
 
-func (m *MockBookingRepository) FindPendingBookingByKamarID(kamarID uint) (*models.Pemesanan, error) {
+func (m *MockBookingRepository) FindPendingBookingsByKamarID(kamarID uint) ([]models.Pemesanan, error) {
 	args := m.Called(kamarID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Pemesanan), args.Error(1)
+	return args.Get(0).([]models.Pemesanan), args.Error(1)
+}
+
+func (m *MockBookingRepository) FindOverlappingBookings(kamarID uint, from, to time.Time) ([]models.Pemesanan, error) {
+	args := m.Called(kamarID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Pemesanan), args.Error(1)
 }
 
 // MockKamarRepository implements repository.KamarRepository
@@ -288,6 +337,11 @@ func (m *MockKamarRepository) UpdateStatus(id uint, status string) error {
 	return args.Error(0)
 }
 
+func (m *MockKamarRepository) SetMaintenance(id uint, alasan string) error {
+	args := m.Called(id, alasan)
+	return args.Error(0)
+}
+
 func (m *MockKamarRepository) WithTx(tx *gorm.DB) repository.KamarRepository {
 	args := m.Called(tx)
 	if args.Get(0) == nil {
@@ -337,6 +391,14 @@ func (m *MockPaymentRepository) FindAll() ([]models.Pembayaran, error) {
 	return args.Get(0).([]models.Pembayaran), args.Error(1)
 }
 
+func (m *MockPaymentRepository) FindAllPaginated(pagination *utils.Pagination, filter repository.PaymentFilter) ([]models.Pembayaran, int64, error) {
+	args := m.Called(pagination, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.Pembayaran), int64(args.Int(1)), args.Error(2)
+}
+
 func (m *MockPaymentRepository) FindByID(id uint) (*models.Pembayaran, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -380,18 +442,29 @@ func (m *MockPaymentRepository) FindByOrderID(orderID string) (*models.Pembayara
 	return args.Get(0).(*models.Pembayaran), args.Error(1)
 }
 
-func (m *MockPaymentRepository) CancelPendingPaymentsByBookingID(bookingID uint) error {
-	args := m.Called(bookingID)
+func (m *MockPaymentRepository) Transition(payment *models.Pembayaran, to models.PaymentStatus, change models.StatusChange) error {
+	args := m.Called(payment, to, change)
+	if args.Error(0) == nil {
+		payment.StatusPembayaran = to
+	}
 	return args.Error(0)
 }
 
+func (m *MockPaymentRepository) FindStatusHistoryByBookingID(bookingID uint) ([]models.PaymentStatusHistory, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PaymentStatusHistory), args.Error(1)
+}
+
 // MockEmailSender implements utils.EmailSender
 type MockEmailSender struct {
 	mock.Mock
 }
 
-func (m *MockEmailSender) SendEmail(to, subject, body string) error {
-	args := m.Called(to, subject, body)
+func (m *MockEmailSender) SendEmail(to, subject, body string, attachment *utils.EmailAttachment) error {
+	args := m.Called(to, subject, body, attachment)
 	return args.Error(0)
 }
 
@@ -400,16 +473,6 @@ func (m *MockEmailSender) SendResetPasswordEmail(to, token string) error {
 	return args.Error(0)
 }
 
-func (m *MockEmailSender) SendPaymentSuccessEmail(toEmail, tenantName string, amount float64, date time.Time) error {
-	args := m.Called(toEmail, tenantName, amount, date)
-	return args.Error(0)
-}
-
-func (m *MockEmailSender) SendPaymentReminderEmail(toEmail, tenantName string, amount float64, dueDate time.Time, paymentLink string) error {
-	args := m.Called(toEmail, tenantName, amount, dueDate, paymentLink)
-	return args.Error(0)
-}
-
 // MockWhatsAppSender implements utils.WhatsAppSender
 type MockWhatsAppSender struct {
 	mock.Mock
@@ -432,3 +495,600 @@ func (m *MockGoogleVerifier) Verify(tokenString string, clientID string) (*utils
 	}
 	return args.Get(0).(*utils.GoogleClaims), args.Error(1)
 }
+
+// MockRefundRepository implements repository.RefundRepository
+type MockRefundRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefundRepository) FindAll(status string) ([]models.Refund, error) {
+	args := m.Called(status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) FindByID(id uint) (*models.Refund, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) FindByPembayaranID(pembayaranID uint) (*models.Refund, error) {
+	args := m.Called(pembayaranID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Refund), args.Error(1)
+}
+
+func (m *MockRefundRepository) Create(refund *models.Refund) error {
+	args := m.Called(refund)
+	return args.Error(0)
+}
+
+func (m *MockRefundRepository) Update(refund *models.Refund) error {
+	args := m.Called(refund)
+	return args.Error(0)
+}
+
+func (m *MockRefundRepository) UpdateFromStatus(refund *models.Refund, from ...string) error {
+	args := m.Called(refund, from)
+	return args.Error(0)
+}
+
+func (m *MockRefundRepository) WithTx(tx *gorm.DB) repository.RefundRepository {
+	return m
+}
+
+// MockDepositRepository implements repository.DepositRepository
+type MockDepositRepository struct {
+	mock.Mock
+}
+
+func (m *MockDepositRepository) FindByBookingID(bookingID uint) (*models.SecurityDeposit, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SecurityDeposit), args.Error(1)
+}
+
+func (m *MockDepositRepository) FindByBookingIDs(bookingIDs []uint) ([]models.SecurityDeposit, error) {
+	args := m.Called(bookingIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SecurityDeposit), args.Error(1)
+}
+
+func (m *MockDepositRepository) FindByIDForUpdate(id uint) (*models.SecurityDeposit, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SecurityDeposit), args.Error(1)
+}
+
+func (m *MockDepositRepository) Create(deposit *models.SecurityDeposit) error {
+	args := m.Called(deposit)
+	return args.Error(0)
+}
+
+func (m *MockDepositRepository) Update(deposit *models.SecurityDeposit) error {
+	args := m.Called(deposit)
+	return args.Error(0)
+}
+
+func (m *MockDepositRepository) CreateDeductions(deductions []models.DepositDeduction) error {
+	args := m.Called(deductions)
+	return args.Error(0)
+}
+
+func (m *MockDepositRepository) WithTx(tx *gorm.DB) repository.DepositRepository {
+	return m
+}
+
+// MockPricingPolicyRepository implements repository.PricingPolicyRepository
+type MockPricingPolicyRepository struct {
+	mock.Mock
+}
+
+func (m *MockPricingPolicyRepository) FindAll() ([]models.PricingPolicy, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PricingPolicy), args.Error(1)
+}
+
+func (m *MockPricingPolicyRepository) FindByID(id uint) (*models.PricingPolicy, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PricingPolicy), args.Error(1)
+}
+
+func (m *MockPricingPolicyRepository) FindByTipeKamar(tipeKamar string) (*models.PricingPolicy, error) {
+	args := m.Called(tipeKamar)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PricingPolicy), args.Error(1)
+}
+
+func (m *MockPricingPolicyRepository) Save(policy *models.PricingPolicy) error {
+	args := m.Called(policy)
+	return args.Error(0)
+}
+
+func (m *MockPricingPolicyRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// MockLateFeeRepository implements repository.LateFeeRepository
+type MockLateFeeRepository struct {
+	mock.Mock
+}
+
+func (m *MockLateFeeRepository) FindOverdueBills(before time.Time, billTypes []string) ([]models.Pembayaran, error) {
+	args := m.Called(before, billTypes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Pembayaran), args.Error(1)
+}
+
+func (m *MockLateFeeRepository) UpsertLateFee(paymentID uint, fee float64, deskripsi string) error {
+	args := m.Called(paymentID, fee, deskripsi)
+	return args.Error(0)
+}
+
+func (m *MockLateFeeRepository) SetEscalationLevel(paymentID uint, level int) error {
+	args := m.Called(paymentID, level)
+	return args.Error(0)
+}
+
+func (m *MockLateFeeRepository) LockKamar(kamarID uint, reason string) error {
+	args := m.Called(kamarID, reason)
+	return args.Error(0)
+}
+
+func (m *MockLateFeeRepository) UnlockKamar(kamarID uint) error {
+	args := m.Called(kamarID)
+	return args.Error(0)
+}
+
+func (m *MockLateFeeRepository) CountEscalatedBills(kamarID uint, minLevel int) (int64, error) {
+	args := m.Called(kamarID, minLevel)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLateFeeRepository) WithTx(tx *gorm.DB) repository.LateFeeRepository {
+	return m
+}
+
+// MockLedgerRepository implements repository.LedgerRepository
+type MockLedgerRepository struct {
+	mock.Mock
+}
+
+func (m *MockLedgerRepository) FindBookingWithPayments(bookingID uint) (*models.Pemesanan, error) {
+	args := m.Called(bookingID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Pemesanan), args.Error(1)
+}
+
+func (m *MockLedgerRepository) FindPaidRefunds(bookingIDs []uint) ([]models.Refund, error) {
+	args := m.Called(bookingIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Refund), args.Error(1)
+}
+
+func (m *MockLedgerRepository) FindAdjustments(bookingIDs []uint) ([]models.LedgerAdjustment, error) {
+	args := m.Called(bookingIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.LedgerAdjustment), args.Error(1)
+}
+
+func (m *MockLedgerRepository) CreateAdjustment(adjustment *models.LedgerAdjustment) error {
+	args := m.Called(adjustment)
+	return args.Error(0)
+}
+
+// MockExportRepository implements repository.ExportRepository
+type MockExportRepository struct {
+	mock.Mock
+	Payments []repository.PaymentExportRow
+	Tenants  []repository.TenantExportRow
+}
+
+func (m *MockExportRepository) StreamPayments(filter repository.PaymentFilter, fn func(repository.PaymentExportRow) error) error {
+	args := m.Called(filter)
+	for _, row := range m.Payments {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return args.Error(0)
+}
+
+func (m *MockExportRepository) StreamBookings(filter repository.BookingFilter, fn func(repository.BookingExportRow) error) error {
+	args := m.Called(filter)
+	return args.Error(0)
+}
+
+func (m *MockExportRepository) StreamTenants(filter repository.TenantFilter, fn func(repository.TenantExportRow) error) error {
+	args := m.Called(filter)
+	for _, row := range m.Tenants {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return args.Error(0)
+}
+
+// MockNotificationRepository implements repository.NotificationRepository
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(notification *models.Notification) error {
+	args := m.Called(notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) CreateBatch(notifications []models.Notification) error {
+	args := m.Called(notifications)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) FindByUserID(userID uint, pagination *utils.Pagination, unreadOnly bool) ([]models.Notification, int64, error) {
+	args := m.Called(userID, pagination, unreadOnly)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.Notification), int64(args.Int(1)), args.Error(2)
+}
+
+func (m *MockNotificationRepository) CountUnread(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkRead(id uint, userID uint) (int64, error) {
+	args := m.Called(id, userID)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkAllRead(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockNotificationRepository) FindAdminUserIDs() ([]uint, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+// MockOutboxRepository implements repository.OutboxRepository
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) Create(message *models.OutboxMessage) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) Update(message *models.OutboxMessage) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) FindByID(id uint) (*models.OutboxMessage, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxRepository) FindByProviderMessageID(provider, messageID string) (*models.OutboxMessage, error) {
+	args := m.Called(provider, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxRepository) ClaimDue(now, claimUntil time.Time, limit int) ([]models.OutboxMessage, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxRepository) ClaimByID(id uint, now, claimUntil time.Time) (*models.OutboxMessage, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxRepository) FindAllPaginated(pagination *utils.Pagination, filter repository.OutboxFilter) ([]models.OutboxMessage, int64, error) {
+	args := m.Called(pagination, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.OutboxMessage), int64(args.Int(1)), args.Error(2)
+}
+
+// MockMessageTemplateRepository implements repository.MessageTemplateRepository
+type MockMessageTemplateRepository struct {
+	mock.Mock
+}
+
+func (m *MockMessageTemplateRepository) FindAll() ([]models.MessageTemplate, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MessageTemplate), args.Error(1)
+}
+
+func (m *MockMessageTemplateRepository) Find(event, channel, language string) (*models.MessageTemplate, error) {
+	args := m.Called(event, channel, language)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MessageTemplate), args.Error(1)
+}
+
+func (m *MockMessageTemplateRepository) Save(template *models.MessageTemplate) error {
+	args := m.Called(template)
+	return args.Error(0)
+}
+
+func (m *MockMessageTemplateRepository) Delete(event, channel, language string) (int64, error) {
+	args := m.Called(event, channel, language)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+// MockSessionRepository implements repository.SessionRepository
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(session *models.UserSession) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) FindByTokenHash(hash string) (*models.UserSession, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserSession), args.Error(1)
+}
+
+func (m *MockSessionRepository) Rotate(oldID uint, next *models.UserSession) error {
+	args := m.Called(oldID, next)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeFamily(familyID string, reason string) (int64, error) {
+	args := m.Called(familyID, reason)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeUserFamily(userID uint, familyID string, reason string) (int64, error) {
+	args := m.Called(userID, familyID, reason)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeAllByUserID(userID uint, exceptFamilyID string, reason string) (int64, error) {
+	args := m.Called(userID, exceptFamilyID, reason)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindActiveByUserID(userID uint) ([]models.UserSession, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserSession), args.Error(1)
+}
+
+// MockLoginRepository implements repository.LoginRepository
+type MockLoginRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginRepository) FindThrottle(username string) (*models.LoginThrottle, error) {
+	args := m.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginRepository) IncrementThrottle(key string, now time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginRepository) LockThrottle(key string, until time.Time) error {
+	args := m.Called(key, until)
+	return args.Error(0)
+}
+
+func (m *MockLoginRepository) ResetThrottle(username string) error {
+	args := m.Called(username)
+	return args.Error(0)
+}
+
+func (m *MockLoginRepository) CreateAttempt(attempt *models.LoginAttempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+
+func (m *MockLoginRepository) CountSuccessful(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockLoginRepository) CountSuccessfulFromDevice(userID uint, userAgent string) (int64, error) {
+	args := m.Called(userID, userAgent)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockLoginRepository) FindAttemptsByUserID(userID uint, pagination *utils.Pagination) ([]models.LoginAttempt, int64, error) {
+	args := m.Called(userID, pagination)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.LoginAttempt), int64(args.Int(1)), args.Error(2)
+}
+
+// MockTwoFactorRepository implements repository.TwoFactorRepository
+type MockTwoFactorRepository struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	args := m.Called(userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	args := m.Called(userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) DeleteRecoveryCodes(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// MockAuditRepository implements repository.AuditRepository
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Create(log *models.AuditLog) error {
+	args := m.Called(log)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) FindAll(pagination *utils.Pagination, filter repository.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	args := m.Called(pagination, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.AuditLog), args.Get(1).(int64), args.Error(2)
+}
+
+// MockMaintenanceRepository implements repository.MaintenanceRepository
+type MockMaintenanceRepository struct {
+	mock.Mock
+}
+
+func (m *MockMaintenanceRepository) FindByID(id uint) (*models.MaintenanceTicket, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MaintenanceTicket), args.Error(1)
+}
+
+func (m *MockMaintenanceRepository) FindAll(pagination *utils.Pagination, filter repository.MaintenanceTicketFilter) ([]models.MaintenanceTicket, int64, error) {
+	args := m.Called(pagination, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.MaintenanceTicket), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMaintenanceRepository) FindByPenyewaID(penyewaID uint) ([]models.MaintenanceTicket, error) {
+	args := m.Called(penyewaID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MaintenanceTicket), args.Error(1)
+}
+
+func (m *MockMaintenanceRepository) Create(ticket *models.MaintenanceTicket) error {
+	args := m.Called(ticket)
+	return args.Error(0)
+}
+
+func (m *MockMaintenanceRepository) Update(ticket *models.MaintenanceTicket) error {
+	args := m.Called(ticket)
+	return args.Error(0)
+}
+
+func (m *MockMaintenanceRepository) AddComment(comment *models.MaintenanceComment) error {
+	args := m.Called(comment)
+	return args.Error(0)
+}
+
+func (m *MockMaintenanceRepository) CountOutOfService(kamarID uint, excludeTicketID uint) (int64, error) {
+	args := m.Called(kamarID, excludeTicketID)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+// txStubDriver adalah driver database/sql yang hanya mendukung BEGIN/COMMIT/ROLLBACK, sehingga
+// service yang memakai db.Transaction bisa diuji dengan repository mock (WithTx).
+type txStubDriver struct{}
+
+type txStubConn struct{}
+
+func (txStubDriver) Open(name string) (driver.Conn, error) { return txStubConn{}, nil }
+
+func (txStubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("txstub: query tidak didukung: " + query)
+}
+func (txStubConn) Close() error              { return nil }
+func (txStubConn) Begin() (driver.Tx, error) { return txStubConn{}, nil }
+func (txStubConn) Commit() error             { return nil }
+func (txStubConn) Rollback() error           { return nil }
+
+func init() {
+	sql.Register("txstub", txStubDriver{})
+}
+
+// newTxStubDB mengembalikan *gorm.DB untuk db.Transaction di test; semua query harus lewat mock
+func newTxStubDB() *gorm.DB {
+	conn, _ := sql.Open("txstub", "")
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/config"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"log"
+	"time"
+
+	github_uuid "github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Alasan pencabutan sesi (models.UserSession.RevokedReason)
+const (
+	sessionRevokedRotated         = "rotated"
+	sessionRevokedLogout          = "logout"
+	sessionRevokedPasswordChanged = "password_changed"
+	sessionRevokedPasswordReset   = "password_reset"
+	sessionRevokedDeactivated     = "deactivated"
+	sessionRevokedReuse           = "reuse_detected"
+	sessionRevokedByUser          = "revoked"
+	sessionRevokedTwoFactor       = "two_factor_required"
+	sessionRevokedRoleChanged     = "role_changed"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// TokenPair adalah access & refresh token hasil login / refresh
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	SessionID    string
+}
+
+// SessionView adalah satu perangkat yang sedang login
+type SessionView struct {
+	ID         string    `json:"id"` // FamilyID
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// SessionService menyimpan refresh token di database, merotasinya setiap refresh dan mendeteksi
+// pemakaian ulang token lama (indikasi token dicuri) dengan mencabut seluruh family.
+type SessionService interface {
+	CreateSession(user *models.User, userAgent, ipAddress string) (*TokenPair, error)
+	Refresh(refreshToken, userAgent, ipAddress string) (*TokenPair, error)
+	Logout(refreshToken string) error
+	ListSessions(userID uint, currentSessionID string) ([]SessionView, error)
+	RevokeSession(userID uint, sessionID string) error
+	RevokeOtherSessions(userID uint, currentSessionID string) (int64, error)
+}
+
+type sessionService struct {
+	repo     repository.SessionRepository
+	userRepo repository.UserRepository
+	config   *config.Config
+}
+
+func NewSessionService(repo repository.SessionRepository, userRepo repository.UserRepository, cfg *config.Config) SessionService {
+	return &sessionService{repo, userRepo, cfg}
+}
+
+func (s *sessionService) issue(user *models.User, familyID string, signedInAt time.Time, userAgent, ipAddress string) (*TokenPair, *models.UserSession, error) {
+	accessToken, refreshToken, err := utils.GenerateSessionTokenPair(int(user.ID), user.Username, user.Role, familyID, s.config.JWTSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	session := &models.UserSession{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		TokenHash:  utils.HashToken(refreshToken),
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		SignedInAt: signedInAt,
+		ExpiresAt:  time.Now().Add(utils.RefreshTokenExpiry),
+	}
+	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken, SessionID: familyID}, session, nil
+}
+
+// CreateSession memulai family baru (login dari satu perangkat)
+func (s *sessionService) CreateSession(user *models.User, userAgent, ipAddress string) (*TokenPair, error) {
+	pair, session, err := s.issue(user, github_uuid.New().String(), time.Now(), userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(session); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh menukar refresh token dengan pasangan token baru. Token yang sudah dirotasi lalu dipakai
+// lagi berarti ada dua pemegang token yang sama, sehingga seluruh family dicabut.
+func (s *sessionService) Refresh(refreshToken, userAgent, ipAddress string) (*TokenPair, error) {
+	claims, err := utils.ValidateRefreshToken(refreshToken, s.config.JWTSecret)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	current, err := s.repo.FindByTokenHash(utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken // token lama (sebelum sesi disimpan) atau palsu
+		}
+		return nil, err
+	}
+
+	if current.Revoked {
+		if current.RevokedReason == sessionRevokedRotated {
+			s.revokeReusedFamily(current)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Role bisa berubah (mis. guest -> tenant) sejak login, ambil data user terbaru
+	user, err := s.userRepo.FindByID(uint(claims.UserID))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	// Sesi staff yang dibuat sebelum 2FA diwajibkan harus login ulang dan enrol
+	if utils.IsStaffRole(user.Role) && !user.TwoFactorEnabled {
+		if _, err := s.repo.RevokeFamily(current.FamilyID, sessionRevokedTwoFactor); err != nil {
+			log.Printf("[WARN] Failed to revoke session %s: %v", current.FamilyID, err)
+		}
+		utils.DisconnectSession(current.FamilyID)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	pair, next, err := s.issue(user, current.FamilyID, current.SignedInAt, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Rotate(current.ID, next); err != nil {
+		if errors.Is(err, repository.ErrSessionAlreadyRotated) {
+			s.revokeReusedFamily(current)
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return pair, nil
+}
+
+func (s *sessionService) revokeReusedFamily(session *models.UserSession) {
+	log.Printf("[WARN] Refresh token reuse detected for user %d (session %s), revoking session", session.UserID, session.FamilyID)
+	if _, err := s.repo.RevokeFamily(session.FamilyID, sessionRevokedReuse); err != nil {
+		log.Printf("[WARN] Failed to revoke session %s: %v", session.FamilyID, err)
+	}
+	utils.DisconnectSession(session.FamilyID)
+}
+
+// Logout mencabut sesi (family) milik refresh token; token tidak valid diabaikan
+func (s *sessionService) Logout(refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+	session, err := s.repo.FindByTokenHash(utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if _, err = s.repo.RevokeFamily(session.FamilyID, sessionRevokedLogout); err != nil {
+		return err
+	}
+	utils.DisconnectSession(session.FamilyID)
+	return nil
+}
+
+func (s *sessionService) ListSessions(userID uint, currentSessionID string) ([]SessionView, error) {
+	sessions, err := s.repo.FindActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]SessionView, 0, len(sessions))
+	for _, session := range sessions {
+		views = append(views, SessionView{
+			ID:         session.FamilyID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			SignedInAt: session.SignedInAt,
+			LastUsedAt: session.CreatedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.FamilyID == currentSessionID,
+		})
+	}
+	return views, nil
+}
+
+func (s *sessionService) RevokeSession(userID uint, sessionID string) error {
+	revoked, err := s.repo.RevokeUserFamily(userID, sessionID, sessionRevokedByUser)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	utils.DisconnectSession(sessionID)
+	return nil
+}
+
+func (s *sessionService) RevokeOtherSessions(userID uint, currentSessionID string) (int64, error) {
+	revoked, err := s.repo.RevokeAllByUserID(userID, currentSessionID, sessionRevokedByUser)
+	if err != nil {
+		return 0, err
+	}
+	utils.DisconnectUserSessions(userID, currentSessionID)
+	return revoked, nil
+}
+
+// revokeUserSessions mencabut semua sesi user (kecuali exceptSessionID) setelah perubahan
+// kredensial / status akun, lalu memutus socket notifikasinya. Kegagalan hanya dicatat agar
+// aksi utama tetap berhasil.
+func revokeUserSessions(repo repository.SessionRepository, userID uint, exceptSessionID, reason string) {
+	if repo == nil {
+		return
+	}
+	if _, err := repo.RevokeAllByUserID(userID, exceptSessionID, reason); err != nil {
+		log.Printf("[WARN] Failed to revoke sessions of user %d: %v", userID, err)
+		return
+	}
+	utils.DisconnectUserSessions(userID, exceptSessionID)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"koskosan-be/internal/config"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+var sessionTestConfig = &config.Config{JWTSecret: "test-secret-key-32-characters-long"}
+
+func newSessionTestUser() *models.User {
+	user := &models.User{Username: "budi", Role: "tenant"}
+	user.ID = 7
+	return user
+}
+
+// Test CreateSession - refresh token disimpan sebagai hash dalam family baru
+func TestSessionService_CreateSession(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, new(MockUserRepository), sessionTestConfig)
+
+	var stored *models.UserSession
+	repo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.UserSession)
+	}).Return(nil)
+
+	tokens, err := service.CreateSession(newSessionTestUser(), "Firefox", "10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.HashToken(tokens.RefreshToken), stored.TokenHash)
+	assert.Equal(t, tokens.SessionID, stored.FamilyID)
+	assert.Equal(t, uint(7), stored.UserID)
+
+	claims, err := utils.ValidateAccessToken(tokens.AccessToken, sessionTestConfig.JWTSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, tokens.SessionID, claims.SessionID)
+}
+
+// Test Refresh - token dirotasi dalam family yang sama
+func TestSessionService_Refresh_Rotates(t *testing.T) {
+	repo := new(MockSessionRepository)
+	userRepo := new(MockUserRepository)
+	service := NewSessionService(repo, userRepo, sessionTestConfig)
+
+	_, refreshToken, _ := utils.GenerateSessionTokenPair(7, "budi", "tenant", "family-1", sessionTestConfig.JWTSecret)
+	current := &models.UserSession{ID: 1, UserID: 7, FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}
+	repo.On("FindByTokenHash", utils.HashToken(refreshToken)).Return(current, nil)
+	userRepo.On("FindByID", uint(7)).Return(newSessionTestUser(), nil)
+
+	var next *models.UserSession
+	repo.On("Rotate", uint(1), mock.Anything).Run(func(args mock.Arguments) {
+		next = args.Get(1).(*models.UserSession)
+	}).Return(nil)
+
+	tokens, err := service.Refresh(refreshToken, "Firefox", "10.0.0.2")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, refreshToken, tokens.RefreshToken)
+	assert.Equal(t, "family-1", next.FamilyID)
+	assert.Equal(t, utils.HashToken(tokens.RefreshToken), next.TokenHash)
+}
+
+// Test Refresh - token yang sudah dirotasi dipakai lagi mencabut seluruh family
+func TestSessionService_Refresh_ReuseRevokesFamily(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, new(MockUserRepository), sessionTestConfig)
+
+	_, refreshToken, _ := utils.GenerateSessionTokenPair(7, "budi", "tenant", "family-1", sessionTestConfig.JWTSecret)
+	repo.On("FindByTokenHash", utils.HashToken(refreshToken)).Return(&models.UserSession{
+		ID: 1, UserID: 7, FamilyID: "family-1", Revoked: true, RevokedReason: "rotated", ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	repo.On("RevokeFamily", "family-1", "reuse_detected").Return(1, nil)
+
+	tokens, err := service.Refresh(refreshToken, "", "")
+
+	assert.Nil(t, tokens)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+	repo.AssertExpectations(t)
+}
+
+// Test Refresh - dua request bersamaan dengan token yang sama: yang kalah dianggap reuse
+func TestSessionService_Refresh_ConcurrentRotation(t *testing.T) {
+	repo := new(MockSessionRepository)
+	userRepo := new(MockUserRepository)
+	service := NewSessionService(repo, userRepo, sessionTestConfig)
+
+	_, refreshToken, _ := utils.GenerateSessionTokenPair(7, "budi", "tenant", "family-1", sessionTestConfig.JWTSecret)
+	repo.On("FindByTokenHash", utils.HashToken(refreshToken)).Return(&models.UserSession{ID: 1, UserID: 7, FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	userRepo.On("FindByID", uint(7)).Return(newSessionTestUser(), nil)
+	repo.On("Rotate", uint(1), mock.Anything).Return(repository.ErrSessionAlreadyRotated)
+	repo.On("RevokeFamily", "family-1", "reuse_detected").Return(2, nil)
+
+	_, err := service.Refresh(refreshToken, "", "")
+
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+	repo.AssertExpectations(t)
+}
+
+// Test Refresh - token yang dicabut karena logout tidak bisa dipakai
+func TestSessionService_Refresh_RevokedSession(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, new(MockUserRepository), sessionTestConfig)
+
+	_, refreshToken, _ := utils.GenerateSessionTokenPair(7, "budi", "tenant", "family-1", sessionTestConfig.JWTSecret)
+	repo.On("FindByTokenHash", utils.HashToken(refreshToken)).Return(&models.UserSession{ID: 1, FamilyID: "family-1", Revoked: true, RevokedReason: "logout"}, nil)
+
+	_, err := service.Refresh(refreshToken, "", "")
+
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+	repo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+}
+
+// Test Logout - family token dicabut; token tidak dikenal diabaikan
+func TestSessionService_Logout(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, new(MockUserRepository), sessionTestConfig)
+
+	repo.On("FindByTokenHash", utils.HashToken("known")).Return(&models.UserSession{FamilyID: "family-1"}, nil)
+	repo.On("FindByTokenHash", utils.HashToken("unknown")).Return(nil, gorm.ErrRecordNotFound)
+	repo.On("RevokeFamily", "family-1", "logout").Return(1, nil)
+
+	assert.NoError(t, service.Logout("known"))
+	assert.NoError(t, service.Logout("unknown"))
+	repo.AssertNumberOfCalls(t, "RevokeFamily", 1)
+}
+
+// Test ListSessions & RevokeSession - sesi saat ini ditandai, sesi milik user lain tidak ditemukan
+func TestSessionService_ListAndRevoke(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, new(MockUserRepository), sessionTestConfig)
+
+	repo.On("FindActiveByUserID", uint(7)).Return([]models.UserSession{
+		{FamilyID: "family-1", UserAgent: "Firefox"},
+		{FamilyID: "family-2", UserAgent: "Android"},
+	}, nil)
+	repo.On("RevokeUserFamily", uint(7), "family-2", "revoked").Return(1, nil)
+	repo.On("RevokeUserFamily", uint(7), "family-9", "revoked").Return(0, nil)
+
+	sessions, err := service.ListSessions(7, "family-1")
+	assert.NoError(t, err)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+
+	assert.NoError(t, service.RevokeSession(7, "family-2"))
+	assert.Equal(t, gorm.ErrRecordNotFound, service.RevokeSession(7, "family-9"))
+}
+
+// Test DeactivateTenant - semua sesi penyewa dicabut
+func TestTenantService_DeactivateRevokesSessions(t *testing.T) {
+	penyewaRepo := new(MockPenyewaRepository)
+	sessionRepo := new(MockSessionRepository)
+	userRepo := new(MockUserRepository)
+	service := NewTenantService(penyewaRepo, sessionRepo, nil, nil, userRepo)
+
+	penyewaRepo.On("FindByID", uint(3)).Return(&models.Penyewa{ID: 3, UserID: 7, Role: "tenant"}, nil)
+	userRepo.On("FindByID", uint(7)).Return(&models.User{ID: 7, Role: "tenant"}, nil)
+	penyewaRepo.On("UpdateRole", uint(3), "non_active").Return(nil)
+	sessionRepo.On("RevokeAllByUserID", uint(7), "", "deactivated").Return(2, nil)
+
+	assert.NoError(t, service.DeactivateTenant(3, AuditActor{}))
+	sessionRepo.AssertExpectations(t)
+}
+
+// Test DeactivateTenant - penyewa yang dipromosikan jadi staff tidak dapat dinonaktifkan
+func TestTenantService_DeactivateRejectsStaff(t *testing.T) {
+	for _, role := range []string{"owner", "admin", "caretaker", "accountant"} {
+		penyewaRepo := new(MockPenyewaRepository)
+		userRepo := new(MockUserRepository)
+		service := NewTenantService(penyewaRepo, new(MockSessionRepository), nil, nil, userRepo)
+
+		penyewaRepo.On("FindByID", uint(3)).Return(&models.Penyewa{ID: 3, UserID: 7, Role: "tenant"}, nil)
+		userRepo.On("FindByID", uint(7)).Return(&models.User{ID: 7, Role: role}, nil)
+
+		err := service.DeactivateTenant(3, AuditActor{})
+
+		assert.EqualError(t, err, "akun staff tidak dapat dinonaktifkan", role)
+		penyewaRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+	}
+}
+
+// Test Refresh - sesi admin tanpa 2FA aktif dicabut dan harus login ulang
+func TestSessionService_Refresh_AdminWithoutTwoFactor(t *testing.T) {
+	repo := new(MockSessionRepository)
+	userRepo := new(MockUserRepository)
+	service := NewSessionService(repo, userRepo, sessionTestConfig)
+
+	_, refreshToken, _ := utils.GenerateSessionTokenPair(1, "admin", "admin", "family-9", sessionTestConfig.JWTSecret)
+	repo.On("FindByTokenHash", utils.HashToken(refreshToken)).Return(&models.UserSession{ID: 3, UserID: 1, FamilyID: "family-9", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	admin := &models.User{Username: "admin", Role: "admin"}
+	admin.ID = 1
+	userRepo.On("FindByID", uint(1)).Return(admin, nil)
+	repo.On("RevokeFamily", "family-9", "two_factor_required").Return(1, nil)
+
+	_, err := service.Refresh(refreshToken, "Firefox", "10.0.0.1")
+
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+	repo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidStaffRole   = errors.New("role staff tidak valid (owner, admin, caretaker, accountant)")
+	ErrStaffSelfModify    = errors.New("tidak dapat mengubah role atau menghapus akun sendiri")
+	ErrStaffOwnerOnly     = errors.New("hanya owner yang dapat mengelola akun owner")
+	ErrNotStaff           = errors.New("user bukan staff")
+	ErrStaffUsernameTaken = errors.New("username sudah digunakan")
+)
+
+// StaffRole adalah role staff beserta permission-nya (untuk halaman manajemen staff)
+type StaffRole struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type StaffService interface {
+	GetStaff() ([]models.User, error)
+	GetRoles() []StaffRole
+	CreateStaff(actor AuditActor, username, password, role string) (*models.User, error)
+	UpdateRole(actor AuditActor, id uint, role string) (*models.User, error)
+	DeleteStaff(actor AuditActor, id uint) error
+}
+
+type staffService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	audit       AuditService
+}
+
+func NewStaffService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, audit AuditService) StaffService {
+	return &staffService{userRepo, sessionRepo, audit}
+}
+
+func (s *staffService) GetStaff() ([]models.User, error) {
+	return s.userRepo.FindByRoles(utils.StaffRoles())
+}
+
+func (s *staffService) GetRoles() []StaffRole {
+	roles := make([]StaffRole, 0, len(utils.StaffRoles()))
+	for _, role := range utils.StaffRoles() {
+		roles = append(roles, StaffRole{Role: role, Permissions: utils.PermissionsForRole(role)})
+	}
+	return roles
+}
+
+// CreateStaff membuat akun staff baru; 2FA wajib di-enrol saat login pertama
+func (s *staffService) CreateStaff(actor AuditActor, username, password, role string) (*models.User, error) {
+	if err := checkStaffRoleAssignable(actor.Role, role); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.FindByUsername(username); err == nil {
+		return nil, ErrStaffUsernameTaken
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{Username: username, Password: string(hashed), Role: role}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	recordAudit(s.audit, actor, "staff.create", "user", user.ID, nil, user)
+	return user, nil
+}
+
+// UpdateRole mengganti role staff dan mencabut sesinya agar permission baru langsung berlaku
+func (s *staffService) UpdateRole(actor AuditActor, id uint, role string) (*models.User, error) {
+	user, err := s.findManageableStaff(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStaffRoleAssignable(actor.Role, role); err != nil {
+		return nil, err
+	}
+
+	before := *user
+	user.Role = role
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	recordAudit(s.audit, actor, "staff.update_role", "user", user.ID, before, *user)
+	revokeUserSessions(s.sessionRepo, user.ID, "", sessionRevokedRoleChanged)
+	return user, nil
+}
+
+// DeleteStaff menghapus (soft delete) akun staff dan mengeluarkannya dari semua perangkat
+func (s *staffService) DeleteStaff(actor AuditActor, id uint) error {
+	user, err := s.findManageableStaff(actor, id)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return err
+	}
+	recordAudit(s.audit, actor, "staff.delete", "user", user.ID, user, nil)
+	revokeUserSessions(s.sessionRepo, user.ID, "", sessionRevokedRoleChanged)
+	return nil
+}
+
+func (s *staffService) findManageableStaff(actor AuditActor, id uint) (*models.User, error) {
+	if actor.UserID == id {
+		return nil, ErrStaffSelfModify
+	}
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.IsStaffRole(user.Role) {
+		return nil, ErrNotStaff
+	}
+	if user.Role == utils.RoleOwner && actor.Role != utils.RoleOwner {
+		return nil, ErrStaffOwnerOnly
+	}
+	return user, nil
+}
+
+func checkStaffRoleAssignable(actorRole, role string) error {
+	if !utils.IsStaffRole(role) {
+		return ErrInvalidStaffRole
+	}
+	if role == utils.RoleOwner && actorRole != utils.RoleOwner {
+		return ErrStaffOwnerOnly
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newStaffUser(id uint, role string) *models.User {
+	user := &models.User{Username: role, Role: role}
+	user.ID = id
+	return user
+}
+
+// Test permission - caretaker boleh konfirmasi pembayaran tetapi tidak hapus kamar / lihat NIK
+func TestRoleHasPermission(t *testing.T) {
+	assert.True(t, utils.RoleHasPermission(utils.RoleCaretaker, utils.PermPaymentsConfirm))
+	assert.False(t, utils.RoleHasPermission(utils.RoleCaretaker, utils.PermRoomsWrite))
+	assert.False(t, utils.RoleHasPermission(utils.RoleCaretaker, utils.PermTenantsReadPII))
+	assert.True(t, utils.RoleHasPermission(utils.RoleAccountant, utils.PermExportsRead))
+	assert.False(t, utils.RoleHasPermission(utils.RoleAccountant, utils.PermPaymentsConfirm))
+	assert.True(t, utils.RoleHasPermission(utils.RoleAdmin, utils.PermStaffManage))
+	assert.False(t, utils.RoleHasPermission("tenant", utils.PermPaymentsRead))
+	assert.Equal(t, "************3456", utils.MaskNIK("3201234567893456"))
+}
+
+// Test CreateStaff - username unik dan password di-hash
+func TestStaffService_CreateStaff(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := NewStaffService(userRepo, nil, nil)
+
+	userRepo.On("FindByUsername", "penjaga").Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("Create", mock.MatchedBy(func(u *models.User) bool {
+		return u.Username == "penjaga" && u.Role == utils.RoleCaretaker && u.Password != "Rahasia123"
+	})).Return(nil)
+
+	user, err := service.CreateStaff(AuditActor{UserID: 1, Role: utils.RoleAdmin}, "penjaga", "Rahasia123", utils.RoleCaretaker)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.RoleCaretaker, user.Role)
+	userRepo.AssertExpectations(t)
+}
+
+// Test CreateStaff - role bukan staff dan owner oleh non-owner ditolak
+func TestStaffService_CreateStaff_RoleChecks(t *testing.T) {
+	service := NewStaffService(new(MockUserRepository), nil, nil)
+
+	_, err := service.CreateStaff(AuditActor{UserID: 1, Role: utils.RoleAdmin}, "budi", "Rahasia123", "tenant")
+	assert.Equal(t, ErrInvalidStaffRole, err)
+
+	_, err = service.CreateStaff(AuditActor{UserID: 1, Role: utils.RoleAdmin}, "bos", "Rahasia123", utils.RoleOwner)
+	assert.Equal(t, ErrStaffOwnerOnly, err)
+}
+
+// Test UpdateRole - role berubah dan semua sesi staff dicabut
+func TestStaffService_UpdateRole_RevokesSessions(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	sessionRepo := new(MockSessionRepository)
+	service := NewStaffService(userRepo, sessionRepo, nil)
+
+	userRepo.On("FindByID", uint(5)).Return(newStaffUser(5, utils.RoleCaretaker), nil)
+	userRepo.On("Update", mock.MatchedBy(func(u *models.User) bool { return u.Role == utils.RoleAccountant })).Return(nil)
+	sessionRepo.On("RevokeAllByUserID", uint(5), "", "role_changed").Return(2, nil)
+
+	user, err := service.UpdateRole(AuditActor{UserID: 1, Role: utils.RoleAdmin}, 5, utils.RoleAccountant)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.RoleAccountant, user.Role)
+	sessionRepo.AssertExpectations(t)
+}
+
+// Test UpdateRole/DeleteStaff - akun sendiri, owner (oleh admin) dan penyewa tidak bisa dikelola
+func TestStaffService_ManageGuards(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := NewStaffService(userRepo, nil, nil)
+
+	userRepo.On("FindByID", uint(2)).Return(newStaffUser(2, utils.RoleOwner), nil)
+	userRepo.On("FindByID", uint(3)).Return(newStaffUser(3, "tenant"), nil)
+
+	_, err := service.UpdateRole(AuditActor{UserID: 1, Role: utils.RoleAdmin}, 1, utils.RoleCaretaker)
+	assert.Equal(t, ErrStaffSelfModify, err)
+	assert.Equal(t, ErrStaffOwnerOnly, service.DeleteStaff(AuditActor{UserID: 1, Role: utils.RoleAdmin}, 2))
+	assert.Equal(t, ErrNotStaff, service.DeleteStaff(AuditActor{UserID: 1, Role: utils.RoleAdmin}, 3))
+	userRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Test state machine booking - status akhir tidak bisa kembali ke Pending
+func TestBookingStatus_CanTransitionTo(t *testing.T) {
+	assert.True(t, models.BookingPending.CanTransitionTo(models.BookingConfirmed))
+	assert.True(t, models.BookingPending.CanTransitionTo(models.BookingPartiallyPaid))
+	assert.True(t, models.BookingPartiallyPaid.CanTransitionTo(models.BookingConfirmed))
+	assert.True(t, models.BookingConfirmed.CanTransitionTo(models.BookingCancelled))
+	assert.True(t, models.BookingConfirmed.CanTransitionTo(models.BookingConfirmed))
+
+	assert.False(t, models.BookingConfirmed.CanTransitionTo(models.BookingPending))
+	assert.False(t, models.BookingCancelled.CanTransitionTo(models.BookingConfirmed))
+	assert.False(t, models.BookingCancelled.CanTransitionTo(models.BookingPending))
+}
+
+// Test state machine pembayaran - Confirmed dan Cancelled adalah status akhir
+func TestPaymentStatus_CanTransitionTo(t *testing.T) {
+	assert.True(t, models.PaymentPending.CanTransitionTo(models.PaymentConfirmed))
+	assert.True(t, models.PaymentRejected.CanTransitionTo(models.PaymentPending))
+	assert.True(t, models.PaymentAwaitingConfirmation.CanTransitionTo(models.PaymentConfirmed))
+
+	assert.False(t, models.PaymentConfirmed.CanTransitionTo(models.PaymentPending))
+	assert.False(t, models.PaymentConfirmed.CanTransitionTo(models.PaymentRejected))
+	assert.False(t, models.PaymentCancelled.CanTransitionTo(models.PaymentConfirmed))
+	assert.False(t, models.PaymentRejected.CanTransitionTo(models.PaymentConfirmed))
+}
+
+// Test UploadPaymentProof - bukti baru untuk pembayaran Confirmed ditolak tanpa mengubah data
+func TestPaymentService_UploadPaymentProof_ConfirmedIsRejected(t *testing.T) {
+	mockRepo := new(MockPaymentRepository)
+	mockBookingRepo := new(MockBookingRepository)
+	mockPenyewaRepo := new(MockPenyewaRepository)
+	service := NewPaymentService(mockRepo, mockBookingRepo, new(MockKamarRepository), mockPenyewaRepo,
+		new(MockPricingPolicyRepository), nil, nil, nil, nil, nil, nil, nil)
+
+	payment := &models.Pembayaran{ID: 1, PemesananID: 1, StatusPembayaran: models.PaymentConfirmed}
+	transitionErr := &models.StatusTransitionError{Entity: "payment", ID: 1, From: "Confirmed", To: "Pending"}
+
+	mockRepo.On("FindByID", uint(1)).Return(payment, nil)
+	mockBookingRepo.On("FindByID", uint(1)).Return(&models.Pemesanan{ID: 1, PenyewaID: 1}, nil)
+	mockPenyewaRepo.On("FindByUserID", uint(1)).Return(&models.Penyewa{ID: 1, UserID: 1}, nil)
+	mockRepo.On("Transition", payment, models.PaymentPending, mock.Anything).Return(transitionErr)
+
+	err := service.UploadPaymentProof(1, "/uploads/proofs/late.jpg", 1, AuditActor{UserID: 1})
+
+	var target *models.StatusTransitionError
+	assert.True(t, errors.As(err, &target))
+	assert.Empty(t, payment.BuktiTransfer)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// Test GetBookingHistory - penyewa hanya bisa melihat riwayat booking miliknya
+func TestBookingService_GetBookingHistory_Ownership(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	mockPenyewaRepo := new(MockPenyewaRepository)
+	mockPaymentRepo := new(MockPaymentRepository)
+	service := NewBookingService(mockBookingRepo, new(MockUserRepository), mockPenyewaRepo, new(MockKamarRepository), mockPaymentRepo, new(MockRefundRepository), new(MockPricingPolicyRepository), new(MockLedgerRepository), nil, nil, nil, nil, nil, nil, nil)
+
+	booking := &models.Pemesanan{ID: 5, PenyewaID: 1, StatusPemesanan: models.BookingConfirmed}
+	mockBookingRepo.On("FindByID", uint(5)).Return(booking, nil)
+	mockPenyewaRepo.On("FindByUserID", uint(2)).Return(&models.Penyewa{ID: 2, UserID: 2}, nil)
+	mockBookingRepo.On("FindStatusHistory", uint(5)).Return([]models.BookingStatusHistory{
+		{PemesananID: 5, FromStatus: models.BookingPending, ToStatus: models.BookingConfirmed, ChangedBy: 3, ChangedByRole: utils.RoleAdmin},
+	}, nil)
+	mockPaymentRepo.On("FindStatusHistoryByBookingID", uint(5)).Return(nil, nil)
+
+	_, err := service.GetBookingHistory(5, 2, "tenant")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthorized")
+
+	history, err := service.GetBookingHistory(5, 3, utils.RoleAccountant)
+	assert.NoError(t, err)
+	assert.Equal(t, models.BookingConfirmed, history.StatusPemesanan)
+	assert.Len(t, history.Booking, 1)
+	assert.NotNil(t, history.Payments)
+}
@@ -11,17 +11,22 @@ import (
 type TenantService interface {
 	GetAllTenants() ([]models.Penyewa, error)
 	GetTenantsByRole(role string) ([]models.Penyewa, error)
-	GetTenantsPaginated(pagination *utils.Pagination, search, role string) ([]models.Penyewa, int64, error)
+	GetTenantsPaginated(pagination *utils.Pagination, filter repository.TenantFilter) ([]models.Penyewa, int64, error)
 	ValidateTenant(penyewa *models.Penyewa) error
-	DeactivateTenant(id uint) error
+	DeactivateTenant(id uint, actor AuditActor) error
+	GetLoginHistory(id uint, pagination *utils.Pagination) ([]models.LoginAttempt, int64, error)
 }
 
 type tenantService struct {
-	repo repository.PenyewaRepository
+	repo        repository.PenyewaRepository
+	sessionRepo repository.SessionRepository
+	loginRepo   repository.LoginRepository
+	audit       AuditService
+	userRepo    repository.UserRepository
 }
 
-func NewTenantService(repo repository.PenyewaRepository) TenantService {
-	return &tenantService{repo}
+func NewTenantService(repo repository.PenyewaRepository, sessionRepo repository.SessionRepository, loginRepo repository.LoginRepository, audit AuditService, userRepo repository.UserRepository) TenantService {
+	return &tenantService{repo, sessionRepo, loginRepo, audit, userRepo}
 }
 
 func (s *tenantService) GetAllTenants() ([]models.Penyewa, error) {
@@ -32,8 +37,8 @@ func (s *tenantService) GetTenantsByRole(role string) ([]models.Penyewa, error)
 	return s.repo.FindByRole(role)
 }
 
-func (s *tenantService) GetTenantsPaginated(pagination *utils.Pagination, search, role string) ([]models.Penyewa, int64, error) {
-	return s.repo.FindAllPaginated(pagination, search, role)
+func (s *tenantService) GetTenantsPaginated(pagination *utils.Pagination, filter repository.TenantFilter) ([]models.Penyewa, int64, error) {
+	return s.repo.FindAllPaginated(pagination, filter)
 }
 
 func (s *tenantService) ValidateTenant(penyewa *models.Penyewa) error {
@@ -46,17 +51,47 @@ func (s *tenantService) ValidateTenant(penyewa *models.Penyewa) error {
 	return nil
 }
 
-func (s *tenantService) DeactivateTenant(id uint) error {
+func (s *tenantService) DeactivateTenant(id uint, actor AuditActor) error {
 	// Fetch penyewa first to check role
 	penyewa, err := s.repo.FindByID(id)
 	if err != nil {
 		return errors.New("user tidak ditemukan")
 	}
 
-	// Lindungi akun admin dari di-nonaktifkan
-	if penyewa.Role == "admin" {
-		return errors.New("akun admin tidak dapat dinonaktifkan")
+	// Lindungi akun staff dari di-nonaktifkan. Role staff disimpan di User.Role; Penyewa.Role
+	// penyewa yang dipromosikan (staffService.UpdateRole) tetap role penyewa.
+	if utils.IsStaffRole(penyewa.Role) {
+		return errors.New("akun staff tidak dapat dinonaktifkan")
 	}
+	if penyewa.UserID != 0 {
+		user, err := s.userRepo.FindByID(penyewa.UserID)
+		if err != nil {
+			return err
+		}
+		if utils.IsStaffRole(user.Role) {
+			return errors.New("akun staff tidak dapat dinonaktifkan")
+		}
+	}
+
+	if err := s.repo.UpdateRole(id, "non_active"); err != nil {
+		return err
+	}
+	recordAudit(s.audit, actor, "tenant.deactivate", "penyewa", id,
+		map[string]interface{}{"role": penyewa.Role}, map[string]interface{}{"role": "non_active"})
+
+	// Paksa logout di semua perangkat; access token yang sudah terbit berlaku maksimal 15 menit
+	revokeUserSessions(s.sessionRepo, penyewa.UserID, "", sessionRevokedDeactivated)
+	return nil
+}
 
-	return s.repo.UpdateRole(id, "non_active")
+// GetLoginHistory mengembalikan riwayat login (berhasil & gagal) akun penyewa, terbaru dulu
+func (s *tenantService) GetLoginHistory(id uint, pagination *utils.Pagination) ([]models.LoginAttempt, int64, error) {
+	penyewa, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if penyewa.UserID == 0 {
+		return []models.LoginAttempt{}, 0, nil
+	}
+	return s.loginRepo.FindAttemptsByUserID(penyewa.UserID, pagination)
 }
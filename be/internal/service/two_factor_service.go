@@ -0,0 +1,221 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/repository"
+	"koskosan-be/internal/utils"
+	"strings"
+	"time"
+)
+
+// Jumlah recovery code yang diterbitkan setiap kali 2FA diaktifkan / kode dibuat ulang
+const recoveryCodeCount = 10
+
+var (
+	ErrInvalidOTP              = errors.New("kode OTP tidak valid")
+	ErrTwoFactorNotSetup       = errors.New("2FA belum disiapkan, lakukan setup terlebih dahulu")
+	ErrTwoFactorAlreadyEnabled = errors.New("2FA sudah aktif")
+	ErrTwoFactorNotEnabled     = errors.New("2FA belum aktif")
+	ErrTwoFactorMandatory      = errors.New("2FA wajib untuk akun staff dan tidak dapat dinonaktifkan")
+)
+
+// TwoFactorSetup berisi secret yang harus dimasukkan ke aplikasi authenticator (langsung atau via QR)
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth://totp/... untuk dirender sebagai QR code
+}
+
+// TwoFactorStatus adalah ringkasan 2FA untuk halaman profil
+type TwoFactorStatus struct {
+	Enabled           bool  `json:"enabled"`
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+type TwoFactorService interface {
+	Required(user *models.User) bool
+	GetStatus(userID uint) (*TwoFactorStatus, error)
+	Setup(userID uint) (*TwoFactorSetup, error)
+	Enable(userID uint, code string) ([]string, error)
+	Verify(user *models.User, code string) error
+	Disable(userID uint, code string) error
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+}
+
+type twoFactorService struct {
+	userRepo repository.UserRepository
+	repo     repository.TwoFactorRepository
+	issuer   string
+}
+
+func NewTwoFactorService(userRepo repository.UserRepository, repo repository.TwoFactorRepository, issuer string) TwoFactorService {
+	return &twoFactorService{userRepo, repo, issuer}
+}
+
+// Required: 2FA wajib untuk staff (owner, admin, caretaker, accountant) dan user lain yang sudah mengaktifkannya
+func (s *twoFactorService) Required(user *models.User) bool {
+	return user.TwoFactorEnabled || utils.IsStaffRole(user.Role)
+}
+
+func (s *twoFactorService) GetStatus(userID uint) (*TwoFactorStatus, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{Enabled: user.TwoFactorEnabled, Required: utils.IsStaffRole(user.Role)}
+	if user.TwoFactorEnabled {
+		if status.RecoveryCodesLeft, err = s.repo.CountUnusedRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Setup membuat secret baru (belum aktif); setup ulang sebelum Enable menggantikan secret sebelumnya
+func (s *twoFactorService) Setup(userID uint) (*TwoFactorSetup, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.TwoFactorSecret = secret
+	user.TwoFactorLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Enable mengaktifkan 2FA setelah kode pertama dari authenticator cocok, lalu menerbitkan recovery code
+func (s *twoFactorService) Enable(userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if !s.acceptTOTP(user, code) {
+		return nil, ErrInvalidOTP
+	}
+
+	user.TwoFactorEnabled = true
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(userID)
+}
+
+// Verify menerima kode TOTP atau recovery code (sekali pakai) untuk user yang 2FA-nya aktif
+func (s *twoFactorService) Verify(user *models.User, code string) error {
+	if !user.TwoFactorEnabled || user.TwoFactorSecret == "" {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.acceptTOTP(user, code) {
+		return s.userRepo.Update(user)
+	}
+
+	used, err := s.repo.UseRecoveryCode(user.ID, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+func (s *twoFactorService) Disable(userID uint, code string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if utils.IsStaffRole(user.Role) {
+		return ErrTwoFactorMandatory
+	}
+	if err := s.Verify(user, code); err != nil {
+		return err
+	}
+
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret = ""
+	user.TwoFactorLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	return s.repo.DeleteRecoveryCodes(userID)
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code; kode lama langsung tidak berlaku
+func (s *twoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Verify(user, code); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(userID)
+}
+
+// acceptTOTP memvalidasi kode dan menolak langkah waktu yang sudah pernah dipakai (replay).
+// Langkah yang diterima disimpan di user; pemanggil bertanggung jawab menyimpan user.
+func (s *twoFactorService) acceptTOTP(user *models.User, code string) bool {
+	step, ok := utils.ValidateTOTP(user.TwoFactorSecret, code, time.Now())
+	if !ok || step <= user.TwoFactorLastStep {
+		return false
+	}
+	user.TwoFactorLastStep = step
+	return true
+}
+
+func (s *twoFactorService) issueRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = utils.HashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode membuat kode 10 karakter base32 dengan format xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"errors"
+	"koskosan-be/internal/models"
+	"koskosan-be/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Secret ASCII "12345678901234567890" dari test vector RFC 6238
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func currentTOTP(t *testing.T, secret string) string {
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	assert.NoError(t, err)
+	return code
+}
+
+func newTwoFactorAdmin(enabled bool) *models.User {
+	user := &models.User{Username: "admin", Role: "admin", TwoFactorSecret: rfcTOTPSecret, TwoFactorEnabled: enabled}
+	user.ID = 1
+	return user
+}
+
+// Test TOTPCode - cocok dengan test vector RFC 6238 (6 digit terakhir)
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	for unix, expected := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924", 2000000000: "279037"} {
+		code, err := utils.TOTPCode(rfcTOTPSecret, utils.TOTPStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, "t=%d", unix)
+	}
+}
+
+// Test ValidateTOTP - toleransi satu langkah sebelum/sesudah
+func TestValidateTOTP_Skew(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	previous, _ := utils.TOTPCode(rfcTOTPSecret, utils.TOTPStep(now)-1)
+	tooOld, _ := utils.TOTPCode(rfcTOTPSecret, utils.TOTPStep(now)-2)
+
+	step, ok := utils.ValidateTOTP(rfcTOTPSecret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, utils.TOTPStep(now)-1, step)
+
+	_, ok = utils.ValidateTOTP(rfcTOTPSecret, tooOld, now)
+	assert.False(t, ok)
+	_, ok = utils.ValidateTOTP(rfcTOTPSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := utils.TOTPProvisioningURI("Koskosan", "admin", rfcTOTPSecret)
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Koskosan:admin?"))
+	assert.Contains(t, uri, "secret="+rfcTOTPSecret)
+	assert.Contains(t, uri, "issuer=Koskosan")
+	assert.Contains(t, uri, "digits=6")
+}
+
+// Test Enable - kode pertama mengaktifkan 2FA dan menerbitkan 10 recovery code
+func TestTwoFactorService_Enable(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	repo := new(MockTwoFactorRepository)
+	service := NewTwoFactorService(userRepo, repo, "Koskosan")
+
+	user := newTwoFactorAdmin(false)
+	userRepo.On("FindByID", uint(1)).Return(user, nil)
+	userRepo.On("Update", mock.Anything).Return(nil)
+
+	var hashes []string
+	repo.On("ReplaceRecoveryCodes", uint(1), mock.Anything).Run(func(args mock.Arguments) {
+		hashes = args.Get(1).([]string)
+	}).Return(nil)
+
+	codes, err := service.Enable(1, currentTOTP(t, rfcTOTPSecret))
+
+	assert.NoError(t, err)
+	assert.True(t, user.TwoFactorEnabled)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	assert.Equal(t, utils.HashToken(normalizeRecoveryCode(codes[0])), hashes[0])
+}
+
+// Test Verify - kode TOTP yang sama tidak bisa dipakai dua kali (replay)
+func TestTwoFactorService_Verify_RejectsReplay(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	repo := new(MockTwoFactorRepository)
+	service := NewTwoFactorService(userRepo, repo, "Koskosan")
+
+	user := newTwoFactorAdmin(true)
+	userRepo.On("Update", user).Return(nil)
+	repo.On("UseRecoveryCode", uint(1), mock.Anything).Return(false, nil)
+
+	code := currentTOTP(t, rfcTOTPSecret)
+	assert.NoError(t, service.Verify(user, code))
+	assert.Equal(t, ErrInvalidOTP, service.Verify(user, code))
+}
+
+// Test Verify - recovery code diterima (format bebas huruf besar/tanda hubung)
+func TestTwoFactorService_Verify_RecoveryCode(t *testing.T) {
+	repo := new(MockTwoFactorRepository)
+	service := NewTwoFactorService(new(MockUserRepository), repo, "Koskosan")
+
+	repo.On("UseRecoveryCode", uint(1), utils.HashToken("abcdefghij")).Return(true, nil)
+
+	assert.NoError(t, service.Verify(newTwoFactorAdmin(true), "ABCDE-FGHIJ"))
+}
+
+// Test Disable - admin tidak boleh menonaktifkan 2FA
+func TestTwoFactorService_Disable_AdminMandatory(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := NewTwoFactorService(userRepo, new(MockTwoFactorRepository), "Koskosan")
+	userRepo.On("FindByID", uint(1)).Return(newTwoFactorAdmin(true), nil)
+
+	assert.Equal(t, ErrTwoFactorMandatory, service.Disable(1, "000000"))
+}
+
+// Test Login - admin tanpa enrol mendapat challenge setup, bukan token
+func TestLogin_AdminRequiresTwoFactor(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	penyewaRepo := new(MockPenyewaRepository)
+	twoFactor := NewTwoFactorService(userRepo, new(MockTwoFactorRepository), "Koskosan")
+	service := NewAuthService(userRepo, penyewaRepo, loginTestConfig, nil, nil, nil, nil, nil, nil, twoFactor)
+
+	admin := newLoginTestUser("admin123")
+	admin.Role = "admin"
+	userRepo.On("FindByUsername", "admin").Return(admin, nil)
+	penyewaRepo.On("FindByUserID", uint(7)).Return(nil, gorm.ErrRecordNotFound)
+
+	user, err := service.Login("admin", "admin123", loginTestClient)
+
+	var required *TwoFactorRequiredError
+	assert.True(t, errors.As(err, &required))
+	assert.True(t, required.SetupRequired)
+	assert.Nil(t, user)
+
+	claims, err := utils.ValidateTwoFactorChallenge(required.ChallengeToken, loginTestConfig.JWTSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, "password", claims.Method)
+	_, err = utils.ValidateAccessToken(required.ChallengeToken, loginTestConfig.JWTSecret)
+	assert.Error(t, err)
+}
+
+// Test VerifyTwoFactor - OTP salah dihitung ke penguncian login
+func TestVerifyTwoFactor_WrongCodeCountsAsFailure(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	loginRepo := new(MockLoginRepository)
+	twoFactorRepo := new(MockTwoFactorRepository)
+	twoFactor := NewTwoFactorService(userRepo, twoFactorRepo, "Koskosan")
+	service := NewAuthService(userRepo, new(MockPenyewaRepository), loginTestConfig, nil, nil, nil, loginRepo, nil, nil, twoFactor)
+
+	admin := newTwoFactorAdmin(true)
+	userRepo.On("FindByID", uint(1)).Return(admin, nil)
+	twoFactorRepo.On("UseRecoveryCode", uint(1), mock.Anything).Return(false, nil)
+	loginRepo.On("FindThrottle", "admin").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("FindThrottle", "ip:10.0.0.9").Return(nil, gorm.ErrRecordNotFound)
+	loginRepo.On("CreateAttempt", mock.MatchedBy(func(a *models.LoginAttempt) bool {
+		return a.Reason == "wrong_otp" && a.Method == "password"
+	})).Return(nil)
+	loginRepo.On("IncrementThrottle", "admin").Return(&models.LoginThrottle{Username: "admin", FailedAttempts: 1}, nil)
+
+	challenge, _ := utils.GenerateTwoFactorChallenge(1, "admin", "admin", "password", loginTestConfig.JWTSecret)
+	wrong := "000000"
+	if currentTOTP(t, rfcTOTPSecret) == wrong {
+		wrong = "111111"
+	}
+	user, _, err := service.VerifyTwoFactor(challenge, wrong, loginTestClient)
+
+	assert.Equal(t, ErrInvalidOTP, err)
+	assert.Nil(t, user)
+	loginRepo.AssertExpectations(t)
+}
+
+// Test VerifyTwoFactor - enrol saat login mengaktifkan 2FA dan mengembalikan recovery code
+func TestVerifyTwoFactor_EnrolsOnFirstLogin(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	twoFactorRepo := new(MockTwoFactorRepository)
+	twoFactor := NewTwoFactorService(userRepo, twoFactorRepo, "Koskosan")
+	service := NewAuthService(userRepo, new(MockPenyewaRepository), loginTestConfig, nil, nil, nil, nil, nil, nil, twoFactor)
+
+	admin := newTwoFactorAdmin(false)
+	userRepo.On("FindByID", uint(1)).Return(admin, nil)
+	userRepo.On("Update", admin).Return(nil)
+	twoFactorRepo.On("ReplaceRecoveryCodes", uint(1), mock.Anything).Return(nil)
+
+	challenge, _ := utils.GenerateTwoFactorChallenge(1, "admin", "admin", "password", loginTestConfig.JWTSecret)
+	user, codes, err := service.VerifyTwoFactor(challenge, currentTOTP(t, rfcTOTPSecret), loginTestClient)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", user.Username)
+	assert.True(t, admin.TwoFactorEnabled)
+	assert.Len(t, codes, recoveryCodeCount)
+}
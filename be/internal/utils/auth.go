@@ -2,7 +2,9 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"google.golang.org/api/idtoken"
 )
 
@@ -40,14 +43,17 @@ type TokenClaims struct {
 	UserID    int    `json:"user_id"`
 	Username  string `json:"username"`
 	Role      string `json:"role"`
-	TokenType string `json:"token_type"` // "access" or "refresh"
+	TokenType string `json:"token_type"`    // "access" or "refresh"
+	SessionID string `json:"sid,omitempty"` // FamilyID sesi refresh token (lihat models.UserSession)
+	Method    string `json:"amr,omitempty"` // Metode login pertama (password/google) pada token challenge 2FA
 	jwt.RegisteredClaims
 }
 
 // Token expiry durations
 const (
-	AccessTokenExpiry  = 15 * time.Minute   // Short-lived
-	RefreshTokenExpiry = 7 * 24 * time.Hour // 7 days
+	AccessTokenExpiry        = 15 * time.Minute   // Short-lived
+	RefreshTokenExpiry       = 7 * 24 * time.Hour // 7 days
+	TwoFactorChallengeExpiry = 5 * time.Minute    // Langkah kedua login (OTP)
 )
 
 // GetAuthToken mendapatkan token dari cookie (prioritas) atau Authorization header (fallback)
@@ -87,6 +93,93 @@ func GenerateTokenPair(userID int, username string, role string, jwtSecret strin
 	return accessToken, refreshToken, nil
 }
 
+// GenerateSessionTokenPair membuat access & refresh token yang terikat ke sesi (family) tertentu.
+// Refresh token diberi jti acak sehingga setiap rotasi menghasilkan token (dan hash) yang unik.
+func GenerateSessionTokenPair(userID int, username string, role string, sessionID string, jwtSecret string) (accessToken, refreshToken string, err error) {
+	now := time.Now()
+	build := func(tokenType string, expiresIn time.Duration) (string, error) {
+		claims := &TokenClaims{
+			UserID:    userID,
+			Username:  username,
+			Role:      role,
+			TokenType: tokenType,
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        uuid.New().String(),
+				ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+			},
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+	}
+
+	if accessToken, err = build("access", AccessTokenExpiry); err != nil {
+		return "", "", err
+	}
+	if refreshToken, err = build("refresh", RefreshTokenExpiry); err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// GenerateTwoFactorChallenge membuat token sementara setelah password benar; hanya dapat ditukar
+// dengan token pair lewat verifikasi OTP (tidak diterima sebagai access token).
+func GenerateTwoFactorChallenge(userID int, username string, role string, method string, jwtSecret string) (string, error) {
+	now := time.Now()
+	claims := &TokenClaims{
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		TokenType: "2fa",
+		Method:    method,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TwoFactorChallengeExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+}
+
+// ValidateTwoFactorChallenge validates 2FA challenge token specifically
+func ValidateTwoFactorChallenge(token string, jwtSecret string) (*TokenClaims, error) {
+	claims, err := ValidateToken(token, jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != "2fa" {
+		return nil, errors.New("invalid token type: expected 2fa challenge token")
+	}
+
+	return claims, nil
+}
+
+// HashToken mengembalikan SHA-256 (hex) token; hanya hash yang disimpan di database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetRefreshToken mendapatkan refresh token dari cookie (prioritas) atau Authorization header (desktop)
+func GetRefreshToken(c *gin.Context) string {
+	refreshToken, err := c.Cookie("refresh_token")
+	if err == nil && refreshToken != "" {
+		return refreshToken
+	}
+
+	authHeader := c.GetHeader("Authorization")
+	if authHeader != "" {
+		parts := strings.Split(authHeader, " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
 // GenerateToken generate JWT token with type
 func GenerateToken(userID int, username string, role string, tokenType string, jwtSecret string, expiresIn time.Duration) (string, error) {
 	expiresAt := time.Now().Add(expiresIn)
@@ -15,6 +15,9 @@ type Pagination struct {
 	TotalPages int   `json:"total_pages"`
 }
 
+// MaxPageLimit membatasi ukuran halaman agar satu request tidak memuat seluruh tabel
+const MaxPageLimit = 1000
+
 type PaginatedResponse struct {
 	Data interface{} `json:"data"`
 	Meta Pagination  `json:"meta"`
@@ -33,12 +36,27 @@ func GeneratePaginationFromRequest(c *gin.Context) Pagination {
 			page, _ = strconv.Atoi(queryValue)
 		}
 	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+	if page < 1 {
+		page = 1
+	}
 	return Pagination{
 		Limit: limit,
 		Page:  page,
 	}
 }
 
+// SetTotal mengisi TotalRows dan TotalPages dari jumlah baris hasil filter
+func (p *Pagination) SetTotal(totalRows int64) {
+	p.TotalRows = totalRows
+	p.TotalPages = int(math.Ceil(float64(totalRows) / float64(p.GetLimit())))
+}
+
 func (p *Pagination) GetOffset() int {
 	return (p.GetPage() - 1) * p.GetLimit()
 }
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PDFDocument adalah penulis PDF minimal (satu halaman A4, font standar Helvetica)
+// untuk invoice dan kwitansi, tanpa dependensi pihak ketiga.
+// Koordinat memakai titik (1/72 inch) dengan origin di pojok kiri atas halaman.
+type PDFDocument struct {
+	content bytes.Buffer
+}
+
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+)
+
+func NewPDFDocument() *PDFDocument {
+	return &PDFDocument{}
+}
+
+// Text menulis satu baris teks. Karakter di luar Latin-1 diganti "?".
+func (d *PDFDocument) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&d.content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, pdfPageHeight-y, pdfEscape(text))
+}
+
+// Line menggambar garis tipis dari (x1, y1) ke (x2, y2).
+func (d *PDFDocument) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&d.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, pdfPageHeight-y1, x2, pdfPageHeight-y2)
+}
+
+// Bytes merakit dokumen PDF lengkap (objek, xref dan trailer).
+func (d *PDFDocument) Bytes() []byte {
+	stream := d.content.String()
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>", pdfPageWidth, pdfPageHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20 || r > 0xFF:
+			b.WriteByte('?')
+		case r >= 0x80:
+			// Latin-1 (mis. "é") ditulis sebagai escape oktal satu byte
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
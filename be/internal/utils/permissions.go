@@ -0,0 +1,83 @@
+package utils
+
+import "strings"
+
+// Permission staff dalam format resource:action. Route admin dilindungi per permission
+// (lihat middleware.RequirePermission), bukan per role.
+const (
+	PermDashboardRead     = "dashboard:read"
+	PermRoomsWrite        = "rooms:write"
+	PermRoomsStatus       = "rooms:status"
+	PermPaymentsRead      = "payments:read"
+	PermPaymentsConfirm   = "payments:confirm"
+	PermLedgerWrite       = "ledger:write"
+	PermRefundsManage     = "refunds:manage"
+	PermPricingManage     = "pricing:manage"
+	PermExportsRead       = "exports:read"
+	PermOutboxManage      = "outbox:manage"
+	PermTemplatesManage   = "templates:manage"
+	PermTenantsRead       = "tenants:read"
+	PermTenantsReadPII    = "tenants:read_pii" // NIK penyewa tanpa masking
+	PermTenantsWrite      = "tenants:write"
+	PermStaffManage       = "staff:manage"
+	PermMaintenanceManage = "maintenance:manage" // tiket perbaikan: assign, komentar, ubah status
+	PermAuditRead         = "audit:read"         // hanya owner: admin tidak boleh membaca jejak auditnya sendiri
+)
+
+// Role staff (models.User.Role). Role lain (guest, tenant, ...) adalah penyewa tanpa permission.
+const (
+	RoleOwner      = "owner"
+	RoleAdmin      = "admin"
+	RoleCaretaker  = "caretaker"
+	RoleAccountant = "accountant"
+)
+
+var adminPermissions = []string{
+	PermDashboardRead, PermRoomsWrite, PermRoomsStatus, PermPaymentsRead, PermPaymentsConfirm,
+	PermLedgerWrite, PermRefundsManage, PermPricingManage, PermExportsRead, PermOutboxManage,
+	PermTemplatesManage, PermTenantsRead, PermTenantsReadPII, PermTenantsWrite, PermStaffManage,
+	PermMaintenanceManage,
+}
+
+var rolePermissions = map[string][]string{
+	RoleOwner: append(append([]string{}, adminPermissions...), PermAuditRead),
+	RoleAdmin: adminPermissions,
+	// Penjaga kos: konfirmasi pembayaran (termasuk tunai), status kamar & tiket perbaikan, tanpa hapus kamar atau data NIK
+	RoleCaretaker: {PermDashboardRead, PermRoomsStatus, PermPaymentsRead, PermPaymentsConfirm, PermTenantsRead, PermMaintenanceManage},
+	// Akuntan: keuangan & laporan, tanpa mengubah kamar atau konfirmasi pembayaran
+	RoleAccountant: {PermDashboardRead, PermPaymentsRead, PermLedgerWrite, PermRefundsManage, PermExportsRead, PermTenantsRead},
+}
+
+// StaffRoles mengembalikan semua role staff, urut dari hak akses terbesar
+func StaffRoles() []string {
+	return []string{RoleOwner, RoleAdmin, RoleCaretaker, RoleAccountant}
+}
+
+// IsStaffRole true untuk role yang punya akses ke route admin
+func IsStaffRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// PermissionsForRole mengembalikan salinan daftar permission role (kosong untuk non-staff)
+func PermissionsForRole(role string) []string {
+	return append([]string{}, rolePermissions[role]...)
+}
+
+// RoleHasPermission memeriksa apakah role memiliki permission tertentu
+func RoleHasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskNIK menyamarkan NIK kecuali 4 digit terakhir (untuk staff tanpa tenants:read_pii)
+func MaskNIK(nik string) string {
+	if len(nik) <= 4 {
+		return strings.Repeat("*", len(nik))
+	}
+	return strings.Repeat("*", len(nik)-4) + nik[len(nik)-4:]
+}
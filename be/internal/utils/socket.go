@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	socketio "github.com/googollee/go-socket.io"
 	"github.com/googollee/go-socket.io/engineio"
@@ -16,12 +19,41 @@ type SocketServer struct {
 	Server *socketio.Server
 	// Map UserID -> Set of SocketIDs (a user might have multiple tabs open)
 	UserSockets map[uint]map[string]bool
-	mu          sync.RWMutex
+	// Koneksi yang sudah terautentikasi, untuk memutus socket saat sesinya dicabut
+	conns map[string]socketio.Conn
+	mu    sync.RWMutex
 }
 
 var GlobalSocket *SocketServer
 
-func InitSocketServer() (*SocketServer, error) {
+// SocketEvent adalah payload event "notification" yang dikirim ke client
+type SocketEvent struct {
+	Event   string      `json:"event"` // mis. booking.created, payment.confirmed
+	Title   string      `json:"title"`
+	Message string      `json:"message"`
+	Type    string      `json:"type"` // info, success, error
+	Data    interface{} `json:"data,omitempty"`
+	// NotificationID terisi jika event juga disimpan di pusat notifikasi (untuk mark-read)
+	NotificationID uint `json:"notification_id,omitempty"`
+}
+
+const adminSocketRoom = "admins"
+
+// socketSession disimpan sebagai context koneksi setelah token tervalidasi
+type socketSession struct {
+	UserID    uint
+	Role      string
+	SessionID string      // FamilyID sesi refresh token (claim sid)
+	expiry    *time.Timer // memutus koneksi saat access token kedaluwarsa
+}
+
+// Alasan pada event "session_expired" sebelum server memutus koneksi
+const (
+	socketDropTokenExpired   = "token_expired"
+	socketDropSessionRevoked = "session_revoked"
+)
+
+func InitSocketServer(jwtSecret string) (*SocketServer, error) {
 	server := socketio.NewServer(&engineio.Options{
 		PingTimeout:  20 * 1000,
 		PingInterval: 10 * 1000,
@@ -33,46 +65,37 @@ func InitSocketServer() (*SocketServer, error) {
 			},
 		},
 	})
-	
+
 	ss := &SocketServer{
 		Server:      server,
 		UserSockets: make(map[uint]map[string]bool),
+		conns:       make(map[string]socketio.Conn),
 	}
 
+	// Token diambil dari cookie access_token, query ?token= atau header Authorization saat handshake.
+	// Koneksi tanpa token tetap diterima tapi tidak masuk room apa pun sampai event "authenticate".
 	server.OnConnect("/", func(s socketio.Conn) error {
-		s.SetContext("")
+		s.SetContext(nil)
+		if token := socketHandshakeToken(s); token != "" {
+			if err := ss.authenticate(s, token, jwtSecret); err != nil {
+				log.Printf("Socket %s handshake token rejected: %v", s.ID(), err)
+			}
+		}
 		log.Printf("Socket connected: %s", s.ID())
 		return nil
 	})
 
-	server.OnEvent("/", "authenticate", func(s socketio.Conn, userID uint) {
-		ss.mu.Lock()
-		defer ss.mu.Unlock()
-		
-		// Join room for targeting
-		roomName := fmt.Sprintf("user_%d", userID)
-		s.Join(roomName)
-		
-		if ss.UserSockets[userID] == nil {
-			ss.UserSockets[userID] = make(map[string]bool)
+	// Fallback untuk client yang tidak bisa mengirim cookie: emit("authenticate", accessToken).
+	// Hanya untuk koneksi yang belum terautentikasi; ganti user harus lewat koneksi baru.
+	server.OnEvent("/", "authenticate", func(s socketio.Conn, token string) {
+		if err := ss.authenticate(s, token, jwtSecret); err != nil {
+			log.Printf("Socket %s authentication failed: %v", s.ID(), err)
+			s.Emit("unauthorized", err.Error())
 		}
-		ss.UserSockets[userID][s.ID()] = true
-		s.SetContext(userID)
-		log.Printf("Socket %s authenticated for user %d and joined room %s", s.ID(), userID, roomName)
 	})
 
 	server.OnDisconnect("/", func(s socketio.Conn, reason string) {
-		userID, ok := s.Context().(uint)
-		if ok {
-			ss.mu.Lock()
-			if ss.UserSockets[userID] != nil {
-				delete(ss.UserSockets[userID], s.ID())
-				if len(ss.UserSockets[userID]) == 0 {
-					delete(ss.UserSockets, userID)
-				}
-			}
-			ss.mu.Unlock()
-		}
+		ss.forget(s)
 		log.Printf("Socket disconnected: %s (reason: %s)", s.ID(), reason)
 	})
 
@@ -85,12 +108,154 @@ func InitSocketServer() (*SocketServer, error) {
 	return ss, nil
 }
 
+// authenticate memvalidasi access token (JWT yang sama dengan REST API), lalu memasukkan
+// koneksi ke room user_<id> dan, untuk staff, ke room admins. Koneksi diputus saat token
+// kedaluwarsa; client menyambung ulang dengan cookie hasil refresh.
+func (ss *SocketServer) authenticate(s socketio.Conn, token, jwtSecret string) error {
+	claims, err := ValidateAccessToken(token, jwtSecret)
+	if err != nil {
+		return err
+	}
+	if claims.ExpiresAt == nil {
+		return errors.New("token has no expiry")
+	}
+	userID := uint(claims.UserID)
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if _, ok := s.Context().(*socketSession); ok {
+		return errors.New("socket already authenticated")
+	}
+
+	s.Join(fmt.Sprintf("user_%d", userID))
+	if IsStaffRole(claims.Role) {
+		s.Join(adminSocketRoom)
+	}
+
+	if ss.UserSockets[userID] == nil {
+		ss.UserSockets[userID] = make(map[string]bool)
+	}
+	ss.UserSockets[userID][s.ID()] = true
+	ss.conns[s.ID()] = s
+	session := &socketSession{UserID: userID, Role: claims.Role, SessionID: claims.SessionID}
+	session.expiry = time.AfterFunc(time.Until(claims.ExpiresAt.Time), func() {
+		ss.drop(s, socketDropTokenExpired)
+	})
+	s.SetContext(session)
+	log.Printf("Socket %s authenticated for user %d (role %s)", s.ID(), userID, claims.Role)
+	return nil
+}
+
+// forget menghapus koneksi dari daftar socket user; aman dipanggil lebih dari sekali
+func (ss *SocketServer) forget(s socketio.Conn) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	session, ok := s.Context().(*socketSession)
+	if !ok {
+		return
+	}
+	session.expiry.Stop()
+	delete(ss.conns, s.ID())
+	if ss.UserSockets[session.UserID] != nil {
+		delete(ss.UserSockets[session.UserID], s.ID())
+		if len(ss.UserSockets[session.UserID]) == 0 {
+			delete(ss.UserSockets, session.UserID)
+		}
+	}
+}
+
+// drop memberi tahu client alasannya, mengeluarkan koneksi dari semua room lalu menutupnya
+func (ss *SocketServer) drop(s socketio.Conn, reason string) {
+	ss.forget(s)
+	s.Emit("session_expired", reason)
+	s.LeaveAll()
+	if err := s.Close(); err != nil {
+		log.Printf("Socket %s close failed: %v", s.ID(), err)
+	}
+	log.Printf("Socket %s dropped: %s", s.ID(), reason)
+}
+
+// dropMatching memutus semua koneksi terautentikasi yang sesinya cocok dengan match
+func (ss *SocketServer) dropMatching(match func(session *socketSession) bool) {
+	ss.mu.RLock()
+	var targets []socketio.Conn
+	for _, conn := range ss.conns {
+		if session, ok := conn.Context().(*socketSession); ok && match(session) {
+			targets = append(targets, conn)
+		}
+	}
+	ss.mu.RUnlock()
+
+	for _, conn := range targets {
+		ss.drop(conn, socketDropSessionRevoked)
+	}
+}
+
+func socketHandshakeToken(s socketio.Conn) string {
+	header := s.RemoteHeader()
+	request := http.Request{Header: header}
+	if cookie, err := request.Cookie("access_token"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	if u := s.URL(); u.Query().Get("token") != "" {
+		return u.Query().Get("token")
+	}
+	if auth := header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
 func (ss *SocketServer) BroadcastToUser(userID uint, event string, data interface{}) {
 	roomName := fmt.Sprintf("user_%d", userID)
 	ss.Server.BroadcastToRoom("/", roomName, event, data)
 	log.Printf("Broadcasted event '%s' to user %d (room %s)", event, userID, roomName)
 }
 
+func (ss *SocketServer) BroadcastToAdmins(event string, data interface{}) {
+	ss.Server.BroadcastToRoom("/", adminSocketRoom, event, data)
+}
+
 func (ss *SocketServer) BroadcastToAll(event string, data interface{}) {
 	ss.Server.BroadcastToRoom("/", "", event, data)
 }
+
+// EmitToUser mengirim event "notification" ke semua tab milik user. No-op jika socket
+// server belum diinisialisasi (mis. di unit test atau worker).
+func EmitToUser(userID uint, event SocketEvent) {
+	if GlobalSocket == nil || userID == 0 {
+		return
+	}
+	GlobalSocket.BroadcastToUser(userID, "notification", event)
+}
+
+// EmitToAdmins mengirim event "notification" ke semua admin yang sedang terhubung
+func EmitToAdmins(event SocketEvent) {
+	if GlobalSocket == nil {
+		return
+	}
+	GlobalSocket.BroadcastToAdmins("notification", event)
+}
+
+// DisconnectSession memutus socket yang terautentikasi dengan sesi (FamilyID) yang dicabut.
+// No-op jika socket server belum diinisialisasi.
+func DisconnectSession(sessionID string) {
+	if GlobalSocket == nil || sessionID == "" {
+		return
+	}
+	GlobalSocket.dropMatching(func(session *socketSession) bool {
+		return session.SessionID == sessionID
+	})
+}
+
+// DisconnectUserSessions memutus semua socket user, kecuali milik exceptSessionID (opsional)
+func DisconnectUserSessions(userID uint, exceptSessionID string) {
+	if GlobalSocket == nil || userID == 0 {
+		return
+	}
+	GlobalSocket.dropMatching(func(session *socketSession) bool {
+		return session.UserID == userID && (exceptSessionID == "" || session.SessionID != exceptSessionID)
+	})
+}
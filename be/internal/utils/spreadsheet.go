@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TableWriter menulis tabel baris demi baris (streaming) ke CSV atau XLSX.
+// Sel bertipe angka ditulis sebagai angka, time.Time sebagai teks "2006-01-02 15:04".
+type TableWriter interface {
+	WriteRow(cells ...interface{}) error
+	Close() error
+}
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// ExportContentTypes memetakan format export ke Content-Type response
+var ExportContentTypes = map[string]string{
+	ExportFormatCSV:  "text/csv; charset=utf-8",
+	ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// NewTableWriter membuat TableWriter sesuai format ("csv" atau "xlsx")
+func NewTableWriter(format string, w io.Writer, sheetName string) (TableWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		return &csvTableWriter{w: csv.NewWriter(w)}, nil
+	case ExportFormatXLSX:
+		return newXLSXTableWriter(w, sheetName)
+	default:
+		return nil, fmt.Errorf("format export tidak didukung: %s", format)
+	}
+}
+
+func formatCell(cell interface{}) (string, bool) {
+	switch v := cell.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, false
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	case time.Time:
+		if v.IsZero() || v.Year() <= 1 {
+			return "", false
+		}
+		return v.Format("2006-01-02 15:04"), false
+	default:
+		return fmt.Sprint(v), false
+	}
+}
+
+type csvTableWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+// escapeCSVFormula mencegah formula injection: teks yang diawali =, +, -, @ (atau tab/CR)
+// dieksekusi sebagai formula oleh Excel/Sheets, jadi diberi awalan ' agar dibaca sebagai teks
+func escapeCSVFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (t *csvTableWriter) WriteRow(cells ...interface{}) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		value, numeric := formatCell(cell)
+		if !numeric {
+			value = escapeCSVFormula(value)
+		}
+		record[i] = value
+	}
+	if err := t.w.Write(record); err != nil {
+		return err
+	}
+	t.rows++
+	if t.rows%100 == 0 {
+		t.w.Flush()
+	}
+	return t.w.Error()
+}
+
+func (t *csvTableWriter) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// xlsxTableWriter menulis workbook satu sheet. Bagian statis ditulis di awal, lalu sheet
+// di-stream sebagai entry zip terakhir dengan sel inlineStr (tanpa shared strings).
+type xlsxTableWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+func newXLSXTableWriter(w io.Writer, sheetName string) (*xlsxTableWriter, error) {
+	zw := zip.NewWriter(w)
+
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheetName))
+
+	parts := []struct{ path, body string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+		{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts><fills count="1"><fill><patternFill patternType="none"/></fill></fills><borders count="1"><border/></borders><cellStyleXfs count="1"><xf/></cellStyleXfs><cellXfs count="1"><xf xfId="0"/></cellXfs></styleSheet>`},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	return &xlsxTableWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (t *xlsxTableWriter) WriteRow(cells ...interface{}) error {
+	t.rows++
+	fmt.Fprintf(t.sheet, `<row r="%d">`, t.rows)
+	for i, cell := range cells {
+		value, numeric := formatCell(cell)
+		ref := xlsxColumnName(i) + strconv.Itoa(t.rows)
+		if numeric {
+			fmt.Fprintf(t.sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+			continue
+		}
+		fmt.Fprintf(t.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		if err := xml.EscapeText(t.sheet, []byte(value)); err != nil {
+			return err
+		}
+		t.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := t.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (t *xlsxTableWriter) Close() error {
+	t.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := t.sheet.Flush(); err != nil {
+		return err
+	}
+	return t.zw.Close()
+}
+
+// xlsxColumnName: 0 -> A, 25 -> Z, 26 -> AA
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
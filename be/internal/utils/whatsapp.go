@@ -3,10 +3,12 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"koskosan-be/internal/config"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -14,55 +16,208 @@ type WhatsAppSender interface {
 	SendWhatsApp(to, message string) error
 }
 
-type FonnteSender struct {
-	token string
+// WhatsAppProvider adalah gateway WA yang bisa dipilih lewat WHATSAPP_PROVIDER. Send mengembalikan
+// ID pesan dari provider sehingga status pengiriman (callback) bisa dicocokkan ke pesan outbox.
+type WhatsAppProvider interface {
+	WhatsAppSender
+	Name() string
+	Send(to, message string) (messageID string, err error)
+	ParseStatusCallback(body []byte) ([]WhatsAppDeliveryStatus, error)
 }
 
-func NewFonnteSender(token string) *FonnteSender {
-	return &FonnteSender{token: token}
+// Status pengiriman yang sudah dinormalisasi dari format masing-masing provider
+const (
+	WhatsAppStatusSent      = "sent"
+	WhatsAppStatusDelivered = "delivered"
+	WhatsAppStatusRead      = "read"
+	WhatsAppStatusFailed    = "failed"
+)
+
+type WhatsAppDeliveryStatus struct {
+	MessageID string
+	Status    string
+	Error     string
+	Timestamp time.Time
 }
 
-func (s *FonnteSender) SendWhatsApp(to, message string) error {
-	// Fonnte API endpoint
-	url := "https://api.fonnte.com/send"
+// normalizeWhatsAppStatus memetakan status provider ke sent/delivered/read/failed ("" jika tidak dikenal)
+func normalizeWhatsAppStatus(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "sent", "pending", "processing", "queued":
+		return WhatsAppStatusSent
+	case "delivered":
+		return WhatsAppStatusDelivered
+	case "read":
+		return WhatsAppStatusRead
+	case "failed", "invalid", "expired", "undelivered", "rejected":
+		return WhatsAppStatusFailed
+	}
+	return ""
+}
 
-	// Prepare payload
-	payload := map[string]string{
-		"target":  to,
-		"message": message,
+// WhatsAppProviderFactory membuat provider dari konfigurasi
+type WhatsAppProviderFactory func(cfg *config.Config) (WhatsAppProvider, error)
+
+var whatsAppProviders = map[string]WhatsAppProviderFactory{}
+
+// RegisterWhatsAppProvider mendaftarkan provider WA baru dengan nama yang dipakai di WHATSAPP_PROVIDER
+func RegisterWhatsAppProvider(name string, factory WhatsAppProviderFactory) {
+	whatsAppProviders[name] = factory
+}
+
+func init() {
+	RegisterWhatsAppProvider("fonnte", func(cfg *config.Config) (WhatsAppProvider, error) {
+		if cfg.FonnteToken == "" {
+			return nil, errors.New("FONNTE_TOKEN is required")
+		}
+		return NewFonnteSender(cfg.FonnteToken), nil
+	})
+	RegisterWhatsAppProvider("webhook", func(cfg *config.Config) (WhatsAppProvider, error) {
+		if cfg.WhatsAppWebhookURL == "" {
+			return nil, errors.New("WHATSAPP_WEBHOOK_URL is required")
+		}
+		return NewWebhookWASender(cfg.WhatsAppWebhookURL, cfg.WhatsAppWebhookToken), nil
+	})
+	RegisterWhatsAppProvider("cloudapi", func(cfg *config.Config) (WhatsAppProvider, error) {
+		if cfg.WhatsAppCloudToken == "" || cfg.WhatsAppCloudPhoneNumberID == "" {
+			return nil, errors.New("WHATSAPP_CLOUD_TOKEN and WHATSAPP_CLOUD_PHONE_NUMBER_ID are required")
+		}
+		return NewCloudAPIWASender(cfg.WhatsAppCloudBaseURL, cfg.WhatsAppCloudPhoneNumberID, cfg.WhatsAppCloudToken), nil
+	})
+	RegisterWhatsAppProvider("fake", func(cfg *config.Config) (WhatsAppProvider, error) {
+		return NewFakeWhatsAppProvider(), nil
+	})
+	RegisterWhatsAppProvider("log", func(cfg *config.Config) (WhatsAppProvider, error) {
+		return &LogWASender{}, nil
+	})
+}
+
+// NewWhatsAppProvider membuat provider sesuai cfg.WhatsAppProvider. Jika kosong dipakai Fonnte
+// (bila FONNTE_TOKEN diisi) atau simulasi log, sama dengan perilaku lama.
+func NewWhatsAppProvider(cfg *config.Config) (WhatsAppProvider, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.WhatsAppProvider))
+	if name == "" {
+		name = "log"
+		if cfg.FonnteToken != "" {
+			name = "fonnte"
+		}
 	}
-	jsonPayload, _ := json.Marshal(payload)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	factory, ok := whatsAppProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown WhatsApp provider: %s", name)
+	}
+	return factory(cfg)
+}
+
+// waHTTPClient dipakai semua provider HTTP
+var waHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// postWhatsAppJSON mengirim payload JSON dan mengembalikan body response (error jika status bukan 2xx)
+func postWhatsAppJSON(provider, url string, headers map[string]string, payload interface{}) ([]byte, error) {
+	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", s.token)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := waHTTPClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Log the response for debugging Fonnte issues
 	var buf bytes.Buffer
 	buf.ReadFrom(resp.Body)
-	respBody := buf.String()
-	log.Printf("[Fonnte API Response] Status: %s, Body: %s", resp.Status, respBody)
+	log.Printf("[%s API Response] Status: %s, Body: %s", provider, resp.Status, buf.String())
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("fonnte api returned status: %s, body: %s", resp.Status, respBody)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s api returned status: %s, body: %s", strings.ToLower(provider), resp.Status, buf.String())
 	}
+	return buf.Bytes(), nil
+}
 
-	return nil
+type FonnteSender struct {
+	token string
+}
+
+func NewFonnteSender(token string) *FonnteSender {
+	return &FonnteSender{token: token}
+}
+
+func (s *FonnteSender) Name() string { return "fonnte" }
+
+func (s *FonnteSender) SendWhatsApp(to, message string) error {
+	_, err := s.Send(to, message)
+	return err
+}
+
+func (s *FonnteSender) Send(to, message string) (string, error) {
+	// Fonnte API endpoint
+	payload := map[string]string{
+		"target":  to,
+		"message": message,
+	}
+	body, err := postWhatsAppJSON("Fonnte", "https://api.fonnte.com/send", map[string]string{"Authorization": s.token}, payload)
+	if err != nil {
+		return "", err
+	}
+
+	// Fonnte membalas 200 juga saat gagal, status ada di field "status"
+	var resp struct {
+		Status bool          `json:"status"`
+		Reason string        `json:"reason"`
+		ID     []interface{} `json:"id"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", nil // response tidak dikenal; anggap terkirim tanpa ID
+	}
+	if !resp.Status {
+		return "", fmt.Errorf("fonnte rejected message: %s", resp.Reason)
+	}
+	if len(resp.ID) > 0 {
+		return fmt.Sprint(resp.ID[0]), nil
+	}
+	return "", nil
+}
+
+// ParseStatusCallback membaca webhook status Fonnte: {"id": "...", "status": "...", "state": "..."}
+func (s *FonnteSender) ParseStatusCallback(body []byte) ([]WhatsAppDeliveryStatus, error) {
+	var callback struct {
+		ID     interface{} `json:"id"`
+		Status string      `json:"status"`
+		State  string      `json:"state"`
+	}
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, err
+	}
+	if callback.ID == nil {
+		return nil, errors.New("missing message id")
+	}
+
+	state := callback.State
+	if state == "" {
+		state = callback.Status
+	}
+	status := normalizeWhatsAppStatus(state)
+	if status == "" {
+		return nil, nil
+	}
+	return []WhatsAppDeliveryStatus{{MessageID: fmt.Sprint(callback.ID), Status: status, Timestamp: time.Now()}}, nil
 }
 
 type LogWASender struct{}
 
+func (s *LogWASender) Name() string { return "log" }
+
 func (s *LogWASender) SendWhatsApp(to, message string) error {
 	log.Printf("---------------------------------------------------------")
 	log.Printf("[WA SIMULATION] To: %s", to)
@@ -71,12 +226,54 @@ func (s *LogWASender) SendWhatsApp(to, message string) error {
 	return nil
 }
 
-func NewWhatsAppSender(cfg *config.Config) WhatsAppSender {
-	if cfg.FonnteToken != "" {
-		log.Println("[INFO] Initializing Real Fonnte WhatsApp Sender")
-		return NewFonnteSender(cfg.FonnteToken)
+func (s *LogWASender) Send(to, message string) (string, error) {
+	return "", s.SendWhatsApp(to, message)
+}
+
+func (s *LogWASender) ParseStatusCallback(body []byte) ([]WhatsAppDeliveryStatus, error) {
+	return nil, errors.New("log provider does not support status callbacks")
+}
+
+// NewWhatsAppSender membuat provider dari konfigurasi; jika gagal (mis. kredensial kosong) pesan
+// hanya di-log (Simulation Mode).
+func NewWhatsAppSender(cfg *config.Config) WhatsAppProvider {
+	provider, err := NewWhatsAppProvider(cfg)
+	if err != nil {
+		log.Printf("[WARNING] WhatsApp provider not available (%v). WhatsApp messages will only be logged locally (Simulation Mode).", err)
+		return &LogWASender{}
+	}
+	if provider.Name() == "log" {
+		log.Println("[WARNING] FONNTE_TOKEN is not set. WhatsApp messages will only be logged locally (Simulation Mode).")
+	} else {
+		log.Printf("[INFO] Initializing WhatsApp provider: %s", provider.Name())
 	}
+	return provider
+}
 
-	log.Println("[WARNING] FONNTE_TOKEN is not set. WhatsApp messages will only be logged locally (Simulation Mode).")
-	return &LogWASender{}
+// NormalizePhoneNumber mengubah nomor HP ke format internasional 62xxx
+// (mis. "0813-3231-4854" / "+62 813 3231 4854" -> "6281332314854"). Kosong jika tidak valid.
+func NormalizePhoneNumber(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+
+	switch {
+	case strings.HasPrefix(number, "62"):
+	case strings.HasPrefix(number, "0"):
+		number = "62" + strings.TrimPrefix(number, "0")
+	case strings.HasPrefix(number, "8"):
+		number = "62" + number
+	default:
+		return ""
+	}
+
+	// 62 + 8xx, minimal 9 digit nomor pelanggan
+	if len(number) < 10 || len(number) > 15 {
+		return ""
+	}
+	return number
 }
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CloudAPIWASender mengirim pesan teks lewat WhatsApp Cloud API (Meta):
+// POST {baseURL}/{phoneNumberID}/messages dengan format messaging_product "whatsapp".
+type CloudAPIWASender struct {
+	baseURL       string
+	phoneNumberID string
+	token         string
+}
+
+func NewCloudAPIWASender(baseURL, phoneNumberID, token string) *CloudAPIWASender {
+	return &CloudAPIWASender{baseURL: strings.TrimRight(baseURL, "/"), phoneNumberID: phoneNumberID, token: token}
+}
+
+func (s *CloudAPIWASender) Name() string { return "cloudapi" }
+
+func (s *CloudAPIWASender) SendWhatsApp(to, message string) error {
+	_, err := s.Send(to, message)
+	return err
+}
+
+func (s *CloudAPIWASender) Send(to, message string) (string, error) {
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                to,
+		"type":              "text",
+		"text":              map[string]interface{}{"preview_url": false, "body": message},
+	}
+	url := fmt.Sprintf("%s/%s/messages", s.baseURL, s.phoneNumberID)
+	body, err := postWhatsAppJSON("CloudAPI", url, map[string]string{"Authorization": "Bearer " + s.token}, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Messages) == 0 {
+		return "", errors.New("cloud api response has no message id")
+	}
+	return resp.Messages[0].ID, nil
+}
+
+// ParseStatusCallback membaca webhook Cloud API: entry[].changes[].value.statuses[]
+func (s *CloudAPIWASender) ParseStatusCallback(body []byte) ([]WhatsAppDeliveryStatus, error) {
+	var callback struct {
+		Entry []struct {
+			Changes []struct {
+				Value struct {
+					Statuses []struct {
+						ID        string `json:"id"`
+						Status    string `json:"status"`
+						Timestamp string `json:"timestamp"`
+						Errors    []struct {
+							Code  int    `json:"code"`
+							Title string `json:"title"`
+						} `json:"errors"`
+					} `json:"statuses"`
+				} `json:"value"`
+			} `json:"changes"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, err
+	}
+
+	var statuses []WhatsAppDeliveryStatus
+	for _, entry := range callback.Entry {
+		for _, change := range entry.Changes {
+			for _, st := range change.Value.Statuses {
+				status := normalizeWhatsAppStatus(st.Status)
+				if st.ID == "" || status == "" {
+					continue
+				}
+				delivery := WhatsAppDeliveryStatus{MessageID: st.ID, Status: status, Timestamp: time.Now()}
+				if unix, err := strconv.ParseInt(st.Timestamp, 10, 64); err == nil {
+					delivery.Timestamp = time.Unix(unix, 0)
+				}
+				if len(st.Errors) > 0 {
+					delivery.Error = fmt.Sprintf("%d: %s", st.Errors[0].Code, st.Errors[0].Title)
+				}
+				statuses = append(statuses, delivery)
+			}
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// FakeWhatsAppMessage adalah pesan yang "dikirim" lewat FakeWhatsAppProvider
+type FakeWhatsAppMessage struct {
+	ID      string
+	To      string
+	Message string
+}
+
+// FakeWhatsAppProvider adalah provider in-process untuk test/integrasi lokal: pesan disimpan di
+// memori sehingga test bisa memeriksa apa yang dikirim. Callback status memakai format generik.
+// Provider ini juga bisa dijalankan sebagai stub server gateway generik (lihat ServeHTTP).
+type FakeWhatsAppProvider struct {
+	mu      sync.Mutex
+	sent    []FakeWhatsAppMessage
+	failErr error
+	nextID  int
+}
+
+func NewFakeWhatsAppProvider() *FakeWhatsAppProvider {
+	return &FakeWhatsAppProvider{}
+}
+
+func (p *FakeWhatsAppProvider) Name() string { return "fake" }
+
+func (p *FakeWhatsAppProvider) SendWhatsApp(to, message string) error {
+	_, err := p.Send(to, message)
+	return err
+}
+
+func (p *FakeWhatsAppProvider) Send(to, message string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failErr != nil {
+		return "", p.failErr
+	}
+	p.nextID++
+	id := fmt.Sprintf("fake-%d", p.nextID)
+	p.sent = append(p.sent, FakeWhatsAppMessage{ID: id, To: to, Message: message})
+	return id, nil
+}
+
+func (p *FakeWhatsAppProvider) ParseStatusCallback(body []byte) ([]WhatsAppDeliveryStatus, error) {
+	return parseGenericStatusCallback(body)
+}
+
+// Sent mengembalikan salinan pesan yang sudah dikirim (urut kirim)
+func (p *FakeWhatsAppProvider) Sent() []FakeWhatsAppMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]FakeWhatsAppMessage(nil), p.sent...)
+}
+
+// FailWith membuat pengiriman berikutnya gagal dengan err (nil untuk kembali normal)
+func (p *FakeWhatsAppProvider) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failErr = err
+}
+
+// Reset menghapus pesan yang tersimpan
+func (p *FakeWhatsAppProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = nil
+	p.failErr = nil
+}
+
+// ServeHTTP membuat FakeWhatsAppProvider berperilaku sebagai gateway HTTP generik (provider
+// "webhook"), mis. httptest.NewServer(fake) untuk menguji WebhookWASender end-to-end.
+func (p *FakeWhatsAppProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		To      string `json:"to"`
+		Message string `json:"message"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&req) != nil || req.To == "" {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+
+	id, err := p.Send(req.To, req.Message)
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id": id})
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// WebhookWASender mengirim pesan ke gateway HTTP generik:
+// POST {url} {"to": "62xxx", "message": "..."} dengan header Authorization: Bearer {token}.
+// Response {"id": "..."} (opsional) dipakai sebagai ID pesan.
+type WebhookWASender struct {
+	url   string
+	token string
+}
+
+func NewWebhookWASender(url, token string) *WebhookWASender {
+	return &WebhookWASender{url: url, token: token}
+}
+
+func (s *WebhookWASender) Name() string { return "webhook" }
+
+func (s *WebhookWASender) SendWhatsApp(to, message string) error {
+	_, err := s.Send(to, message)
+	return err
+}
+
+func (s *WebhookWASender) Send(to, message string) (string, error) {
+	headers := map[string]string{}
+	if s.token != "" {
+		headers["Authorization"] = "Bearer " + s.token
+	}
+	body, err := postWhatsAppJSON("Webhook", s.url, headers, map[string]string{"to": to, "message": message})
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		ID        string `json:"id"`
+		MessageID string `json:"message_id"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", nil // gateway tidak mengembalikan JSON; status tidak bisa dilacak
+	}
+	if resp.ID != "" {
+		return resp.ID, nil
+	}
+	return resp.MessageID, nil
+}
+
+// webhookStatusCallback adalah format callback generik: {"id", "status", "error", "timestamp"}
+type webhookStatusCallback struct {
+	ID        string    `json:"id"`
+	MessageID string    `json:"message_id"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// parseGenericStatusCallback menerima satu objek atau array callback
+func parseGenericStatusCallback(body []byte) ([]WhatsAppDeliveryStatus, error) {
+	var callbacks []webhookStatusCallback
+	if err := json.Unmarshal(body, &callbacks); err != nil {
+		var single webhookStatusCallback
+		if err := json.Unmarshal(body, &single); err != nil {
+			return nil, err
+		}
+		callbacks = []webhookStatusCallback{single}
+	}
+
+	statuses := make([]WhatsAppDeliveryStatus, 0, len(callbacks))
+	for _, callback := range callbacks {
+		id := callback.ID
+		if id == "" {
+			id = callback.MessageID
+		}
+		if id == "" {
+			return nil, errors.New("missing message id")
+		}
+		status := normalizeWhatsAppStatus(callback.Status)
+		if status == "" {
+			continue
+		}
+		timestamp := callback.Timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+		statuses = append(statuses, WhatsAppDeliveryStatus{MessageID: id, Status: status, Error: callback.Error, Timestamp: timestamp})
+	}
+	return statuses, nil
+}
+
+func (s *WebhookWASender) ParseStatusCallback(body []byte) ([]WhatsAppDeliveryStatus, error) {
+	return parseGenericStatusCallback(body)
+}
@@ -6,6 +6,8 @@ import { api } from '@/app/services/api';
 interface DashboardStats {
   total_revenue: number;
   pending_revenue: number;
+  confirmed_payments: number;
+  pending_payments: number;
   rejected_payments: number;
   potential_revenue: number;
   occupied_rooms: number;
@@ -14,16 +16,12 @@ interface DashboardStats {
   type_breakdown?: { type: string; revenue: number; count: number; occupied: number }[];
 }
 
-interface Payment {
-  id: string | number;
-  status_pembayaran: string;
-  jumlah_bayar: number;
-}
-
 export function FinancialReports() {
   const [stats, setStats] = useState<DashboardStats>({
     total_revenue: 0,
     pending_revenue: 0,
+    confirmed_payments: 0,
+    pending_payments: 0,
     rejected_payments: 0,
     potential_revenue: 0,
     occupied_rooms: 0,
@@ -31,18 +29,12 @@ export function FinancialReports() {
     monthly_trend: [],
     type_breakdown: []
   });
-  const [payments, setPayments] = useState<Payment[]>([]);
   const [isLoading, setIsLoading] = useState(true);
 
   useEffect(() => {
     const fetchData = async () => {
       try {
-        const [dashStats, p] = await Promise.all([
-          api.getDashboardStats(),
-          api.getAllPayments()
-        ]);
-        setStats(dashStats);
-        setPayments(p);
+        setStats(await api.getDashboardStats());
       } catch (e) {
         console.error(e);
       } finally {
@@ -70,10 +62,6 @@ export function FinancialReports() {
   const totalRooms = occupiedRooms + availableRooms;
   const occupancyRate = totalRooms > 0 ? (occupiedRooms / totalRooms) * 100 : 0;
 
-  const confirmedPayments = payments.filter(p => p.status_pembayaran === 'Confirmed');
-  const pendingPayments = payments.filter(p => p.status_pembayaran === 'Pending');
-  const rejectedPayments = payments.filter(p => p.status_pembayaran === 'Rejected');
-
   const formatPrice = (price: number) => {
     return new Intl.NumberFormat('id-ID', {
       style: 'currency',
@@ -200,7 +188,7 @@ export function FinancialReports() {
                 <div>
                   <p className="font-medium">Confirmed Payments</p>
                   <p className="text-sm text-slate-600 mt-1">
-                    {confirmedPayments.length} transactions
+                    {stats.confirmed_payments} transactions
                   </p>
                 </div>
                 <div className="text-right">
@@ -214,7 +202,7 @@ export function FinancialReports() {
                 <div>
                   <p className="font-medium">Pending Payments</p>
                   <p className="text-sm text-slate-600 mt-1">
-                    {pendingPayments.length} transactions
+                    {stats.pending_payments} transactions
                   </p>
                 </div>
                 <div className="text-right">
@@ -228,7 +216,7 @@ export function FinancialReports() {
                 <div>
                   <p className="font-medium">Rejected Payments</p>
                   <p className="text-sm text-slate-600 mt-1">
-                    {rejectedPayments.length} transactions
+                    {stats.rejected_payments} transactions
                   </p>
                 </div>
                 <div className="text-right">
@@ -22,7 +22,7 @@ import {
 } from "recharts";
 import { motion } from "framer-motion";
 import { useEffect, useState } from "react";
-import { api, DashboardStats as DashboardStatResponse, Tenant, Payment } from "@/app/services/api";
+import { api, DashboardStats as DashboardStatResponse, Tenant } from "@/app/services/api";
 import { useTranslations } from "next-intl";
 
 interface TooltipPayload {
@@ -79,6 +79,8 @@ export function LuxuryDashboard() {
     active_tenants: 0,
     available_rooms: 0,
     occupied_rooms: 0,
+    total_payments: 0,
+    confirmed_payments: 0,
     pending_payments: 0,
     pending_revenue: 0,
     rejected_payments: 0,
@@ -89,19 +91,17 @@ export function LuxuryDashboard() {
     recent_checkouts: [],
   });
   const [tenants, setTenants] = useState<Tenant[]>([]);
-  const [payments, setPayments] = useState<Payment[]>([]);
   const [roomsCount, setRoomsCount] = useState(0);
   // Suppress unused warnings — these setters are used indirectly below
-  void setStats; void setPayments;
+  void setStats;
 
   useEffect(() => {
     const fetchAllData = async () => {
       try {
-        const [dashStats, tenantsData, paymentsRes, roomsData] =
+        const [dashStats, tenantsData, roomsData] =
           await Promise.all([
             api.getDashboardStats(),
             api.getAllTenants(),
-            api.getAllPayments(),
             api.getRooms(),
           ]);
         setStats(dashStats);
@@ -110,7 +110,6 @@ export function LuxuryDashboard() {
         } else if (tenantsData && tenantsData.data) {
             setTenants(tenantsData.data);
         }
-        setPayments(Array.isArray(paymentsRes) ? paymentsRes : []);
         setRoomsCount(roomsData.length);
       } catch (error) {
         console.error("Failed to fetch dashboard data:", error);
@@ -127,6 +126,7 @@ export function LuxuryDashboard() {
   const activeTenants = tenants.filter(t => t.role === 'tenant').length;
   const pendingPayments = stats.pending_payments;
   const totalRevenue = stats.total_revenue;
+  const paymentCompletion = stats.total_payments > 0 ? (stats.confirmed_payments / stats.total_payments) * 100 : 0;
 
   // Historical revenue data from backend
   const revenueData =
@@ -572,15 +572,7 @@ export function LuxuryDashboard() {
                   {t('paymentCompletion')}
                 </span>
                 <span className="text-sm font-semibold text-slate-900 dark:text-white">
-                  {payments.length > 0
-                    ? Math.round(
-                        (payments.filter(
-                          (p: Payment) => p.status_pembayaran === "Confirmed",
-                        ).length /
-                          payments.length) *
-                          100,
-                      )
-                    : 0}
+                  {Math.round(paymentCompletion)}
                   %
                 </span>
               </div>
@@ -588,7 +580,7 @@ export function LuxuryDashboard() {
                 <div
                   className="h-full bg-gradient-to-r from-green-400 to-green-600 rounded-full transition-all duration-500 shadow-[0_0_12px_rgba(16,185,129,0.4)]"
                   style={{
-                    width: `${payments.length > 0 ? (payments.filter((p: Payment) => p.status_pembayaran === "Confirmed").length / payments.length) * 100 : 0}%`,
+                    width: `${paymentCompletion}%`,
                   }}
                 />
               </div>
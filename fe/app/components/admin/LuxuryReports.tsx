@@ -9,10 +9,31 @@ import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '@
 import { useTranslations } from 'next-intl';
 import { motion } from "framer-motion";
 
+type ReportPeriod = 'all' | '30days' | '6months' | 'year';
+
+const periodDays: Record<Exclude<ReportPeriod, 'all'>, number> = { '30days': 30, '6months': 180, 'year': 365 };
+
+// periodStart mengubah filter periode menjadi date_from (YYYY-MM-DD, waktu lokal); undefined = semua waktu
+function periodStart(period: ReportPeriod): string | undefined {
+  if (period === 'all') return undefined;
+  const from = new Date();
+  from.setDate(from.getDate() - periodDays[period]);
+  return from.toLocaleDateString('en-CA');
+}
+
+// fetchPeriodPayments mengambil seluruh transaksi periode halaman demi halaman untuk tabel PDF
+async function fetchPeriodPayments(period: ReportPeriod): Promise<ApiPayment[]> {
+  const all: ApiPayment[] = [];
+  for (let page = 1; ; page++) {
+    const res = await api.getPaymentsPaginated({ page, limit: 1000, date_from: periodStart(period) });
+    all.push(...res.data);
+    if (page >= res.meta.total_pages || res.data.length === 0) return all;
+  }
+}
+
 export function LuxuryReports() {
   const t = useTranslations('admin');
-  const [dateFilter, setDateFilter] = useState<'all' | '30days' | '6months' | 'year'>('all');
-  const [payments, setPayments] = useState<ApiPayment[]>([]);
+  const [dateFilter, setDateFilter] = useState<ReportPeriod>('all');
   const [rooms, setRooms] = useState<Room[]>([]);
   const [tenants, setTenants] = useState<Tenant[]>([]); // Add tenants state
   const [stats, setStats] = useState<DashboardStats | null>(null);
@@ -22,15 +43,11 @@ export function LuxuryReports() {
   useEffect(() => {
     const fetchData = async () => {
       try {
-        const [pData, rData, sData, tData] = await Promise.all([
-          api.getAllPayments({ limit: 1000 }), // Fetch all payments
+        const [rData, tData] = await Promise.all([
           api.getRooms({ limit: 1000 }), // Fetch all rooms
-          api.getDashboardStats(),
           api.getAllTenants({ limit: 1000 }) // Fetch all tenants
         ]);
-        setPayments(pData);
         setRooms(rData);
-        setStats(sData);
         // Handle paginated response for tenants
         if (tData && 'data' in tData) {
             setTenants(tData.data as Tenant[]);
@@ -46,24 +63,16 @@ export function LuxuryReports() {
     void fetchData();
   }, []);
 
-  const filteredPayments = payments.filter(p => {
-    if (dateFilter === 'all') return true;
-    const date = new Date(p.tanggal_bayar);
-    const now = new Date();
-    if (dateFilter === '30days') return now.getTime() - date.getTime() < 30 * 24 * 60 * 60 * 1000;
-    if (dateFilter === '6months') return now.getTime() - date.getTime() < 6 * 30 * 24 * 60 * 60 * 1000;
-    if (dateFilter === 'year') return now.getTime() - date.getTime() < 365 * 24 * 60 * 60 * 1000;
-    return true;
-  });
-
-  // Use filtered values for revenue metrics
-  const totalRevenue = filteredPayments
-    .filter(p => p.status_pembayaran === 'Confirmed')
-    .reduce((sum, p) => sum + p.jumlah_bayar, 0);
-
-  const pendingRevenue = filteredPayments
-    .filter(p => p.status_pembayaran === 'Pending')
-    .reduce((sum, p) => sum + p.jumlah_bayar, 0);
+  // Total pendapatan, jumlah transaksi, tren bulanan dan pendapatan per tipe dihitung server
+  // untuk periode yang dipilih
+  useEffect(() => {
+    api.getDashboardStats({ date_from: periodStart(dateFilter) })
+      .then(setStats)
+      .catch(e => console.error("Failed to fetch report stats:", e));
+  }, [dateFilter]);
+
+  const totalRevenue = stats?.total_revenue ?? 0;
+  const pendingRevenue = stats?.pending_revenue ?? 0;
 
   // Real-time Property & User Summary (Synchronized with Room Data & Tenant Data)
   const currentOccupiedRooms = rooms.filter(r => r.status?.toLowerCase() === 'penuh' || ['terisi', 'occupied'].includes(r.status?.toLowerCase()));
@@ -75,30 +84,7 @@ export function LuxuryReports() {
 
   const occupancyRate = rooms.length > 0 ? Math.round((currentOccupiedRooms.length / rooms.length) * 100) : 0;
 
-  // Calculate derived stats from filtered payments
-  const derivedRevenueByType = rooms.map(room => {
-    const revenue = filteredPayments
-      .filter(p => p.pemesanan?.kamar_id === room.id && p.status_pembayaran === 'Confirmed')
-      .reduce((sum, p) => sum + p.jumlah_bayar, 0);
-    return {
-      type: room.tipe_kamar,
-      revenue,
-      count: 1,
-      occupied: ['terisi', 'penuh', 'occupied'].includes(room.status?.toLowerCase()) ? 1 : 0
-    };
-  }).reduce((acc, current) => {
-    const existing = acc.find(a => a.type === current.type);
-    if (existing) {
-      existing.revenue += current.revenue;
-      existing.count += 1;
-      existing.occupied += current.occupied;
-    } else {
-      acc.push({ ...current });
-    }
-    return acc;
-  }, [] as { type: string; revenue: number; count: number; occupied: number }[]);
-
-  const revenueByType = derivedRevenueByType;
+  const revenueByType = stats?.type_breakdown ?? [];
 
   // Recalculate demographics based on filtered period
   const derivedDemographics = () => {
@@ -139,31 +125,7 @@ export function LuxuryReports() {
 
   const tenantDemographics = derivedDemographics();
 
-  // Recalculate Monthly Data from filtered payments
-  const derivedMonthlyTrend = () => {
-    const monthMap: Record<string, number> = {};
-    const months = ['Jan', 'Feb', 'Mar', 'Apr', 'May', 'Jun', 'Jul', 'Aug', 'Sep', 'Oct', 'Nov', 'Dec'];
-    
-    // Default show last 6 months or all months in filtered payments
-    filteredPayments.forEach(p => {
-      const date = new Date(p.tanggal_bayar);
-      const month = months[date.getMonth()];
-      const year = date.getFullYear();
-      const key = `${month} ${year}`;
-      monthMap[key] = (monthMap[key] || 0) + p.jumlah_bayar;
-    });
-
-    return Object.entries(monthMap).map(([month, revenue]) => ({
-      month,
-      revenue
-    })).sort((a, b) => {
-      const [mA, yA] = a.month.split(' ');
-      const [mB, yB] = b.month.split(' ');
-      return new Date(`${mA} 1, ${yA}`).getTime() - new Date(`${mB} 1, ${yB}`).getTime();
-    });
-  };
-
-  const monthlyData = derivedMonthlyTrend();
+  const monthlyData = stats?.monthly_trend ?? [];
 
   const handleExport = async () => {
     setIsExporting(true);
@@ -318,8 +280,9 @@ export function LuxuryReports() {
     const tableColumn = [t('date'), t('tenantName'), t('roomType'), t('roomNo'), t('status'), t('amount')];
     const tableRows: (string | number)[][] = [];
 
-    // Sort filtered payments by date desc
-    const sortedPayments = [...filteredPayments].sort((a, b) => 
+    // Sort period payments by date desc
+    const periodPayments = await fetchPeriodPayments(dateFilter);
+    const sortedPayments = [...periodPayments].sort((a, b) => 
       new Date(b.created_at || '').getTime() - new Date(a.created_at || '').getTime()
     );
 
@@ -510,7 +473,7 @@ export function LuxuryReports() {
         <div className="flex items-center gap-2 md:gap-3">
           <Select 
             value={dateFilter} 
-            onValueChange={(value: 'all' | '30days' | '6months' | 'year') => setDateFilter(value)}
+            onValueChange={(value: ReportPeriod) => setDateFilter(value)}
           >
             <SelectTrigger className="w-[140px] md:w-[180px] bg-white dark:bg-slate-800/50 border-slate-200 dark:border-slate-700 text-slate-700 dark:text-white hover:bg-slate-100 dark:hover:bg-slate-800 text-xs md:text-sm h-9 md:h-10">
               <div className="flex items-center">
@@ -741,14 +704,14 @@ export function LuxuryReports() {
             <div className="flex items-center justify-between p-4 bg-gradient-to-r from-green-500/10 to-green-600/10 border border-green-200 dark:border-green-500/20 rounded-xl">
               <div>
                 <p className="font-semibold text-slate-900 dark:text-white text-sm">{t('confirmed')}</p>
-                <p className="text-[10px] text-slate-500 dark:text-slate-400">{payments.filter(p => p.status_pembayaran === 'Confirmed').length} {t('transactionCount')}</p>
+                <p className="text-[10px] text-slate-500 dark:text-slate-400">{stats?.confirmed_payments ?? 0} {t('transactionCount')}</p>
               </div>
               <p className="text-lg md:text-xl font-bold text-green-600 dark:text-green-400">{formatPrice(totalRevenue)}</p>
             </div>
             <div className="flex items-center justify-between p-4 bg-gradient-to-r from-orange-500/10 to-orange-600/10 border border-orange-200 dark:border-orange-500/20 rounded-xl">
               <div>
                 <p className="font-semibold text-slate-900 dark:text-white text-sm">{t('pending')}</p>
-                <p className="text-[10px] text-slate-500 dark:text-slate-400">{payments.filter(p => p.status_pembayaran === 'Pending').length} {t('transactionCount')}</p>
+                <p className="text-[10px] text-slate-500 dark:text-slate-400">{stats?.pending_payments ?? 0} {t('transactionCount')}</p>
               </div>
               <p className="text-lg md:text-xl font-bold text-orange-600 dark:text-orange-400">{formatPrice(pendingRevenue)}</p>
             </div>
@@ -3,6 +3,7 @@
 import React, { createContext, useContext, useEffect, useState } from "react";
 import { io, Socket } from "socket.io-client";
 import { toast } from "sonner";
+import { api } from "@/app/services/api";
 
 interface NotificationContextType {
   socket: Socket | null;
@@ -30,20 +31,8 @@ export const NotificationProvider = ({ children }: { children: React.ReactNode }
 
     newSocket.on("connect", () => {
       console.log("Connected to notification server");
+      // Autentikasi dilakukan server saat handshake lewat cookie access_token (withCredentials)
       setIsConnected(true);
-      
-      // Authenticate with user ID if available
-      const userStr = localStorage.getItem("user");
-      if (userStr) {
-        try {
-          const user = JSON.parse(userStr);
-          if (user.id) {
-            newSocket.emit("authenticate", user.id);
-          }
-        } catch (e) {
-          console.error("Failed to parse user for socket auth", e);
-        }
-      }
     });
 
     newSocket.on("disconnect", () => {
@@ -51,6 +40,14 @@ export const NotificationProvider = ({ children }: { children: React.ReactNode }
       setIsConnected(false);
     });
 
+    // Server memutus socket saat access token kedaluwarsa atau sesi dicabut. Untuk token
+    // kedaluwarsa, perbarui cookie lalu sambung ulang agar handshake memakai token baru.
+    newSocket.on("session_expired", async (reason: string) => {
+      if (reason === "token_expired" && (await api.refreshSession())) {
+        newSocket.connect();
+      }
+    });
+
     // Listen for generic notifications
     newSocket.on("notification", (data: { title: string; message: string; type?: string }) => {
       toast[data.type === "success" ? "success" : data.type === "error" ? "error" : "info"](data.message, {
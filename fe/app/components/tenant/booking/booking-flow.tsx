@@ -20,7 +20,7 @@ import {
   SelectTrigger,
   SelectValue,
 } from "@/app/components/ui/select";
-import { api, Room } from "@/app/services/api";
+import { api, Room, PricingPolicy } from "@/app/services/api";
 import { toast } from "sonner";
 
 
@@ -132,11 +132,34 @@ export function BookingFlow({ roomId, onBack, initialData }: BookingFlowProps) {
     }
   }, [roomId]);
 
+  // Kebijakan DP mengikuti tipe kamar; nilai default sama dengan default backend
+  const [dpPolicy, setDpPolicy] = useState<Pick<PricingPolicy, "dp_percentage" | "min_dp_amount" | "settlement_days" | "installment_count">>({
+    dp_percentage: 30,
+    min_dp_amount: 0,
+    settlement_days: 30,
+    installment_count: 1,
+  });
+
+  useEffect(() => {
+    if (!room?.tipe_kamar) return;
+    api.getPricingPolicy(room.tipe_kamar)
+      .then(setDpPolicy)
+      .catch((e) => console.warn("Failed to load pricing policy, using defaults", e));
+  }, [room?.tipe_kamar]);
+
   // Robust price calculation: check multiple fields and ensure number
   const pricePerMonth = room 
     ? (Number(room.harga_per_bulan) || 0) 
     : 0;
 
+  // DP dihitung seperti backend: persentase kebijakan, minimal min_dp_amount, maksimal total sewa
+  const totalRent = pricePerMonth * (parseInt(formData.duration) || 0);
+  const dpAmount = Math.min(totalRent, Math.max(Math.round(totalRent * dpPolicy.dp_percentage / 100), dpPolicy.min_dp_amount));
+  const remainingPercentage = 100 - dpPolicy.dp_percentage;
+  const installmentSchedule = dpPolicy.installment_count > 1
+    ? `dalam ${dpPolicy.installment_count} cicilan bulanan, mulai ${dpPolicy.settlement_days} hari setelah check-in`
+    : `${dpPolicy.settlement_days} hari setelah check-in`;
+
   useEffect(() => {
     if (room) {
         console.log("Current Room State:", room);
@@ -532,10 +555,10 @@ export function BookingFlow({ roomId, onBack, initialData }: BookingFlowProps) {
                             </div>
                             <div>
                               <p className="font-semibold text-sm">
-                                Down Payment (30%)
+                                Down Payment ({dpPolicy.dp_percentage}%)
                               </p>
                               <p className="text-xs text-muted-foreground">
-                                Cicil sisa 70% bulan depan
+                                Sisa {remainingPercentage}% dibayar {installmentSchedule}
                               </p>
                             </div>
                           </div>
@@ -652,10 +675,10 @@ export function BookingFlow({ roomId, onBack, initialData }: BookingFlowProps) {
                             <div className="pt-2 border-t border-border">
                               <div className="flex justify-between">
                                 <span className="text-muted-foreground">
-                                  Down Payment (30%)
+                                  Down Payment ({dpPolicy.dp_percentage}%)
                                 </span>
                                 <span className="font-semibold text-orange-600">
-                                  Rp {(pricePerMonth * parseInt(formData.duration) * 0.3).toLocaleString("id-ID")}
+                                  Rp {dpAmount.toLocaleString("id-ID")}
                                 </span>
                               </div>
                               <p className="text-xs text-muted-foreground mt-1">
@@ -664,14 +687,14 @@ export function BookingFlow({ roomId, onBack, initialData }: BookingFlowProps) {
                             </div>
                             <div className="flex justify-between">
                               <span className="text-muted-foreground">
-                                Remaining (70%)
+                                Remaining ({remainingPercentage}%)
                               </span>
                               <span className="font-semibold text-blue-600">
-                                Rp {(pricePerMonth * parseInt(formData.duration) * 0.7).toLocaleString("id-ID")}
+                                Rp {(totalRent - dpAmount).toLocaleString("id-ID")}
                               </span>
                             </div>
                             <p className="text-xs text-muted-foreground">
-                              Cicilan 1 bulan setelah check-in
+                              Jatuh tempo {installmentSchedule}
                             </p>
                           </>
                         )}
@@ -686,7 +709,7 @@ export function BookingFlow({ roomId, onBack, initialData }: BookingFlowProps) {
                           <span className="font-bold">Amount to Pay Now</span>
                           <span className="font-bold text-lg">
                              {formData.paymentType === "dp"
-                              ? `Rp ${(pricePerMonth * parseInt(formData.duration) * 0.3).toLocaleString("id-ID")}`
+                              ? `Rp ${dpAmount.toLocaleString("id-ID")}`
                               : `Rp ${(pricePerMonth * parseInt(formData.duration)).toLocaleString("id-ID")}`
                              }
                           </span>
@@ -697,8 +720,8 @@ export function BookingFlow({ roomId, onBack, initialData }: BookingFlowProps) {
                     {formData.paymentType === "dp" && (
                       <div className="p-4 bg-blue-50 dark:bg-blue-950/30 rounded-lg border border-blue-200 dark:border-blue-900">
                         <p className="text-sm text-blue-900 dark:text-blue-200">
-                          <strong>📅 Reminder:</strong> Sisa pembayaran (70%)
-                          akan jatuh tempo 1 bulan setelah tanggal check-in.
+                          <strong>📅 Reminder:</strong> Sisa pembayaran ({remainingPercentage}%)
+                          jatuh tempo {installmentSchedule}.
                           Anda akan menerima notifikasi pembayaran.
                         </p>
                       </div>
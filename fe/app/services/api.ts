@@ -149,6 +149,8 @@ export interface DashboardStats {
   active_tenants: number;
   available_rooms: number;
   occupied_rooms: number;
+  total_payments: number;
+  confirmed_payments: number;
   pending_payments: number;
   pending_revenue: number;
   rejected_payments: number;
@@ -164,6 +166,16 @@ export interface DashboardStats {
   }[];
 }
 
+// Kebijakan DP yang berlaku untuk satu tipe kamar (GET /pricing-policy)
+export interface PricingPolicy {
+  tipe_kamar: string;
+  dp_percentage: number;
+  min_dp_amount: number;
+  settlement_days: number;
+  installment_count: number;
+  deposit_amount: number;
+}
+
 export interface LoginResponse {
     token?: string; // Token is now in HttpOnly cookie, but kept optional for compatibility
     user: User;
@@ -318,6 +330,9 @@ export const api = {
     return apiCall<MessageResponse>('POST', '/auth/reset-password', { token, new_password: newPassword });
   },
 
+  // Perbarui cookie access_token memakai refresh token (mis. sebelum socket menyambung ulang)
+  refreshSession: refreshAccessToken,
+
   logout: async () => {
     // Call backend to clear HttpOnly cookies
     try {
@@ -437,6 +452,11 @@ export const api = {
     return apiCall<Review[]>('GET', '/reviews');
   },
 
+  getPricingPolicy: async (tipeKamar?: string) => {
+    const endpoint = tipeKamar ? `/pricing-policy?tipe_kamar=${encodeURIComponent(tipeKamar)}` : '/pricing-policy';
+    return apiCall<PricingPolicy>('GET', endpoint);
+  },
+
   getReviews: async (roomId: string) => {
     return apiCall<Review[]>('GET', `/kamar/${roomId}/reviews`);
   },
@@ -457,11 +477,23 @@ export const api = {
   },
 
   getAllPayments: async (params?: { page?: number; limit?: number }) => {
+    // Endpoint sekarang paginated; layar lama masih membutuhkan seluruh daftar sebagai array.
+    // Jangan dipakai untuk total/statistik, gunakan getDashboardStats (agregat server).
+    const res = await api.getPaymentsPaginated({ page: params?.page, limit: params?.limit ?? 1000 });
+    return res.data;
+  },
+
+  getPaymentsPaginated: async (params?: {
+    page?: number; limit?: number; status?: string; method?: string; type?: string;
+    date_from?: string; date_to?: string; kamar_id?: number; tenant?: string; order_id?: string;
+    sort_by?: string; order?: 'asc' | 'desc';
+  }) => {
     const query = new URLSearchParams();
-    if (params?.page) query.append('page', params.page.toString());
-    if (params?.limit) query.append('limit', params.limit.toString());
+    Object.entries(params ?? {}).forEach(([key, value]) => {
+      if (value !== undefined && value !== '') query.append(key, String(value));
+    });
     const endpoint = query.toString() ? `/payments?${query.toString()}` : '/payments';
-    return apiCall<Payment[]>('GET', endpoint);
+    return apiCall<PaginatedResponse<Payment[]>>('GET', endpoint);
   },
 
   confirmPayment: async (paymentId: string) => {
@@ -490,8 +522,10 @@ export const api = {
     return apiCall<MessageResponse>('POST', '/contact', data);
   },
 
-  getDashboardStats: async () => {
-    return apiCall<DashboardStats>('GET', '/dashboard');
+  getDashboardStats: async (params?: { date_from?: string }) => {
+    // date_from (YYYY-MM-DD) membatasi agregat pembayaran ke periode sejak tanggal tersebut
+    const endpoint = params?.date_from ? `/dashboard?date_from=${params.date_from}` : '/dashboard';
+    return apiCall<DashboardStats>('GET', endpoint);
   },
 
   getRoomOccupancy: async () => {